@@ -2,10 +2,12 @@ module github.com/david-botos/BearHug/services/ai-agent
 
 go 1.23.4
 
-require github.com/joho/godotenv v1.5.1
+require (
+	github.com/deepgram/deepgram-go-sdk v1.6.2
+	github.com/joho/godotenv v1.5.1
+)
 
 require (
-	github.com/deepgram/deepgram-go-sdk v1.6.2 // indirect
 	github.com/dvonthenen/websocket v1.5.1-dyv.2 // indirect
 	github.com/ebitengine/oto/v3 v3.3.2 // indirect
 	github.com/ebitengine/purego v0.8.0 // indirect
@@ -28,11 +30,11 @@ require (
 	github.com/google/uuid v1.6.0 // indirect
 	github.com/gopxl/beep v1.4.1
 	github.com/gorilla/websocket v1.5.3
-	github.com/haguro/elevenlabs-go v0.2.4 // indirect
+	github.com/haguro/elevenlabs-go v0.2.4
 	github.com/hajimehoshi/ebiten/v2 v2.8.6
 	github.com/hajimehoshi/oto/v2 v2.4.2
 	github.com/henomis/cohere-go v1.1.2 // indirect
-	github.com/henomis/lingoose v0.3.0 // indirect
+	github.com/henomis/lingoose v0.3.0
 	github.com/henomis/restclientgo v1.2.0 // indirect
 	github.com/iancoleman/orderedmap v0.0.0-20190318233801-ac98e3ecb4b0 // indirect
 	github.com/invopop/jsonschema v0.7.0 // indirect
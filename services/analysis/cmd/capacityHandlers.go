@@ -0,0 +1,46 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/david-botos/BearHug/services/analysis/internal/hsds_types"
+	"github.com/david-botos/BearHug/services/analysis/internal/supabase"
+	"github.com/david-botos/BearHug/services/analysis/pkg/logger"
+)
+
+// handleCapacityHistory returns the capacity readings for a service as one series per unit
+func handleCapacityHistory(w http.ResponseWriter, r *http.Request) {
+	log := logger.Get()
+
+	serviceID := r.PathValue("id")
+	if !hsds_types.ValidateUUID(serviceID) {
+		writeErrorResponse(w, http.StatusBadRequest, "invalid_service_id", "service id must be a UUID")
+		return
+	}
+
+	series, err := supabase.FetchCapacityHistory(serviceID)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Str("service_id", serviceID).
+			Msg("Failed to fetch capacity history")
+		writeErrorResponse(w, http.StatusInternalServerError, "fetching_failed", err.Error())
+		return
+	}
+
+	response := map[string]interface{}{
+		"service_id": serviceID,
+		"series":     series,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Error().
+			Err(err).
+			Str("service_id", serviceID).
+			Msg("Failed to serialize response JSON")
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+}
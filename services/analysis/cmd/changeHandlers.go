@@ -0,0 +1,196 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/david-botos/BearHug/services/analysis/internal/hsds_types"
+	"github.com/david-botos/BearHug/services/analysis/internal/supabase"
+	"github.com/david-botos/BearHug/services/analysis/pkg/logger"
+)
+
+const (
+	defaultChangeLimit = 100
+	maxChangeLimit     = 1000
+	// defaultChangePollInterval is used when CHANGE_FEED_POLL_INTERVAL is not set
+	defaultChangePollInterval = 5 * time.Second
+)
+
+// ChangeFeedResponse is a page of the change feed. Pass next_cursor back as cursor to continue.
+type ChangeFeedResponse struct {
+	Changes    []supabase.Change `json:"changes"`
+	NextCursor string            `json:"next_cursor,omitempty"`
+	HasMore    bool              `json:"has_more"`
+}
+
+// parseChangeFeedParams reads the filters shared by the change feed and its stream
+func parseChangeFeedParams(r *http.Request) (supabase.ChangeFeedParams, error) {
+	query := r.URL.Query()
+	params := supabase.ChangeFeedParams{
+		ResourceType:   query.Get("resource_type"),
+		OrganizationID: query.Get("organization_id"),
+		Limit:          defaultChangeLimit,
+	}
+
+	if raw := query.Get("since"); raw != "" {
+		since, err := hsds_types.ParseTime(raw)
+		if err != nil {
+			return params, fmt.Errorf("since must be an ISO 8601 date or datetime")
+		}
+		params.Since = &since
+	}
+	if raw := query.Get("cursor"); raw != "" {
+		cursor, err := supabase.ParseChangeCursor(raw)
+		if err != nil {
+			return params, fmt.Errorf("cursor must be a next_cursor returned by the feed")
+		}
+		params.After = &cursor
+	}
+	if raw := query.Get("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 || limit > maxChangeLimit {
+			return params, fmt.Errorf("limit must be between 1 and %d", maxChangeLimit)
+		}
+		params.Limit = limit
+	}
+	if params.OrganizationID != "" && !hsds_types.ValidateUUID(params.OrganizationID) {
+		return params, fmt.Errorf("organization_id must be a UUID")
+	}
+
+	return params, nil
+}
+
+// handleChanges returns metadata changes oldest first, each linked to the call and transcript behind it
+func handleChanges(w http.ResponseWriter, r *http.Request) {
+	log := logger.Get()
+
+	params, err := parseChangeFeedParams(r)
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "invalid_query", err.Error())
+		return
+	}
+
+	changes, err := supabase.FetchChanges(params)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Msg("Failed to fetch changes")
+		writeErrorResponse(w, http.StatusInternalServerError, "fetching_failed", err.Error())
+		return
+	}
+
+	response := ChangeFeedResponse{
+		Changes: changes,
+		HasMore: len(changes) == params.Limit,
+	}
+	if len(changes) > 0 {
+		last := changes[len(changes)-1]
+		response.NextCursor = supabase.ChangeCursor{Seq: last.Seq}.String()
+	} else if params.After != nil {
+		response.NextCursor = params.After.String()
+	}
+	if response.Changes == nil {
+		response.Changes = []supabase.Change{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Error().
+			Err(err).
+			Msg("Failed to serialize response JSON")
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
+// handleChangeStream streams changes as server-sent events, polling for new ones until the client
+// disconnects. Each event's ID is a cursor, so reconnecting clients resume from Last-Event-ID.
+func handleChangeStream(w http.ResponseWriter, r *http.Request) {
+	log := logger.Get()
+
+	params, err := parseChangeFeedParams(r)
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "invalid_query", err.Error())
+		return
+	}
+	if lastEventID := r.Header.Get("Last-Event-ID"); lastEventID != "" {
+		cursor, err := supabase.ParseChangeCursor(lastEventID)
+		if err != nil {
+			writeErrorResponse(w, http.StatusBadRequest, "invalid_query", "Last-Event-ID must be an event ID sent by the stream")
+			return
+		}
+		params.After = &cursor
+	}
+	// Without a starting point only changes made after connecting are sent
+	if params.After == nil && params.Since == nil {
+		latest, err := supabase.LatestChangeCursor()
+		if err != nil {
+			log.Error().
+				Err(err).
+				Msg("Failed to find the latest change for stream")
+			writeErrorResponse(w, http.StatusInternalServerError, "fetching_failed", err.Error())
+			return
+		}
+		params.After = &latest
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeErrorResponse(w, http.StatusInternalServerError, "streaming_unsupported", "streaming is not supported")
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	flusher.Flush()
+
+	ticker := time.NewTicker(changePollInterval())
+	defer ticker.Stop()
+	for {
+		for {
+			changes, err := supabase.FetchChanges(params)
+			if err != nil {
+				log.Error().
+					Err(err).
+					Msg("Failed to fetch changes for stream")
+				break
+			}
+			for _, change := range changes {
+				data, err := json.Marshal(change)
+				if err != nil {
+					log.Error().
+						Err(err).
+						Str("change_id", change.ID).
+						Msg("Failed to serialize change")
+					continue
+				}
+				cursor := supabase.ChangeCursor{Seq: change.Seq}
+				fmt.Fprintf(w, "id: %s\nevent: change\ndata: %s\n\n", cursor, data)
+				params.After = &cursor
+			}
+			flusher.Flush()
+			if len(changes) < params.Limit {
+				break
+			}
+		}
+
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// changePollInterval returns how often the stream checks for new changes
+func changePollInterval() time.Duration {
+	if raw := os.Getenv("CHANGE_FEED_POLL_INTERVAL"); raw != "" {
+		if interval, err := time.ParseDuration(raw); err == nil && interval > 0 {
+			return interval
+		}
+	}
+	return defaultChangePollInterval
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/david-botos/BearHug/services/analysis/internal/hsds_types"
+	"github.com/david-botos/BearHug/services/analysis/internal/processor/structOutputs"
+	"github.com/david-botos/BearHug/services/analysis/internal/supabase"
+	"github.com/david-botos/BearHug/services/analysis/pkg/logger"
+)
+
+// handleContactReconciliation returns the contact reconciliation report stored for a call
+func handleContactReconciliation(w http.ResponseWriter, r *http.Request) {
+	log := logger.Get()
+
+	callID := r.PathValue("id")
+	if !hsds_types.ValidateUUID(callID) {
+		writeErrorResponse(w, http.StatusBadRequest, "invalid_call_id", "call id must be a UUID")
+		return
+	}
+
+	report, err := supabase.FetchContactReconciliationReport(callID)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Str("call_id", callID).
+			Msg("Failed to fetch contact reconciliation report")
+		writeErrorResponse(w, http.StatusInternalServerError, "fetching_failed", err.Error())
+		return
+	}
+	if report == nil {
+		writeErrorResponse(w, http.StatusNotFound, "not_found", "no contact reconciliation report for this call")
+		return
+	}
+
+	// The report is stored as JSON already, so it is passed through unchanged
+	w.Header().Set("Content-Type", "application/json")
+	if _, err := w.Write(report); err != nil {
+		log.Error().
+			Err(err).
+			Str("call_id", callID).
+			Msg("Failed to write response")
+	}
+}
+
+// handleContactMergeProposals lists groups of an organization's contacts that look like the same person
+func handleContactMergeProposals(w http.ResponseWriter, r *http.Request) {
+	log := logger.Get()
+
+	orgID := r.PathValue("id")
+	if !hsds_types.ValidateUUID(orgID) {
+		writeErrorResponse(w, http.StatusBadRequest, "invalid_organization_id", "organization id must be a UUID")
+		return
+	}
+
+	proposals, err := structOutputs.ProposeContactMerges(orgID)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Str("organization_id", orgID).
+			Msg("Failed to propose contact merges")
+		writeErrorResponse(w, http.StatusInternalServerError, "fetching_failed", err.Error())
+		return
+	}
+	if proposals == nil {
+		proposals = []structOutputs.ContactMergeProposal{}
+	}
+
+	response := map[string]interface{}{
+		"proposals": proposals,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Error().
+			Err(err).
+			Msg("Failed to serialize response JSON")
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+}
+
+// handleContactMerge merges approved duplicate contacts into the survivor
+func handleContactMerge(w http.ResponseWriter, r *http.Request) {
+	log := logger.Get()
+
+	var input supabase.ContactMergeInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "invalid_body", "Invalid request body")
+		return
+	}
+	if input.SurvivorID == "" || len(input.DuplicateIDs) == 0 {
+		writeErrorResponse(w, http.StatusBadRequest, "invalid_body", "survivor_id and duplicate_ids are required")
+		return
+	}
+
+	result, err := supabase.MergeContacts(input)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Str("survivor_id", input.SurvivorID).
+			Strs("duplicate_ids", input.DuplicateIDs).
+			Interface("partial_result", result).
+			Msg("Contact merge failed")
+		writeErrorResponse(w, http.StatusInternalServerError, "merge_failed", err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		log.Error().
+			Err(err).
+			Msg("Failed to serialize response JSON")
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+}
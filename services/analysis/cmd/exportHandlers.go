@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/david-botos/BearHug/services/analysis/internal/datapackage"
+	"github.com/david-botos/BearHug/services/analysis/internal/hsds_types"
+	"github.com/david-botos/BearHug/services/analysis/pkg/logger"
+)
+
+// handleExportDatapackage returns an HSDS tabular data package as a zip of CSVs and datapackage.json.
+// Pass organization_id to export a single organization and since to keep only records changed after it.
+func handleExportDatapackage(w http.ResponseWriter, r *http.Request) {
+	log := logger.Get()
+
+	query := r.URL.Query()
+	opts := datapackage.ExportOptions{OrganizationID: query.Get("organization_id")}
+	if opts.OrganizationID != "" && !hsds_types.ValidateUUID(opts.OrganizationID) {
+		writeErrorResponse(w, http.StatusBadRequest, "invalid_query", "organization_id must be a UUID")
+		return
+	}
+	if raw := query.Get("since"); raw != "" {
+		since, err := hsds_types.ParseTime(raw)
+		if err != nil {
+			writeErrorResponse(w, http.StatusBadRequest, "invalid_query", "since must be an ISO 8601 date or datetime")
+			return
+		}
+		opts.Since = &since
+	}
+
+	// Build the archive first so a failure part way through can still be reported as an error
+	var archive bytes.Buffer
+	if _, err := datapackage.Export(&archive, opts); err != nil {
+		if errors.Is(err, datapackage.ErrOrganizationNotFound) {
+			writeErrorResponse(w, http.StatusNotFound, "not_found", "organization not found")
+			return
+		}
+		log.Error().
+			Err(err).
+			Str("organization_id", opts.OrganizationID).
+			Msg("Failed to export data package")
+		writeErrorResponse(w, http.StatusInternalServerError, "export_failed", err.Error())
+		return
+	}
+
+	filename := "hsds"
+	if opts.OrganizationID != "" {
+		filename += "_" + opts.OrganizationID
+	}
+	filename += "_" + time.Now().UTC().Format("20060102T150405Z") + ".zip"
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.Header().Set("Content-Length", strconv.Itoa(archive.Len()))
+	if _, err := archive.WriteTo(w); err != nil {
+		log.Error().
+			Err(err).
+			Msg("Failed to write data package response")
+	}
+}
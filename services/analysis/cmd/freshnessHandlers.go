@@ -0,0 +1,157 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/david-botos/BearHug/services/analysis/internal/freshness"
+	"github.com/david-botos/BearHug/services/analysis/internal/hsds_types"
+	"github.com/david-botos/BearHug/services/analysis/pkg/logger"
+)
+
+const (
+	defaultQueueLimit = 50
+	maxQueueLimit     = 1000
+)
+
+// ReverificationQueueResponse is the re-verification queue, most in need of a call first
+type ReverificationQueueResponse struct {
+	Queue          []freshness.QueueEntry `json:"queue"`
+	Total          int                    `json:"total"`
+	InCallingHours bool                   `json:"in_calling_hours"`
+}
+
+// handleOrganizationFreshness returns when each field of an organization and its services was last confirmed
+func handleOrganizationFreshness(w http.ResponseWriter, r *http.Request) {
+	log := logger.Get()
+
+	organizationID := r.PathValue("id")
+	if !hsds_types.ValidateUUID(organizationID) {
+		writeErrorResponse(w, http.StatusBadRequest, "invalid_organization_id", "organization id must be a UUID")
+		return
+	}
+
+	result, err := freshness.ForOrganization(organizationID, time.Now())
+	switch {
+	case errors.Is(err, freshness.ErrOrganizationNotFound):
+		writeErrorResponse(w, http.StatusNotFound, "not_found", "organization not found")
+		return
+	case err != nil:
+		log.Error().
+			Err(err).
+			Str("organization_id", organizationID).
+			Msg("Failed to score organization freshness")
+		writeErrorResponse(w, http.StatusInternalServerError, "fetching_failed", err.Error())
+		return
+	}
+
+	writeHSDSResponse(w, result)
+}
+
+// handleServiceFreshness returns when each field of a service was last confirmed
+func handleServiceFreshness(w http.ResponseWriter, r *http.Request) {
+	log := logger.Get()
+
+	serviceID := r.PathValue("id")
+	if !hsds_types.ValidateUUID(serviceID) {
+		writeErrorResponse(w, http.StatusBadRequest, "invalid_service_id", "service id must be a UUID")
+		return
+	}
+
+	result, err := freshness.ForService(serviceID, time.Now())
+	switch {
+	case errors.Is(err, freshness.ErrServiceNotFound), errors.Is(err, freshness.ErrOrganizationNotFound):
+		writeErrorResponse(w, http.StatusNotFound, "not_found", "service not found")
+		return
+	case err != nil:
+		log.Error().
+			Err(err).
+			Str("service_id", serviceID).
+			Msg("Failed to score service freshness")
+		writeErrorResponse(w, http.StatusInternalServerError, "fetching_failed", err.Error())
+		return
+	}
+
+	writeHSDSResponse(w, result)
+}
+
+// handleReverificationQueue lists organizations with stale fields in the order the scheduler would call
+// them. Organizations that can't be dialed are left out unless include_blocked=true.
+func handleReverificationQueue(w http.ResponseWriter, r *http.Request) {
+	log := logger.Get()
+
+	query := r.URL.Query()
+	limit := defaultQueueLimit
+	if raw := query.Get("limit"); raw != "" {
+		value, err := strconv.Atoi(raw)
+		if err != nil || value < 1 || value > maxQueueLimit {
+			writeErrorResponse(w, http.StatusBadRequest, "invalid_query", "limit must be between 1 and 1000")
+			return
+		}
+		limit = value
+	}
+	includeBlocked := query.Get("include_blocked") == "true"
+
+	config, err := freshness.LoadConfig()
+	if err != nil && !errors.Is(err, freshness.ErrGoBotNotConfigured) {
+		writeErrorResponse(w, http.StatusInternalServerError, "invalid_configuration", err.Error())
+		return
+	}
+
+	now := time.Now()
+	queue, err := freshness.Queue(config, now)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Msg("Failed to build re-verification queue")
+		writeErrorResponse(w, http.StatusInternalServerError, "fetching_failed", err.Error())
+		return
+	}
+
+	response := ReverificationQueueResponse{
+		Queue:          []freshness.QueueEntry{},
+		InCallingHours: config.Window.Contains(now),
+	}
+	for _, entry := range queue {
+		if entry.Blocked != "" && !includeBlocked {
+			continue
+		}
+		response.Total++
+		if len(response.Queue) < limit {
+			response.Queue = append(response.Queue, entry)
+		}
+	}
+
+	writeHSDSResponse(w, response)
+}
+
+// handleReverificationRun runs one scheduler pass now. With dry_run=true it reports who would be called.
+func handleReverificationRun(w http.ResponseWriter, r *http.Request) {
+	log := logger.Get()
+
+	dryRun := r.URL.Query().Get("dry_run") == "true"
+
+	config, err := freshness.LoadConfig()
+	if err != nil && !errors.Is(err, freshness.ErrGoBotNotConfigured) {
+		writeErrorResponse(w, http.StatusInternalServerError, "invalid_configuration", err.Error())
+		return
+	}
+
+	result, err := freshness.RunOnce(config, time.Now(), dryRun)
+	switch {
+	case errors.Is(err, freshness.ErrGoBotNotConfigured):
+		writeErrorResponse(w, http.StatusServiceUnavailable, "not_configured", err.Error())
+		return
+	case err != nil:
+		log.Error().
+			Err(err).
+			Interface("partial_result", result).
+			Msg("Re-verification pass failed")
+		writeErrorResponse(w, http.StatusInternalServerError, "reverification_failed", err.Error())
+		return
+	}
+
+	writeHSDSResponse(w, result)
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/david-botos/BearHug/services/analysis/internal/hsds_types"
+	"github.com/david-botos/BearHug/services/analysis/internal/supabase"
+	"github.com/david-botos/BearHug/services/analysis/pkg/logger"
+)
+
+// Read endpoints following the HSDS 3.0 API Reference. List endpoints take page, per_page and
+// modified_after, and return the reference's paged envelope.
+
+const (
+	defaultPerPage = 50
+	maxPerPage     = 200
+)
+
+// parseHSDSListParams reads the paging and filter query parameters shared by the list endpoints
+func parseHSDSListParams(r *http.Request) (supabase.HSDSListParams, error) {
+	query := r.URL.Query()
+	params := supabase.HSDSListParams{
+		Page:           1,
+		PerPage:        defaultPerPage,
+		Search:         query.Get("search"),
+		OrganizationID: query.Get("organization_id"),
+	}
+
+	if raw := query.Get("page"); raw != "" {
+		page, err := strconv.Atoi(raw)
+		if err != nil || page < 1 {
+			return params, fmt.Errorf("page must be a positive integer")
+		}
+		params.Page = page
+	}
+	if raw := query.Get("per_page"); raw != "" {
+		perPage, err := strconv.Atoi(raw)
+		if err != nil || perPage < 1 || perPage > maxPerPage {
+			return params, fmt.Errorf("per_page must be between 1 and %d", maxPerPage)
+		}
+		params.PerPage = perPage
+	}
+	if raw := query.Get("modified_after"); raw != "" {
+		modifiedAfter, err := hsds_types.ParseTime(raw)
+		if err != nil {
+			return params, fmt.Errorf("modified_after must be an ISO 8601 date or datetime")
+		}
+		params.ModifiedAfter = &modifiedAfter
+	}
+	if params.OrganizationID != "" && !hsds_types.ValidateUUID(params.OrganizationID) {
+		return params, fmt.Errorf("organization_id must be a UUID")
+	}
+
+	return params, nil
+}
+
+// writeHSDSResponse encodes a read API response
+func writeHSDSResponse(w http.ResponseWriter, response interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		logger.Get().Error().
+			Err(err).
+			Msg("Failed to serialize response JSON")
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
+// handleListServices returns a page of services with their related objects nested
+func handleListServices(w http.ResponseWriter, r *http.Request) {
+	log := logger.Get()
+
+	params, err := parseHSDSListParams(r)
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "invalid_query", err.Error())
+		return
+	}
+
+	page, err := supabase.FetchHSDSServices(params)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Msg("Failed to fetch services")
+		writeErrorResponse(w, http.StatusInternalServerError, "fetching_failed", err.Error())
+		return
+	}
+
+	writeHSDSResponse(w, page)
+}
+
+// handleGetService returns one service with its related objects nested
+func handleGetService(w http.ResponseWriter, r *http.Request) {
+	log := logger.Get()
+
+	serviceID := r.PathValue("id")
+	if !hsds_types.ValidateUUID(serviceID) {
+		writeErrorResponse(w, http.StatusBadRequest, "invalid_service_id", "service id must be a UUID")
+		return
+	}
+
+	service, err := supabase.FetchHSDSService(serviceID)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Str("service_id", serviceID).
+			Msg("Failed to fetch service")
+		writeErrorResponse(w, http.StatusInternalServerError, "fetching_failed", err.Error())
+		return
+	}
+	if service == nil {
+		writeErrorResponse(w, http.StatusNotFound, "not_found", "service not found")
+		return
+	}
+
+	writeHSDSResponse(w, service)
+}
+
+// handleListOrganizations returns a page of organizations. Pass full_service=true to nest their services.
+func handleListOrganizations(w http.ResponseWriter, r *http.Request) {
+	log := logger.Get()
+
+	params, err := parseHSDSListParams(r)
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "invalid_query", err.Error())
+		return
+	}
+	fullServices, _ := strconv.ParseBool(r.URL.Query().Get("full_service"))
+
+	page, err := supabase.FetchHSDSOrganizations(params, fullServices)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Msg("Failed to fetch organizations")
+		writeErrorResponse(w, http.StatusInternalServerError, "fetching_failed", err.Error())
+		return
+	}
+
+	writeHSDSResponse(w, page)
+}
+
+// handleGetOrganization returns one organization with its services and other related objects nested
+func handleGetOrganization(w http.ResponseWriter, r *http.Request) {
+	log := logger.Get()
+
+	orgID := r.PathValue("id")
+	if !hsds_types.ValidateUUID(orgID) {
+		writeErrorResponse(w, http.StatusBadRequest, "invalid_organization_id", "organization id must be a UUID")
+		return
+	}
+
+	organization, err := supabase.FetchHSDSOrganization(orgID)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Str("organization_id", orgID).
+			Msg("Failed to fetch organization")
+		writeErrorResponse(w, http.StatusInternalServerError, "fetching_failed", err.Error())
+		return
+	}
+	if organization == nil {
+		writeErrorResponse(w, http.StatusNotFound, "not_found", "organization not found")
+		return
+	}
+
+	writeHSDSResponse(w, organization)
+}
+
+// handleListServiceAtLocations returns a page of service at locations with their service and location nested
+func handleListServiceAtLocations(w http.ResponseWriter, r *http.Request) {
+	log := logger.Get()
+
+	params, err := parseHSDSListParams(r)
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "invalid_query", err.Error())
+		return
+	}
+
+	page, err := supabase.FetchHSDSServiceAtLocations(params)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Msg("Failed to fetch service at locations")
+		writeErrorResponse(w, http.StatusInternalServerError, "fetching_failed", err.Error())
+		return
+	}
+
+	writeHSDSResponse(w, page)
+}
+
+// handleListTaxonomies returns a page of taxonomies
+func handleListTaxonomies(w http.ResponseWriter, r *http.Request) {
+	log := logger.Get()
+
+	params, err := parseHSDSListParams(r)
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "invalid_query", err.Error())
+		return
+	}
+
+	page, err := supabase.FetchHSDSTaxonomies(params)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Msg("Failed to fetch taxonomies")
+		writeErrorResponse(w, http.StatusInternalServerError, "fetching_failed", err.Error())
+		return
+	}
+
+	writeHSDSResponse(w, page)
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/david-botos/BearHug/services/analysis/internal/datapackage"
+	"github.com/david-botos/BearHug/services/analysis/pkg/logger"
+)
+
+// maxImportBytes caps the size of an uploaded data package
+const maxImportBytes = 100 << 20
+
+// handleImport seeds Supabase from a zipped HSDS tabular data package or HSDS JSON. Pass dry_run=true to
+// see what would change without writing, and updated_by to name the source on the metadata rows.
+func handleImport(w http.ResponseWriter, r *http.Request) {
+	log := logger.Get()
+
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxImportBytes))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeErrorResponse(w, http.StatusRequestEntityTooLarge, "payload_too_large", "import must be under 100MB")
+			return
+		}
+		writeErrorResponse(w, http.StatusBadRequest, "invalid_request", "failed to read request body")
+		return
+	}
+
+	var records datapackage.Records
+	if strings.Contains(r.Header.Get("Content-Type"), "zip") || bytes.HasPrefix(data, []byte("PK\x03\x04")) {
+		records, err = datapackage.ReadTabular(data)
+	} else {
+		records, err = datapackage.ReadJSON(data)
+	}
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "invalid_import", err.Error())
+		return
+	}
+
+	dryRun, _ := strconv.ParseBool(r.URL.Query().Get("dry_run"))
+	result, err := datapackage.Import(records, datapackage.ImportOptions{
+		UpdatedBy: r.URL.Query().Get("updated_by"),
+		DryRun:    dryRun,
+	})
+	if err != nil {
+		log.Error().
+			Err(err).
+			Msg("Failed to import HSDS records")
+		writeErrorResponse(w, http.StatusInternalServerError, "import_failed", err.Error())
+		return
+	}
+
+	writeHSDSResponse(w, result)
+}
@@ -1,10 +1,14 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 
+	"github.com/david-botos/BearHug/services/analysis/internal/freshness"
+	"github.com/david-botos/BearHug/services/analysis/internal/language"
 	"github.com/david-botos/BearHug/services/analysis/internal/processor"
+	"github.com/david-botos/BearHug/services/analysis/internal/redaction"
 	"github.com/david-botos/BearHug/services/analysis/internal/supabase"
 	"github.com/david-botos/BearHug/services/analysis/internal/types"
 	"github.com/david-botos/BearHug/services/analysis/pkg/logger"
@@ -71,6 +75,12 @@ func handleTranscript(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Mask client PII before the transcript is logged, stored or sent for inference
+	redactionMap := redactTranscript(&reqBody)
+
+	// Record the spoken language; HSDS fields are written in English whatever it is
+	reqBody.Language = resolveLanguage(reqBody)
+
 	// Store transcript synchronously
 	callID, err := supabase.StoreCallData(reqBody)
 	if err != nil {
@@ -83,12 +93,22 @@ func handleTranscript(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := supabase.StoreTranscriptRedactions(callID, redactionMap); err != nil {
+		log.Error().
+			Err(err).
+			Str("request_id", requestID).
+			Str("call_id", callID).
+			Int("redaction_count", len(redactionMap.Entries)).
+			Msg("Failed to store redaction map")
+	}
+
 	// Create a new reqBody with callID
 	procTranscriptParams := types.ProcTranscriptParams{
 		OrganizationID: reqBody.OrganizationID,
 		RoomURL:        reqBody.RoomURL,
-		Transcript:     reqBody.Transcript,
+		Transcript:     reqBody.Transcript.Render(),
 		CallID:         callID,
+		Language:       reqBody.Language,
 	}
 
 	// Process transcript synchronously
@@ -112,6 +132,10 @@ func handleTranscript(w http.ResponseWriter, r *http.Request) {
 		"call_id": callID,
 		"result":  result,
 	}
+	if !result.Success {
+		response["status"] = "partial_success"
+		response["message"] = "Transcript processed, but some detail categories failed"
+	}
 
 	if err := json.NewEncoder(w).Encode(response); err != nil {
 		log.Error().
@@ -123,6 +147,62 @@ func handleTranscript(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// redactTranscript masks the request's transcript in place and returns what was masked. The
+// organization's known staff are protected so their names survive for contact records.
+func redactTranscript(reqBody *types.TranscriptsReqBody) redaction.Map {
+	log := logger.Get()
+
+	var staffNames []string
+	contacts, err := supabase.FetchOrgContacts(reqBody.OrganizationID)
+	if err != nil {
+		// Redacting a staff name by mistake is safer than skipping redaction
+		log.Warn().
+			Err(err).
+			Str("organization_id", reqBody.OrganizationID).
+			Msg("Failed to fetch staff contacts, redacting without protected names")
+	}
+	for _, contact := range contacts {
+		if contact.Name != nil {
+			staffNames = append(staffNames, *contact.Name)
+		}
+	}
+
+	redacted, redactionMap := redaction.RedactTranscript(reqBody.Transcript, redaction.LoadPolicy(), staffNames...)
+	reqBody.Transcript = redacted
+
+	log.Info().
+		Str("organization_id", reqBody.OrganizationID).
+		Bool("redaction_enabled", redactionMap.Policy.Enabled).
+		Int("redaction_count", len(redactionMap.Entries)).
+		Msg("Redacted transcript")
+
+	return redactionMap
+}
+
+// resolveLanguage returns the ISO code of the transcript's language, trusting the caller's value when it
+// is a language we know and detecting it otherwise
+func resolveLanguage(reqBody types.TranscriptsReqBody) string {
+	log := logger.Get()
+
+	if reqBody.Language != "" {
+		if code, _, ok := language.Lookup(reqBody.Language); ok {
+			return code
+		}
+		log.Warn().
+			Str("language", reqBody.Language).
+			Msg("Unrecognized transcript language, detecting it instead")
+	}
+
+	detection := language.DetectTranscript(reqBody.Transcript)
+	log.Info().
+		Str("organization_id", reqBody.OrganizationID).
+		Str("language", detection.Code).
+		Float64("confidence", detection.Confidence).
+		Msg("Detected transcript language")
+
+	return detection.Code
+}
+
 func writeErrorResponse(w http.ResponseWriter, statusCode int, errorCode, message string) {
 	log := logger.Get()
 
@@ -162,6 +242,42 @@ func main() {
 	// Configure routes
 	http.HandleFunc("/transcript", handleTranscript)
 	http.HandleFunc("/test", handleTest)
+	http.HandleFunc("GET /services/{id}/capacity/history", handleCapacityHistory)
+	http.HandleFunc("GET /units/merge-proposals", handleUnitMergeProposals)
+	http.HandleFunc("POST /units/merge", handleUnitMerge)
+	http.HandleFunc("GET /calls/{id}/contacts/reconciliation", handleContactReconciliation)
+	http.HandleFunc("GET /calls/{id}/redactions", handleTranscriptRedactions)
+	http.HandleFunc("POST /calls/{id}/revert", handleCallRevert)
+	http.HandleFunc("GET /organizations/{id}/contacts/merge-proposals", handleContactMergeProposals)
+	http.HandleFunc("POST /contacts/merge", handleContactMerge)
+
+	// HSDS read API
+	http.HandleFunc("GET /services", handleListServices)
+	http.HandleFunc("GET /services/{id}", handleGetService)
+	http.HandleFunc("GET /organizations", handleListOrganizations)
+	http.HandleFunc("GET /organizations/{id}", handleGetOrganization)
+	http.HandleFunc("GET /service_at_locations", handleListServiceAtLocations)
+	http.HandleFunc("GET /taxonomies", handleListTaxonomies)
+	http.HandleFunc("GET /export/datapackage", handleExportDatapackage)
+	http.HandleFunc("POST /import", handleImport)
+	http.HandleFunc("GET /changes", handleChanges)
+	http.HandleFunc("GET /changes/stream", handleChangeStream)
+
+	// Data freshness and re-verification
+	http.HandleFunc("GET /organizations/{id}/freshness", handleOrganizationFreshness)
+	http.HandleFunc("GET /services/{id}/freshness", handleServiceFreshness)
+	http.HandleFunc("GET /reverification/queue", handleReverificationQueue)
+	http.HandleFunc("POST /reverification/run", handleReverificationRun)
+
+	reverifyConfig, err := freshness.LoadConfig()
+	switch {
+	case err != nil:
+		log.Error().
+			Err(err).
+			Msg("Invalid re-verification configuration, scheduler not started")
+	case reverifyConfig.Enabled:
+		go freshness.Run(context.Background(), reverifyConfig)
+	}
 
 	port := "8500"
 	log.Info().
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/david-botos/BearHug/services/analysis/internal/hsds_types"
+	"github.com/david-botos/BearHug/services/analysis/internal/supabase"
+	"github.com/david-botos/BearHug/services/analysis/pkg/logger"
+)
+
+// handleTranscriptRedactions returns the audit map of what was redacted from a call's transcript
+func handleTranscriptRedactions(w http.ResponseWriter, r *http.Request) {
+	log := logger.Get()
+
+	callID := r.PathValue("id")
+	if !hsds_types.ValidateUUID(callID) {
+		writeErrorResponse(w, http.StatusBadRequest, "invalid_call_id", "call id must be a UUID")
+		return
+	}
+
+	redactionMap, err := supabase.FetchTranscriptRedactions(callID)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Str("call_id", callID).
+			Msg("Failed to fetch transcript redactions")
+		writeErrorResponse(w, http.StatusInternalServerError, "fetching_failed", err.Error())
+		return
+	}
+	if redactionMap == nil {
+		writeErrorResponse(w, http.StatusNotFound, "not_found", "no redaction map for this call")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if _, err := w.Write(redactionMap); err != nil {
+		log.Error().
+			Err(err).
+			Str("call_id", callID).
+			Msg("Failed to write response")
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/david-botos/BearHug/services/analysis/internal/hsds_types"
+	"github.com/david-botos/BearHug/services/analysis/internal/supabase"
+	"github.com/david-botos/BearHug/services/analysis/pkg/logger"
+)
+
+// handleCallRevert undoes the directory changes a call made, reporting any it had to leave in place
+func handleCallRevert(w http.ResponseWriter, r *http.Request) {
+	log := logger.Get()
+
+	callID := r.PathValue("id")
+	if !hsds_types.ValidateUUID(callID) {
+		writeErrorResponse(w, http.StatusBadRequest, "invalid_call_id", "call id must be a UUID")
+		return
+	}
+
+	// The body is optional
+	var input supabase.CallRevertInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil && !errors.Is(err, io.EOF) {
+		writeErrorResponse(w, http.StatusBadRequest, "invalid_body", "Invalid request body")
+		return
+	}
+
+	result, err := supabase.RevertCall(callID, input)
+	switch {
+	case errors.Is(err, supabase.ErrCallNotFound):
+		writeErrorResponse(w, http.StatusNotFound, "not_found", "call not found")
+		return
+	case errors.Is(err, supabase.ErrCallAlreadyReverted):
+		writeErrorResponse(w, http.StatusConflict, "already_reverted", err.Error())
+		return
+	case err != nil:
+		log.Error().
+			Err(err).
+			Str("call_id", callID).
+			Interface("partial_result", result).
+			Msg("Call revert failed")
+		writeErrorResponse(w, http.StatusInternalServerError, "revert_failed", err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		log.Error().
+			Err(err).
+			Msg("Failed to serialize response JSON")
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
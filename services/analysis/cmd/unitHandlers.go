@@ -0,0 +1,70 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/david-botos/BearHug/services/analysis/internal/processor/structOutputs"
+	"github.com/david-botos/BearHug/services/analysis/internal/supabase"
+	"github.com/david-botos/BearHug/services/analysis/pkg/logger"
+)
+
+// handleUnitMergeProposals lists stored units that look like duplicates of each other
+func handleUnitMergeProposals(w http.ResponseWriter, r *http.Request) {
+	log := logger.Get()
+
+	registry, err := structOutputs.LoadUnitRegistry()
+	if err != nil {
+		writeErrorResponse(w, http.StatusInternalServerError, "fetching_failed", err.Error())
+		return
+	}
+
+	response := map[string]interface{}{
+		"proposals": registry.ProposeUnitMerges(),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Error().
+			Err(err).
+			Msg("Failed to serialize response JSON")
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+}
+
+// handleUnitMerge folds the source units into the target unit
+func handleUnitMerge(w http.ResponseWriter, r *http.Request) {
+	log := logger.Get()
+
+	var input supabase.UnitMergeInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "invalid_body", "Invalid request body")
+		return
+	}
+	if input.TargetID == "" || len(input.SourceIDs) == 0 {
+		writeErrorResponse(w, http.StatusBadRequest, "invalid_body", "target_id and source_ids are required")
+		return
+	}
+
+	result, err := supabase.MergeUnits(input)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Str("target_id", input.TargetID).
+			Strs("source_ids", input.SourceIDs).
+			Interface("partial_result", result).
+			Msg("Unit merge failed")
+		writeErrorResponse(w, http.StatusInternalServerError, "merge_failed", err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		log.Error().
+			Err(err).
+			Msg("Failed to serialize response JSON")
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+}
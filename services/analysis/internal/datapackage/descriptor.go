@@ -0,0 +1,138 @@
+package datapackage
+
+import (
+	"strings"
+	"time"
+)
+
+// Descriptor is the datapackage.json of an HSDS tabular data package
+type Descriptor struct {
+	Name        string     `json:"name"`
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	Profile     string     `json:"profile"`
+	Version     string     `json:"version"`
+	Created     string     `json:"created"`
+	Resources   []Resource `json:"resources"`
+}
+
+// Resource describes one CSV file of the package
+type Resource struct {
+	Name      string         `json:"name"`
+	Path      string         `json:"path"`
+	Format    string         `json:"format"`
+	MediaType string         `json:"mediatype"`
+	Encoding  string         `json:"encoding"`
+	Profile   string         `json:"profile"`
+	Schema    ResourceSchema `json:"schema"`
+}
+
+type ResourceSchema struct {
+	Fields      []Field             `json:"fields"`
+	PrimaryKey  string              `json:"primaryKey,omitempty"`
+	ForeignKeys []ResourceReference `json:"foreignKeys,omitempty"`
+}
+
+type Field struct {
+	Name        string           `json:"name"`
+	Type        string           `json:"type"`
+	Format      string           `json:"format,omitempty"`
+	Description string           `json:"description,omitempty"`
+	Constraints FieldConstraints `json:"constraints,omitempty"`
+}
+
+type FieldConstraints struct {
+	Required bool     `json:"required,omitempty"`
+	Unique   bool     `json:"unique,omitempty"`
+	Enum     []string `json:"enum,omitempty"`
+}
+
+type ResourceReference struct {
+	Fields    string         `json:"fields"`
+	Reference ReferenceField `json:"reference"`
+}
+
+type ReferenceField struct {
+	Resource string `json:"resource"`
+	Fields   string `json:"fields"`
+}
+
+// buildDescriptor describes the exported tables following the HSDS 3.0 tabular data package
+func buildDescriptor(tables []Table, opts ExportOptions) Descriptor {
+	description := "Human Services Data Specification 3.0 export"
+	if opts.OrganizationID != "" {
+		description += " of organization " + opts.OrganizationID
+	}
+	if opts.Since != nil {
+		description += ", limited to records changed since " + opts.Since.UTC().Format(time.RFC3339)
+	}
+
+	resources := make([]Resource, 0, len(tables))
+	for _, table := range tables {
+		schema := ResourceSchema{PrimaryKey: table.PrimaryKey}
+		for _, column := range table.Columns {
+			fieldType, format := fieldType(column.SQLType)
+			schema.Fields = append(schema.Fields, Field{
+				Name:        column.Name,
+				Type:        fieldType,
+				Format:      format,
+				Description: column.Description,
+				Constraints: FieldConstraints{
+					Required: column.Required,
+					Unique:   column.Name == table.PrimaryKey,
+					Enum:     column.Enum,
+				},
+			})
+		}
+		for _, key := range table.ForeignKeys {
+			schema.ForeignKeys = append(schema.ForeignKeys, ResourceReference{
+				Fields: key.Column,
+				Reference: ReferenceField{
+					Resource: key.ReferenceTable,
+					Fields:   key.ReferenceField,
+				},
+			})
+		}
+
+		resources = append(resources, Resource{
+			Name:      table.Name,
+			Path:      table.Name + ".csv",
+			Format:    "csv",
+			MediaType: "text/csv",
+			Encoding:  "utf-8",
+			Profile:   "tabular-data-resource",
+			Schema:    schema,
+		})
+	}
+
+	return Descriptor{
+		Name:        "human_services_data",
+		Title:       "Human Services Data",
+		Description: description,
+		Profile:     "tabular-data-package",
+		Version:     "3.0",
+		Created:     time.Now().UTC().Format(time.RFC3339),
+		Resources:   resources,
+	}
+}
+
+// fieldType maps a column's SQL type to a Table Schema type and format
+func fieldType(sqlType string) (string, string) {
+	switch {
+	case sqlType == "date":
+		return "date", "%Y-%m-%d"
+	case strings.HasPrefix(sqlType, "timestamp"):
+		return "datetime", ""
+	case strings.HasPrefix(sqlType, "time"):
+		return "time", "%H:%M:%S"
+	case strings.HasPrefix(sqlType, "numeric"):
+		return "number", ""
+	case sqlType == "integer", sqlType == "bigint", sqlType == "smallint":
+		return "integer", ""
+	case sqlType == "boolean":
+		return "boolean", ""
+	case sqlType == "json", sqlType == "jsonb":
+		return "object", ""
+	}
+	return "string", ""
+}
@@ -0,0 +1,391 @@
+package datapackage
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/david-botos/BearHug/services/analysis/internal/hsds_types"
+	"github.com/david-botos/BearHug/services/analysis/internal/supabase"
+	"github.com/david-botos/BearHug/services/analysis/pkg/logger"
+)
+
+// ErrOrganizationNotFound is returned when an export is scoped to an organization that does not exist
+var ErrOrganizationNotFound = errors.New("organization not found")
+
+// ExportOptions scope an export. The zero value exports the whole database.
+type ExportOptions struct {
+	OrganizationID string     // Only the organization and the records that belong to it
+	Since          *time.Time // Only records with metadata recorded on or after this time
+}
+
+// decoders read each table's rows through its hsds_types struct, so values are normalized the same way as
+// everywhere else in the service
+var decoders = map[string]func([]json.RawMessage) ([]map[string]interface{}, error){
+	"accessibility":           decodeRows[hsds_types.Accessibility],
+	"address":                 decodeRows[hsds_types.Address],
+	"attribute":               decodeRows[hsds_types.Attribute],
+	"contact":                 decodeRows[hsds_types.Contact],
+	"cost_option":             decodeRows[hsds_types.CostOption],
+	"funding":                 decodeRows[hsds_types.Funding],
+	"language":                decodeRows[hsds_types.Language],
+	"location":                decodeRows[hsds_types.Location],
+	"meta_table_description":  decodeRows[hsds_types.MetaTableDescription],
+	"metadata":                decodeRows[hsds_types.Metadata],
+	"organization":            decodeRows[hsds_types.Organization],
+	"organization_identifier": decodeRows[hsds_types.OrganizationIdentifier],
+	"phone":                   decodeRows[hsds_types.Phone],
+	"program":                 decodeRows[hsds_types.Program],
+	"required_document":       decodeRows[hsds_types.RequiredDocument],
+	"schedule":                decodeRows[hsds_types.Schedule],
+	"service":                 decodeRows[hsds_types.Service],
+	"service_area":            decodeRows[hsds_types.ServiceArea],
+	"service_at_location":     decodeRows[hsds_types.ServiceAtLocation],
+	"service_capacity":        decodeRows[hsds_types.ServiceCapacity],
+	"taxonomy":                decodeRows[hsds_types.Taxonomy],
+	"taxonomy_term":           decodeRows[hsds_types.TaxonomyTerm],
+	"unit":                    decodeRows[hsds_types.Unit],
+	"url":                     decodeRows[hsds_types.URL],
+}
+
+// scopeRule pulls in the rows of Table whose Column matches a value of FromColumn in the rows already
+// collected for From. From "*" matches the id of any collected row.
+type scopeRule struct {
+	Table      string
+	Column     string
+	From       string
+	FromColumn string
+}
+
+const anyCollected = "*"
+
+// organizationScope walks out from an organization to everything that belongs to it. Rules run in order,
+// so a table is only used as a source after all of its own rules have run.
+var organizationScope = []scopeRule{
+	{"service", "organization_id", "organization", "id"},
+	{"program", "organization_id", "organization", "id"},
+	{"location", "organization_id", "organization", "id"},
+	{"organization_identifier", "organization_id", "organization", "id"},
+	{"service_at_location", "service_id", "service", "id"},
+	{"contact", "organization_id", "organization", "id"},
+	{"contact", "service_id", "service", "id"},
+	{"contact", "location_id", "location", "id"},
+	{"contact", "service_at_location_id", "service_at_location", "id"},
+	{"phone", "organization_id", "organization", "id"},
+	{"phone", "service_id", "service", "id"},
+	{"phone", "location_id", "location", "id"},
+	{"phone", "service_at_location_id", "service_at_location", "id"},
+	{"phone", "contact_id", "contact", "id"},
+	{"schedule", "service_id", "service", "id"},
+	{"schedule", "location_id", "location", "id"},
+	{"schedule", "service_at_location_id", "service_at_location", "id"},
+	{"service_area", "service_id", "service", "id"},
+	{"service_area", "service_at_location_id", "service_at_location", "id"},
+	{"address", "location_id", "location", "id"},
+	{"accessibility", "location_id", "location", "id"},
+	{"language", "service_id", "service", "id"},
+	{"language", "location_id", "location", "id"},
+	{"language", "phone_id", "phone", "id"},
+	{"url", "organization_id", "organization", "id"},
+	{"url", "service_id", "service", "id"},
+	{"funding", "organization_id", "organization", "id"},
+	{"funding", "service_id", "service", "id"},
+	{"required_document", "service_id", "service", "id"},
+	{"cost_option", "service_id", "service", "id"},
+	{"service_capacity", "service_id", "service", "id"},
+	{"unit", "id", "service_capacity", "unit_id"},
+	{"attribute", "link_id", anyCollected, "id"},
+	{"taxonomy_term", "id", "attribute", "taxonomy_term_id"},
+	{"taxonomy_term", "id", "taxonomy_term", "parent_id"},
+	{"taxonomy", "id", "taxonomy_term", "taxonomy_id"},
+	{"metadata", "resource_id", anyCollected, "id"},
+}
+
+// sharedTables are exported whole even when the export is scoped to an organization
+var sharedTables = map[string]bool{
+	"meta_table_description": true,
+}
+
+// Export writes an HSDS tabular data package to w as a zip archive: one CSV per HSDS table and a
+// datapackage.json describing them. It returns the number of rows written per table.
+func Export(w io.Writer, opts ExportOptions) (map[string]int, error) {
+	log := logger.Get()
+
+	tables, err := HSDSTables()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read HSDS table definitions: %w", err)
+	}
+	tablesByName := make(map[string]Table, len(tables))
+	for _, table := range tables {
+		if _, ok := decoders[table.Name]; !ok {
+			return nil, fmt.Errorf("no hsds_types struct for table %s", table.Name)
+		}
+		tablesByName[table.Name] = table
+	}
+
+	var rows map[string][]map[string]interface{}
+	if opts.OrganizationID != "" {
+		rows, err = collectOrganization(tablesByName, opts.OrganizationID)
+	} else {
+		rows, err = collectAll(tables)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.Since != nil {
+		changed, err := supabase.FetchChangedResourceIDs(*opts.Since)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find changed records: %w", err)
+		}
+		for name := range rows {
+			rows[name] = changedRows(name, rows[name], changed, *opts.Since)
+		}
+	}
+
+	archive := zip.NewWriter(w)
+	counts := make(map[string]int, len(tables))
+	for _, table := range tables {
+		file, err := archive.Create(table.Name + ".csv")
+		if err != nil {
+			return nil, fmt.Errorf("failed to add %s.csv: %w", table.Name, err)
+		}
+		if err := writeCSV(file, table, rows[table.Name]); err != nil {
+			return nil, fmt.Errorf("failed to write %s.csv: %w", table.Name, err)
+		}
+		counts[table.Name] = len(rows[table.Name])
+	}
+
+	descriptor, err := json.MarshalIndent(buildDescriptor(tables, opts), "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode datapackage.json: %w", err)
+	}
+	file, err := archive.Create("datapackage.json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to add datapackage.json: %w", err)
+	}
+	if _, err := file.Write(descriptor); err != nil {
+		return nil, fmt.Errorf("failed to write datapackage.json: %w", err)
+	}
+
+	if err := archive.Close(); err != nil {
+		return nil, fmt.Errorf("failed to finish archive: %w", err)
+	}
+
+	log.Info().
+		Str("organization_id", opts.OrganizationID).
+		Interface("row_counts", counts).
+		Msg("Exported HSDS data package")
+	return counts, nil
+}
+
+// collectAll reads every row of every table
+func collectAll(tables []Table) (map[string][]map[string]interface{}, error) {
+	rows := make(map[string][]map[string]interface{}, len(tables))
+	for _, table := range tables {
+		tableRows, err := fetchRows(table, "", nil)
+		if err != nil {
+			return nil, err
+		}
+		rows[table.Name] = tableRows
+	}
+	return rows, nil
+}
+
+// collectOrganization reads an organization and, following organizationScope, every row that belongs to it
+func collectOrganization(tables map[string]Table, organizationID string) (map[string][]map[string]interface{}, error) {
+	rows := make(map[string][]map[string]interface{}, len(tables))
+	seen := make(map[string]map[string]bool, len(tables))
+	add := func(table string, tableRows []map[string]interface{}) {
+		if seen[table] == nil {
+			seen[table] = make(map[string]bool)
+		}
+		for _, row := range tableRows {
+			id := fmt.Sprint(row["id"])
+			if !seen[table][id] {
+				seen[table][id] = true
+				rows[table] = append(rows[table], row)
+			}
+		}
+	}
+
+	organizations, err := fetchRows(tables["organization"], "id", []string{organizationID})
+	if err != nil {
+		return nil, err
+	}
+	if len(organizations) == 0 {
+		return nil, ErrOrganizationNotFound
+	}
+	add("organization", organizations)
+
+	for _, rule := range organizationScope {
+		var values []string
+		if rule.From == anyCollected {
+			for _, ids := range seen {
+				for id := range ids {
+					values = append(values, id)
+				}
+			}
+		} else {
+			for _, row := range rows[rule.From] {
+				if value, ok := row[rule.FromColumn].(string); ok && value != "" {
+					values = append(values, value)
+				}
+			}
+		}
+		sort.Strings(values)
+
+		tableRows, err := fetchRows(tables[rule.Table], rule.Column, values)
+		if err != nil {
+			return nil, err
+		}
+		add(rule.Table, tableRows)
+	}
+
+	for name := range sharedTables {
+		tableRows, err := fetchRows(tables[name], "", nil)
+		if err != nil {
+			return nil, err
+		}
+		add(name, tableRows)
+	}
+
+	return rows, nil
+}
+
+// fetchRows reads a table's HSDS columns and decodes them through its hsds_types struct
+func fetchRows(table Table, filterColumn string, values []string) ([]map[string]interface{}, error) {
+	raw, err := supabase.FetchTableRows(table.Name, table.ColumnNames(), filterColumn, values)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := decoders[table.Name](raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s rows: %w", table.Name, err)
+	}
+	return rows, nil
+}
+
+// decodeRows passes rows through T and back to column maps. Columns T does not model keep their stored value.
+func decodeRows[T any](raw []json.RawMessage) ([]map[string]interface{}, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []T
+	if err := hsds_types.UnmarshalJSONWithTime(data, &items); err != nil {
+		return nil, err
+	}
+
+	rows := make([]map[string]interface{}, 0, len(items))
+	for i, item := range items {
+		row := make(map[string]interface{})
+		if err := json.Unmarshal(raw[i], &row); err != nil {
+			return nil, err
+		}
+		normalized, err := json.Marshal(item)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(normalized, &row); err != nil {
+			return nil, err
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// changedRows keeps the rows changed on or after since. Metadata rows are the changes themselves.
+func changedRows(table string, rows []map[string]interface{}, changed map[string]bool, since time.Time) []map[string]interface{} {
+	if sharedTables[table] {
+		return rows
+	}
+
+	kept := make([]map[string]interface{}, 0, len(rows))
+	for _, row := range rows {
+		if table == "metadata" {
+			if value, ok := row["last_action_date"].(string); ok {
+				if actionDate, err := hsds_types.ParseTime(value); err == nil && !actionDate.Before(since.Truncate(24*time.Hour)) {
+					kept = append(kept, row)
+				}
+			}
+			continue
+		}
+		if changed[fmt.Sprint(row["id"])] {
+			kept = append(kept, row)
+		}
+	}
+	return kept
+}
+
+// writeCSV writes a table's rows, sorted by id, under a header of its HSDS columns
+func writeCSV(w io.Writer, table Table, rows []map[string]interface{}) error {
+	sort.Slice(rows, func(i, j int) bool {
+		return fmt.Sprint(rows[i]["id"]) < fmt.Sprint(rows[j]["id"])
+	})
+
+	writer := csv.NewWriter(w)
+	if err := writer.Write(table.ColumnNames()); err != nil {
+		return err
+	}
+	for _, row := range rows {
+		record := make([]string, 0, len(table.Columns))
+		for _, column := range table.Columns {
+			record = append(record, formatValue(column, row[column.Name]))
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// formatValue writes a value the way the data package declares it: dates as YYYY-MM-DD, times as hh:mm:ss
+// and timestamps as RFC 3339 in UTC
+func formatValue(column Column, value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		if layout := temporalLayout(column.SQLType); layout != "" {
+			if t, err := hsds_types.ParseTime(v); err == nil {
+				return t.UTC().Format(layout)
+			}
+		}
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	}
+
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(encoded)
+}
+
+func temporalLayout(sqlType string) string {
+	switch {
+	case sqlType == "date":
+		return "2006-01-02"
+	case strings.HasPrefix(sqlType, "time "), sqlType == "time":
+		return "15:04:05"
+	case strings.HasPrefix(sqlType, "timestamp"):
+		return time.RFC3339
+	}
+	return ""
+}
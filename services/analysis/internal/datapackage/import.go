@@ -0,0 +1,441 @@
+package datapackage
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+
+	"github.com/david-botos/BearHug/services/analysis/internal/hsds_types"
+	"github.com/david-botos/BearHug/services/analysis/internal/supabase"
+	"github.com/david-botos/BearHug/services/analysis/pkg/logger"
+)
+
+// importTables are the tables an import writes, parents before the rows that reference them
+var importTables = []string{
+	"organization",
+	"location",
+	"address",
+	"service",
+	"service_at_location",
+	"contact",
+	"phone",
+	"schedule",
+}
+
+const defaultImportedBy = "HSDS import"
+
+// ImportOptions control how records are written
+type ImportOptions struct {
+	UpdatedBy string // Recorded on the metadata rows, defaults to "HSDS import"
+	DryRun    bool   // Validate and diff without writing anything
+}
+
+// ImportIssue is a row that was rejected and why
+type ImportIssue struct {
+	Table   string `json:"table"`
+	ID      string `json:"id,omitempty"`
+	Message string `json:"message"`
+}
+
+// ImportConflict is an imported value that was not written because a call already set the field
+type ImportConflict struct {
+	Table         string `json:"table"`
+	ResourceID    string `json:"resource_id"`
+	Field         string `json:"field"`
+	CurrentValue  string `json:"current_value"`
+	ImportedValue string `json:"imported_value"`
+}
+
+// ImportResult summarizes an import per table
+type ImportResult struct {
+	DryRun    bool             `json:"dry_run"`
+	Created   map[string]int   `json:"created"`
+	Updated   map[string]int   `json:"updated"`
+	Unchanged map[string]int   `json:"unchanged"`
+	Rejected  []ImportIssue    `json:"rejected"`
+	Conflicts []ImportConflict `json:"conflicts"`
+}
+
+// Import validates records against the hsds_types structs and the HSDS table definitions, then upserts
+// organizations, locations, addresses, services, service at locations, contacts, phones and schedules.
+// Rows that fail validation, share an id with another row of the package or reference a missing row are
+// rejected. Existing rows are updated field by field, except fields a call has set, which are reported as
+// conflicts instead of overwritten. Every write is recorded as an IMPORT metadata row and every conflict
+// as a CONFLICT row; the rows of a write that fails are withdrawn.
+func Import(records Records, opts ImportOptions) (*ImportResult, error) {
+	log := logger.Get()
+
+	if opts.UpdatedBy == "" {
+		opts.UpdatedBy = defaultImportedBy
+	}
+
+	tables, err := HSDSTables()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read HSDS table definitions: %w", err)
+	}
+	tablesByName := make(map[string]Table, len(tables))
+	for _, table := range tables {
+		tablesByName[table.Name] = table
+	}
+
+	result := &ImportResult{
+		DryRun:    opts.DryRun,
+		Created:   make(map[string]int),
+		Updated:   make(map[string]int),
+		Unchanged: make(map[string]int),
+		Rejected:  []ImportIssue{},
+		Conflicts: []ImportConflict{},
+	}
+
+	// IDs accepted so far, so later tables can reference rows that are only written in this import
+	accepted := make(map[string]map[string]bool, len(importTables))
+	for _, name := range importTables {
+		table := tablesByName[name]
+		accepted[name] = make(map[string]bool)
+
+		raws, duplicates := rejectDuplicateIDs(name, records[name])
+		result.Rejected = append(result.Rejected, duplicates...)
+
+		var rows []map[string]interface{}
+		for _, raw := range raws {
+			row, err := normalizeRow(table, raw)
+			if err != nil {
+				id, _ := raw["id"].(string)
+				result.Rejected = append(result.Rejected, ImportIssue{Table: name, ID: id, Message: err.Error()})
+				continue
+			}
+			rows = append(rows, row)
+		}
+
+		rows, issues, err := resolveReferences(table, rows, accepted)
+		if err != nil {
+			return nil, err
+		}
+		result.Rejected = append(result.Rejected, issues...)
+		for _, issue := range issues {
+			delete(accepted[name], issue.ID)
+		}
+
+		if err := upsertRows(table, rows, opts, result); err != nil {
+			return nil, err
+		}
+	}
+
+	log.Info().
+		Bool("dry_run", opts.DryRun).
+		Interface("created", result.Created).
+		Interface("updated", result.Updated).
+		Int("rejected", len(result.Rejected)).
+		Int("conflicts", len(result.Conflicts)).
+		Msg("Imported HSDS records")
+
+	return result, nil
+}
+
+// rejectDuplicateIDs rejects every row whose id appears more than once in a table, since there is no
+// telling which copy is right. HSDS JSON is already deduplicated as it is read.
+func rejectDuplicateIDs(table string, raws []map[string]interface{}) ([]map[string]interface{}, []ImportIssue) {
+	counts := make(map[string]int, len(raws))
+	for _, raw := range raws {
+		if id, ok := raw["id"].(string); ok && id != "" {
+			counts[id]++
+		}
+	}
+
+	kept := make([]map[string]interface{}, 0, len(raws))
+	var issues []ImportIssue
+	for _, raw := range raws {
+		id, _ := raw["id"].(string)
+		if counts[id] > 1 {
+			issues = append(issues, ImportIssue{Table: table, ID: id, Message: fmt.Sprintf("id appears %d times in the package", counts[id])})
+			continue
+		}
+		kept = append(kept, raw)
+	}
+	return kept, issues
+}
+
+// normalizeRow decodes a row through its hsds_types struct, keeps the table's columns and validates them.
+// A column given as an explicit null is kept as nil, so the import clears it.
+func normalizeRow(table Table, raw map[string]interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode row: %w", err)
+	}
+	decoded, err := decoders[table.Name]([]json.RawMessage{data})
+	if err != nil {
+		return nil, fmt.Errorf("does not match the HSDS %s definition: %w", table.Name, err)
+	}
+
+	row := make(map[string]interface{})
+	for _, column := range table.Columns {
+		if value, ok := raw[column.Name]; ok && value == nil {
+			row[column.Name] = nil
+		} else if value, ok := decoded[0][column.Name]; ok && value != nil {
+			row[column.Name] = value
+		}
+	}
+
+	id, _ := row["id"].(string)
+	if !hsds_types.ValidateUUID(id) {
+		return nil, fmt.Errorf("id must be a UUIDv4")
+	}
+	for _, column := range table.Columns {
+		value, present := row[column.Name]
+		if column.Required && (!present || isEmpty(value)) {
+			return nil, fmt.Errorf("%s is required", column.Name)
+		}
+		if !present || value == nil || len(column.Enum) == 0 {
+			continue
+		}
+		if s, ok := value.(string); !ok || !slices.Contains(column.Enum, s) {
+			return nil, fmt.Errorf("%s must be one of %v", column.Name, column.Enum)
+		}
+	}
+	for _, key := range table.ForeignKeys {
+		if value, ok := row[key.Column]; ok && value != nil {
+			if s, _ := value.(string); !hsds_types.ValidateUUID(s) {
+				return nil, fmt.Errorf("%s must be a UUIDv4", key.Column)
+			}
+		}
+	}
+
+	return row, nil
+}
+
+// resolveReferences rejects rows whose foreign keys point at rows neither accepted in this import nor
+// already stored
+func resolveReferences(table Table, rows []map[string]interface{}, accepted map[string]map[string]bool) ([]map[string]interface{}, []ImportIssue, error) {
+	// Rows may reference others in the same table, such as a parent organization
+	for _, row := range rows {
+		accepted[table.Name][row["id"].(string)] = true
+	}
+
+	stored := make(map[string]map[string]bool)
+	for _, key := range table.ForeignKeys {
+		missing := make(map[string]bool)
+		for _, row := range rows {
+			if value, ok := row[key.Column].(string); ok && !accepted[key.ReferenceTable][value] {
+				missing[value] = true
+			}
+		}
+		if len(missing) == 0 {
+			continue
+		}
+
+		ids := make([]string, 0, len(missing))
+		for id := range missing {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		found, err := supabase.FetchTableRows(key.ReferenceTable, []string{key.ReferenceField}, key.ReferenceField, ids)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to look up referenced %s rows: %w", key.ReferenceTable, err)
+		}
+		if stored[key.ReferenceTable] == nil {
+			stored[key.ReferenceTable] = make(map[string]bool)
+		}
+		for _, raw := range found {
+			var ref map[string]interface{}
+			if err := json.Unmarshal(raw, &ref); err != nil {
+				return nil, nil, fmt.Errorf("failed to unmarshal referenced %s row: %w", key.ReferenceTable, err)
+			}
+			stored[key.ReferenceTable][fmt.Sprint(ref[key.ReferenceField])] = true
+		}
+	}
+
+	var resolved []map[string]interface{}
+	var issues []ImportIssue
+	for _, row := range rows {
+		var problem string
+		for _, key := range table.ForeignKeys {
+			value, ok := row[key.Column].(string)
+			if ok && !accepted[key.ReferenceTable][value] && !stored[key.ReferenceTable][value] {
+				problem = fmt.Sprintf("%s %s does not exist", key.ReferenceTable, value)
+				break
+			}
+		}
+		if problem != "" {
+			issues = append(issues, ImportIssue{Table: table.Name, ID: row["id"].(string), Message: problem})
+			continue
+		}
+		resolved = append(resolved, row)
+	}
+
+	return resolved, issues, nil
+}
+
+// upsertRows inserts new rows and updates changed fields of stored ones, recording metadata for both
+func upsertRows(table Table, rows []map[string]interface{}, opts ImportOptions, result *ImportResult) error {
+	if len(rows) == 0 {
+		return nil
+	}
+
+	ids := make([]string, 0, len(rows))
+	for _, row := range rows {
+		ids = append(ids, row["id"].(string))
+	}
+	storedRows, err := fetchRows(table, "id", ids)
+	if err != nil {
+		return err
+	}
+	stored := make(map[string]map[string]interface{}, len(storedRows))
+	storedIDs := make([]string, 0, len(storedRows))
+	for _, row := range storedRows {
+		id := fmt.Sprint(row["id"])
+		stored[id] = row
+		storedIDs = append(storedIDs, id)
+	}
+	verified, err := supabase.FetchCallVerifiedFields(storedIDs)
+	if err != nil {
+		return err
+	}
+
+	var inserts []map[string]interface{}
+	var insertMetadata []supabase.MetadataInput
+	for _, row := range rows {
+		id := row["id"].(string)
+
+		current, exists := stored[id]
+		if !exists {
+			// Bulk inserts need every row to carry the same columns
+			insert := make(map[string]interface{}, len(table.Columns))
+			for _, column := range table.Columns {
+				insert[column.Name] = writeValue(column, row[column.Name])
+			}
+			inserts = append(inserts, insert)
+			insertMetadata = append(insertMetadata, supabase.MetadataInput{
+				ResourceID:       id,
+				ResourceType:     table.Name,
+				ReplacementValue: "new entry",
+				LastActionType:   "IMPORT",
+				UpdatedBy:        opts.UpdatedBy,
+			})
+			result.Created[table.Name]++
+			continue
+		}
+
+		changes, metadataInputs, conflicts := diffRow(table, row, current, verified[id], opts.UpdatedBy)
+		result.Conflicts = append(result.Conflicts, conflicts...)
+
+		if len(changes) == 0 {
+			result.Unchanged[table.Name]++
+		} else {
+			result.Updated[table.Name]++
+		}
+		if opts.DryRun {
+			continue
+		}
+		// Record the change before making it, so a failed import never leaves unexplained edits behind
+		metadataIDs, err := storeImportMetadata(table, metadataInputs)
+		if err != nil {
+			return err
+		}
+		if len(changes) > 0 {
+			if err := supabase.UpdateRow(table.Name, id, changes); err != nil {
+				withdrawImportMetadata(table, metadataIDs)
+				return err
+			}
+		}
+	}
+
+	if opts.DryRun {
+		return nil
+	}
+	metadataIDs, err := storeImportMetadata(table, insertMetadata)
+	if err != nil {
+		return err
+	}
+	if err := supabase.InsertRows(table.Name, inserts); err != nil {
+		// Otherwise the change feed would announce rows that were never created
+		withdrawImportMetadata(table, metadataIDs)
+		return err
+	}
+	return nil
+}
+
+// diffRow compares an imported row with the stored one. Changed fields are returned with their IMPORT
+// metadata, except fields a call verified, which are kept and reported as conflicts with CONFLICT metadata.
+func diffRow(table Table, row, current map[string]interface{}, verified map[string]bool, updatedBy string) (map[string]interface{}, []supabase.MetadataInput, []ImportConflict) {
+	id := row["id"].(string)
+	changes := make(map[string]interface{})
+	var metadataInputs []supabase.MetadataInput
+	var conflicts []ImportConflict
+	for _, column := range table.Columns {
+		value, ok := row[column.Name]
+		if column.Name == "id" || !ok {
+			continue
+		}
+		imported := formatValue(column, value)
+		previous := formatValue(column, current[column.Name])
+		if imported == previous {
+			continue
+		}
+
+		if verified[supabase.CallVerifiedAllFields] || verified[column.Name] {
+			conflicts = append(conflicts, ImportConflict{
+				Table:         table.Name,
+				ResourceID:    id,
+				Field:         column.Name,
+				CurrentValue:  previous,
+				ImportedValue: imported,
+			})
+			metadataInputs = append(metadataInputs, supabase.MetadataInput{
+				ResourceID:       id,
+				ResourceType:     table.Name,
+				FieldName:        column.Name,
+				PreviousValue:    current[column.Name],
+				ReplacementValue: writeValue(column, value),
+				LastActionType:   "CONFLICT",
+				Rationale:        "Imported value differs from a value set on a call, kept the call's value",
+				UpdatedBy:        updatedBy,
+			})
+			continue
+		}
+
+		changes[column.Name] = writeValue(column, value)
+		metadataInputs = append(metadataInputs, supabase.MetadataInput{
+			ResourceID:       id,
+			ResourceType:     table.Name,
+			FieldName:        column.Name,
+			PreviousValue:    current[column.Name],
+			ReplacementValue: changes[column.Name],
+			LastActionType:   "IMPORT",
+			UpdatedBy:        updatedBy,
+		})
+	}
+	return changes, metadataInputs, conflicts
+}
+
+// storeImportMetadata records the import's changes to a table, if there are any, returning the rows' IDs
+func storeImportMetadata(table Table, metadataInputs []supabase.MetadataInput) ([]string, error) {
+	if len(metadataInputs) == 0 {
+		return nil, nil
+	}
+	ids, err := supabase.RecordMetadata(metadataInputs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create metadata for imported %s rows: %w", table.Name, err)
+	}
+	return ids, nil
+}
+
+// withdrawImportMetadata removes the metadata of a write that failed. The import is already failing, so a
+// metadata row that can't be removed is only logged.
+func withdrawImportMetadata(table Table, ids []string) {
+	if err := supabase.WithdrawMetadata(ids); err != nil {
+		logger.Get().Error().
+			Err(err).
+			Str("table", table.Name).
+			Int("metadata_count", len(ids)).
+			Msg("Failed to withdraw metadata for a failed import write")
+	}
+}
+
+// writeValue formats dates and times the way their columns store them
+func writeValue(column Column, value interface{}) interface{} {
+	if s, ok := value.(string); ok && temporalLayout(column.SQLType) != "" {
+		return formatValue(column, s)
+	}
+	return value
+}
@@ -0,0 +1,195 @@
+package datapackage
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/david-botos/BearHug/services/analysis/internal/supabase"
+)
+
+const (
+	organizationFixtureID = "3f2a6c1e-8b4d-4e5f-9a7b-1c2d3e4f5a6b"
+	serviceFixtureID      = "7c9e6679-7425-40de-944b-e07fc1f90ae7"
+)
+
+func tableFixture(t *testing.T, name string) Table {
+	t.Helper()
+	tables, err := HSDSTables()
+	if err != nil {
+		t.Fatalf("HSDSTables(): %v", err)
+	}
+	for _, table := range tables {
+		if table.Name == name {
+			return table
+		}
+	}
+	t.Fatalf("no %s table", name)
+	return Table{}
+}
+
+func TestNormalizeRow(t *testing.T) {
+	tests := []struct {
+		name    string
+		table   string
+		raw     map[string]interface{}
+		want    map[string]interface{}
+		wantErr bool
+	}{
+		{
+			name:  "unknown columns are dropped",
+			table: "organization",
+			raw:   map[string]interface{}{"id": organizationFixtureID, "name": "Food Bank", "description": "Groceries", "mascot": "bear"},
+			want:  map[string]interface{}{"id": organizationFixtureID, "name": "Food Bank", "description": "Groceries"},
+		},
+		{
+			name:  "explicit null is kept",
+			table: "organization",
+			raw:   map[string]interface{}{"id": organizationFixtureID, "name": "Food Bank", "description": "Groceries", "email": nil, "parent_organization_id": nil},
+			want:  map[string]interface{}{"id": organizationFixtureID, "name": "Food Bank", "description": "Groceries", "email": nil, "parent_organization_id": nil},
+		},
+		{
+			name:    "null required column",
+			table:   "organization",
+			raw:     map[string]interface{}{"id": organizationFixtureID, "name": nil, "description": "Groceries"},
+			wantErr: true,
+		},
+		{
+			name:    "missing required column",
+			table:   "organization",
+			raw:     map[string]interface{}{"id": organizationFixtureID, "name": "Food Bank"},
+			wantErr: true,
+		},
+		{
+			name:    "id is not a UUID",
+			table:   "organization",
+			raw:     map[string]interface{}{"id": "org-1", "name": "Food Bank", "description": "Groceries"},
+			wantErr: true,
+		},
+		{
+			name:  "enum value",
+			table: "service",
+			raw:   map[string]interface{}{"id": serviceFixtureID, "organization_id": organizationFixtureID, "name": "Pantry", "status": "active", "url": nil},
+			want:  map[string]interface{}{"id": serviceFixtureID, "organization_id": organizationFixtureID, "name": "Pantry", "status": "active", "url": nil},
+		},
+		{
+			name:    "value outside the enum",
+			table:   "service",
+			raw:     map[string]interface{}{"id": serviceFixtureID, "organization_id": organizationFixtureID, "name": "Pantry", "status": "open"},
+			wantErr: true,
+		},
+		{
+			name:    "foreign key is not a UUID",
+			table:   "service",
+			raw:     map[string]interface{}{"id": serviceFixtureID, "organization_id": "org-1", "name": "Pantry", "status": "active"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := normalizeRow(tableFixture(t, tt.table), tt.raw)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("normalizeRow() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("normalizeRow() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRejectDuplicateIDs(t *testing.T) {
+	raws := []map[string]interface{}{
+		{"id": "a", "name": "First"},
+		{"id": "b", "name": "Only"},
+		{"id": "a", "name": "Second"},
+		{"name": "No id"},
+		{"name": "Also no id"},
+	}
+
+	kept, issues := rejectDuplicateIDs("organization", raws)
+
+	wantKept := []map[string]interface{}{{"id": "b", "name": "Only"}, {"name": "No id"}, {"name": "Also no id"}}
+	if !reflect.DeepEqual(kept, wantKept) {
+		t.Errorf("kept = %v, want %v", kept, wantKept)
+	}
+	wantIssues := []ImportIssue{
+		{Table: "organization", ID: "a", Message: "id appears 2 times in the package"},
+		{Table: "organization", ID: "a", Message: "id appears 2 times in the package"},
+	}
+	if !reflect.DeepEqual(issues, wantIssues) {
+		t.Errorf("issues = %+v, want %+v", issues, wantIssues)
+	}
+}
+
+func TestDiffRow(t *testing.T) {
+	table := tableFixture(t, "service")
+	current := map[string]interface{}{
+		"id": serviceFixtureID, "organization_id": organizationFixtureID, "name": "Pantry", "status": "active",
+		"email": "old@example.org", "minimum_age": 18.0, "assured_date": "2024-05-01T00:00:00",
+	}
+
+	tests := []struct {
+		name          string
+		row           map[string]interface{}
+		verified      map[string]bool
+		wantChanges   map[string]interface{}
+		wantMetadata  []string // field:action
+		wantConflicts []string
+	}{
+		{
+			name:        "same values in other formats",
+			row:         map[string]interface{}{"id": serviceFixtureID, "name": "Pantry", "minimum_age": 18.0, "assured_date": "2024-05-01"},
+			wantChanges: map[string]interface{}{},
+		},
+		{
+			name:         "changed and cleared fields",
+			row:          map[string]interface{}{"id": serviceFixtureID, "name": "Food Pantry", "email": nil, "assured_date": "2025-01-02T00:00:00Z"},
+			wantChanges:  map[string]interface{}{"name": "Food Pantry", "email": nil, "assured_date": "2025-01-02"},
+			wantMetadata: []string{"name:IMPORT", "email:IMPORT", "assured_date:IMPORT"},
+		},
+		{
+			name:          "field set on a call is kept",
+			row:           map[string]interface{}{"id": serviceFixtureID, "name": "Food Pantry", "email": "new@example.org"},
+			verified:      map[string]bool{"email": true},
+			wantChanges:   map[string]interface{}{"name": "Food Pantry"},
+			wantMetadata:  []string{"name:IMPORT", "email:CONFLICT"},
+			wantConflicts: []string{"email"},
+		},
+		{
+			name:          "resource created on a call keeps every field",
+			row:           map[string]interface{}{"id": serviceFixtureID, "name": "Food Pantry", "minimum_age": 18.0},
+			verified:      map[string]bool{supabase.CallVerifiedAllFields: true},
+			wantChanges:   map[string]interface{}{},
+			wantMetadata:  []string{"name:CONFLICT"},
+			wantConflicts: []string{"name"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changes, metadataInputs, conflicts := diffRow(table, tt.row, current, tt.verified, "importer")
+
+			if !reflect.DeepEqual(changes, tt.wantChanges) {
+				t.Errorf("changes = %v, want %v", changes, tt.wantChanges)
+			}
+			var metadata []string
+			for _, input := range metadataInputs {
+				if input.ResourceID != serviceFixtureID || input.ResourceType != "service" || input.UpdatedBy != "importer" {
+					t.Errorf("metadata %+v does not describe the service import", input)
+				}
+				metadata = append(metadata, input.FieldName+":"+input.LastActionType)
+			}
+			if !reflect.DeepEqual(metadata, tt.wantMetadata) {
+				t.Errorf("metadata = %v, want %v", metadata, tt.wantMetadata)
+			}
+			var conflictFields []string
+			for _, conflict := range conflicts {
+				conflictFields = append(conflictFields, conflict.Field)
+			}
+			if !reflect.DeepEqual(conflictFields, tt.wantConflicts) {
+				t.Errorf("conflicts = %v, want %v", conflictFields, tt.wantConflicts)
+			}
+		})
+	}
+}
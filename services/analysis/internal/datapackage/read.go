@@ -0,0 +1,291 @@
+package datapackage
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// Records are raw HSDS rows keyed by table name
+type Records map[string][]map[string]interface{}
+
+// nestedResource is an object the HSDS API Reference nests inside another, and the foreign key linking them
+type nestedResource struct {
+	Key      string
+	Table    string
+	Column   string
+	OnParent bool // The foreign key is on the parent, pointing at the nested object
+}
+
+// hsdsNesting lists the nested objects an import reads from HSDS JSON. Anything else is ignored.
+var hsdsNesting = map[string][]nestedResource{
+	"organization": {
+		{Key: "services", Table: "service", Column: "organization_id"},
+		{Key: "locations", Table: "location", Column: "organization_id"},
+		{Key: "contacts", Table: "contact", Column: "organization_id"},
+		{Key: "phones", Table: "phone", Column: "organization_id"},
+	},
+	"service": {
+		{Key: "organization", Table: "organization", Column: "organization_id", OnParent: true},
+		{Key: "service_at_locations", Table: "service_at_location", Column: "service_id"},
+		{Key: "contacts", Table: "contact", Column: "service_id"},
+		{Key: "phones", Table: "phone", Column: "service_id"},
+		{Key: "schedules", Table: "schedule", Column: "service_id"},
+	},
+	"service_at_location": {
+		{Key: "location", Table: "location", Column: "location_id", OnParent: true},
+		{Key: "contacts", Table: "contact", Column: "service_at_location_id"},
+		{Key: "phones", Table: "phone", Column: "service_at_location_id"},
+		{Key: "schedules", Table: "schedule", Column: "service_at_location_id"},
+	},
+	"location": {
+		{Key: "addresses", Table: "address", Column: "location_id"},
+		{Key: "phones", Table: "phone", Column: "location_id"},
+		{Key: "schedules", Table: "schedule", Column: "location_id"},
+	},
+	"contact": {
+		{Key: "phones", Table: "phone", Column: "contact_id"},
+	},
+}
+
+// ReadTabular reads the importable tables from a zipped HSDS tabular data package. CSV paths come from
+// datapackage.json when the package has one, otherwise each table is read from <table>.csv.
+func ReadTabular(data []byte) (Records, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open data package archive: %w", err)
+	}
+
+	// Packages are often zipped inside a folder, so match files by name alone
+	files := make(map[string]*zip.File)
+	for _, file := range archive.File {
+		files[path.Base(file.Name)] = file
+	}
+
+	paths := make(map[string]string, len(importTables))
+	for _, name := range importTables {
+		paths[name] = name + ".csv"
+	}
+	if file, ok := files["datapackage.json"]; ok {
+		var descriptor Descriptor
+		if err := readJSONFile(file, &descriptor); err != nil {
+			return nil, fmt.Errorf("failed to read datapackage.json: %w", err)
+		}
+		for _, resource := range descriptor.Resources {
+			if _, ok := paths[resource.Name]; ok && resource.Path != "" {
+				paths[resource.Name] = path.Base(resource.Path)
+			}
+		}
+	}
+
+	tables, err := HSDSTables()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read HSDS table definitions: %w", err)
+	}
+
+	records := make(Records)
+	for _, table := range tables {
+		name, ok := paths[table.Name]
+		if !ok {
+			continue
+		}
+		file, ok := files[name]
+		if !ok {
+			continue
+		}
+		rows, err := readCSVFile(file, table)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", name, err)
+		}
+		records[table.Name] = rows
+	}
+
+	if len(records) == 0 {
+		return nil, fmt.Errorf("data package has none of the tables %s", strings.Join(importTables, ", "))
+	}
+	return records, nil
+}
+
+func readJSONFile(file *zip.File, v interface{}) error {
+	reader, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	return json.NewDecoder(reader).Decode(v)
+}
+
+// readCSVFile reads a table's CSV into rows, typing each value by its column. Empty cells are left out
+// and columns the table does not define are ignored.
+func readCSVFile(file *zip.File, table Table) ([]map[string]interface{}, error) {
+	reader, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	csvReader := csv.NewReader(reader)
+	header, err := csvReader.Read()
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(header) > 0 {
+		header[0] = strings.TrimPrefix(header[0], "\ufeff")
+	}
+
+	var rows []map[string]interface{}
+	for {
+		record, err := csvReader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		row := make(map[string]interface{})
+		for i, value := range record {
+			if i >= len(header) || value == "" {
+				continue
+			}
+			column, ok := table.Column(strings.TrimSpace(header[i]))
+			if !ok {
+				continue
+			}
+			row[column.Name] = parseCSVValue(column, value)
+		}
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+// parseCSVValue types a CSV cell by its column. Values that do not parse are kept as text and rejected
+// when the row is validated.
+func parseCSVValue(column Column, value string) interface{} {
+	switch fieldType, _ := fieldType(column.SQLType); fieldType {
+	case "number", "integer":
+		if number, err := strconv.ParseFloat(value, 64); err == nil {
+			return number
+		}
+	case "boolean":
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	case "object":
+		var object interface{}
+		if err := json.Unmarshal([]byte(value), &object); err == nil {
+			return object
+		}
+	}
+	return value
+}
+
+// ReadJSON reads HSDS JSON in the shape of the HSDS API Reference: a full organization or service, a list
+// of them, or a page of them. Nested objects are split into their tables and linked to their parent.
+func ReadJSON(data []byte) (Records, error) {
+	var document interface{}
+	if err := json.Unmarshal(data, &document); err != nil {
+		return nil, fmt.Errorf("failed to parse HSDS JSON: %w", err)
+	}
+
+	var items []interface{}
+	switch v := document.(type) {
+	case []interface{}:
+		items = v
+	case map[string]interface{}:
+		if contents, ok := v["contents"].([]interface{}); ok {
+			items = contents
+		} else {
+			items = []interface{}{v}
+		}
+	default:
+		return nil, fmt.Errorf("HSDS JSON must be an object or a list of objects")
+	}
+
+	records := make(Records)
+	seen := make(map[string]map[string]bool)
+	for i, item := range items {
+		object, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("item %d is not an object", i)
+		}
+		// Organizations have no organization_id, services always do
+		table := "organization"
+		if _, ok := object["organization_id"]; ok {
+			table = "service"
+		}
+		flattenHSDS(table, object, records, seen)
+	}
+
+	if len(records) == 0 {
+		return nil, fmt.Errorf("HSDS JSON has no organizations or services")
+	}
+	return records, nil
+}
+
+// flattenHSDS adds an object and everything nested inside it to records, filling in missing foreign keys
+// from the nesting. Objects that appear more than once are only added the first time.
+func flattenHSDS(table string, object map[string]interface{}, records Records, seen map[string]map[string]bool) {
+	row := make(map[string]interface{}, len(object))
+	nestedKeys := make(map[string]bool)
+	for _, nested := range hsdsNesting[table] {
+		nestedKeys[nested.Key] = true
+	}
+	for key, value := range object {
+		if !nestedKeys[key] {
+			row[key] = value
+		}
+	}
+
+	id, _ := row["id"].(string)
+	for _, nested := range hsdsNesting[table] {
+		var children []map[string]interface{}
+		switch v := object[nested.Key].(type) {
+		case map[string]interface{}:
+			children = append(children, v)
+		case []interface{}:
+			for _, child := range v {
+				if childObject, ok := child.(map[string]interface{}); ok {
+					children = append(children, childObject)
+				}
+			}
+		}
+
+		for _, child := range children {
+			if nested.OnParent {
+				if childID, ok := child["id"].(string); ok && isEmpty(row[nested.Column]) {
+					row[nested.Column] = childID
+				}
+			} else if id != "" && isEmpty(child[nested.Column]) {
+				child[nested.Column] = id
+			}
+			flattenHSDS(nested.Table, child, records, seen)
+		}
+	}
+
+	if seen[table] == nil {
+		seen[table] = make(map[string]bool)
+	}
+	if id != "" {
+		if seen[table][id] {
+			return
+		}
+		seen[table][id] = true
+	}
+	records[table] = append(records[table], row)
+}
+
+func isEmpty(value interface{}) bool {
+	s, ok := value.(string)
+	return value == nil || (ok && s == "")
+}
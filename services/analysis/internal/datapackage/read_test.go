@@ -0,0 +1,135 @@
+package datapackage
+
+import (
+	"archive/zip"
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func zipFixture(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	for name, content := range files {
+		file, err := archive.Create(name)
+		if err != nil {
+			t.Fatalf("Create(%s): %v", name, err)
+		}
+		if _, err := file.Write([]byte(content)); err != nil {
+			t.Fatalf("Write(%s): %v", name, err)
+		}
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestReadTabular(t *testing.T) {
+	const organizationID = "3f2a6c1e-8b4d-4e5f-9a7b-1c2d3e4f5a6b"
+	organizationCSV := "\ufeffid,name,description,year_incorporated,unknown_column\n" +
+		organizationID + ",Food Bank,,1998,ignored\n"
+
+	tests := []struct {
+		name    string
+		files   map[string]string
+		want    Records
+		wantErr bool
+	}{
+		{
+			name:  "table named csv in a folder",
+			files: map[string]string{"export/organization.csv": organizationCSV},
+			want: Records{"organization": {
+				{"id": organizationID, "name": "Food Bank", "year_incorporated": 1998.0},
+			}},
+		},
+		{
+			name: "path from datapackage.json",
+			files: map[string]string{
+				"datapackage.json": `{"resources":[{"name":"phone","path":"data/phones.csv"}]}`,
+				"data/phones.csv":  "id,number,extension\nphone-1,+12065550123,12\n",
+			},
+			want: Records{"phone": {{"id": "phone-1", "number": "+12065550123", "extension": 12.0}}},
+		},
+		{
+			name:  "header only",
+			files: map[string]string{"organization.csv": "id,name\n", "notes.txt": "hello"},
+			want:  Records{"organization": nil},
+		},
+		{name: "no tables", files: map[string]string{"notes.txt": "hello"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ReadTabular(zipFixture(t, tt.files))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ReadTabular() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ReadTabular() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    Records
+		wantErr bool
+	}{
+		{
+			name: "organization with nested services and phones",
+			input: `{"id":"org","name":"Food Bank","services":[
+				{"id":"svc","name":"Pantry","phones":[{"id":"phone-1","number":"+12065550123"}]}
+			],"phones":[{"id":"phone-2","number":"+12065550199"}]}`,
+			want: Records{
+				"organization": {{"id": "org", "name": "Food Bank"}},
+				"service":      {{"id": "svc", "name": "Pantry", "organization_id": "org"}},
+				"phone": {
+					{"id": "phone-1", "number": "+12065550123", "service_id": "svc"},
+					{"id": "phone-2", "number": "+12065550199", "organization_id": "org"},
+				},
+			},
+		},
+		{
+			name: "page of services sharing an organization",
+			input: `{"contents":[
+				{"id":"svc-1","organization_id":"","organization":{"id":"org","name":"Food Bank"}},
+				{"id":"svc-2","organization_id":"org","organization":{"id":"org","name":"Food Bank"}}
+			]}`,
+			want: Records{
+				"organization": {{"id": "org", "name": "Food Bank"}},
+				"service": {
+					{"id": "svc-1", "organization_id": "org"},
+					{"id": "svc-2", "organization_id": "org"},
+				},
+			},
+		},
+		{
+			name:  "stated foreign key is kept",
+			input: `[{"id":"org","services":[{"id":"svc","organization_id":"other-org"}]}]`,
+			want: Records{
+				"organization": {{"id": "org"}},
+				"service":      {{"id": "svc", "organization_id": "other-org"}},
+			},
+		},
+		{name: "not an object", input: `"food bank"`, wantErr: true},
+		{name: "list of strings", input: `["food bank"]`, wantErr: true},
+		{name: "empty list", input: `[]`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ReadJSON([]byte(tt.input))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ReadJSON() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ReadJSON() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
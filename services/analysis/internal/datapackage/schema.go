@@ -0,0 +1,139 @@
+package datapackage
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	schema "github.com/david-botos/BearHug/services/analysis/pkg"
+)
+
+// Column is one column of an HSDS table as defined in pkg/hsds.sql
+type Column struct {
+	Name        string
+	SQLType     string
+	Required    bool
+	Description string
+	Enum        []string // Allowed values when the column is an enum type
+}
+
+// ForeignKey links a column to the id of another table
+type ForeignKey struct {
+	Column         string
+	ReferenceTable string
+	ReferenceField string
+}
+
+// Table is an HSDS table as defined in pkg/hsds.sql
+type Table struct {
+	Name        string
+	Columns     []Column
+	PrimaryKey  string
+	ForeignKeys []ForeignKey
+}
+
+// ColumnNames returns the table's column names in definition order
+func (t Table) ColumnNames() []string {
+	names := make([]string, 0, len(t.Columns))
+	for _, column := range t.Columns {
+		names = append(names, column.Name)
+	}
+	return names
+}
+
+// Column returns the named column, if the table has it
+func (t Table) Column(name string) (Column, bool) {
+	for _, column := range t.Columns {
+		if column.Name == name {
+			return column, true
+		}
+	}
+	return Column{}, false
+}
+
+var (
+	createTypePattern    = regexp.MustCompile(`(?s)CREATE TYPE public\.(\w+) AS ENUM \((.*?)\);`)
+	createTablePattern   = regexp.MustCompile(`(?s)CREATE TABLE public\.(\w+) \(\n(.*?)\n\);`)
+	columnCommentPattern = regexp.MustCompile(`COMMENT ON COLUMN public\.(\w+)\.(\w+) IS '((?:[^']|'')*)';`)
+	primaryKeyPattern    = regexp.MustCompile(`ALTER TABLE ONLY public\.(\w+)\s+ADD CONSTRAINT \w+ PRIMARY KEY \((\w+)\);`)
+	foreignKeyPattern    = regexp.MustCompile(`ALTER TABLE ONLY public\.(\w+)\s+ADD CONSTRAINT \w+ FOREIGN KEY \((\w+)\) REFERENCES public\.(\w+)\((\w+)\);`)
+	enumValuePattern     = regexp.MustCompile(`'((?:[^']|'')*)'`)
+)
+
+// HSDSTables parses the HSDS table definitions from pkg/hsds.sql, sorted by name
+func HSDSTables() ([]Table, error) {
+	return parseTables(schema.HSDS)
+}
+
+func parseTables(dump string) ([]Table, error) {
+	enums := make(map[string][]string)
+	for _, match := range createTypePattern.FindAllStringSubmatch(dump, -1) {
+		for _, value := range enumValuePattern.FindAllStringSubmatch(match[2], -1) {
+			enums[match[1]] = append(enums[match[1]], strings.ReplaceAll(value[1], "''", "'"))
+		}
+	}
+
+	tables := make(map[string]*Table)
+	for _, match := range createTablePattern.FindAllStringSubmatch(dump, -1) {
+		table := &Table{Name: match[1]}
+		for _, line := range strings.Split(match[2], "\n") {
+			line = strings.TrimSuffix(strings.TrimSpace(line), ",")
+			if line == "" {
+				continue
+			}
+			name, definition, ok := strings.Cut(line, " ")
+			if !ok {
+				return nil, fmt.Errorf("table %s: cannot parse column definition %q", table.Name, line)
+			}
+
+			column := Column{
+				Name:     strings.Trim(name, `"`),
+				Required: strings.Contains(definition, "NOT NULL"),
+			}
+			column.SQLType = strings.TrimSpace(strings.Split(strings.Split(definition, " NOT NULL")[0], " DEFAULT ")[0])
+			if values, ok := enums[strings.TrimPrefix(column.SQLType, "public.")]; ok {
+				column.Enum = values
+			}
+			table.Columns = append(table.Columns, column)
+		}
+		tables[table.Name] = table
+	}
+
+	for _, match := range columnCommentPattern.FindAllStringSubmatch(dump, -1) {
+		table, ok := tables[match[1]]
+		if !ok {
+			continue
+		}
+		for i := range table.Columns {
+			if table.Columns[i].Name == match[2] {
+				table.Columns[i].Description = strings.ReplaceAll(match[3], "''", "'")
+			}
+		}
+	}
+	for _, match := range primaryKeyPattern.FindAllStringSubmatch(dump, -1) {
+		if table, ok := tables[match[1]]; ok {
+			table.PrimaryKey = match[2]
+		}
+	}
+	for _, match := range foreignKeyPattern.FindAllStringSubmatch(dump, -1) {
+		if table, ok := tables[match[1]]; ok {
+			table.ForeignKeys = append(table.ForeignKeys, ForeignKey{
+				Column:         match[2],
+				ReferenceTable: match[3],
+				ReferenceField: match[4],
+			})
+		}
+	}
+
+	if len(tables) == 0 {
+		return nil, fmt.Errorf("no tables found in schema dump")
+	}
+
+	sorted := make([]Table, 0, len(tables))
+	for _, table := range tables {
+		sorted = append(sorted, *table)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+	return sorted, nil
+}
@@ -0,0 +1,231 @@
+// Package freshness measures how recently each field of a service or organization was confirmed, and
+// schedules go-bot calls to re-verify the organizations whose data has gone stale.
+package freshness
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/david-botos/BearHug/services/analysis/internal/hsds_types"
+	"github.com/david-botos/BearHug/services/analysis/internal/supabase"
+)
+
+var (
+	ErrOrganizationNotFound = errors.New("organization not found")
+	ErrServiceNotFound      = errors.New("service not found")
+)
+
+const day = 24 * time.Hour
+
+// Where a field's last confirmation came from, strongest first
+const (
+	SourceCall         = "call"          // A call created, updated or confirmed the field
+	SourceCallMade     = "call_made"     // A call was made to the organization, which confirms who it is
+	SourceAssuredDate  = "assured_date"  // Someone checked the whole service on that date
+	SourceLastModified = "last_modified" // Only known to have changed then, used when nothing better exists
+)
+
+// fieldPolicy says how long a field stays fresh after it was confirmed and how much it counts toward a score
+type fieldPolicy struct {
+	Field  string
+	MaxAge time.Duration
+	Weight float64
+}
+
+// servicePolicies are the service fields a call re-verifies. Status goes stale fastest and matters most.
+var servicePolicies = []fieldPolicy{
+	{Field: "status", MaxAge: 30 * day, Weight: 3},
+	{Field: "eligibility_description", MaxAge: 90 * day, Weight: 2},
+	{Field: "application_process", MaxAge: 90 * day, Weight: 2},
+	{Field: "fees_description", MaxAge: 180 * day, Weight: 1},
+	{Field: "description", MaxAge: 180 * day, Weight: 1},
+	{Field: "email", MaxAge: 180 * day, Weight: 1},
+	{Field: "url", MaxAge: 365 * day, Weight: 0.5},
+}
+
+// organizationPolicies are the organization fields a call re-verifies
+var organizationPolicies = []fieldPolicy{
+	{Field: "name", MaxAge: 365 * day, Weight: 1},
+	{Field: "description", MaxAge: 365 * day, Weight: 1},
+	{Field: "email", MaxAge: 180 * day, Weight: 1},
+	{Field: "website", MaxAge: 365 * day, Weight: 0.5},
+}
+
+// FieldFreshness is when one field was last confirmed and how fresh that makes it
+type FieldFreshness struct {
+	Field         string     `json:"field"`
+	LastConfirmed *time.Time `json:"last_confirmed,omitempty"`
+	Source        string     `json:"source,omitempty"`
+	MaxAgeDays    int        `json:"max_age_days"`
+	Weight        float64    `json:"weight"`
+	Score         float64    `json:"score"` // 1 when just confirmed, falling to 0 at max_age_days
+	Stale         bool       `json:"stale"` // Never confirmed, or confirmed more than max_age_days ago
+}
+
+// ResourceFreshness scores the fields of one service or organization
+type ResourceFreshness struct {
+	ResourceType string           `json:"resource_type"`
+	ResourceID   string           `json:"resource_id"`
+	Name         string           `json:"name"`
+	Score        float64          `json:"score"` // Weighted mean of the field scores
+	StaleFields  []string         `json:"stale_fields"`
+	Fields       []FieldFreshness `json:"fields"`
+}
+
+// OrganizationFreshness scores an organization together with its services
+type OrganizationFreshness struct {
+	OrganizationID  string              `json:"organization_id"`
+	Name            string              `json:"name"`
+	Score           float64             `json:"score"` // Weighted mean over the organization's and its services' fields
+	StaleFieldCount int                 `json:"stale_field_count"`
+	LastCallAt      *time.Time          `json:"last_call_at,omitempty"`
+	Organization    ResourceFreshness   `json:"organization"`
+	Services        []ResourceFreshness `json:"services"`
+}
+
+// ForOrganization scores an organization and its services as of now
+func ForOrganization(organizationID string, now time.Time) (*OrganizationFreshness, error) {
+	data, err := supabase.FetchFreshnessData([]string{organizationID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch freshness data: %w", err)
+	}
+	if len(data) == 0 {
+		return nil, ErrOrganizationNotFound
+	}
+
+	freshness := Score(data[0], now)
+	return &freshness, nil
+}
+
+// ForService scores one service as of now
+func ForService(serviceID string, now time.Time) (*ResourceFreshness, error) {
+	organizationID, err := supabase.FetchServiceOrganizationID(serviceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch service: %w", err)
+	}
+	if organizationID == "" {
+		return nil, ErrServiceNotFound
+	}
+
+	freshness, err := ForOrganization(organizationID, now)
+	if err != nil {
+		return nil, err
+	}
+	for _, service := range freshness.Services {
+		if service.ResourceID == serviceID {
+			return &service, nil
+		}
+	}
+	// Defunct services are not scored
+	return nil, ErrServiceNotFound
+}
+
+// Score computes the freshness of an organization and its services. Defunct services are left out, since
+// there is nothing left to re-verify.
+func Score(data supabase.FreshnessData, now time.Time) OrganizationFreshness {
+	organization := data.Organization
+	result := OrganizationFreshness{
+		OrganizationID: organization.ID,
+		Name:           organization.Name,
+		LastCallAt:     data.LastCallAt,
+		Organization: scoreResource("organization", organization.ID, organization.Name, organizationPolicies,
+			data.Confirmations[organization.ID], resourceDates{Checked: data.LastCallAt, CheckedSource: SourceCallMade}, now),
+		Services: []ResourceFreshness{},
+	}
+
+	fields := append([]FieldFreshness{}, result.Organization.Fields...)
+	for _, service := range data.Services {
+		if service.Status == hsds_types.ServiceStatusDefunct {
+			continue
+		}
+		dates := resourceDates{Checked: service.AssuredDate, CheckedSource: SourceAssuredDate, LastModified: service.LastModified}
+		scored := scoreResource("service", service.ID, service.Name, servicePolicies,
+			data.Confirmations[service.ID], dates, now)
+		result.Services = append(result.Services, scored)
+		fields = append(fields, scored.Fields...)
+	}
+
+	result.Score = weightedScore(fields)
+	for _, field := range fields {
+		if field.Stale {
+			result.StaleFieldCount++
+		}
+	}
+	return result
+}
+
+// resourceDates are when a whole resource was last checked or changed, used for fields no call confirmed
+// more recently
+type resourceDates struct {
+	Checked       *time.Time
+	CheckedSource string
+	LastModified  *time.Time // Only used when nothing else confirms the field
+}
+
+// scoreResource scores each policy field of a resource from its call confirmations and dates
+func scoreResource(resourceType, id, name string, policies []fieldPolicy, confirmed map[string]time.Time,
+	dates resourceDates, now time.Time) ResourceFreshness {
+	resource := ResourceFreshness{
+		ResourceType: resourceType,
+		ResourceID:   id,
+		Name:         name,
+		StaleFields:  []string{},
+		Fields:       make([]FieldFreshness, 0, len(policies)),
+	}
+
+	for _, policy := range policies {
+		field := FieldFreshness{
+			Field:      policy.Field,
+			MaxAgeDays: int(policy.MaxAge / day),
+			Weight:     policy.Weight,
+			Stale:      true,
+		}
+
+		var at time.Time
+		if t, ok := confirmed[policy.Field]; ok {
+			at, field.Source = t, SourceCall
+		}
+		if t, ok := confirmed[supabase.CallVerifiedAllFields]; ok && t.After(at) {
+			at, field.Source = t, SourceCall
+		}
+		if dates.Checked != nil && dates.Checked.After(at) {
+			at, field.Source = *dates.Checked, dates.CheckedSource
+		}
+		if field.Source == "" && dates.LastModified != nil {
+			at, field.Source = *dates.LastModified, SourceLastModified
+		}
+
+		if field.Source != "" {
+			field.LastConfirmed = &at
+			age := now.Sub(at)
+			field.Score = round(math.Min(1, math.Max(0, 1-float64(age)/float64(policy.MaxAge))))
+			field.Stale = age > policy.MaxAge
+		}
+		if field.Stale {
+			resource.StaleFields = append(resource.StaleFields, field.Field)
+		}
+		resource.Fields = append(resource.Fields, field)
+	}
+
+	resource.Score = weightedScore(resource.Fields)
+	return resource
+}
+
+// weightedScore is the weighted mean of field scores, 0 when there are no fields
+func weightedScore(fields []FieldFreshness) float64 {
+	var total, weights float64
+	for _, field := range fields {
+		total += field.Score * field.Weight
+		weights += field.Weight
+	}
+	if weights == 0 {
+		return 0
+	}
+	return round(total / weights)
+}
+
+func round(score float64) float64 {
+	return math.Round(score*1000) / 1000
+}
@@ -0,0 +1,75 @@
+package freshness
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/david-botos/BearHug/services/analysis/internal/supabase"
+)
+
+func TestScoreResource(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	ago := func(days int) time.Time { return now.Add(-time.Duration(days) * day) }
+	at := func(days int) *time.Time { t := ago(days); return &t }
+	policies := []fieldPolicy{{Field: "status", MaxAge: 30 * day, Weight: 3}}
+
+	tests := []struct {
+		name       string
+		confirmed  map[string]time.Time
+		dates      resourceDates
+		wantSource string
+		wantAt     *time.Time
+		wantScore  float64
+		wantStale  bool
+	}{
+		{name: "never confirmed", wantStale: true},
+		{name: "field confirmed on a call", confirmed: map[string]time.Time{"status": ago(10)}, wantSource: SourceCall, wantAt: at(10), wantScore: 0.667},
+		{
+			name:       "resource created on a later call",
+			confirmed:  map[string]time.Time{"status": ago(20), supabase.CallVerifiedAllFields: ago(3)},
+			wantSource: SourceCall, wantAt: at(3), wantScore: 0.9,
+		},
+		{
+			name:       "newer assured date wins",
+			confirmed:  map[string]time.Time{"status": ago(20)},
+			dates:      resourceDates{Checked: at(6), CheckedSource: SourceAssuredDate},
+			wantSource: SourceAssuredDate, wantAt: at(6), wantScore: 0.8,
+		},
+		{
+			name:       "last modified only without anything better",
+			confirmed:  map[string]time.Time{"status": ago(15)},
+			dates:      resourceDates{LastModified: at(1)},
+			wantSource: SourceCall, wantAt: at(15), wantScore: 0.5,
+		},
+		{
+			name:       "last modified",
+			dates:      resourceDates{LastModified: at(45)},
+			wantSource: SourceLastModified, wantAt: at(45), wantScore: 0, wantStale: true,
+		},
+		{name: "exactly max age", confirmed: map[string]time.Time{"status": ago(30)}, wantSource: SourceCall, wantAt: at(30), wantScore: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := scoreResource("service", "pantry", "Food Pantry", policies, tt.confirmed, tt.dates, now)
+			if len(got.Fields) != 1 {
+				t.Fatalf("got %d fields, want 1", len(got.Fields))
+			}
+			field := got.Fields[0]
+			if field.Source != tt.wantSource || !reflect.DeepEqual(field.LastConfirmed, tt.wantAt) {
+				t.Errorf("confirmed = %v from %q, want %v from %q", field.LastConfirmed, field.Source, tt.wantAt, tt.wantSource)
+			}
+			if field.Score != tt.wantScore || field.Stale != tt.wantStale {
+				t.Errorf("score/stale = %v/%v, want %v/%v", field.Score, field.Stale, tt.wantScore, tt.wantStale)
+			}
+			wantStaleFields := []string{}
+			if tt.wantStale {
+				wantStaleFields = []string{"status"}
+			}
+			if !reflect.DeepEqual(got.StaleFields, wantStaleFields) || got.Score != tt.wantScore {
+				t.Errorf("resource = %+v, want score %v and stale fields %v", got, tt.wantScore, wantStaleFields)
+			}
+		})
+	}
+}
@@ -0,0 +1,199 @@
+package freshness
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/david-botos/BearHug/services/analysis/internal/hsds_types"
+	"github.com/david-botos/BearHug/services/analysis/internal/phonenumber"
+	"github.com/david-botos/BearHug/services/analysis/internal/supabase"
+)
+
+// Reasons an organization in the queue can't be dialed
+const (
+	BlockedNoPhone      = "no dialable phone number"
+	BlockedNoCategories = "no service categories go-bot can ask about"
+)
+
+// dialCategories are go-bot's prompt.ServiceCategory values, matched by keywords in service names and
+// descriptions. Keywords match at the start of a word, so "employ" matches "employment". Short or broad
+// words are qualified: a bare "abuse" would send substance abuse programs a domestic violence script, and
+// a bare "tax" matches "taxi".
+var dialCategories = []struct {
+	Name     string
+	Keywords *regexp.Regexp
+}{
+	{"DisabledResources", keywords("disab", "wheelchair", "deaf", "blind")},
+	{"UnemploymentResources", keywords("employ", "unemploy", "job", "career", "workforce", "resume")},
+	{"FoodResources", keywords("food", "meal", "pantry", "pantries", "groceries", "grocery", "nutrition", "snap")},
+	{"ClothingHygiene", keywords("cloth", "hygiene", "shower", "laundry", "diaper")},
+	{"Transportation", keywords("transport", "transit", "bus pass", "rides", "shuttle")},
+	{"MentalHealth", keywords("mental health", "counsel", "therap", "psychiatr", "behavioral health")},
+	{"DomesticViolence", keywords("domestic violence", "domestic abuse", "dating violence", "family violence", "intimate partner",
+		"abuse survivor", "survivors of abuse", "survivors of domestic", "battered")},
+	{"Education", keywords("educat", "tutor", "literacy", "ged", "esl", "school", "classes")},
+	{"Financial", keywords("financ", "rent assistance", "rental assistance", "utility", "utilities", "taxes", "tax prep",
+		"tax return", "tax filing", "tax assistance", "tax credit", "cash assistance")},
+	{"Healthcare", keywords("health", "medical", "clinic", "dental", "prescription")},
+	{"Shelter", keywords("shelter", "housing", "homeless")},
+	{"BrainInjury", keywords("brain injur", "tbi")},
+}
+
+func keywords(words ...string) *regexp.Regexp {
+	quoted := make([]string, len(words))
+	for i, word := range words {
+		quoted[i] = regexp.QuoteMeta(word)
+	}
+	return regexp.MustCompile(`\b(` + strings.Join(quoted, "|") + `)`)
+}
+
+// QueueEntry is an organization due for re-verification
+type QueueEntry struct {
+	OrganizationID    string     `json:"organization_id"`
+	Name              string     `json:"name"`
+	Score             float64    `json:"score"`
+	Priority          float64    `json:"priority"` // Higher is called first
+	StaleFieldCount   int        `json:"stale_field_count"`
+	LastCallAt        *time.Time `json:"last_call_at,omitempty"`
+	LastDialAt        *time.Time `json:"last_dial_at,omitempty"`
+	PhoneNumber       string     `json:"phone_number,omitempty"`
+	ServiceCategories []string   `json:"service_categories"`
+	EligibleAt        time.Time  `json:"eligible_at"`       // When the per-organization rate limit next allows a call
+	Blocked           string     `json:"blocked,omitempty"` // Why the organization can't be dialed at all
+}
+
+// Dialable reports whether the organization can be called at now
+func (e QueueEntry) Dialable(now time.Time) bool {
+	return e.Blocked == "" && !e.EligibleAt.After(now)
+}
+
+// Queue returns every organization with a stale field, most in need of a call first
+func Queue(config Config, now time.Time) ([]QueueEntry, error) {
+	data, err := supabase.FetchFreshnessData(nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch freshness data: %w", err)
+	}
+	return buildQueue(data, config, now), nil
+}
+
+// buildQueue scores each organization and orders those with stale fields by priority. Ties go to the
+// organization called longest ago.
+func buildQueue(data []supabase.FreshnessData, config Config, now time.Time) []QueueEntry {
+	queue := []QueueEntry{}
+	for _, organization := range data {
+		freshness := Score(organization, now)
+		if freshness.StaleFieldCount == 0 {
+			continue
+		}
+
+		entry := QueueEntry{
+			OrganizationID:    freshness.OrganizationID,
+			Name:              freshness.Name,
+			Score:             freshness.Score,
+			Priority:          round(1 - freshness.Score),
+			StaleFieldCount:   freshness.StaleFieldCount,
+			LastCallAt:        organization.LastCallAt,
+			LastDialAt:        organization.LastDialAt,
+			PhoneNumber:       dialNumber(organization.Phones),
+			ServiceCategories: serviceCategories(organization),
+			EligibleAt:        now,
+		}
+		for _, last := range []*time.Time{organization.LastCallAt, organization.LastDialAt} {
+			if last != nil && last.Add(config.OrgMinInterval).After(entry.EligibleAt) {
+				entry.EligibleAt = last.Add(config.OrgMinInterval)
+			}
+		}
+		switch {
+		case entry.PhoneNumber == "":
+			entry.Blocked = BlockedNoPhone
+		case len(entry.ServiceCategories) == 0:
+			entry.Blocked = BlockedNoCategories
+		}
+		queue = append(queue, entry)
+	}
+
+	sort.SliceStable(queue, func(i, j int) bool {
+		a, b := queue[i], queue[j]
+		if a.Priority != b.Priority {
+			return a.Priority > b.Priority
+		}
+		if (a.LastCallAt == nil) != (b.LastCallAt == nil) {
+			return a.LastCallAt == nil
+		}
+		if a.LastCallAt != nil && !a.LastCallAt.Equal(*b.LastCallAt) {
+			return a.LastCallAt.Before(*b.LastCallAt)
+		}
+		return a.Name < b.Name
+	})
+	return queue
+}
+
+// dialNumber picks the number to call: a voice line without an extension if there is one, since go-bot
+// can't dial extensions, otherwise any voice or hotline number. Organization phones come before service
+// phones. Fax, text and short code numbers are never used.
+func dialNumber(phones []hsds_types.Phone) string {
+	var fallback string
+	for _, phone := range phones {
+		number, err := phonenumber.Parse(phone.Number)
+		if err != nil || number.E164 == "" {
+			continue
+		}
+		var description string
+		if phone.Description != nil {
+			description = *phone.Description
+		}
+		var suggested string
+		if phone.Type != nil {
+			suggested = *phone.Type
+		}
+		switch phonenumber.Classify(number, suggested, description) {
+		case phonenumber.TypeVoice:
+			if phone.Extension == nil && number.Extension == nil {
+				return number.E164
+			}
+		case phonenumber.TypeHotline:
+		default:
+			continue
+		}
+		if fallback == "" {
+			fallback = number.E164
+		}
+	}
+	return fallback
+}
+
+// serviceCategories lists the go-bot categories matching the organization's services, falling back to
+// the organization's own description when no service matches
+func serviceCategories(data supabase.FreshnessData) []string {
+	var texts []string
+	for _, service := range data.Services {
+		if service.Status == hsds_types.ServiceStatusDefunct {
+			continue
+		}
+		text := service.Name
+		if service.Description != nil {
+			text += " " + *service.Description
+		}
+		texts = append(texts, text)
+	}
+
+	categories := matchCategories(texts)
+	if len(categories) == 0 {
+		categories = matchCategories([]string{data.Organization.Name + " " + data.Organization.Description})
+	}
+	return categories
+}
+
+func matchCategories(texts []string) []string {
+	text := strings.ToLower(strings.Join(texts, "\n"))
+	categories := []string{}
+	for _, category := range dialCategories {
+		if category.Keywords.MatchString(text) {
+			categories = append(categories, category.Name)
+		}
+	}
+	return categories
+}
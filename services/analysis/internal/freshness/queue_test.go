@@ -0,0 +1,143 @@
+package freshness
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/david-botos/BearHug/services/analysis/internal/hsds_types"
+	"github.com/david-botos/BearHug/services/analysis/internal/supabase"
+)
+
+func phoneFixture(number, phoneType string, extension float64) hsds_types.Phone {
+	phone := hsds_types.Phone{ID: number, Number: number}
+	if phoneType != "" {
+		phone.Type = &phoneType
+	}
+	if extension != 0 {
+		phone.Extension = &extension
+	}
+	return phone
+}
+
+func TestDialNumber(t *testing.T) {
+	tests := []struct {
+		name   string
+		phones []hsds_types.Phone
+		want   string
+	}{
+		{name: "no phones"},
+		{name: "voice line", phones: []hsds_types.Phone{phoneFixture("(206) 555-0123", "voice", 0)}, want: "+12065550123"},
+		{
+			name: "line without an extension is preferred",
+			phones: []hsds_types.Phone{
+				phoneFixture("206-555-0100", "voice", 12),
+				phoneFixture("206-555-0123", "voice", 0),
+			},
+			want: "+12065550123",
+		},
+		{name: "extension in the number", phones: []hsds_types.Phone{phoneFixture("206-555-0100 x12", "", 0)}, want: "+12065550100"},
+		{
+			name: "hotline when there is no plain voice line",
+			phones: []hsds_types.Phone{
+				phoneFixture("206-555-0199", "fax", 0),
+				phoneFixture("1-800-555-0100", "hotline", 0),
+			},
+			want: "+18005550100",
+		},
+		{name: "fax and text are never dialed", phones: []hsds_types.Phone{phoneFixture("206-555-0199", "fax", 0), phoneFixture("206-555-0198", "text", 0)}},
+		{name: "unparseable number", phones: []hsds_types.Phone{phoneFixture("call the front desk", "voice", 0)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := dialNumber(tt.phones); got != tt.want {
+				t.Errorf("dialNumber() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchCategories(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"Domestic violence emergency shelter", []string{"DomesticViolence", "Shelter"}},
+		{"Support for survivors of domestic abuse", []string{"DomesticViolence"}},
+		{"Substance abuse recovery meetings", []string{}},
+		{"Cancer survivor support group", []string{}},
+		{"Free tax preparation", []string{"Financial"}},
+		{"Taxi vouchers", []string{}},
+		{"Job training and resume help", []string{"UnemploymentResources"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			if got := matchCategories([]string{tt.text}); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("matchCategories(%q) = %v, want %v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildQueue(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	ago := func(days int) *time.Time { t := now.Add(-time.Duration(days) * day); return &t }
+	organization := func(id, name string, lastCall *time.Time, service string, phones ...hsds_types.Phone) supabase.FreshnessData {
+		data := supabase.FreshnessData{
+			Organization: hsds_types.Organization{ID: id, Name: name},
+			Phones:       phones,
+			LastCallAt:   lastCall,
+		}
+		if service != "" {
+			data.Services = []supabase.FreshnessService{{Service: hsds_types.Service{ID: id + "-service", Name: service}}}
+		}
+		return data
+	}
+	voice := phoneFixture("206-555-0123", "voice", 0)
+
+	dialed := organization("food", "Food Bank", nil, "Food Pantry", voice)
+	dialed.LastDialAt = ago(2)
+	data := []supabase.FreshnessData{
+		organization("clinic", "Clinic", ago(30), "Dental clinic", voice),
+		organization("fresh", "Fresh Start", ago(0), ""),
+		organization("taxi", "Taxi Co", nil, "Taxi vouchers", voice),
+		organization("shelter", "Shelter", nil, "Emergency shelter"),
+		dialed,
+	}
+
+	type entry struct {
+		id         string
+		categories []string
+		eligibleAt time.Time
+		blocked    string
+	}
+	// Never-called organizations are fully stale and come first, by name. The clinic's own fields were
+	// confirmed by its last call, so only its service is stale.
+	want := []entry{
+		{id: "food", categories: []string{"FoodResources"}, eligibleAt: dialed.LastDialAt.Add(14 * day)},
+		{id: "shelter", categories: []string{"Shelter"}, eligibleAt: now, blocked: BlockedNoPhone},
+		{id: "taxi", categories: []string{}, eligibleAt: now, blocked: BlockedNoCategories},
+		{id: "clinic", categories: []string{"Healthcare"}, eligibleAt: now},
+	}
+
+	queue := buildQueue(data, Config{OrgMinInterval: 14 * day}, now)
+
+	var got []entry
+	for _, e := range queue {
+		got = append(got, entry{id: e.OrganizationID, categories: e.ServiceCategories, eligibleAt: e.EligibleAt, blocked: e.Blocked})
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("buildQueue() = %+v, want %+v", got, want)
+	}
+	if queue[0].Priority != 1 || queue[3].Priority >= 1 {
+		t.Errorf("priorities = %v and %v, want 1 and below 1", queue[0].Priority, queue[3].Priority)
+	}
+	if queue[0].Dialable(now) || !queue[3].Dialable(now) || queue[1].Dialable(now) {
+		t.Errorf("only the clinic should be dialable now")
+	}
+	if queue[3].PhoneNumber != "+12065550123" {
+		t.Errorf("clinic phone = %q, want +12065550123", queue[3].PhoneNumber)
+	}
+}
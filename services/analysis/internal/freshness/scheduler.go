@@ -0,0 +1,313 @@
+package freshness
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/david-botos/BearHug/services/analysis/internal/supabase"
+	"github.com/david-botos/BearHug/services/analysis/pkg/logger"
+)
+
+// ErrGoBotNotConfigured is returned when a call is due but REVERIFY_GO_BOT_URL is not set
+var ErrGoBotNotConfigured = errors.New("REVERIFY_GO_BOT_URL is not set")
+
+// Dial statuses
+const (
+	DialStatusDialed = "dialed"
+	DialStatusFailed = "failed"
+	DialStatusDryRun = "dry_run"
+)
+
+const dialOutTimeout = 30 * time.Second
+
+// runMutex serializes passes, so a manual run and the background scheduler can't both read an
+// organization's last dial before either records a new one and call it twice
+var runMutex sync.Mutex
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// Config controls the re-verification scheduler. LoadConfig reads it from the environment.
+type Config struct {
+	Enabled        bool          // REVERIFY_ENABLED=true starts the scheduler with the server
+	GoBotURL       string        // REVERIFY_GO_BOT_URL, go-bot's base URL
+	Interval       time.Duration // REVERIFY_INTERVAL, how often the queue is checked, default 15m
+	MaxDialsPerRun int           // REVERIFY_MAX_DIALS_PER_RUN, default 5
+	OrgMinInterval time.Duration // REVERIFY_ORG_MIN_INTERVAL, least time between calls to one organization, default 336h
+	Window         CallingWindow
+}
+
+// CallingWindow is when organizations may be called
+type CallingWindow struct {
+	Location *time.Location // REVERIFY_TIMEZONE, an IANA zone, defaults to the server's
+	Start    time.Duration  // Since midnight. REVERIFY_CALLING_HOURS, default 09:00-17:00
+	End      time.Duration
+	Days     map[time.Weekday]bool // REVERIFY_CALLING_DAYS, comma separated, default mon,tue,wed,thu,fri
+}
+
+// Contains reports whether t falls inside the window
+func (w CallingWindow) Contains(t time.Time) bool {
+	local := t.In(w.Location)
+	if !w.Days[local.Weekday()] {
+		return false
+	}
+	midnight := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, w.Location)
+	sinceMidnight := local.Sub(midnight)
+	return sinceMidnight >= w.Start && sinceMidnight < w.End
+}
+
+// NextOpen returns when the window next opens after t, or t itself if it is open
+func (w CallingWindow) NextOpen(t time.Time) time.Time {
+	if w.Contains(t) {
+		return t
+	}
+	local := t.In(w.Location)
+	for i := 0; i <= 7; i++ {
+		date := local.AddDate(0, 0, i)
+		open := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, w.Location).Add(w.Start)
+		if w.Days[open.Weekday()] && open.After(t) {
+			return open
+		}
+	}
+	return t
+}
+
+// LoadConfig reads the scheduler configuration from the environment
+func LoadConfig() (Config, error) {
+	config := Config{
+		Enabled:        strings.EqualFold(strings.TrimSpace(os.Getenv("REVERIFY_ENABLED")), "true"),
+		GoBotURL:       strings.TrimRight(strings.TrimSpace(os.Getenv("REVERIFY_GO_BOT_URL")), "/"),
+		Interval:       15 * time.Minute,
+		MaxDialsPerRun: 5,
+		OrgMinInterval: 14 * day,
+		Window: CallingWindow{
+			Location: time.Local,
+			Start:    9 * time.Hour,
+			End:      17 * time.Hour,
+			Days: map[time.Weekday]bool{
+				time.Monday: true, time.Tuesday: true, time.Wednesday: true, time.Thursday: true, time.Friday: true,
+			},
+		},
+	}
+
+	durations := map[string]*time.Duration{
+		"REVERIFY_INTERVAL":         &config.Interval,
+		"REVERIFY_ORG_MIN_INTERVAL": &config.OrgMinInterval,
+	}
+	for name, target := range durations {
+		if raw := strings.TrimSpace(os.Getenv(name)); raw != "" {
+			value, err := time.ParseDuration(raw)
+			if err != nil || value <= 0 {
+				return config, fmt.Errorf("%s must be a positive duration such as 15m", name)
+			}
+			*target = value
+		}
+	}
+	if raw := strings.TrimSpace(os.Getenv("REVERIFY_MAX_DIALS_PER_RUN")); raw != "" {
+		value, err := strconv.Atoi(raw)
+		if err != nil || value < 1 {
+			return config, fmt.Errorf("REVERIFY_MAX_DIALS_PER_RUN must be a positive integer")
+		}
+		config.MaxDialsPerRun = value
+	}
+	if raw := strings.TrimSpace(os.Getenv("REVERIFY_TIMEZONE")); raw != "" {
+		location, err := time.LoadLocation(raw)
+		if err != nil {
+			return config, fmt.Errorf("REVERIFY_TIMEZONE: %w", err)
+		}
+		config.Window.Location = location
+	}
+	if raw := strings.TrimSpace(os.Getenv("REVERIFY_CALLING_HOURS")); raw != "" {
+		start, end, ok := strings.Cut(raw, "-")
+		startTime, err1 := time.Parse("15:04", strings.TrimSpace(start))
+		endTime, err2 := time.Parse("15:04", strings.TrimSpace(end))
+		if !ok || err1 != nil || err2 != nil || !endTime.After(startTime) {
+			return config, fmt.Errorf("REVERIFY_CALLING_HOURS must look like 09:00-17:00")
+		}
+		config.Window.Start = time.Duration(startTime.Hour())*time.Hour + time.Duration(startTime.Minute())*time.Minute
+		config.Window.End = time.Duration(endTime.Hour())*time.Hour + time.Duration(endTime.Minute())*time.Minute
+	}
+	if raw := strings.TrimSpace(os.Getenv("REVERIFY_CALLING_DAYS")); raw != "" {
+		config.Window.Days = make(map[time.Weekday]bool)
+		for _, name := range strings.Split(raw, ",") {
+			weekday, ok := weekdays[strings.ToLower(strings.TrimSpace(name))]
+			if !ok {
+				return config, fmt.Errorf("REVERIFY_CALLING_DAYS must list days such as mon,tue,wed")
+			}
+			config.Window.Days[weekday] = true
+		}
+	}
+	if config.Enabled && config.GoBotURL == "" {
+		return config, ErrGoBotNotConfigured
+	}
+
+	return config, nil
+}
+
+// Dial is one re-verification call requested, or that would be requested on a dry run
+type Dial struct {
+	OrganizationID    string   `json:"organization_id"`
+	Name              string   `json:"name"`
+	PhoneNumber       string   `json:"phone_number"`
+	ServiceCategories []string `json:"service_categories"`
+	Status            string   `json:"status"`
+	Error             string   `json:"error,omitempty"`
+}
+
+// RunResult reports one pass over the queue
+type RunResult struct {
+	RanAt          time.Time  `json:"ran_at"`
+	DryRun         bool       `json:"dry_run"`
+	InCallingHours bool       `json:"in_calling_hours"`
+	NextWindowAt   *time.Time `json:"next_window_at,omitempty"` // Set when the pass ran outside calling hours
+	Dials          []Dial     `json:"dials"`
+}
+
+// Run checks the queue every config.Interval until ctx is done
+func Run(ctx context.Context, config Config) {
+	log := logger.Get()
+	log.Info().
+		Dur("interval", config.Interval).
+		Int("max_dials_per_run", config.MaxDialsPerRun).
+		Dur("org_min_interval", config.OrgMinInterval).
+		Msg("Starting re-verification scheduler")
+
+	ticker := time.NewTicker(config.Interval)
+	defer ticker.Stop()
+	for {
+		if _, err := RunOnce(config, time.Now(), false); err != nil {
+			log.Error().
+				Err(err).
+				Msg("Re-verification pass failed")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce dials the highest priority organizations that are due, up to config.MaxDialsPerRun. Nothing is
+// dialed outside calling hours. Every request, successful or not, is recorded so the per-organization
+// rate limit applies to it. Passes never overlap; a pass started while another is dialing waits for it.
+func RunOnce(config Config, now time.Time, dryRun bool) (*RunResult, error) {
+	log := logger.Get()
+
+	runMutex.Lock()
+	defer runMutex.Unlock()
+
+	result := &RunResult{
+		RanAt:          now,
+		DryRun:         dryRun,
+		InCallingHours: config.Window.Contains(now),
+		Dials:          []Dial{},
+	}
+	if !result.InCallingHours {
+		next := config.Window.NextOpen(now)
+		result.NextWindowAt = &next
+		return result, nil
+	}
+	if !dryRun && config.GoBotURL == "" {
+		return result, ErrGoBotNotConfigured
+	}
+
+	queue, err := Queue(config, now)
+	if err != nil {
+		return result, err
+	}
+
+	for _, entry := range queue {
+		if len(result.Dials) >= config.MaxDialsPerRun {
+			break
+		}
+		if !entry.Dialable(now) {
+			continue
+		}
+
+		dial := Dial{
+			OrganizationID:    entry.OrganizationID,
+			Name:              entry.Name,
+			PhoneNumber:       entry.PhoneNumber,
+			ServiceCategories: entry.ServiceCategories,
+			Status:            DialStatusDryRun,
+		}
+		if dryRun {
+			result.Dials = append(result.Dials, dial)
+			continue
+		}
+
+		response, err := dialOut(config.GoBotURL, entry)
+		dial.Status = DialStatusDialed
+		if err != nil {
+			dial.Status = DialStatusFailed
+			dial.Error = err.Error()
+			response = err.Error()
+			log.Warn().
+				Err(err).
+				Str("organization_id", entry.OrganizationID).
+				Msg("Re-verification dial-out failed")
+		}
+		result.Dials = append(result.Dials, dial)
+
+		if err := supabase.StoreReverificationDial(supabase.ReverificationDialInput{
+			OrganizationID:    entry.OrganizationID,
+			PhoneNumber:       entry.PhoneNumber,
+			ServiceCategories: entry.ServiceCategories,
+			Status:            dial.Status,
+			Response:          response,
+		}); err != nil {
+			// Without the record the rate limit can't see this call, so stop rather than risk redialing
+			return result, err
+		}
+	}
+
+	log.Info().
+		Bool("dry_run", dryRun).
+		Int("queue_length", len(queue)).
+		Int("dials", len(result.Dials)).
+		Msg("Re-verification pass complete")
+
+	return result, nil
+}
+
+// dialOut asks go-bot to call the organization, returning go-bot's response body
+func dialOut(goBotURL string, entry QueueEntry) (string, error) {
+	body, err := json.Marshal(map[string]interface{}{
+		"cbo_name":           entry.Name,
+		"service_categories": entry.ServiceCategories,
+		"phone_number":       entry.PhoneNumber,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode dial-out request: %w", err)
+	}
+
+	client := &http.Client{Timeout: dialOutTimeout}
+	resp, err := client.Post(goBotURL+"/dial-out", "application/json", bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("failed to contact go-bot: %w", err)
+	}
+	defer resp.Body.Close()
+
+	response, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read go-bot response: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return "", fmt.Errorf("go-bot returned %d: %s", resp.StatusCode, strings.TrimSpace(string(response)))
+	}
+	return string(response), nil
+}
@@ -0,0 +1,113 @@
+package freshness
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCallingWindow(t *testing.T) {
+	zone := time.FixedZone("UTC-5", -5*60*60)
+	window := CallingWindow{
+		Location: zone,
+		Start:    9 * time.Hour,
+		End:      17 * time.Hour,
+		Days: map[time.Weekday]bool{
+			time.Monday: true, time.Tuesday: true, time.Wednesday: true, time.Thursday: true, time.Friday: true,
+		},
+	}
+	// 2026-10-19 is a Monday
+	local := func(day, hour, minute int) time.Time { return time.Date(2026, 10, day, hour, minute, 0, 0, zone) }
+
+	tests := []struct {
+		name         string
+		at           time.Time
+		wantContains bool
+		wantNextOpen time.Time
+	}{
+		{name: "weekday morning", at: local(19, 10, 0), wantContains: true, wantNextOpen: local(19, 10, 0)},
+		{name: "opening minute", at: local(19, 9, 0), wantContains: true, wantNextOpen: local(19, 9, 0)},
+		{name: "before opening", at: local(19, 8, 59), wantNextOpen: local(19, 9, 0)},
+		{name: "closing minute", at: local(19, 17, 0), wantNextOpen: local(20, 9, 0)},
+		{name: "friday evening", at: local(23, 18, 0), wantNextOpen: local(26, 9, 0)},
+		{name: "saturday", at: local(24, 12, 0), wantNextOpen: local(26, 9, 0)},
+		{name: "other time zone", at: time.Date(2026, 10, 19, 14, 30, 0, 0, time.UTC), wantContains: true, wantNextOpen: local(19, 9, 30)},
+		{name: "other time zone before opening", at: time.Date(2026, 10, 19, 13, 0, 0, 0, time.UTC), wantNextOpen: local(19, 9, 0)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := window.Contains(tt.at); got != tt.wantContains {
+				t.Errorf("Contains(%v) = %v, want %v", tt.at, got, tt.wantContains)
+			}
+			if got := window.NextOpen(tt.at); !got.Equal(tt.wantNextOpen) {
+				t.Errorf("NextOpen(%v) = %v, want %v", tt.at, got, tt.wantNextOpen)
+			}
+		})
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		check   func(Config) bool
+		wantErr string // Substring of the error, or empty
+	}{
+		{
+			name: "defaults",
+			check: func(c Config) bool {
+				return !c.Enabled && c.Interval == 15*time.Minute && c.MaxDialsPerRun == 5 && c.OrgMinInterval == 14*day &&
+					c.Window.Start == 9*time.Hour && c.Window.End == 17*time.Hour && len(c.Window.Days) == 5 && !c.Window.Days[time.Saturday]
+			},
+		},
+		{
+			name: "overrides",
+			env: map[string]string{
+				"REVERIFY_ENABLED": "TRUE", "REVERIFY_GO_BOT_URL": " http://go-bot:8080/ ", "REVERIFY_INTERVAL": "5m",
+				"REVERIFY_MAX_DIALS_PER_RUN": "2", "REVERIFY_ORG_MIN_INTERVAL": "72h", "REVERIFY_TIMEZONE": "UTC",
+				"REVERIFY_CALLING_HOURS": "08:30 - 12:15", "REVERIFY_CALLING_DAYS": "Sat, sun",
+			},
+			check: func(c Config) bool {
+				return c.Enabled && c.GoBotURL == "http://go-bot:8080" && c.Interval == 5*time.Minute && c.MaxDialsPerRun == 2 &&
+					c.OrgMinInterval == 72*time.Hour && c.Window.Location == time.UTC &&
+					c.Window.Start == 8*time.Hour+30*time.Minute && c.Window.End == 12*time.Hour+15*time.Minute &&
+					len(c.Window.Days) == 2 && c.Window.Days[time.Saturday] && c.Window.Days[time.Sunday]
+			},
+		},
+		{name: "enabled without go-bot", env: map[string]string{"REVERIFY_ENABLED": "true"}, wantErr: ErrGoBotNotConfigured.Error()},
+		{name: "bad interval", env: map[string]string{"REVERIFY_INTERVAL": "15"}, wantErr: "REVERIFY_INTERVAL"},
+		{name: "negative interval", env: map[string]string{"REVERIFY_ORG_MIN_INTERVAL": "-1h"}, wantErr: "REVERIFY_ORG_MIN_INTERVAL"},
+		{name: "zero dials", env: map[string]string{"REVERIFY_MAX_DIALS_PER_RUN": "0"}, wantErr: "REVERIFY_MAX_DIALS_PER_RUN"},
+		{name: "unknown time zone", env: map[string]string{"REVERIFY_TIMEZONE": "Mars/Olympus"}, wantErr: "REVERIFY_TIMEZONE"},
+		{name: "hours end before start", env: map[string]string{"REVERIFY_CALLING_HOURS": "17:00-09:00"}, wantErr: "REVERIFY_CALLING_HOURS"},
+		{name: "hours without a range", env: map[string]string{"REVERIFY_CALLING_HOURS": "09:00"}, wantErr: "REVERIFY_CALLING_HOURS"},
+		{name: "unknown day", env: map[string]string{"REVERIFY_CALLING_DAYS": "mon,funday"}, wantErr: "REVERIFY_CALLING_DAYS"},
+	}
+
+	variables := []string{
+		"REVERIFY_ENABLED", "REVERIFY_GO_BOT_URL", "REVERIFY_INTERVAL", "REVERIFY_MAX_DIALS_PER_RUN", "REVERIFY_ORG_MIN_INTERVAL",
+		"REVERIFY_TIMEZONE", "REVERIFY_CALLING_HOURS", "REVERIFY_CALLING_DAYS",
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, name := range variables {
+				t.Setenv(name, tt.env[name])
+			}
+
+			config, err := LoadConfig()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("LoadConfig() error = %v, want one mentioning %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadConfig(): %v", err)
+			}
+			if !tt.check(config) {
+				t.Errorf("LoadConfig() = %+v", config)
+			}
+		})
+	}
+}
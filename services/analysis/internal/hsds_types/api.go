@@ -0,0 +1,102 @@
+package hsds_types
+
+// // -- HSDS API Reference Responses -- ////
+// These wrap the table structs with the related objects the HSDS API Reference nests inside them.
+// Nested fields shadow the json:"-" relationship fields of the embedded struct.
+
+// Page is one page of a list endpoint
+type Page[T any] struct {
+	TotalItems int  `json:"total_items"`
+	TotalPages int  `json:"total_pages"`
+	PageNumber int  `json:"page_number"`
+	Size       int  `json:"size"`
+	FirstPage  bool `json:"first_page"`
+	LastPage   bool `json:"last_page"`
+	Empty      bool `json:"empty"`
+	Contents   []T  `json:"contents"`
+}
+
+// NewPage builds a page from its contents, the total matching items and the requested page and size
+func NewPage[T any](contents []T, totalItems int, pageNumber int, perPage int) Page[T] {
+	if contents == nil {
+		contents = []T{}
+	}
+	totalPages := 0
+	if perPage > 0 {
+		totalPages = (totalItems + perPage - 1) / perPage
+	}
+
+	return Page[T]{
+		TotalItems: totalItems,
+		TotalPages: totalPages,
+		PageNumber: pageNumber,
+		Size:       len(contents),
+		FirstPage:  pageNumber <= 1,
+		LastPage:   pageNumber >= totalPages,
+		Empty:      len(contents) == 0,
+		Contents:   contents,
+	}
+}
+
+type ContactDetail struct {
+	Contact
+	Phones []Phone `json:"phones"`
+}
+
+type ServiceCapacityDetail struct {
+	ServiceCapacity
+	Unit *Unit `json:"unit,omitempty"`
+}
+
+type AttributeDetail struct {
+	Attribute
+	TaxonomyTerm *TaxonomyTerm `json:"taxonomy_term,omitempty"`
+}
+
+type LocationDetail struct {
+	Location
+	Addresses     []Address       `json:"addresses"`
+	Phones        []Phone         `json:"phones"`
+	Schedules     []Schedule      `json:"schedules"`
+	Languages     []Language      `json:"languages"`
+	Accessibility []Accessibility `json:"accessibility"`
+}
+
+type ServiceAtLocationDetail struct {
+	ServiceAtLocation
+	Service   *ServiceDetail  `json:"service,omitempty"` // Left out when nested inside its service
+	Location  *LocationDetail `json:"location,omitempty"`
+	Phones    []Phone         `json:"phones"`
+	Schedules []Schedule      `json:"schedules"`
+	Contacts  []ContactDetail `json:"contacts"`
+}
+
+type ServiceDetail struct {
+	Service
+	Organization       *Organization             `json:"organization,omitempty"` // Left out when nested inside its organization
+	Program            *Program                  `json:"program,omitempty"`
+	AdditionalURLs     []URL                     `json:"additional_urls"`
+	Phones             []Phone                   `json:"phones"`
+	Schedules          []Schedule                `json:"schedules"`
+	ServiceAreas       []ServiceArea             `json:"service_areas"`
+	ServiceAtLocations []ServiceAtLocationDetail `json:"service_at_locations"`
+	Languages          []Language                `json:"languages"`
+	Funding            []Funding                 `json:"funding"`
+	CostOptions        []CostOption              `json:"cost_options"`
+	RequiredDocuments  []RequiredDocument        `json:"required_documents"`
+	Contacts           []ContactDetail           `json:"contacts"`
+	Capacities         []ServiceCapacityDetail   `json:"capacities"`
+	Attributes         []AttributeDetail         `json:"attributes"`
+}
+
+type OrganizationDetail struct {
+	Organization
+	AdditionalURLs          []URL                    `json:"additional_urls"`
+	Funding                 []Funding                `json:"funding"`
+	Contacts                []ContactDetail          `json:"contacts"`
+	Phones                  []Phone                  `json:"phones"`
+	Locations               []LocationDetail         `json:"locations"`
+	Programs                []Program                `json:"programs"`
+	OrganizationIdentifiers []OrganizationIdentifier `json:"organization_identifiers"`
+	Services                []ServiceDetail          `json:"services,omitempty"` // Only filled when full services are requested
+}
@@ -34,6 +34,10 @@ var StandardTimeFields = []string{
 	"until",
 	"opens_at",
 	"closes_at",
+	"updated",
+	"observed_at",
+	"recorded_at",
+	"archived_at",
 }
 
 // ParseTime attempts to parse a timestamp string using all supported formats
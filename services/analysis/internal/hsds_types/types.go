@@ -3,7 +3,10 @@
 
 package hsds_types
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 // // -- HSDS Definitions -- ////
 type Organization struct {
@@ -443,7 +446,7 @@ type Metadata struct {
 
 	// Metadata Data
 	ID               string    `json:"id" gorm:"type:varchar(250);primaryKey;not null" validate:"required"`
-	CallID           string    `json:"call_fk" validate:"required"`
+	CallID           string    `json:"call_fk,omitempty"` // Empty for changes made outside a call, e.g. admin merges
 	ResourceType     string    `json:"resource_type" gorm:"type:text;not null" validate:"required"`
 	LastActionDate   time.Time `json:"last_action_date" gorm:"type:date;not null" validate:"required"`
 	LastActionType   string    `json:"last_action_type" gorm:"type:text;not null" validate:"required"`
@@ -451,6 +454,29 @@ type Metadata struct {
 	PreviousValue    string    `json:"previous_value" gorm:"type:text;not null" validate:"required"`
 	ReplacementValue string    `json:"replacement_value" gorm:"type:text;not null" validate:"required"`
 	UpdatedBy        string    `json:"updated_by" gorm:"type:text;not null" validate:"required"`
+
+	// Optional columns are sent as null rather than omitted: PostgREST bulk inserts need every row to have
+	// the same keys
+	Rationale *string `json:"rationale" gorm:"type:text"`
+
+	// Transcript evidence supporting the change, keyed by field name
+	Evidence map[string]FieldEvidence `json:"evidence" gorm:"type:jsonb"`
+
+	// JSON-encoded typed values of a changed field, alongside the text columns above
+	PreviousValueJSON    json.RawMessage `json:"previous_value_json" gorm:"type:jsonb"`
+	ReplacementValueJSON json.RawMessage `json:"replacement_value_json" gorm:"type:jsonb"`
+
+	// Groups the rows written together by one change, e.g. every field of one service update
+	ChangeSetID string `json:"change_set_id" gorm:"type:varchar(250)"`
+}
+
+// FieldEvidence is the transcript span supporting an extracted field value
+type FieldEvidence struct {
+	Quote       string  `json:"quote"`
+	StartOffset int     `json:"start_offset"`
+	EndOffset   int     `json:"end_offset"`
+	Confidence  float64 `json:"confidence"`
+	Verified    bool    `json:"verified"` // Whether the quote was found in the transcript
 }
 
 type MetaTableDescription struct {
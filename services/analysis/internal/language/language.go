@@ -0,0 +1,228 @@
+package language
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/david-botos/BearHug/services/analysis/internal/types"
+)
+
+const (
+	// Undetermined is the ISO 639-2 code used when too little text was seen to tell
+	Undetermined = "und"
+	// minMarkerHits is how many marker words must be seen before a language is reported
+	minMarkerHits = 5
+	// vietnameseLetterShare is the share of letters carrying Vietnamese-only marks above which text is Vietnamese
+	vietnameseLetterShare = 0.02
+)
+
+// Detection is the language a transcript was spoken in
+type Detection struct {
+	Code       string  `json:"code"` // ISO 639-1, or "und" when undetermined
+	Name       string  `json:"name"`
+	Confidence float64 `json:"confidence"`
+}
+
+// markerWords are short, frequent words that rarely appear in the other detected languages. Detection only
+// has to tell apart the languages our partner organizations answer in; anything else is left undetermined.
+var markerWords = map[string][]string{
+	"en": {
+		"the", "and", "is", "are", "we", "you", "have", "for", "with", "to", "of", "it", "that", "this",
+		"yes", "our", "can", "what", "they", "would", "about", "your", "be", "just", "there",
+	},
+	"es": {
+		"el", "la", "los", "las", "que", "de", "y", "es", "un", "una", "por", "para", "con", "sí", "tenemos",
+		"nosotros", "usted", "está", "hay", "pero", "muy", "gracias", "cómo", "qué", "también", "puede",
+	},
+	"vi": {
+		"và", "là", "của", "có", "không", "chúng", "tôi", "được", "cho", "người", "những", "các", "này",
+		"với", "một", "để", "ạ", "dạ", "vâng", "chị", "ở", "thì", "rất", "bạn", "cảm", "ơn",
+	},
+}
+
+// markerLanguage maps each marker word to its language
+var markerLanguage = func() map[string]string {
+	index := make(map[string]string)
+	for code, words := range markerWords {
+		for _, word := range words {
+			index[word] = code
+		}
+	}
+	return index
+}()
+
+// Detect guesses the language of text from marker words, and from Vietnamese diacritics which are
+// distinctive on their own
+func Detect(text string) Detection {
+	hits := make(map[string]int, len(markerWords))
+
+	var letters, vietnameseLetters int
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		if isVietnameseLetter(r) {
+			vietnameseLetters++
+		}
+	}
+
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+	total := 0
+	for _, word := range words {
+		if code, ok := markerLanguage[word]; ok {
+			hits[code]++
+			total++
+		}
+	}
+
+	if letters > 0 && float64(vietnameseLetters)/float64(letters) >= vietnameseLetterShare {
+		return Detection{Code: "vi", Name: names["vi"], Confidence: confidence(hits["vi"], total, true)}
+	}
+	if total < minMarkerHits {
+		return Detection{Code: Undetermined, Name: names[Undetermined]}
+	}
+
+	best := ""
+	for _, code := range []string{"en", "es", "vi"} {
+		if best == "" || hits[code] > hits[best] {
+			best = code
+		}
+	}
+	return Detection{Code: best, Name: names[best], Confidence: confidence(hits[best], total, false)}
+}
+
+// DetectTranscript detects the language the organization's staff spoke in. For diarized transcripts only the
+// representative's turns are used, since the AI caller may open in English before switching.
+func DetectTranscript(transcript types.Transcript) Detection {
+	if !transcript.IsDiarized() {
+		return Detect(transcript.Text)
+	}
+
+	var representative, all strings.Builder
+	for _, turn := range transcript.Turns {
+		all.WriteString(turn.Text)
+		all.WriteString("\n")
+		if turn.Speaker == types.SpeakerRepresentative {
+			representative.WriteString(turn.Text)
+			representative.WriteString("\n")
+		}
+	}
+
+	if detection := Detect(representative.String()); detection.Code != Undetermined {
+		return detection
+	}
+	return Detect(all.String())
+}
+
+func confidence(hits, total int, diacritics bool) float64 {
+	if diacritics {
+		// The diacritics alone are conclusive, marker words only add to it
+		if total == 0 {
+			return 0.9
+		}
+		return 0.9 + 0.1*float64(hits)/float64(total)
+	}
+	return float64(hits) / float64(total)
+}
+
+// isVietnameseLetter reports whether r carries a mark used by Vietnamese and not by Spanish or English
+func isVietnameseLetter(r rune) bool {
+	switch unicode.ToLower(r) {
+	case 'ă', 'đ', 'ơ', 'ư', 'ĩ', 'ũ':
+		return true
+	}
+	// Latin Extended Additional holds the stacked tone marks (ạ, ế, ồ, ự, ...)
+	return r >= 0x1EA0 && r <= 0x1EF9
+}
+
+// names are the English names of the languages we recognize, keyed by ISO 639 code
+var names = map[string]string{
+	Undetermined: "Undetermined",
+	"am":         "Amharic",
+	"ar":         "Arabic",
+	"ase":        "American Sign Language",
+	"bn":         "Bengali",
+	"de":         "German",
+	"en":         "English",
+	"es":         "Spanish",
+	"fa":         "Persian",
+	"fr":         "French",
+	"hi":         "Hindi",
+	"hmn":        "Hmong",
+	"ht":         "Haitian Creole",
+	"it":         "Italian",
+	"ja":         "Japanese",
+	"km":         "Khmer",
+	"ko":         "Korean",
+	"lo":         "Lao",
+	"mh":         "Marshallese",
+	"my":         "Burmese",
+	"ne":         "Nepali",
+	"om":         "Oromo",
+	"pa":         "Punjabi",
+	"pl":         "Polish",
+	"pt":         "Portuguese",
+	"ro":         "Romanian",
+	"ru":         "Russian",
+	"sm":         "Samoan",
+	"so":         "Somali",
+	"sw":         "Swahili",
+	"th":         "Thai",
+	"ti":         "Tigrinya",
+	"tl":         "Tagalog",
+	"uk":         "Ukrainian",
+	"ur":         "Urdu",
+	"vi":         "Vietnamese",
+	"yue":        "Cantonese",
+	"zh":         "Chinese",
+}
+
+// aliases are other ways a language is named in conversation, including in the language itself
+var aliases = map[string]string{
+	"asl":                 "ase",
+	"sign language":       "ase",
+	"español":             "es",
+	"espanol":             "es",
+	"castellano":          "es",
+	"tiếng việt":          "vi",
+	"tieng viet":          "vi",
+	"mandarin":            "zh",
+	"farsi":               "fa",
+	"filipino":            "tl",
+	"creole":              "ht",
+	"kreyol":              "ht",
+	"haitian":             "ht",
+	"cambodian":           "km",
+	"laotian":             "lo",
+	"afaan oromo":         "om",
+	"français":            "fr",
+	"português":           "pt",
+	"русский":             "ru",
+	"soomaali":            "so",
+	"traditional chinese": "zh",
+	"simplified chinese":  "zh",
+}
+
+// Lookup resolves a language name or code, as the model or a caller gave it, to its ISO 639 code and
+// English name
+func Lookup(nameOrCode string) (code string, name string, ok bool) {
+	key := strings.ToLower(strings.TrimSpace(nameOrCode))
+	if key == "" {
+		return "", "", false
+	}
+	if name, ok := names[key]; ok && key != Undetermined {
+		return key, name, true
+	}
+	if code, ok := aliases[key]; ok {
+		return code, names[code], true
+	}
+	for code, name := range names {
+		if code != Undetermined && strings.EqualFold(name, key) {
+			return code, name, true
+		}
+	}
+	return "", "", false
+}
@@ -0,0 +1,141 @@
+package language
+
+import (
+	"testing"
+
+	"github.com/david-botos/BearHug/services/analysis/internal/types"
+)
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name          string
+		text          string
+		want          string
+		minConfidence float64
+	}{
+		{
+			name:          "english",
+			text:          "Yes, we have beds for tonight and you can come by the shelter with your ID.",
+			want:          "en",
+			minConfidence: 0.9,
+		},
+		{
+			name:          "spanish",
+			text:          "Sí, tenemos camas para esta noche. Usted puede venir con su identificación, pero hay una lista de espera. Gracias.",
+			want:          "es",
+			minConfidence: 0.9,
+		},
+		{
+			name:          "vietnamese",
+			text:          "Dạ vâng, chúng tôi có phát thực phẩm vào thứ Bảy cho những người cần.",
+			want:          "vi",
+			minConfidence: 0.9,
+		},
+		{
+			name: "vietnamese without enough marker words",
+			text: "Cảm ơn chị",
+			want: "vi",
+		},
+		{
+			name: "mostly spanish with english names",
+			text: "Hola, el Food Bank de la ciudad tiene comida para las familias. The Pantry está abierto los martes y también hay clases de inglés.",
+			want: "es",
+		},
+		{
+			name: "mostly english with spanish words",
+			text: "We have a promotora who speaks Spanish, and our staff can say gracias, but the intake is in English and you would fill out the form with us.",
+			want: "en",
+		},
+		{name: "short answer", text: "Yes, that's right.", want: Undetermined},
+		{name: "no words", text: "... 555-0123", want: Undetermined},
+		{name: "empty", text: "", want: Undetermined},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Detect(tt.text)
+			if got.Code != tt.want {
+				t.Fatalf("Detect() = %+v, want %s", got, tt.want)
+			}
+			if got.Name != names[tt.want] {
+				t.Errorf("Name = %q, want %q", got.Name, names[tt.want])
+			}
+			if got.Confidence < tt.minConfidence || got.Confidence > 1 {
+				t.Errorf("Confidence = %.2f, want between %.2f and 1", got.Confidence, tt.minConfidence)
+			}
+			if tt.want == Undetermined && got.Confidence != 0 {
+				t.Errorf("undetermined Confidence = %.2f, want 0", got.Confidence)
+			}
+		})
+	}
+}
+
+func TestDetectTranscript(t *testing.T) {
+	english := "Hi, this is an automated call to check that your information is up to date. Is this a good time?"
+	spanish := "Sí, claro. Tenemos el banco de comida abierto los lunes y también los jueves, pero hay que llamar."
+
+	tests := []struct {
+		name       string
+		transcript types.Transcript
+		want       string
+	}{
+		{name: "plain text", transcript: types.Transcript{Text: spanish}, want: "es"},
+		{
+			name: "representative answers in spanish after an english greeting",
+			transcript: types.Transcript{Turns: []types.TranscriptTurn{
+				{Speaker: types.SpeakerAgent, Text: english},
+				{Speaker: types.SpeakerRepresentative, Text: spanish},
+				{Speaker: types.SpeakerAgent, Text: english},
+			}},
+			want: "es",
+		},
+		{
+			name: "representative says too little, so every turn counts",
+			transcript: types.Transcript{Turns: []types.TranscriptTurn{
+				{Speaker: types.SpeakerAgent, Text: english},
+				{Speaker: types.SpeakerRepresentative, Text: "Okay."},
+			}},
+			want: "en",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DetectTranscript(tt.transcript); got.Code != tt.want {
+				t.Errorf("DetectTranscript() = %+v, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLookup(t *testing.T) {
+	tests := []struct {
+		input    string
+		wantCode string
+		wantName string
+		wantOK   bool
+	}{
+		{input: "es", wantCode: "es", wantName: "Spanish", wantOK: true},
+		{input: " VI ", wantCode: "vi", wantName: "Vietnamese", wantOK: true},
+		{input: "yue", wantCode: "yue", wantName: "Cantonese", wantOK: true},
+		{input: "Spanish", wantCode: "es", wantName: "Spanish", wantOK: true},
+		{input: "haitian creole", wantCode: "ht", wantName: "Haitian Creole", wantOK: true},
+		{input: "Español", wantCode: "es", wantName: "Spanish", wantOK: true},
+		{input: "Tiếng Việt", wantCode: "vi", wantName: "Vietnamese", wantOK: true},
+		{input: "Mandarin", wantCode: "zh", wantName: "Chinese", wantOK: true},
+		{input: "ASL", wantCode: "ase", wantName: "American Sign Language", wantOK: true},
+		{input: "und"},
+		{input: "Undetermined"},
+		{input: "Klingon"},
+		{input: "  "},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			code, name, ok := Lookup(tt.input)
+			if code != tt.wantCode || name != tt.wantName || ok != tt.wantOK {
+				t.Errorf("Lookup(%q) = %q, %q, %v, want %q, %q, %v", tt.input, code, name, ok, tt.wantCode, tt.wantName, tt.wantOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,358 @@
+// Package phonenumber parses phone numbers as they are said or written on calls into E.164 with a
+// separate extension, validates them against the North American Numbering Plan and classifies
+// what kind of line they are.
+package phonenumber
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Phone types stored in phone.type
+const (
+	TypeVoice   = "voice"
+	TypeFax     = "fax"
+	TypeText    = "text"
+	TypeHotline = "hotline"
+)
+
+// Number is a parsed phone number
+type Number struct {
+	E164      string // e.g. +12065550123, empty for short codes
+	Extension *int
+	ShortCode string // Service codes such as 211 or 988, which have no E.164 form
+	TollFree  bool
+	Vanity    bool // Letters were converted using the keypad
+}
+
+// String returns the number as it should be stored, without the extension
+func (n Number) String() string {
+	if n.ShortCode != "" {
+		return n.ShortCode
+	}
+	return n.E164
+}
+
+var (
+	e164Pattern = regexp.MustCompile(`^\+[1-9]\d{1,14}$`)
+
+	// extensionPattern finds a trailing extension. "ext" and "x" may run into the digits ("x12", "ext.12"),
+	// but "x" must be followed by a digit so vanity words starting with x are left alone.
+	extensionPattern = regexp.MustCompile(`(?:^|[^a-z])((?:extension|ext)\b\.?\s*:?\s*(.+)|(?:ext|x)\.?\s*:?\s*(\d.*)|#\s*(.+))$`)
+
+	// shortCodes are the N11 service codes plus the 988 crisis line
+	shortCodes = map[string]bool{
+		"211": true, "311": true, "411": true, "511": true, "611": true,
+		"711": true, "811": true, "911": true, "988": true,
+	}
+
+	tollFreeAreaCodes = map[string]bool{
+		"800": true, "833": true, "844": true, "855": true, "866": true, "877": true, "888": true,
+	}
+
+	spokenDigits = map[string]string{
+		"zero": "0", "oh": "0", "o": "0",
+		"one": "1", "two": "2", "three": "3", "four": "4", "five": "5",
+		"six": "6", "seven": "7", "eight": "8", "nine": "9",
+		"ten": "10", "eleven": "11", "twelve": "12", "thirteen": "13", "fourteen": "14",
+		"fifteen": "15", "sixteen": "16", "seventeen": "17", "eighteen": "18", "nineteen": "19",
+	}
+	spokenTens = map[string]string{
+		"twenty": "2", "thirty": "3", "forty": "4", "fifty": "5",
+		"sixty": "6", "seventy": "7", "eighty": "8", "ninety": "9",
+	}
+	spokenRepeats = map[string]int{"double": 2, "triple": 3}
+
+	// fillerWords are said around numbers and carry no digits. Like spoken digits they are only read in
+	// spoken input; inside a letter group such as 1-800-CALL-NOW they are vanity letters.
+	fillerWords = map[string]bool{
+		"and": true, "dash": true, "hyphen": true, "dot": true, "the": true,
+		"number": true, "phone": true, "tel": true, "call": true, "at": true, "is": true,
+	}
+
+	keypad = map[rune]byte{
+		'a': '2', 'b': '2', 'c': '2', 'd': '3', 'e': '3', 'f': '3',
+		'g': '4', 'h': '4', 'i': '4', 'j': '5', 'k': '5', 'l': '5',
+		'm': '6', 'n': '6', 'o': '6', 'p': '7', 'q': '7', 'r': '7', 's': '7',
+		't': '8', 'u': '8', 'v': '8', 'w': '9', 'x': '9', 'y': '9', 'z': '9',
+	}
+)
+
+// IsE164 reports whether a string is already a valid E.164 number
+func IsE164(number string) bool {
+	return e164Pattern.MatchString(number)
+}
+
+// Parse reads a phone number in written, spoken or vanity form, with an optional extension.
+// Numbers without a country code are assumed to be NANP.
+func Parse(raw string) (Number, error) {
+	text := strings.ToLower(strings.TrimSpace(raw))
+	if text == "" {
+		return Number{}, fmt.Errorf("empty phone number")
+	}
+
+	var number Number
+
+	if rest, ext, ok := splitExtension(text); ok {
+		extDigits, _, _, err := toDigits(ext)
+		if err != nil {
+			return Number{}, fmt.Errorf("invalid extension in %q: %w", raw, err)
+		}
+		if len(extDigits) == 0 || len(extDigits) > 9 {
+			return Number{}, fmt.Errorf("invalid extension in %q", raw)
+		}
+		extension, err := strconv.Atoi(extDigits)
+		if err != nil {
+			return Number{}, fmt.Errorf("invalid extension in %q: %w", raw, err)
+		}
+		number.Extension = &extension
+		text = rest
+	}
+
+	digits, hasPlus, vanity, err := toDigits(text)
+	if err != nil {
+		return Number{}, fmt.Errorf("invalid phone number %q: %w", raw, err)
+	}
+	number.Vanity = vanity
+
+	// Vanity words often have more letters than the number has digits; the extras aren't dialed
+	if vanity {
+		switch {
+		case strings.HasPrefix(digits, "1") && len(digits) > 11:
+			digits = digits[:11]
+		case !strings.HasPrefix(digits, "1") && len(digits) > 10:
+			digits = digits[:10]
+		}
+	}
+
+	switch {
+	case hasPlus && !strings.HasPrefix(digits, "1"):
+		// International numbers are only checked for E.164 shape
+		if len(digits) < 8 || len(digits) > 15 {
+			return Number{}, fmt.Errorf("invalid international number %q", raw)
+		}
+		number.E164 = "+" + digits
+		return number, nil
+	case len(digits) == 11 && strings.HasPrefix(digits, "1"):
+		digits = digits[1:]
+	case !hasPlus && shortCodes[digits]:
+		number.ShortCode = digits
+		return number, nil
+	case len(digits) != 10 || hasPlus:
+		return Number{}, fmt.Errorf("phone number %q has %d digits, expected 10 for NANP", raw, len(digits))
+	}
+
+	if err := validateNANP(digits); err != nil {
+		return Number{}, fmt.Errorf("invalid phone number %q: %w", raw, err)
+	}
+
+	number.E164 = "+1" + digits
+	number.TollFree = tollFreeAreaCodes[digits[:3]]
+	return number, nil
+}
+
+// splitExtension separates a trailing extension from the rest of the number
+func splitExtension(text string) (rest string, extension string, ok bool) {
+	loc := extensionPattern.FindStringSubmatchIndex(text)
+	if loc == nil {
+		return text, "", false
+	}
+	for group := 2; group <= 4; group++ {
+		if start := loc[2*group]; start >= 0 {
+			return text[:loc[2]], text[start:loc[2*group+1]], true
+		}
+	}
+	return text, "", false
+}
+
+// validateNANP checks the area code and exchange of a 10 digit number
+func validateNANP(digits string) error {
+	area, exchange := digits[:3], digits[3:6]
+	if area[0] < '2' {
+		return fmt.Errorf("area code %s cannot start with 0 or 1", area)
+	}
+	if area[1:] == "11" {
+		return fmt.Errorf("area code %s is a service code", area)
+	}
+	if exchange[0] < '2' {
+		return fmt.Errorf("exchange %s cannot start with 0 or 1", exchange)
+	}
+	if exchange[1:] == "11" {
+		return fmt.Errorf("exchange %s is a service code", exchange)
+	}
+	return nil
+}
+
+// toDigits converts written digits, spoken words and vanity letters into a digit string
+func toDigits(text string) (digits string, hasPlus bool, vanity bool, err error) {
+	var out strings.Builder
+
+	tokens, letterGroup := tokenize(text)
+
+	repeat := 1
+	for i := 0; i < len(tokens); i++ {
+		token := tokens[i]
+
+		if strings.HasPrefix(token, "+") {
+			if out.Len() == 0 {
+				hasPlus = true
+			}
+			token = strings.TrimLeft(token, "+")
+			if token == "" {
+				continue
+			}
+		}
+		if token == "plus" && out.Len() == 0 {
+			hasPlus = true
+			continue
+		}
+
+		if times, ok := spokenRepeats[token]; ok {
+			repeat = times
+			continue
+		}
+
+		var value string
+		switch {
+		case isDigits(token):
+			value = token
+		case letterGroup[i]:
+			letters, ok := vanityDigits(token)
+			if !ok {
+				return "", false, false, fmt.Errorf("unrecognized token %q", token)
+			}
+			value = letters
+			vanity = true
+		case spokenDigits[token] != "":
+			value = spokenDigits[token]
+		case spokenTens[token] != "":
+			value = spokenTens[token] + "0"
+			if i+1 < len(tokens) && len(spokenDigits[tokens[i+1]]) == 1 && spokenDigits[tokens[i+1]] != "0" {
+				value = spokenTens[token] + spokenDigits[tokens[i+1]]
+				i++
+			}
+		case token == "hundred":
+			out.WriteString("00")
+			continue
+		case token == "thousand":
+			out.WriteString("000")
+			continue
+		case fillerWords[token]:
+			continue
+		default:
+			letters, ok := vanityDigits(token)
+			if !ok {
+				return "", false, false, fmt.Errorf("unrecognized token %q", token)
+			}
+			value = letters
+			vanity = true
+		}
+
+		out.WriteString(strings.Repeat(value, repeat))
+		repeat = 1
+	}
+
+	return out.String(), hasPlus, vanity, nil
+}
+
+// tokenize splits text into words and digit runs. letterGroup marks tokens that were joined to others by
+// dashes or dots, as in 1-800-CALL-NOW or 1-866-O-HELPME, which are read as keypad letters rather than
+// spoken words. A group made only of spoken number words ("two-oh-six") is still spoken.
+func tokenize(text string) (tokens []string, letterGroup []bool) {
+	for _, chunk := range strings.Fields(text) {
+		parts := strings.FieldsFunc(chunk, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '+'
+		})
+
+		grouped := false
+		if len(parts) > 1 {
+			for _, part := range parts {
+				if !isSpokenNumber(part) {
+					grouped = true
+					break
+				}
+			}
+		}
+		for _, part := range parts {
+			tokens = append(tokens, part)
+			letterGroup = append(letterGroup, grouped)
+		}
+	}
+	return tokens, letterGroup
+}
+
+func isSpokenNumber(word string) bool {
+	_, repeat := spokenRepeats[word]
+	return spokenDigits[word] != "" || spokenTens[word] != "" || repeat || word == "hundred" || word == "thousand"
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// vanityDigits maps letters to keypad digits, allowing digits mixed in (e.g. "4help")
+func vanityDigits(token string) (string, bool) {
+	var out strings.Builder
+	for _, r := range token {
+		switch {
+		case r >= '0' && r <= '9':
+			out.WriteRune(r)
+		case keypad[r] != 0:
+			out.WriteByte(keypad[r])
+		default:
+			return "", false
+		}
+	}
+	return out.String(), true
+}
+
+// Key returns a value for comparing numbers that may be stored in different formats
+func Key(raw string) string {
+	if number, err := Parse(raw); err == nil {
+		return number.String()
+	}
+	return strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, raw)
+}
+
+// Classify decides the phone type from a suggested type and any descriptions of the line.
+// A valid suggested type wins; otherwise keywords in the hints decide, then the number itself.
+func Classify(number Number, suggested string, hints ...string) string {
+	switch strings.ToLower(strings.TrimSpace(suggested)) {
+	case TypeVoice:
+		return TypeVoice
+	case TypeFax:
+		return TypeFax
+	case TypeText:
+		return TypeText
+	case TypeHotline:
+		return TypeHotline
+	}
+
+	text := strings.ToLower(strings.Join(hints, " "))
+	switch {
+	case strings.Contains(text, "fax"):
+		return TypeFax
+	case strings.Contains(text, "text") || strings.Contains(text, "sms"):
+		return TypeText
+	case strings.Contains(text, "hotline"), strings.Contains(text, "crisis"), strings.Contains(text, "helpline"),
+		strings.Contains(text, "24/7"), strings.Contains(text, "24 hour"), strings.Contains(text, "toll free"),
+		strings.Contains(text, "toll-free"):
+		return TypeHotline
+	case number.ShortCode != "" || number.TollFree:
+		return TypeHotline
+	}
+	return TypeVoice
+}
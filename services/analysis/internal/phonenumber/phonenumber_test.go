@@ -0,0 +1,120 @@
+package phonenumber
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		e164      string
+		extension int // 0 for none
+		shortCode string
+		tollFree  bool
+		vanity    bool
+		wantErr   bool
+	}{
+		{name: "written", input: "(206) 555-0123", e164: "+12065550123"},
+		{name: "country code", input: "+1 206 555 0123", e164: "+12065550123"},
+		{name: "spoken", input: "two oh six five five five oh one two three", e164: "+12065550123"},
+		{name: "repeated digits", input: "two oh six triple five oh one two three", e164: "+12065550123"},
+		{name: "international", input: "+44 20 7946 0958", e164: "+442079460958"},
+
+		{name: "x run into extension", input: "206-555-0123 x12", e164: "+12065550123", extension: 12},
+		{name: "x run into number", input: "206-555-0123x12", e164: "+12065550123", extension: 12},
+		{name: "x with space", input: "206-555-0123 x 12", e164: "+12065550123", extension: 12},
+		{name: "x with dot", input: "206-555-0123 x.12", e164: "+12065550123", extension: 12},
+		{name: "ext with dot", input: "206.555.0123 ext. 12", e164: "+12065550123", extension: 12},
+		{name: "ext run into extension", input: "206-555-0123 ext12", e164: "+12065550123", extension: 12},
+		{name: "extension spoken", input: "206-555-0123 extension twelve", e164: "+12065550123", extension: 12},
+		{name: "extension with colon", input: "206-555-0123 extension: 4410", e164: "+12065550123", extension: 4410},
+		{name: "hash", input: "206-555-0123 #5", e164: "+12065550123", extension: 5},
+
+		{name: "vanity", input: "1-800-FLOWERS", e164: "+18003569377", tollFree: true, vanity: true},
+		{name: "vanity starting with x", input: "1-800-XFINITY", e164: "+18009346489", tollFree: true, vanity: true},
+		{name: "vanity split", input: "1-800-GOT-JUNK", e164: "+18004685865", tollFree: true, vanity: true},
+		{name: "vanity with extension", input: "1-800-FLOWERS x 5", e164: "+18003569377", extension: 5, tollFree: true, vanity: true},
+		{name: "vanity with extra letters", input: "1-800-FLOWERSS", e164: "+18003569377", tollFree: true, vanity: true},
+		{name: "vanity word that is also filler", input: "1-800-CALL-NOW", e164: "+18002255669", tollFree: true, vanity: true},
+		{name: "vanity starting with the", input: "1-800-THE-HELP", e164: "+18008434357", tollFree: true, vanity: true},
+		{name: "vanity dot", input: "1-800-DOT-INFO", e164: "+18003684636", tollFree: true, vanity: true},
+		{name: "vanity letter o", input: "1-866-O-HELPME", e164: "+18666435763", tollFree: true, vanity: true},
+		{name: "spoken with filler", input: "call the number two oh six dash five five five dash oh one two three", e164: "+12065550123"},
+		{name: "spoken joined by dashes", input: "two-oh-six five five five oh one two three", e164: "+12065550123"},
+		{name: "spoken in groups", input: "two oh six, five five five, one two three four", e164: "+12065551234"},
+
+		{name: "short code", input: "211", shortCode: "211"},
+		{name: "crisis line", input: "988", shortCode: "988"},
+
+		{name: "empty", input: "  ", wantErr: true},
+		{name: "seven digits", input: "555-0123", wantErr: true},
+		{name: "spoken seven digits", input: "five five five, one two three four", wantErr: true},
+		{name: "area code starts with one", input: "(106) 555-0123", wantErr: true},
+		{name: "service code exchange", input: "206-411-0123", wantErr: true},
+		{name: "extension too long", input: "206-555-0123 x1234567890", wantErr: true},
+		{name: "unrecognized letters", input: "206-555-0123 señora", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Parse(%q) = %+v, want error", tt.input, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.input, err)
+			}
+
+			if got.E164 != tt.e164 {
+				t.Errorf("E164 = %q, want %q", got.E164, tt.e164)
+			}
+			if got.ShortCode != tt.shortCode {
+				t.Errorf("ShortCode = %q, want %q", got.ShortCode, tt.shortCode)
+			}
+			switch {
+			case tt.extension == 0 && got.Extension != nil:
+				t.Errorf("Extension = %d, want none", *got.Extension)
+			case tt.extension != 0 && (got.Extension == nil || *got.Extension != tt.extension):
+				t.Errorf("Extension = %v, want %d", got.Extension, tt.extension)
+			}
+			if got.TollFree != tt.tollFree {
+				t.Errorf("TollFree = %v, want %v", got.TollFree, tt.tollFree)
+			}
+			if got.Vanity != tt.vanity {
+				t.Errorf("Vanity = %v, want %v", got.Vanity, tt.vanity)
+			}
+		})
+	}
+}
+
+func TestClassify(t *testing.T) {
+	local, _ := Parse("206-555-0123")
+	tollFree, _ := Parse("1-800-555-0123")
+	shortCode, _ := Parse("211")
+
+	tests := []struct {
+		name      string
+		number    Number
+		suggested string
+		hints     []string
+		want      string
+	}{
+		{"suggested type wins", local, "Fax", []string{"main line"}, TypeFax},
+		{"unknown suggestion falls back to hints", local, "landline", []string{"fax line"}, TypeFax},
+		{"text hint", local, "", []string{"Text us"}, TypeText},
+		{"crisis hint", local, "", []string{"24/7 crisis line"}, TypeHotline},
+		{"toll free number", tollFree, "", nil, TypeHotline},
+		{"short code", shortCode, "", nil, TypeHotline},
+		{"plain number", local, "", []string{"front desk"}, TypeVoice},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Classify(tt.number, tt.suggested, tt.hints...); got != tt.want {
+				t.Errorf("Classify() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
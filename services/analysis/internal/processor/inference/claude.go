@@ -3,6 +3,7 @@ package inference
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -15,9 +16,23 @@ import (
 	"github.com/rs/zerolog"
 )
 
+const (
+	inferenceModel = "claude-3-5-sonnet-20241022"
+
+	// DefaultMaxTokens is the output budget for a request that doesn't set one
+	DefaultMaxTokens = 1500
+	// MaxOutputTokens is the most output the model can produce in one response
+	MaxOutputTokens = 8192
+)
+
+// ErrOutputTruncated is returned when the response hit max_tokens even at the largest output budget,
+// so the tool input is incomplete. Callers can split the work into smaller requests.
+var ErrOutputTruncated = errors.New("tool output truncated at max_tokens")
+
 type PromptParams struct {
-	Prompt string          `json:"prompt"`
-	Schema ToolInputSchema `json:"schema"`
+	Prompt    string          `json:"prompt"`
+	Schema    ToolInputSchema `json:"schema"`
+	MaxTokens int             `json:"max_tokens,omitempty"` // Defaults to DefaultMaxTokens
 }
 
 type Tool struct {
@@ -116,7 +131,7 @@ func (c *ClaudeClient) RunClaudeInference(params PromptParams) (map[string]inter
 			time.Sleep(retryDelay)
 		}
 
-		response, err := c.makeInferenceRequest(params, log)
+		response, err := c.runWithOutputBudget(params, log)
 		if err != nil {
 			lastErr = err
 			if isOverburdenedError(err) && attempt < maxRetries {
@@ -130,15 +145,38 @@ func (c *ClaudeClient) RunClaudeInference(params PromptParams) (map[string]inter
 	return nil, fmt.Errorf("anthropic API is overburdened: %w", lastErr)
 }
 
-func (c *ClaudeClient) makeInferenceRequest(params PromptParams, log *zerolog.Logger) (map[string]interface{}, error) {
+// runWithOutputBudget retries a truncated response with double the output budget until it fits or
+// the model's limit is reached
+func (c *ClaudeClient) runWithOutputBudget(params PromptParams, log *zerolog.Logger) (map[string]interface{}, error) {
+	maxTokens := params.MaxTokens
+	if maxTokens <= 0 {
+		maxTokens = DefaultMaxTokens
+	}
+
+	for {
+		response, err := c.makeInferenceRequest(params, maxTokens, log)
+		if !errors.Is(err, ErrOutputTruncated) || maxTokens >= MaxOutputTokens {
+			return response, err
+		}
+
+		next := min(maxTokens*2, MaxOutputTokens)
+		log.Warn().
+			Int("max_tokens", maxTokens).
+			Int("next_max_tokens", next).
+			Msg("Claude output was truncated, retrying with a larger output budget")
+		maxTokens = next
+	}
+}
+
+func (c *ClaudeClient) makeInferenceRequest(params PromptParams, maxTokens int, log *zerolog.Logger) (map[string]interface{}, error) {
 	log.Debug().
-		Str("model", "claude-3-5-sonnet-20241022").
-		Int("max_tokens", 1500).
+		Str("model", inferenceModel).
+		Int("max_tokens", maxTokens).
 		Msg("Starting Claude inference request")
 
 	reqBody := TriagePromptRequest{
-		Model:     "claude-3-5-sonnet-20241022",
-		MaxTokens: 1500,
+		Model:     inferenceModel,
+		MaxTokens: maxTokens,
 		Tools: []Tool{
 			{
 				Name:        "structured_output",
@@ -212,6 +250,15 @@ func (c *ClaudeClient) makeInferenceRequest(params PromptParams, log *zerolog.Lo
 		Interface("usage", inferenceResp.Usage).
 		Msg("Successfully parsed inference response")
 
+	// A tool_use block cut off at max_tokens has partial input, which must not be mistaken for a full answer
+	if inferenceResp.StopReason == "max_tokens" {
+		log.Warn().
+			Int("max_tokens", maxTokens).
+			Int("output_tokens", inferenceResp.Usage.OutputTokens).
+			Msg("Claude response stopped at max_tokens")
+		return nil, fmt.Errorf("%w after %d output tokens", ErrOutputTruncated, inferenceResp.Usage.OutputTokens)
+	}
+
 	var toolOutput interface{}
 	for _, content := range inferenceResp.Content {
 		if content.Type == "tool_use" {
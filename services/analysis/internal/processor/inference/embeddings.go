@@ -0,0 +1,86 @@
+package inference
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/david-botos/BearHug/services/analysis/pkg/logger"
+)
+
+// EmbeddingClient talks to a locally hosted embedding model exposing an Ollama compatible /api/embed endpoint
+type EmbeddingClient struct {
+	baseURL    string
+	model      string
+	httpClient *http.Client
+}
+
+type embedRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+type embedResponse struct {
+	Embeddings [][]float64 `json:"embeddings"`
+}
+
+// InitEmbeddingClient returns an embedding client when EMBEDDING_API_URL is configured.
+// A nil client with a nil error means embeddings are not available in this environment.
+func InitEmbeddingClient() (*EmbeddingClient, error) {
+	baseURL := os.Getenv("EMBEDDING_API_URL")
+	if baseURL == "" {
+		return nil, nil
+	}
+
+	model := os.Getenv("EMBEDDING_MODEL")
+	if model == "" {
+		model = "nomic-embed-text"
+	}
+
+	return &EmbeddingClient{
+		baseURL:    baseURL,
+		model:      model,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+// Embed returns one embedding vector per input text, in the same order
+func (c *EmbeddingClient) Embed(texts []string) ([][]float64, error) {
+	log := logger.Get()
+
+	jsonData, err := json.Marshal(embedRequest{Model: c.model, Input: texts})
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling embedding request: %w", err)
+	}
+
+	resp, err := c.httpClient.Post(c.baseURL+"/api/embed", "application/json", bytes.NewBuffer(jsonData))
+	if err != nil {
+		log.Error().Err(err).Str("url", c.baseURL).Msg("Failed to reach embedding model")
+		return nil, fmt.Errorf("error making embedding request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading embedding response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("embedding request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var embedResp embedResponse
+	if err := json.Unmarshal(body, &embedResp); err != nil {
+		return nil, fmt.Errorf("error parsing embedding response: %w", err)
+	}
+
+	if len(embedResp.Embeddings) != len(texts) {
+		return nil, fmt.Errorf("expected %d embeddings, got %d", len(texts), len(embedResp.Embeddings))
+	}
+
+	return embedResp.Embeddings, nil
+}
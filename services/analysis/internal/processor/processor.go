@@ -4,16 +4,25 @@ import (
 	"fmt"
 
 	"github.com/david-botos/BearHug/services/analysis/internal/processor/structOutputs"
+	"github.com/david-botos/BearHug/services/analysis/internal/processor/validation"
 	"github.com/david-botos/BearHug/services/analysis/internal/types"
 	"github.com/david-botos/BearHug/services/analysis/pkg/logger"
 )
 
-func ProcessTranscript(params types.ProcTranscriptParams) (bool, error) {
+// ProcessingResult reports what happened to each detail category extracted from a call
+type ProcessingResult struct {
+	Success           bool                            `json:"success"` // False when any category failed or couldn't park what it left unresolved
+	Categories        []structOutputs.CategoryOutcome `json:"categories"`
+	WithheldForReview int                             `json:"withheld_for_review,omitempty"`
+}
+
+func ProcessTranscript(params types.ProcTranscriptParams) (*ProcessingResult, error) {
 	log := logger.Get()
 
 	log.Info().
 		Str("organization_id", params.OrganizationID).
 		Int("transcript_length", len(params.Transcript)).
+		Str("language", params.Language).
 		Msg("Starting transcript processing")
 
 	///* --- Extract services based on the transcript --- *///
@@ -24,7 +33,7 @@ func ProcessTranscript(params types.ProcTranscriptParams) (bool, error) {
 			Err(servicesExtractionErr).
 			Str("organization_id", params.OrganizationID).
 			Msg("Service extraction failed")
-		return false, fmt.Errorf("error with service extraction: %w", servicesExtractionErr)
+		return nil, fmt.Errorf("error with service extraction: %w", servicesExtractionErr)
 	}
 
 	///* --- Verify Service Uniqueness -> Upload or Update --- *///
@@ -34,7 +43,23 @@ func ProcessTranscript(params types.ProcTranscriptParams) (bool, error) {
 		log.Error().
 			Err(serviceUpdateAndUploadErr).
 			Msg("Extracted service reasoning and upload failed")
-		return false, fmt.Errorf(`An error occurred when doing reasoning and upload on extracted services: %w`, serviceUpdateAndUploadErr)
+		return nil, fmt.Errorf(`An error occurred when doing reasoning and upload on extracted services: %w`, serviceUpdateAndUploadErr)
+	}
+
+	///* --- Detect discontinued, paused or resumed services --- *///
+	log.Debug().Msg("Beginning service lifecycle detection")
+	statusChanges, documentedServices, lifecycleErr := structOutputs.DetectServiceLifecycleChanges(params.OrganizationID, params.Transcript)
+	if lifecycleErr != nil {
+		log.Error().
+			Err(lifecycleErr).
+			Msg("Service lifecycle detection failed")
+		return nil, fmt.Errorf("error detecting service lifecycle changes: %w", lifecycleErr)
+	}
+	if applyErr := structOutputs.ApplyServiceLifecycleChanges(statusChanges, documentedServices, &serviceCtx, params.CallID); applyErr != nil {
+		log.Error().
+			Err(applyErr).
+			Msg("Failed to apply service lifecycle changes")
+		return nil, fmt.Errorf("error applying service lifecycle changes: %w", applyErr)
 	}
 
 	///* --- Identify details for triaged analysis --- *///
@@ -44,50 +69,79 @@ func ProcessTranscript(params types.ProcTranscriptParams) (bool, error) {
 		log.Error().
 			Err(detailIdentificationErr).
 			Msg("Failed to identify what details exist in the transcript")
-		return false, fmt.Errorf(`an error occurred when identifying details that are present in the transcript for further detailed analysis: %w`, detailIdentificationErr)
+		return nil, fmt.Errorf(`an error occurred when identifying details that are present in the transcript for further detailed analysis: %w`, detailIdentificationErr)
 	}
 
+	result := &ProcessingResult{Success: true}
+
 	///* --- Conduct Triaged Analyses for Details --- *///
+	var extractedDetails []*structOutputs.DetailAnalysisResult
 	if len(identifiedDetailTypes.DetectedCategories) > 0 {
 		log.Debug().Msg("Starting detail extraction from triaged analysis")
-		extractedDetails, detailExtractionErr := structOutputs.HandleTriagedAnalysis(
+		var outcomes []structOutputs.CategoryOutcome
+		extractedDetails, outcomes = structOutputs.HandleTriagedAnalysis(
 			params.OrganizationID,
 			params.Transcript,
 			identifiedDetailTypes,
 			serviceCtx,
 			params.CallID,
 		)
-		if detailExtractionErr != nil {
+		result.Categories = outcomes
+
+		///* --- Validate the Entire Output --- *///
+		log.Debug().
+			Int("detail_categories", len(extractedDetails)).
+			Msg("Starting validation of extracted information")
+		validationReport, validatorErr := validation.ValidateExtractedInfo(extractedDetails, serviceCtx, params.Transcript, params.CallID)
+		if validatorErr != nil {
 			log.Error().
-				Err(detailExtractionErr).
-				Msg("Failed to extract details from triaged analysis")
-			return false, fmt.Errorf("error extracting details: %w", detailExtractionErr)
+				Err(validatorErr).
+				Msg("Validation failed for extracted information")
+			return nil, fmt.Errorf("error when attempting to validate the information extracted from transcript: %w", validatorErr)
 		}
+		if validationReport.RoutedToReview {
+			log.Warn().
+				Int("unresolved_issues", len(validationReport.UnresolvedIssues)).
+				Msg("Some extracted details were withheld for review")
+			result.WithheldForReview = len(validationReport.UnresolvedIssues)
+		}
+		extractedDetails = validationReport.Details
+		serviceCtx = validationReport.ServiceCtx
+	}
 
-		///* --- Validate the Entire Output --- *///
-		// log.Debug().
-		// 	Interface("extracted_details", extractedDetails).
-		// 	Msg("Starting validation of extracted information")
-		// validationResult, validatorErr := validation.ValidateExtractedInfo(extractedDetails, *serviceCtx, params.Transcript, params.CallID)
-		// if validatorErr != nil {
-		// 	log.Error().
-		// 		Err(validatorErr).
-		// 		Msg("Validation failed for extracted information")
-		// 	return false, fmt.Errorf("error when attempting to validate the information extracted from transcript: %w", validatorErr)
-		// }
-
-		// if !validationResult {
-		// 	log.Error().Msg("Validation failed with unhandled error")
-		// 	return false, fmt.Errorf("validation failed with unhandled error")
-		// }
+	///* --- Store the new services that passed validation --- *///
+	if storeErr := structOutputs.StoreNewServices(serviceCtx, params.CallID); storeErr != nil {
+		log.Error().
+			Err(storeErr).
+			Msg("Failed to store new services")
+		return nil, fmt.Errorf("error storing new services: %w", storeErr)
+	}
 
+	if len(result.Categories) > 0 {
 		///* --- Store all the NEW details --- *///
-		storageFailureErr := structOutputs.StoreDetails(extractedDetails, params.CallID)
-		if storageFailureErr != nil {
+		storageFailures := structOutputs.StoreDetails(extractedDetails, params.CallID)
+		for category, failure := range storageFailures {
 			log.Error().
-				Err(storageFailureErr).
-				Msg("Failed to store all details")
-			return false, fmt.Errorf("error storing details: %w", storageFailureErr)
+				AnErr("records_error", failure.Records).
+				AnErr("parking_error", failure.Parking).
+				Str("category", string(category)).
+				Msg("Failed to store category details")
+		}
+
+		detailsByCategory := make(map[structOutputs.DetailCategory]*structOutputs.DetailAnalysisResult, len(extractedDetails))
+		for _, detail := range extractedDetails {
+			detailsByCategory[detail.Category] = detail
+		}
+		for i := range result.Categories {
+			outcome := &result.Categories[i]
+			if outcome.Status == structOutputs.CategoryFailed {
+				result.Success = false
+				continue
+			}
+			outcome.RecordStorage(detailsByCategory[outcome.Category], storageFailures[outcome.Category])
+			if !outcome.Succeeded() {
+				result.Success = false
+			}
 		}
 	}
 
@@ -96,6 +150,13 @@ func ProcessTranscript(params types.ProcTranscriptParams) (bool, error) {
 	// 	Str("organization_id", params.OrganizationID).
 	// 	Msg("Successfully completed transcript processing")
 
+	if !result.Success {
+		log.Warn().
+			Interface("categories", result.Categories).
+			Msg("Transcript processed with failed detail categories")
+		return result, nil
+	}
+
 	log.Info().Msg("it worked yipeeeeee 🎉🥳")
-	return true, nil
+	return result, nil
 }
@@ -20,21 +20,29 @@ type CategoryDescription struct {
 	Description string
 }
 
-// ServiceContext holds both existing and new services for context
+// ServiceContext holds both existing and new services for context. New services aren't stored until
+// StoreNewServices is called, so validation can still drop or correct them.
 type ServiceContext struct {
 	ExistingServices []*hsds_types.Service
 	NewServices      []*hsds_types.Service
+	// What was extracted for each new service, keyed by its ID, for the evidence and languages stored with it
+	NewServiceSources map[string]ExtractedService
 }
 
 // CapacityResult represents the specific data structure for capacity analysis results
 type CapacityResult struct {
 	Capacities []*hsds_types.ServiceCapacity
 	Units      []*hsds_types.Unit
+	Evidence   map[string]map[string]hsds_types.FieldEvidence // Keyed by capacity ID, then field name
+	Unresolved []UnresolvedCapacity                           // Capacities whose service could not be identified
 }
 
 type ContactResult struct {
 	Contacts []*hsds_types.Contact
-	Phones   []*hsds_types.Phone
+	Phones   []*hsds_types.Phone                            // New phones, including ones added to stored contacts
+	Updates  []*ContactUpdate                               // Changes to stored contacts and phones
+	Evidence map[string]map[string]hsds_types.FieldEvidence // Keyed by contact or phone ID, then field name
+	Report   *ContactReconciliationReport                   // Saved by StoreDetails with what was written
 }
 
 // DetailAnalysisResult holds the results of analyzing a specific category of details
@@ -50,23 +58,95 @@ type DetailAnalysisResult struct {
 	// ReqDocsData    *ReqDocsResult
 }
 
+// CategoryStatus summarizes how a detail category fared for a call
+type CategoryStatus string
+
+const (
+	CategoryStored  CategoryStatus = "stored"  // Everything extracted was stored
+	CategoryPartial CategoryStatus = "partial" // Stored, but some observations were parked for review
+	CategoryFailed  CategoryStatus = "failed"  // Nothing from this category was stored
+)
+
+// CategoryOutcome reports the result of one detail category so a failure in one doesn't hide the others
+type CategoryOutcome struct {
+	Category     DetailCategory `json:"category"`
+	Status       CategoryStatus `json:"status"`
+	Stored       int            `json:"stored"`
+	Unresolved   int            `json:"unresolved,omitempty"`
+	Error        string         `json:"error,omitempty"`
+	ParkingError string         `json:"parking_error,omitempty"` // Set when the unresolved observations couldn't be parked
+}
+
+// RecordStorage fills in the outcome once the category's details have been stored. Records and parked
+// observations are reported separately: stored records with a parking failure are partial, and parked
+// observations are still counted when the records failed.
+func (o *CategoryOutcome) RecordStorage(detail *DetailAnalysisResult, failure StoreFailure) {
+	if detail != nil {
+		o.Unresolved = detail.UnresolvedCount()
+	}
+	if failure.Parking != nil {
+		o.ParkingError = failure.Parking.Error()
+	}
+
+	if failure.Records != nil {
+		o.Status = CategoryFailed
+		o.Error = failure.Records.Error()
+		return
+	}
+	o.Status = CategoryStored
+	if detail != nil {
+		o.Stored = detail.RecordCount()
+	}
+	if o.Unresolved > 0 || failure.Parking != nil {
+		o.Status = CategoryPartial
+	}
+}
+
+// Succeeded reports whether everything in the category was either stored or parked
+func (o CategoryOutcome) Succeeded() bool {
+	return o.Status != CategoryFailed && o.ParkingError == ""
+}
+
+// RecordCount returns how many records the result would store
+func (d *DetailAnalysisResult) RecordCount() int {
+	count := 0
+	if d.CapacityData != nil {
+		count += len(d.CapacityData.Capacities)
+	}
+	if d.ContactData != nil {
+		count += len(d.ContactData.Contacts) + len(d.ContactData.Phones) + len(d.ContactData.Updates)
+	}
+	return count
+}
+
+// UnresolvedCount returns how many observations the result parks for review instead of storing
+func (d *DetailAnalysisResult) UnresolvedCount() int {
+	if d.CapacityData != nil {
+		return len(d.CapacityData.Unresolved)
+	}
+	return 0
+}
+
 // NewCapacityResult creates a new DetailAnalysisResult for capacity data
-func NewCapacityCategoryResult(capacities []*hsds_types.ServiceCapacity, units []*hsds_types.Unit) DetailAnalysisResult {
+func NewCapacityCategoryResult(capacities []*hsds_types.ServiceCapacity, units []*hsds_types.Unit, evidence map[string]map[string]hsds_types.FieldEvidence) DetailAnalysisResult {
 	return DetailAnalysisResult{
 		Category: CapacityCategory,
 		CapacityData: &CapacityResult{
 			Capacities: capacities,
 			Units:      units,
+			Evidence:   evidence,
 		},
 	}
 }
 
-func NewContactCategoryResult(contacts []*hsds_types.Contact, phones []*hsds_types.Phone) DetailAnalysisResult {
+func NewContactCategoryResult(contacts []*hsds_types.Contact, phones []*hsds_types.Phone, updates []*ContactUpdate, evidence map[string]map[string]hsds_types.FieldEvidence) DetailAnalysisResult {
 	return DetailAnalysisResult{
 		Category: ContactCategory,
 		ContactData: &ContactResult{
 			Contacts: contacts,
 			Phones:   phones,
+			Updates:  updates,
+			Evidence: evidence,
 		},
 	}
 }
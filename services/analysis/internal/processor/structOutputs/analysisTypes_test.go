@@ -0,0 +1,75 @@
+package structOutputs
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/david-botos/BearHug/services/analysis/internal/hsds_types"
+)
+
+func TestCategoryOutcomeRecordStorage(t *testing.T) {
+	withUnresolved := &DetailAnalysisResult{
+		Category: CapacityCategory,
+		CapacityData: &CapacityResult{
+			Capacities: []*hsds_types.ServiceCapacity{{ID: "c1"}, {ID: "c2"}},
+			Unresolved: []UnresolvedCapacity{{ServiceName: "Shelter"}},
+		},
+	}
+	resolved := &DetailAnalysisResult{
+		Category:     CapacityCategory,
+		CapacityData: &CapacityResult{Capacities: []*hsds_types.ServiceCapacity{{ID: "c1"}}},
+	}
+	writeErr, parkErr := errors.New("write failed"), errors.New("park failed")
+
+	tests := []struct {
+		name          string
+		detail        *DetailAnalysisResult
+		failure       StoreFailure
+		want          CategoryOutcome
+		wantSucceeded bool
+	}{
+		{
+			name:          "everything stored",
+			detail:        resolved,
+			want:          CategoryOutcome{Status: CategoryStored, Stored: 1},
+			wantSucceeded: true,
+		},
+		{
+			name:          "stored with parked observations",
+			detail:        withUnresolved,
+			want:          CategoryOutcome{Status: CategoryPartial, Stored: 2, Unresolved: 1},
+			wantSucceeded: true,
+		},
+		{
+			name:    "records failed but observations parked",
+			detail:  withUnresolved,
+			failure: StoreFailure{Records: writeErr},
+			want:    CategoryOutcome{Status: CategoryFailed, Unresolved: 1, Error: "write failed"},
+		},
+		{
+			name:    "records stored but parking failed",
+			detail:  withUnresolved,
+			failure: StoreFailure{Parking: parkErr},
+			want:    CategoryOutcome{Status: CategoryPartial, Stored: 2, Unresolved: 1, ParkingError: "park failed"},
+		},
+		{
+			name:    "both failed",
+			detail:  withUnresolved,
+			failure: StoreFailure{Records: writeErr, Parking: parkErr},
+			want:    CategoryOutcome{Status: CategoryFailed, Unresolved: 1, Error: "write failed", ParkingError: "park failed"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var outcome CategoryOutcome
+			outcome.RecordStorage(tt.detail, tt.failure)
+			if outcome != tt.want {
+				t.Errorf("outcome = %+v, want %+v", outcome, tt.want)
+			}
+			if outcome.Succeeded() != tt.wantSucceeded {
+				t.Errorf("Succeeded() = %v, want %v", outcome.Succeeded(), tt.wantSucceeded)
+			}
+		})
+	}
+}
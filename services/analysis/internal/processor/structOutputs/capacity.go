@@ -9,7 +9,6 @@ import (
 	"github.com/agnivade/levenshtein"
 	"github.com/david-botos/BearHug/services/analysis/internal/hsds_types"
 	"github.com/david-botos/BearHug/services/analysis/internal/processor/inference"
-	"github.com/david-botos/BearHug/services/analysis/internal/supabase"
 	"github.com/david-botos/BearHug/services/analysis/pkg/logger"
 )
 
@@ -48,7 +47,12 @@ Existing Services (may or may not be mentioned in the transcript):
 New Services (extracted from the transcript directly):
 %s
 
-IMPORTANT: You must ONLY respond by using the capacities tool to output the structured data. Do not provide any explanatory text, confirmations, or additional messages. Simply use the tool to output the structured data following the schema exactly.`, transcript, existingServiceDesc.String(), newServiceDesc.String())
+For every value you report, include an evidence entry quoting the exact words from the transcript that support it, with their character offsets and your confidence from 0 to 1.
+
+Language:
+%s
+
+IMPORTANT: You must ONLY respond by using the capacities tool to output the structured data. Do not provide any explanatory text, confirmations, or additional messages. Simply use the tool to output the structured data following the schema exactly.`, transcript, existingServiceDesc.String(), newServiceDesc.String(), languageRules)
 
 	log.Debug().Msg("Service capacity prompt generated successfully")
 	return prompt, ServiceCapacitySchema, nil
@@ -82,8 +86,9 @@ var ServiceCapacitySchema = inference.ToolInputSchema{
 						"type":        "string",
 						"description": "Human-readable description of what is being measured",
 					},
+					"evidence": evidenceProperty("serviceName", "available", "maximum", "unitName"),
 				},
-				"required": []string{"available", "unitName", "unitDescription"},
+				"required": []string{"available", "unitName", "unitDescription", "evidence"},
 			},
 		},
 	},
@@ -108,6 +113,8 @@ type capacityInference struct {
 	Maximum         *float64 `json:"maximum,omitempty"`
 	UnitName        string   `json:"unitName"`
 	UnitDescription string   `json:"unitDescription,omitempty"`
+
+	Evidence map[string]hsds_types.FieldEvidence `json:"evidence,omitempty"`
 }
 
 type capacityAndUnitInfOutput struct {
@@ -118,9 +125,10 @@ type serviceMatchResult struct {
 	inference capacityInference
 	service   *hsds_types.Service
 	matched   bool
+	ambiguous *ServiceMatchDecision // Set when the matcher could not choose between services
 }
 
-func infToCapacityAndUnits(inferenceResult map[string]interface{}, serviceCtx ServiceContext) ([]*hsds_types.ServiceCapacity, []*hsds_types.Unit, error) {
+func infToCapacityAndUnits(inferenceResult map[string]interface{}, transcript string, serviceCtx ServiceContext) (*CapacityResult, error) {
 	log := logger.Get()
 	log.Debug().Msg("Starting inference result conversion")
 
@@ -135,14 +143,23 @@ func infToCapacityAndUnits(inferenceResult map[string]interface{}, serviceCtx Se
 	jsonData, err := json.Marshal(inferenceResult)
 	if err != nil {
 		log.Error().Err(err).Msg("Failed to marshal inference result")
-		return nil, nil, fmt.Errorf("error marshaling inference result: %w", err)
+		return nil, fmt.Errorf("error marshaling inference result: %w", err)
 	}
 
 	var output capacityAndUnitInfOutput
 	if err := json.Unmarshal(jsonData, &output); err != nil {
 		log.Error().Err(err).Msg("Failed to unmarshal to structured output")
-		return nil, nil, fmt.Errorf("error unmarshaling to structured output: %w", err)
+		return nil, fmt.Errorf("error unmarshaling to structured output: %w", err)
+	}
+
+	// Drop capacities whose counts the transcript doesn't support
+	supported := make([]capacityInference, 0, len(output.Capacities))
+	for _, capacity := range output.Capacities {
+		if rejectUnsupportedCapacityFields(transcript, &capacity) {
+			supported = append(supported, capacity)
+		}
 	}
+	output.Capacities = mergeRepeatedCapacities(supported)
 
 	// Log parsed output structure
 	log.Debug().
@@ -150,26 +167,11 @@ func infToCapacityAndUnits(inferenceResult map[string]interface{}, serviceCtx Se
 		Interface("capacities", output.Capacities).
 		Msg("Parsed inference output")
 
-	// Fetch all existing units once
-	existingUnits, err := supabase.FetchUnits()
+	// Load the unit registry once so every capacity in this call resolves against the same units
+	unitRegistry, err := LoadUnitRegistry()
 	if err != nil {
-		log.Error().Err(err).Msg("Failed to fetch existing units")
-		return nil, nil, fmt.Errorf("error fetching existing units: %w", err)
-	}
-
-	log.Debug().
-		Int("existing_units_count", len(existingUnits)).
-		Msg("Fetched existing units")
-
-	// Create map for quick unit lookup
-	unitMap := make(map[string]*hsds_types.Unit)
-	for _, unit := range existingUnits {
-		unitCopy := unit
-		unitMap[strings.ToLower(strings.TrimSpace(unit.Name))] = &unitCopy
-		log.Debug().
-			Str("unit_name", unit.Name).
-			Str("unit_id", unit.ID).
-			Msg("Mapped existing unit")
+		log.Error().Err(err).Msg("Failed to load unit registry")
+		return nil, fmt.Errorf("error loading unit registry: %w", err)
 	}
 
 	// Match services first (keeping existing logic)
@@ -192,6 +194,7 @@ func infToCapacityAndUnits(inferenceResult map[string]interface{}, serviceCtx Se
 
 	// Track service matching results
 	matchResults := make([]serviceMatchResult, len(output.Capacities))
+	matcher := NewServiceMatcher()
 	for i, capacity := range output.Capacities {
 		log.Debug().
 			Str("capacity_service_name", capacity.ServiceName).
@@ -200,11 +203,12 @@ func infToCapacityAndUnits(inferenceResult map[string]interface{}, serviceCtx Se
 			Interface("maximum", capacity.Maximum).
 			Msg("Attempting to match capacity")
 
-		matchedService := findMatchingService(capacity, totalServices)
+		matchedService, ambiguous := findMatchingService(matcher, capacity, totalServices)
 		matchResults[i] = serviceMatchResult{
 			inference: capacity,
 			service:   matchedService,
 			matched:   matchedService != nil,
+			ambiguous: ambiguous,
 		}
 
 		if matchedService != nil {
@@ -221,85 +225,74 @@ func infToCapacityAndUnits(inferenceResult map[string]interface{}, serviceCtx Se
 		}
 	}
 
-	// Check for unmatched services
-	var unmatched []string
-	for _, result := range matchResults {
+	// Give unmatched capacities a second chance with Claude, and park whatever is still unmatched
+	var unmatched []capacityInference
+	var unmatchedIdx []int
+	for i, result := range matchResults {
 		if !result.matched {
-			unmatched = append(unmatched, result.inference.ServiceName)
+			unmatched = append(unmatched, result.inference)
+			unmatchedIdx = append(unmatchedIdx, i)
 		}
 	}
+	var unresolved []UnresolvedCapacity
 	if len(unmatched) > 0 {
-		log.Error().
-			Strs("unmatched_services", unmatched).
-			Interface("total_services", totalServices).
-			Interface("match_results", matchResults).
-			Msg("Failed to match all services")
-		return nil, nil, fmt.Errorf("unable to match services for: %s", strings.Join(unmatched, ", "))
+		log.Warn().
+			Int("unmatched_count", len(unmatched)).
+			Msg("Some capacities did not match a service, attempting disambiguation")
+
+		var disambiguated map[int]*hsds_types.Service
+		disambiguated, unresolved = disambiguateCapacityServices(transcript, unmatched, totalServices)
+		for i, service := range disambiguated {
+			matchResults[unmatchedIdx[i]].service = service
+			matchResults[unmatchedIdx[i]].matched = true
+		}
+
+		// Unresolved capacities keep the order of unmatched ones, so they line up once the placed ones are skipped
+		next := 0
+		for i, idx := range unmatchedIdx {
+			if _, ok := disambiguated[i]; ok {
+				continue
+			}
+			if ambiguous := matchResults[idx].ambiguous; ambiguous != nil {
+				unresolved[next].noteAmbiguousMatch(*ambiguous)
+			}
+			next++
+		}
+
+		for _, capacity := range unresolved {
+			log.Warn().
+				Str("service_name", capacity.ServiceName).
+				Str("unit_name", capacity.UnitName).
+				Str("reason", capacity.Reason).
+				Msg("Capacity left unresolved")
+		}
 	}
 
 	// Arrays for results
 	var newUnits []*hsds_types.Unit
 	capacities := make([]*hsds_types.ServiceCapacity, 0, len(matchResults))
+	evidence := make(map[string]map[string]hsds_types.FieldEvidence, len(matchResults))
 
 	// Process matched capacities with unit reconciliation
 	for _, match := range matchResults {
+		if !match.matched {
+			continue
+		}
 		log.Debug().
 			Str("service_name", match.inference.ServiceName).
 			Str("unit_name", match.inference.UnitName).
 			Msg("Processing capacity entry")
 
-		normalizedUnitName := strings.ToLower(strings.TrimSpace(match.inference.UnitName))
-		var unit *hsds_types.Unit
-
-		// Check for existing unit
-		if existingUnit, exists := unitMap[normalizedUnitName]; exists {
-			unit = existingUnit
-			log.Debug().
+		unit, isNew, err := unitRegistry.Resolve(match.inference.UnitName)
+		if err != nil {
+			log.Error().
+				Err(err).
 				Str("unit_name", match.inference.UnitName).
-				Str("unit_id", unit.ID).
-				Msg("Using existing unit")
-		} else {
-			// Try fuzzy matching for units
-			var bestMatch *hsds_types.Unit
-			threshold := 0.8
-			highestSimilarity := 0.0
-
-			for _, existing := range existingUnits {
-				similarity := calculateStringSimilarity(normalizedUnitName,
-					strings.ToLower(strings.TrimSpace(existing.Name)))
-				if similarity > threshold && similarity > highestSimilarity {
-					highestSimilarity = similarity
-					existingCopy := existing
-					bestMatch = &existingCopy
-				}
-			}
-
-			if bestMatch != nil {
-				unit = bestMatch
-				log.Debug().
-					Str("unit_name", match.inference.UnitName).
-					Str("matched_unit", unit.Name).
-					Float64("similarity", highestSimilarity).
-					Msg("Found fuzzy unit match")
-			} else {
-				// Create new unit if no match found
-				unitOpts := &hsds_types.UnitOptions{}
-				newUnit, err := hsds_types.NewUnit(match.inference.UnitName, unitOpts)
-				if err != nil {
-					log.Error().
-						Err(err).
-						Str("unit_name", match.inference.UnitName).
-						Msg("Failed to create unit")
-					return nil, nil, fmt.Errorf("error creating unit for %s: %w",
-						match.inference.UnitName, err)
-				}
-				unit = newUnit
-				newUnits = append(newUnits, unit)
-				log.Debug().
-					Str("unit_name", match.inference.UnitName).
-					Str("unit_id", unit.ID).
-					Msg("Created new unit")
-			}
+				Msg("Failed to resolve unit")
+			return nil, fmt.Errorf("error resolving unit %s: %w", match.inference.UnitName, err)
+		}
+		if isNew {
+			newUnits = append(newUnits, unit)
 		}
 
 		// Create ServiceCapacity with reconciled unit
@@ -323,22 +316,31 @@ func infToCapacityAndUnits(inferenceResult map[string]interface{}, serviceCtx Se
 				Str("unit_id", unit.ID).
 				Interface("options", capOpts).
 				Msg("Failed to create service capacity")
-			return nil, nil, fmt.Errorf("error creating service capacity for unit %s: %w",
+			return nil, fmt.Errorf("error creating service capacity for unit %s: %w",
 				unit.ID, err)
 		}
 		capacities = append(capacities, serviceCapacity)
+		evidence[serviceCapacity.ID] = match.inference.Evidence
 	}
 
 	log.Info().
 		Int("units_created", len(newUnits)).
 		Int("capacities_created", len(capacities)).
+		Int("capacities_unresolved", len(unresolved)).
 		Msg("Successfully converted inference results")
 
-	return capacities, newUnits, nil
+	return &CapacityResult{
+		Capacities: capacities,
+		Units:      newUnits,
+		Evidence:   evidence,
+		Unresolved: unresolved,
+	}, nil
 }
 
-// findMatchingService attempts to find the corresponding service for a capacity
-func findMatchingService(inf capacityInference, services []*hsds_types.Service) *hsds_types.Service {
+// findMatchingService attempts to find the corresponding service for a capacity. The matcher is shared
+// across a call's capacities so its clients are only set up once. When the matcher can't choose between
+// services its decision is returned instead, so the capacity can go to review with the candidates.
+func findMatchingService(matcher ServiceMatcher, inf capacityInference, services []*hsds_types.Service) (*hsds_types.Service, *ServiceMatchDecision) {
 	log := logger.Get()
 	log.Debug().
 		Str("service_name", inf.ServiceName).
@@ -354,7 +356,7 @@ func findMatchingService(inf capacityInference, services []*hsds_types.Service)
 				Str("service_name", inf.ServiceName).
 				Str("matched_id", svc.ID).
 				Msg("Found exact name match")
-			return svc
+			return svc, nil
 		}
 	}
 
@@ -366,45 +368,42 @@ func findMatchingService(inf capacityInference, services []*hsds_types.Service)
 				Str("service_name", inf.ServiceName).
 				Str("matched_id", svc.ID).
 				Msg("Found alternate name match")
-			return svc
+			return svc, nil
 		}
 	}
 
-	// Try fuzzy matching
-	threshold := 0.8
-	var bestMatch *hsds_types.Service
-	highestSimilarity := 0.0
-
-	for _, svc := range services {
-		similarity := calculateStringSimilarity(normalizedInfName, strings.ToLower(strings.TrimSpace(svc.Name)))
-		if similarity > threshold && similarity > highestSimilarity {
-			highestSimilarity = similarity
-			bestMatch = svc
-		}
-
-		if svc.AlternateName != nil {
-			altNameSimilarity := calculateStringSimilarity(normalizedInfName,
-				strings.ToLower(strings.TrimSpace(*svc.AlternateName)))
-			if altNameSimilarity > threshold && altNameSimilarity > highestSimilarity {
-				highestSimilarity = altNameSimilarity
-				bestMatch = svc
-			}
-		}
+	// Fall back to the service matcher, which only accepts confident, unambiguous matches
+	decision, err := matcher.MatchService(ServiceMatchCandidate{Name: inf.ServiceName}, services)
+	if err != nil {
+		log.Warn().
+			Err(err).
+			Str("service_name", inf.ServiceName).
+			Msg("Service matcher failed")
+		return nil, nil
 	}
 
-	if bestMatch != nil {
+	switch decision.Outcome {
+	case ServiceMatchConfirmed:
 		log.Debug().
 			Str("service_name", inf.ServiceName).
-			Str("matched_id", bestMatch.ID).
-			Float64("similarity", highestSimilarity).
+			Str("matched_id", decision.Best.ID).
+			Float64("confidence", decision.Confidence).
 			Msg("Found fuzzy match")
-	} else {
+		return decision.Best, nil
+	case ServiceMatchAmbiguous:
+		log.Warn().
+			Str("service_name", inf.ServiceName).
+			Float64("confidence", decision.Confidence).
+			Str("reason", decision.Reason).
+			Msg("Ambiguous service match for capacity, not picking one")
+		return nil, &decision
+	default:
 		log.Debug().
 			Str("service_name", inf.ServiceName).
 			Msg("No matching service found")
 	}
 
-	return bestMatch
+	return nil, nil
 }
 
 // calculateStringSimilarity implements Levenshtein distance based similarity
@@ -422,39 +421,28 @@ func AnalyzeCapacityCategoryDetails(transcript string, serviceCtx ServiceContext
 	log := logger.Get()
 	log.Debug().Msg("Starting capacity details analysis")
 
-	// Generate Prompt and Schema
-	capacityCategoryPrompt, capacitySchema, err := GenerateServiceCapacityPrompt(transcript, serviceCtx)
-	if err != nil {
-		log.Error().Err(err).Msg("Failed to generate service capacity prompt")
-		return DetailAnalysisResult{}, fmt.Errorf(`failure when generating service capacity prompt: %w`, err)
-	}
-
-	// Declare Claude Inference Client
-	client, err := inference.InitInferenceClient()
-	if err != nil {
-		log.Error().Err(err).Msg("Failed to initialize inference client")
-		return DetailAnalysisResult{}, fmt.Errorf("failed to initialize inference client: %w", err)
-	}
-
 	log.Debug().Msg("Running Claude inference for capacity analysis")
-	// Run inference
-	unformattedCapacityDetails, inferenceErr := client.RunClaudeInference(inference.PromptParams{Prompt: capacityCategoryPrompt, Schema: capacitySchema})
+	// Run inference, per segment for long transcripts
+	unformattedCapacityDetails, inferenceErr := runSegmentedExtraction(transcript, "capacities", func(segment string) (string, inference.ToolInputSchema, error) {
+		return GenerateServiceCapacityPrompt(segment, serviceCtx)
+	})
 	if inferenceErr != nil {
 		log.Error().Err(inferenceErr).Msg("Error during inference execution")
 		return DetailAnalysisResult{}, fmt.Errorf(`error running inference to extract capacity details: %w`, inferenceErr)
 	}
 
 	log.Debug().Msg("Converting inference response to capacity and unit objects")
-	capacityDetails, unitDetails, infConvErr := infToCapacityAndUnits(unformattedCapacityDetails, serviceCtx)
+	capacityData, infConvErr := infToCapacityAndUnits(unformattedCapacityDetails, transcript, serviceCtx)
 	if infConvErr != nil {
 		log.Error().Err(infConvErr).Msg("Failed to convert inference response")
 		return DetailAnalysisResult{}, fmt.Errorf(`error while converting the inference response to clean capacity and unit objects: %w`, infConvErr)
 	}
 
-	var result DetailAnalysisResult = NewCapacityCategoryResult(capacityDetails, unitDetails)
+	result := DetailAnalysisResult{Category: CapacityCategory, CapacityData: capacityData}
 	log.Info().
-		Int("capacities_count", len(capacityDetails)).
-		Int("units_count", len(unitDetails)).
+		Int("capacities_count", len(capacityData.Capacities)).
+		Int("units_count", len(capacityData.Units)).
+		Int("unresolved_count", len(capacityData.Unresolved)).
 		Msg("Capacity analysis completed successfully")
 
 	return result, nil
@@ -0,0 +1,200 @@
+package structOutputs
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/david-botos/BearHug/services/analysis/internal/hsds_types"
+	"github.com/david-botos/BearHug/services/analysis/internal/processor/inference"
+	"github.com/david-botos/BearHug/services/analysis/internal/supabase"
+	"github.com/david-botos/BearHug/services/analysis/pkg/logger"
+)
+
+// minDisambiguationConfidence is the confidence needed to attach a capacity to the service Claude picked
+const minDisambiguationConfidence = 0.7
+
+// UnresolvedCapacity is a capacity reading whose service could not be identified
+type UnresolvedCapacity struct {
+	ServiceName     string                                `json:"service_name"`
+	Available       float64                               `json:"available"`
+	Maximum         *float64                              `json:"maximum,omitempty"`
+	UnitName        string                                `json:"unit_name"`
+	UnitDescription string                                `json:"unit_description,omitempty"`
+	Candidates      []supabase.ServiceMatchCandidateInput `json:"candidates,omitempty"` // Set when the name matched several services
+	Evidence        map[string]hsds_types.FieldEvidence   `json:"-"`
+	Reason          string                                `json:"-"`
+}
+
+// noteAmbiguousMatch records the services the matcher could not choose between, so the reviewer starts
+// from them
+func (u *UnresolvedCapacity) noteAmbiguousMatch(decision ServiceMatchDecision) {
+	u.Candidates = reviewCandidates(decision)
+	u.Reason = fmt.Sprintf("service match was ambiguous (%s); %s", decision.Reason, u.Reason)
+}
+
+var CapacityDisambiguationSchema = inference.ToolInputSchema{
+	Type: "object",
+	Properties: map[string]inference.Property{
+		"matches": {
+			Type: "array",
+			Items: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"index": map[string]interface{}{
+						"type":        "integer",
+						"description": "The index of the capacity mention being matched",
+					},
+					"service_id": map[string]interface{}{
+						"type":        "string",
+						"description": "The Service ID the capacity belongs to, or 'NONE' if it belongs to none of the listed services",
+					},
+					"confidence": map[string]interface{}{
+						"type":        "number",
+						"description": "Confidence between 0.0 and 1.0 in the match",
+					},
+					"reasoning": map[string]interface{}{
+						"type":        "string",
+						"description": "Short explanation of the match",
+					},
+				},
+				"required": []string{"index", "service_id", "confidence", "reasoning"},
+			},
+		},
+	},
+	Required: []string{"matches"},
+}
+
+type capacityDisambiguation struct {
+	Matches []struct {
+		Index      int     `json:"index"`
+		ServiceID  string  `json:"service_id"`
+		Confidence float64 `json:"confidence"`
+		Reasoning  string  `json:"reasoning"`
+	} `json:"matches"`
+}
+
+// disambiguateCapacityServices asks Claude which service each unmatched capacity belongs to.
+// Capacities it can't confidently place are returned as unresolved with the reason.
+func disambiguateCapacityServices(transcript string, unmatched []capacityInference, services []*hsds_types.Service) (map[int]*hsds_types.Service, []UnresolvedCapacity) {
+	log := logger.Get()
+
+	matched := make(map[int]*hsds_types.Service)
+	reasons := make(map[int]string, len(unmatched))
+	for i := range unmatched {
+		reasons[i] = "no service matched the name and disambiguation did not place it"
+	}
+
+	if len(services) == 0 {
+		for i := range unmatched {
+			reasons[i] = "the organization has no services to match against"
+		}
+		return matched, unresolvedCapacities(unmatched, matched, reasons)
+	}
+
+	var servicesText, mentionsText strings.Builder
+	servicesByID := make(map[string]*hsds_types.Service, len(services))
+	for _, service := range services {
+		writeServiceDescription(&servicesText, *service)
+		servicesByID[service.ID] = service
+	}
+	for i, capacity := range unmatched {
+		mentionsText.WriteString(fmt.Sprintf("Index: %d\nService name as said: %s\nUnit: %s\n", i, capacity.ServiceName, capacity.UnitName))
+		if capacity.UnitDescription != "" {
+			mentionsText.WriteString(fmt.Sprintf("What is measured: %s\n", capacity.UnitDescription))
+		}
+		if ev, ok := capacity.Evidence["available"]; ok && ev.Quote != "" {
+			mentionsText.WriteString(fmt.Sprintf("Quote: %q\n", ev.Quote))
+		}
+		mentionsText.WriteString("\n")
+	}
+
+	prompt := fmt.Sprintf(`During a phone call a community organization reported capacity for services, but the service names used did not match any documented service. Decide which documented service each capacity belongs to.
+
+Conversation Transcript:
+%s
+
+Documented services:
+%s
+Capacity mentions:
+%s
+Only match a capacity to a service when the transcript makes clear it is about that service. Use 'NONE' if it belongs to none of them.
+
+IMPORTANT: You must ONLY respond by using the capacity_disambiguation tool to output the structured data.`,
+		transcript, servicesText.String(), mentionsText.String())
+
+	client, err := inference.InitInferenceClient()
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to initialize inference client for capacity disambiguation")
+		for i := range unmatched {
+			reasons[i] = fmt.Sprintf("disambiguation unavailable: %v", err)
+		}
+		return matched, unresolvedCapacities(unmatched, matched, reasons)
+	}
+
+	result, err := client.RunClaudeInference(inference.PromptParams{Prompt: prompt, Schema: CapacityDisambiguationSchema})
+	if err != nil {
+		log.Warn().Err(err).Msg("Capacity disambiguation inference failed")
+		for i := range unmatched {
+			reasons[i] = fmt.Sprintf("disambiguation failed: %v", err)
+		}
+		return matched, unresolvedCapacities(unmatched, matched, reasons)
+	}
+
+	var disambiguation capacityDisambiguation
+	jsonData, err := json.Marshal(result)
+	if err == nil {
+		err = json.Unmarshal(jsonData, &disambiguation)
+	}
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to parse capacity disambiguation result")
+		for i := range unmatched {
+			reasons[i] = fmt.Sprintf("disambiguation result unreadable: %v", err)
+		}
+		return matched, unresolvedCapacities(unmatched, matched, reasons)
+	}
+
+	for _, match := range disambiguation.Matches {
+		if match.Index < 0 || match.Index >= len(unmatched) {
+			continue
+		}
+		service, ok := servicesByID[match.ServiceID]
+		switch {
+		case match.ServiceID == "NONE" || match.ServiceID == "":
+			reasons[match.Index] = "llm: " + match.Reasoning
+		case !ok:
+			reasons[match.Index] = fmt.Sprintf("llm picked unknown service %s", match.ServiceID)
+		case match.Confidence < minDisambiguationConfidence:
+			reasons[match.Index] = fmt.Sprintf("llm match to %s below confidence threshold (%.2f): %s", service.Name, match.Confidence, match.Reasoning)
+		default:
+			matched[match.Index] = service
+			log.Debug().
+				Str("capacity_service_name", unmatched[match.Index].ServiceName).
+				Str("matched_service_id", service.ID).
+				Float64("confidence", match.Confidence).
+				Str("reasoning", match.Reasoning).
+				Msg("Disambiguated capacity service")
+		}
+	}
+
+	return matched, unresolvedCapacities(unmatched, matched, reasons)
+}
+
+func unresolvedCapacities(unmatched []capacityInference, matched map[int]*hsds_types.Service, reasons map[int]string) []UnresolvedCapacity {
+	var unresolved []UnresolvedCapacity
+	for i, capacity := range unmatched {
+		if _, ok := matched[i]; ok {
+			continue
+		}
+		unresolved = append(unresolved, UnresolvedCapacity{
+			ServiceName:     capacity.ServiceName,
+			Available:       capacity.Available,
+			Maximum:         capacity.Maximum,
+			UnitName:        capacity.UnitName,
+			UnitDescription: capacity.UnitDescription,
+			Evidence:        capacity.Evidence,
+			Reason:          reasons[i],
+		})
+	}
+	return unresolved
+}
@@ -0,0 +1,342 @@
+package structOutputs
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/david-botos/BearHug/services/analysis/internal/hsds_types"
+	"github.com/david-botos/BearHug/services/analysis/internal/supabase"
+	"github.com/david-botos/BearHug/services/analysis/pkg/logger"
+)
+
+// Confidence given to each kind of link between two contacts
+const (
+	dedupEmailConfidence = 1.0
+	dedupPhoneConfidence = 0.9
+	dedupNameConfidence  = 0.75
+)
+
+// ContactMergeProposal is a group of an organization's contacts that look like the same person
+type ContactMergeProposal struct {
+	OrganizationID string               `json:"organization_id"`
+	Survivor       hsds_types.Contact   `json:"survivor"`
+	Duplicates     []hsds_types.Contact `json:"duplicates"`
+	Confidence     float64              `json:"confidence"` // Weakest link that joined the group
+	Reasons        []string             `json:"reasons"`
+}
+
+// contactLink is evidence that two contacts are the same person
+type contactLink struct {
+	a, b       int
+	confidence float64
+	reason     string
+}
+
+// ProposeContactMerges clusters an organization's contacts by shared email, shared phone and name
+// similarity. Nothing is changed; an admin approves a proposal by calling the contact merge endpoint.
+func ProposeContactMerges(orgID string) ([]ContactMergeProposal, error) {
+	log := logger.Get()
+
+	contacts, err := supabase.FetchOrgContacts(orgID)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching organization contacts: %w", err)
+	}
+	if len(contacts) < 2 {
+		return nil, nil
+	}
+
+	contactIDs := make([]string, 0, len(contacts))
+	indexByID := make(map[string]int, len(contacts))
+	for i, contact := range contacts {
+		contactIDs = append(contactIDs, contact.ID)
+		indexByID[contact.ID] = i
+	}
+
+	phones, err := supabase.FetchRelevantPhones(orgID, contactIDs, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching relevant phones: %w", err)
+	}
+	phoneCounts := make(map[string]int)
+	for _, phone := range phones {
+		if phone.ContactID != nil {
+			phoneCounts[*phone.ContactID]++
+		}
+	}
+
+	links := findContactLinks(contacts, phones, indexByID)
+	groups := clusterContacts(contacts, links)
+
+	var proposals []ContactMergeProposal
+	for _, group := range groups {
+		survivor := group.members[0]
+		for _, i := range group.members[1:] {
+			if moreComplete(contacts[i], contacts[survivor], phoneCounts) {
+				survivor = i
+			}
+		}
+
+		proposal := ContactMergeProposal{
+			OrganizationID: orgID,
+			Survivor:       contacts[survivor],
+			Confidence:     1.0,
+		}
+		for _, i := range group.members {
+			if i != survivor {
+				proposal.Duplicates = append(proposal.Duplicates, contacts[i])
+			}
+		}
+		for _, link := range group.links {
+			proposal.Reasons = append(proposal.Reasons, link.reason)
+			if link.confidence < proposal.Confidence {
+				proposal.Confidence = link.confidence
+			}
+		}
+		proposals = append(proposals, proposal)
+	}
+
+	sort.Slice(proposals, func(i, j int) bool {
+		if proposals[i].Confidence != proposals[j].Confidence {
+			return proposals[i].Confidence > proposals[j].Confidence
+		}
+		return getStringValue(proposals[i].Survivor.Name) < getStringValue(proposals[j].Survivor.Name)
+	})
+
+	log.Info().
+		Str("org_id", orgID).
+		Int("contact_count", len(contacts)).
+		Int("proposal_count", len(proposals)).
+		Msg("Proposed contact merges")
+
+	return proposals, nil
+}
+
+// contactGroup is a cluster of contacts and the links that joined it
+type contactGroup struct {
+	members []int
+	links   []contactLink
+}
+
+// clusterContacts joins contacts link by link, strongest first, refusing any join that would put two
+// people with incompatible names in the same group. Groups of one are left out.
+func clusterContacts(contacts []hsds_types.Contact, links []contactLink) []contactGroup {
+	log := logger.Get()
+
+	parent := make([]int, len(contacts))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	members := make(map[int][]int, len(contacts))
+	for i := range contacts {
+		members[i] = []int{i}
+	}
+	groupLinks := make(map[int][]contactLink)
+
+	sort.SliceStable(links, func(i, j int) bool {
+		return links[i].confidence > links[j].confidence
+	})
+	for _, link := range links {
+		rootA, rootB := find(link.a), find(link.b)
+		if rootA == rootB {
+			groupLinks[rootA] = append(groupLinks[rootA], link)
+			continue
+		}
+		if !groupsCompatible(contacts, members[rootA], members[rootB]) {
+			log.Debug().
+				Str("contact_a", contacts[link.a].ID).
+				Str("contact_b", contacts[link.b].ID).
+				Str("reason", link.reason).
+				Msg("Skipping contact link that would join incompatible names")
+			continue
+		}
+		parent[rootB] = rootA
+		members[rootA] = append(members[rootA], members[rootB]...)
+		groupLinks[rootA] = append(groupLinks[rootA], groupLinks[rootB]...)
+		groupLinks[rootA] = append(groupLinks[rootA], link)
+		delete(members, rootB)
+		delete(groupLinks, rootB)
+	}
+
+	var groups []contactGroup
+	for root, group := range members {
+		if len(group) < 2 {
+			continue
+		}
+		sort.Ints(group)
+		groups = append(groups, contactGroup{members: group, links: groupLinks[root]})
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		return groups[i].members[0] < groups[j].members[0]
+	})
+	return groups
+}
+
+// findContactLinks uses the same lookup maps as call reconciliation to find contacts sharing an email
+// or phone, then compares names pairwise. Shared lines such as a front desk only link contacts whose
+// names could be the same person, so contacts without a name are never linked.
+func findContactLinks(contacts []hsds_types.Contact, phones []hsds_types.Phone, indexByID map[string]int) []contactLink {
+	var links []contactLink
+	linked := make(map[[2]int]bool)
+	addLink := func(a, b int, confidence float64, reason string) {
+		if a > b {
+			a, b = b, a
+		}
+		key := [2]int{a, b}
+		if a == b || linked[key] {
+			return
+		}
+		linked[key] = true
+		links = append(links, contactLink{a: a, b: b, confidence: confidence, reason: reason})
+	}
+
+	phoneToContacts, emailToContact := buildLookupMaps(contacts, phones)
+
+	// The email map keeps one contact per address, so any other contact with the address is a duplicate
+	for i, contact := range contacts {
+		if contact.Email == nil || *contact.Email == "" {
+			continue
+		}
+		email := strings.ToLower(*contact.Email)
+		other, ok := emailToContact[email]
+		if !ok || other.ID == contact.ID {
+			continue
+		}
+		j := indexByID[other.ID]
+		if namesCompatible(contact.Name, other.Name) {
+			addLink(i, j, dedupEmailConfidence, fmt.Sprintf("%s and %s share email %s", describeContact(contact), describeContact(*other), email))
+		}
+	}
+
+	for number, mapping := range phoneToContacts {
+		for x := 0; x < len(mapping.contacts); x++ {
+			for y := x + 1; y < len(mapping.contacts); y++ {
+				a, b := mapping.contacts[x], mapping.contacts[y]
+				if namesCompatible(a.Name, b.Name) && !emailsConflict(*a, *b) {
+					addLink(indexByID[a.ID], indexByID[b.ID], dedupPhoneConfidence,
+						fmt.Sprintf("%s and %s share phone %s", describeContact(*a), describeContact(*b), number))
+				}
+			}
+		}
+	}
+
+	for i := range contacts {
+		for j := i + 1; j < len(contacts); j++ {
+			if emailsConflict(contacts[i], contacts[j]) {
+				continue
+			}
+			if namesCompatible(contacts[i].Name, contacts[j].Name) {
+				addLink(i, j, dedupNameConfidence, fmt.Sprintf("%s and %s have similar names", describeContact(contacts[i]), describeContact(contacts[j])))
+			}
+		}
+	}
+
+	return links
+}
+
+// namesCompatible reports whether two names could belong to the same person. Besides close spellings it
+// accepts a first name on its own ("Maria" / "Maria Lopez") and initials ("M. Lopez" / "Maria Lopez").
+// A missing name is compatible with nothing: unnamed contacts sharing a front desk line would otherwise
+// chain everyone on that line into one group.
+func namesCompatible(a, b *string) bool {
+	if a == nil || b == nil || strings.TrimSpace(*a) == "" || strings.TrimSpace(*b) == "" {
+		return false
+	}
+	if calculateNameSimilarity(*a, *b) > 0.8 {
+		return true
+	}
+
+	short, long := nameTokens(*a), nameTokens(*b)
+	if len(short) > len(long) {
+		short, long = long, short
+	}
+	if len(short) == 0 {
+		return false
+	}
+
+	// Each token of the shorter name must match a later token of the longer one, in order
+	fullMatch := false
+	next := 0
+	for _, token := range short {
+		matched := false
+		for next < len(long) {
+			candidate := long[next]
+			next++
+			if token == candidate {
+				matched = true
+				fullMatch = fullMatch || len(token) > 1
+				break
+			}
+			if (len(token) == 1 && strings.HasPrefix(candidate, token)) ||
+				(len(candidate) == 1 && strings.HasPrefix(token, candidate)) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return fullMatch
+}
+
+func nameTokens(name string) []string {
+	return strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '\''
+	})
+}
+
+// emailsConflict reports whether both contacts have an email and they differ
+func emailsConflict(a, b hsds_types.Contact) bool {
+	if a.Email == nil || b.Email == nil || *a.Email == "" || *b.Email == "" {
+		return false
+	}
+	return !strings.EqualFold(*a.Email, *b.Email)
+}
+
+// moreComplete reports whether a should survive over b: more filled fields and phones win, then the longer
+// name, then the older record
+func moreComplete(a, b hsds_types.Contact, phoneCounts map[string]int) bool {
+	score := func(c hsds_types.Contact) int {
+		filled := phoneCounts[c.ID]
+		for _, field := range []*string{c.Name, c.Title, c.Department, c.Email, c.ServiceID} {
+			if getStringValue(field) != "" {
+				filled++
+			}
+		}
+		return filled
+	}
+	if scoreA, scoreB := score(a), score(b); scoreA != scoreB {
+		return scoreA > scoreB
+	}
+	if lenA, lenB := len(getStringValue(a.Name)), len(getStringValue(b.Name)); lenA != lenB {
+		return lenA > lenB
+	}
+	return a.CreatedAt.Before(b.CreatedAt)
+}
+
+// groupsCompatible checks every pair of names across two groups before they are joined
+func groupsCompatible(contacts []hsds_types.Contact, groupA, groupB []int) bool {
+	for _, a := range groupA {
+		for _, b := range groupB {
+			if !namesCompatible(contacts[a].Name, contacts[b].Name) || emailsConflict(contacts[a], contacts[b]) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func describeContact(contact hsds_types.Contact) string {
+	if name := getStringValue(contact.Name); name != "" {
+		return fmt.Sprintf("%q", name)
+	}
+	return contact.ID
+}
@@ -0,0 +1,133 @@
+package structOutputs
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/david-botos/BearHug/services/analysis/internal/hsds_types"
+)
+
+func TestNamesCompatible(t *testing.T) {
+	name := func(s string) *string { return &s }
+
+	tests := []struct {
+		a, b *string
+		want bool
+	}{
+		{name("Maria"), name("Maria Lopez"), true},
+		{name("M. Lopez"), name("Maria Lopez"), true},
+		{name("Maria Lopez"), name("maria lopez"), true},
+		{name("Maria Lopes"), name("Maria Lopez"), true},
+		{name("Maria"), name("M. Lopez"), false},
+		{name("Maria Lopez"), name("Juan Lopez"), false},
+		{name("Lopez"), name("Maria"), false},
+		{nil, name("Maria Lopez"), false},
+		{name(" "), name("Maria Lopez"), false},
+		{nil, nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(getStringValue(tt.a)+"/"+getStringValue(tt.b), func(t *testing.T) {
+			if got := namesCompatible(tt.a, tt.b); got != tt.want {
+				t.Errorf("namesCompatible(%q, %q) = %v, want %v", getStringValue(tt.a), getStringValue(tt.b), got, tt.want)
+			}
+			if got := namesCompatible(tt.b, tt.a); got != tt.want {
+				t.Errorf("namesCompatible(%q, %q) = %v, want %v", getStringValue(tt.b), getStringValue(tt.a), got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClusterContacts(t *testing.T) {
+	text := func(s string) *string { return &s }
+	contact := func(id string, name, email *string) hsds_types.Contact {
+		return hsds_types.Contact{ID: id, Name: name, Email: email}
+	}
+	phone := func(id, number, contactID string) hsds_types.Phone {
+		return hsds_types.Phone{ID: id, Number: number, ContactID: text(contactID)}
+	}
+
+	tests := []struct {
+		name     string
+		contacts []hsds_types.Contact
+		phones   []hsds_types.Phone
+		want     [][]string
+	}{
+		{
+			name: "first name, full name and initial",
+			contacts: []hsds_types.Contact{
+				contact("maria", text("Maria"), nil),
+				contact("maria-lopez", text("Maria Lopez"), nil),
+				contact("m-lopez", text("M. Lopez"), nil),
+			},
+			want: [][]string{{"maria", "maria-lopez"}},
+		},
+		{
+			name: "initial joins through a shared phone",
+			contacts: []hsds_types.Contact{
+				contact("maria-lopez", text("Maria Lopez"), nil),
+				contact("m-lopez", text("M. Lopez"), nil),
+				contact("maria", text("Maria"), nil),
+			},
+			phones: []hsds_types.Phone{
+				phone("p1", "+12065550123", "maria-lopez"),
+				phone("p2", "+12065550123", "m-lopez"),
+			},
+			// Maria Lopez and M. Lopez join first on the phone, so Maria can't join the two of them
+			want: [][]string{{"maria-lopez", "m-lopez"}},
+		},
+		{
+			name: "unnamed contacts on a front desk line",
+			contacts: []hsds_types.Contact{
+				contact("front-desk-1", nil, nil),
+				contact("front-desk-2", text(""), nil),
+				contact("maria", text("Maria Lopez"), nil),
+				contact("juan", text("Juan Perez"), nil),
+			},
+			phones: []hsds_types.Phone{
+				phone("p1", "+12065550100", "front-desk-1"),
+				phone("p2", "+12065550100", "front-desk-2"),
+				phone("p3", "+12065550100", "maria"),
+				phone("p4", "+12065550100", "juan"),
+			},
+		},
+		{
+			name: "shared email with a different name",
+			contacts: []hsds_types.Contact{
+				contact("maria", text("Maria Lopez"), text("info@example.org")),
+				contact("juan", text("Juan Perez"), text("INFO@example.org")),
+				contact("maria-2", text("Maria Lopez"), text("maria@example.org")),
+			},
+			want: nil,
+		},
+		{
+			name: "shared email",
+			contacts: []hsds_types.Contact{
+				contact("maria", text("Maria Lopez"), text("maria@example.org")),
+				contact("m-lopez", text("M. Lopez"), text("Maria@example.org")),
+			},
+			want: [][]string{{"maria", "m-lopez"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			indexByID := make(map[string]int, len(tt.contacts))
+			for i, contact := range tt.contacts {
+				indexByID[contact.ID] = i
+			}
+
+			var got [][]string
+			for _, group := range clusterContacts(tt.contacts, findContactLinks(tt.contacts, tt.phones, indexByID)) {
+				var ids []string
+				for _, i := range group.members {
+					ids = append(ids, tt.contacts[i].ID)
+				}
+				got = append(got, ids)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("clusterContacts() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,157 @@
+package structOutputs
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/david-botos/BearHug/services/analysis/internal/supabase"
+	"github.com/david-botos/BearHug/services/analysis/pkg/logger"
+)
+
+// Contact reconciliation outcomes
+const (
+	ContactDecisionMatched   = "matched"   // Mention was applied to an existing contact
+	ContactDecisionNew       = "new"       // No usable match, a new contact is created
+	ContactDecisionAmbiguous = "ambiguous" // Several matches were too close to pick one, a new contact is created
+)
+
+// ContactReconciliationReport records every decision made while reconciling the contacts mentioned on a call
+type ContactReconciliationReport struct {
+	CallID         string            `json:"call_id"`
+	OrganizationID string            `json:"organization_id"`
+	GeneratedAt    time.Time         `json:"generated_at"`
+	Decisions      []ContactDecision `json:"decisions"`
+}
+
+// ContactDecision explains how one mentioned contact was reconciled
+type ContactDecision struct {
+	Mentioned  ContactMention     `json:"mentioned"`
+	Candidates []ContactCandidate `json:"candidates"`
+	Rule       string             `json:"rule"` // Match type that decided the outcome, or no_match / ambiguous
+	Outcome    string             `json:"outcome"`
+	Reason     string             `json:"reason"`
+	Actions    []ContactAction    `json:"actions"`
+}
+
+// ContactMention is the contact as it was extracted from the transcript
+type ContactMention struct {
+	Name  string  `json:"name,omitempty"`
+	Email *string `json:"email,omitempty"`
+	Phone *string `json:"phone,omitempty"`
+
+	ServiceID      *string `json:"service_id,omitempty"`
+	PhoneServiceID *string `json:"phone_service_id,omitempty"`
+}
+
+// ContactCandidate is an existing record that the mention was compared against
+type ContactCandidate struct {
+	ContactID   string  `json:"contact_id,omitempty"`
+	ContactName string  `json:"contact_name,omitempty"`
+	PhoneID     string  `json:"phone_id,omitempty"`
+	MatchType   string  `json:"match_type"`
+	Confidence  float64 `json:"confidence"`
+	Selected    bool    `json:"selected"`
+}
+
+// ContactAction is a create or update that resulted from a decision. Actions are planned when the decision
+// is made and marked applied once the record is written, which only happens after validation.
+type ContactAction struct {
+	Action       string   `json:"action"` // "create" or "update"
+	ResourceType string   `json:"resource_type"`
+	ResourceID   string   `json:"resource_id"`
+	Fields       []string `json:"fields,omitempty"`
+	Applied      bool     `json:"applied"`
+	Withheld     bool     `json:"withheld,omitempty"` // Validation dropped the record before it was written
+}
+
+func newContactDecision(mentioned contactInference, matches []contactMatch) ContactDecision {
+	decision := ContactDecision{
+		Mentioned: ContactMention{
+			Name:  mentioned.Name,
+			Email: mentioned.Email,
+			Phone: mentioned.Phone,
+
+			ServiceID:      mentioned.ServiceID,
+			PhoneServiceID: mentioned.PhoneServiceID,
+		},
+		Candidates: make([]ContactCandidate, 0, len(matches)),
+	}
+	for _, match := range matches {
+		candidate := ContactCandidate{
+			MatchType:  match.MatchType,
+			Confidence: match.MatchConfidence,
+		}
+		if match.ExistingContact != nil {
+			candidate.ContactID = match.ExistingContact.ID
+			candidate.ContactName = getStringValue(match.ExistingContact.Name)
+		}
+		if match.ExistingPhone != nil {
+			candidate.PhoneID = match.ExistingPhone.ID
+		}
+		decision.Candidates = append(decision.Candidates, candidate)
+	}
+	return decision
+}
+
+// recordOutcomes marks each planned action applied when it was written, or withheld when validation dropped
+// its record. Updates take the fields that were actually written, since validation can correct them.
+func (r *ContactReconciliationReport) recordOutcomes(data *ContactResult, written []ContactAction) {
+	kept := make(map[string]bool)
+	for _, contact := range data.Contacts {
+		kept["contact|"+contact.ID] = true
+	}
+	for _, phone := range data.Phones {
+		kept["phone|"+phone.ID] = true
+	}
+	for _, update := range data.Updates {
+		kept["contact|"+update.Contact.ID] = true
+		if update.Phone != nil {
+			kept["phone|"+update.Phone.ID] = true
+		}
+	}
+
+	writtenByKey := make(map[string]ContactAction, len(written))
+	for _, action := range written {
+		writtenByKey[action.Action+"|"+action.ResourceType+"|"+action.ResourceID] = action
+	}
+
+	for i := range r.Decisions {
+		for j := range r.Decisions[i].Actions {
+			action := &r.Decisions[i].Actions[j]
+			resource := action.ResourceType + "|" + action.ResourceID
+			if applied, ok := writtenByKey[action.Action+"|"+resource]; ok {
+				action.Applied = true
+				action.Fields = applied.Fields
+				continue
+			}
+			action.Applied = false
+			action.Withheld = !kept[resource]
+		}
+	}
+}
+
+// storeContactReconciliationReport saves the report for the call. Failing to save it is logged but doesn't
+// fail contact processing, since the report only explains decisions that have already been made.
+func storeContactReconciliationReport(report ContactReconciliationReport) {
+	log := logger.Get()
+	if err := supabase.StoreContactReconciliationReport(report.CallID, report.OrganizationID, report); err != nil {
+		log.Error().
+			Err(err).
+			Str("call_id", report.CallID).
+			Int("decision_count", len(report.Decisions)).
+			Msg("Failed to store contact reconciliation report")
+		return
+	}
+	log.Debug().
+		Str("call_id", report.CallID).
+		Int("decision_count", len(report.Decisions)).
+		Msg("Stored contact reconciliation report")
+}
+
+func describeSelection(best contactMatch, secondBest *contactMatch) string {
+	if secondBest == nil {
+		return fmt.Sprintf("only candidate, %s match with confidence %.2f", best.MatchType, best.MatchConfidence)
+	}
+	return fmt.Sprintf("%s match with confidence %.2f beat the next candidate (%.2f) by more than 0.2",
+		best.MatchType, best.MatchConfidence, secondBest.MatchConfidence)
+}
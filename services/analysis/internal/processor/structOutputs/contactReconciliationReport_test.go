@@ -0,0 +1,58 @@
+package structOutputs
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/david-botos/BearHug/services/analysis/internal/hsds_types"
+)
+
+func TestRecordOutcomes(t *testing.T) {
+	report := ContactReconciliationReport{Decisions: []ContactDecision{
+		{Actions: []ContactAction{
+			{Action: "create", ResourceType: "contact", ResourceID: "new-contact"},
+			{Action: "create", ResourceType: "phone", ResourceID: "new-phone"},
+		}},
+		{Actions: []ContactAction{
+			{Action: "update", ResourceType: "contact", ResourceID: "stored-contact", Fields: []string{"title"}},
+			{Action: "update", ResourceType: "phone", ResourceID: "stored-phone", Fields: []string{"extension"}},
+		}},
+		{Actions: []ContactAction{
+			{Action: "create", ResourceType: "contact", ResourceID: "dropped-contact"},
+		}},
+	}}
+
+	// Validation dropped the new phone and the third contact; the stored phone update failed to write
+	data := &ContactResult{
+		Contacts: []*hsds_types.Contact{{ID: "new-contact"}},
+		Updates: []*ContactUpdate{{
+			Contact: &hsds_types.Contact{ID: "stored-contact"},
+			Phone:   &hsds_types.Phone{ID: "stored-phone"},
+		}},
+	}
+	written := []ContactAction{
+		{Action: "create", ResourceType: "contact", ResourceID: "new-contact", Applied: true},
+		{Action: "update", ResourceType: "contact", ResourceID: "stored-contact", Fields: []string{"email", "title"}, Applied: true},
+	}
+
+	report.recordOutcomes(data, written)
+
+	want := [][]ContactAction{
+		{
+			{Action: "create", ResourceType: "contact", ResourceID: "new-contact", Applied: true},
+			{Action: "create", ResourceType: "phone", ResourceID: "new-phone", Withheld: true},
+		},
+		{
+			{Action: "update", ResourceType: "contact", ResourceID: "stored-contact", Fields: []string{"email", "title"}, Applied: true},
+			{Action: "update", ResourceType: "phone", ResourceID: "stored-phone", Fields: []string{"extension"}},
+		},
+		{
+			{Action: "create", ResourceType: "contact", ResourceID: "dropped-contact", Withheld: true},
+		},
+	}
+	for i, decision := range report.Decisions {
+		if !reflect.DeepEqual(decision.Actions, want[i]) {
+			t.Errorf("decision %d actions = %+v, want %+v", i, decision.Actions, want[i])
+		}
+	}
+}
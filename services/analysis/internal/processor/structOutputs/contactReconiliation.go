@@ -1,10 +1,12 @@
 package structOutputs
 
 import (
+	"fmt"
 	"sort"
 	"strings"
 
 	"github.com/david-botos/BearHug/services/analysis/internal/hsds_types"
+	"github.com/david-botos/BearHug/services/analysis/internal/phonenumber"
 	"github.com/david-botos/BearHug/services/analysis/pkg/logger"
 )
 
@@ -28,6 +30,12 @@ type matchResult struct {
 	UpdatedPhones   []*hsds_types.Phone
 	NewContacts     []*hsds_types.Contact
 	NewPhones       []*hsds_types.Phone
+
+	// Decisions explains every mention; MatchDecisions and UnmatchedDecisions index into it in step
+	// with Matches and UnmatchedInf
+	Decisions          []ContactDecision
+	MatchDecisions     []int
+	UnmatchedDecisions []int
 }
 
 // phoneMapping represents the relationship between a phone number and its associated contacts
@@ -271,21 +279,27 @@ func findMatchesForContact(
 	return matches
 }
 
-// processMatches handles the results of finding matches for a contact
+// processMatches handles the results of finding matches for a contact and records the decision
 func processMatches(matches []contactMatch, mentionedContact contactInference, result *matchResult) {
 	log := logger.Get()
 
+	decision := newContactDecision(mentionedContact, matches)
+	selected := -1
+
 	switch len(matches) {
 	case 0:
 		// No matches - treat as new contact
-		result.UnmatchedInf = append(result.UnmatchedInf, mentionedContact)
+		decision.Rule = "no_match"
+		decision.Outcome = ContactDecisionNew
+		decision.Reason = "no existing contact shares the phone or email, and no name is similar enough"
 		log.Debug().
 			Interface("contact", mentionedContact).
 			Msg("No matches found - treating as new contact")
 
 	case 1:
 		// Single match - straightforward case
-		result.Matches = append(result.Matches, matches[0])
+		selected = 0
+		decision.Reason = describeSelection(matches[0], nil)
 		log.Debug().
 			Interface("contact", mentionedContact).
 			Str("match_type", matches[0].MatchType).
@@ -300,7 +314,8 @@ func processMatches(matches []contactMatch, mentionedContact contactInference, r
 		secondBestConfidence := matches[1].MatchConfidence
 
 		if bestMatch.MatchConfidence > secondBestConfidence+0.2 { // Significant confidence gap
-			result.Matches = append(result.Matches, bestMatch)
+			selected = 0
+			decision.Reason = describeSelection(bestMatch, &matches[1])
 			log.Debug().
 				Interface("contact", mentionedContact).
 				Float64("best_confidence", bestMatch.MatchConfidence).
@@ -308,13 +323,39 @@ func processMatches(matches []contactMatch, mentionedContact contactInference, r
 				Msg("Selected best match from multiple")
 		} else {
 			// Confidence difference too small - treat as new contact
-			result.UnmatchedInf = append(result.UnmatchedInf, mentionedContact)
+			decision.Rule = "ambiguous"
+			decision.Outcome = ContactDecisionAmbiguous
+			decision.Reason = fmt.Sprintf("top candidates were within 0.2 confidence (%.2f vs %.2f)", bestMatch.MatchConfidence, secondBestConfidence)
 			log.Debug().
 				Interface("contact", mentionedContact).
 				Interface("matches", matches).
 				Msg("Ambiguous matches - treating as new contact")
 		}
 	}
+
+	// An orphaned phone has no contact to update, so the mention still becomes a new contact
+	if selected >= 0 && matches[selected].ExistingContact == nil {
+		decision.Candidates[selected].Selected = true
+		decision.Rule = matches[selected].MatchType
+		decision.Outcome = ContactDecisionNew
+		decision.Reason = "phone number is on file but not linked to a contact"
+		selected = -1
+	}
+
+	result.Decisions = append(result.Decisions, decision)
+	decisionIdx := len(result.Decisions) - 1
+
+	if selected < 0 {
+		result.UnmatchedInf = append(result.UnmatchedInf, mentionedContact)
+		result.UnmatchedDecisions = append(result.UnmatchedDecisions, decisionIdx)
+		return
+	}
+
+	result.Decisions[decisionIdx].Candidates[selected].Selected = true
+	result.Decisions[decisionIdx].Rule = matches[selected].MatchType
+	result.Decisions[decisionIdx].Outcome = ContactDecisionMatched
+	result.Matches = append(result.Matches, matches[selected])
+	result.MatchDecisions = append(result.MatchDecisions, decisionIdx)
 }
 
 // hasHighConfidenceMatch checks if there's already a high-confidence match
@@ -381,15 +422,10 @@ func calculateNameSimilarity(name1, name2 string) float64 {
 
 // Helper functions
 
+// normalizePhoneNumber returns the E.164 form when the number parses, so numbers stored in
+// different formats still compare equal
 func normalizePhoneNumber(phone string) string {
-	// Remove all non-numeric characters
-	normalized := strings.Map(func(r rune) rune {
-		if r >= '0' && r <= '9' {
-			return r
-		}
-		return -1
-	}, phone)
-	return normalized
+	return phonenumber.Key(phone)
 }
 
 func findBestNameMatch(inferredContact contactInference, orgContacts []hsds_types.Contact) *contactMatch {
@@ -2,38 +2,38 @@ package structOutputs
 
 import (
 	"fmt"
-	"strconv"
+	"sort"
 
 	"github.com/david-botos/BearHug/services/analysis/internal/hsds_types"
 	"github.com/david-botos/BearHug/services/analysis/internal/supabase"
 	"github.com/david-botos/BearHug/services/analysis/pkg/logger"
 )
 
-func CreateNewContactAndPhoneRecords(unmatchedResults []contactInference, org_id, call_id string) ([]*hsds_types.Contact, []*hsds_types.Phone, error) {
+// CreateNewContactAndPhoneRecords builds contact and phone records for unmatched mentions. Nothing is written
+// here; the records are stored by StoreDetails once they have passed validation. The returned evidence is keyed
+// by contact or phone ID.
+func CreateNewContactAndPhoneRecords(unmatchedResults []contactInference, org_id string) ([]*hsds_types.Contact, []*hsds_types.Phone, map[string]map[string]hsds_types.FieldEvidence, error) {
 	log := logger.Get()
 	log.Info().
 		Int("unmatched_count", len(unmatchedResults)).
 		Msg("Starting creation of new contacts and phones")
 
-	client, err := supabase.InitSupabaseClient()
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed to initialize Supabase client: %w", err)
-	}
-
 	var newContacts []*hsds_types.Contact
 	var newPhones []*hsds_types.Phone
-	var metadataInputs []supabase.MetadataInput
+	evidence := make(map[string]map[string]hsds_types.FieldEvidence)
 
-	// First create all contacts
 	for _, inference := range unmatchedResults {
 		// Create new contact
 		contactOpts := &hsds_types.ContactOptions{
 			OrganizationID: &org_id,
-			Name:           &inference.Name,
+			ServiceID:      inference.ServiceID,
 			Title:          inference.Title,
 			Department:     inference.Department,
 			Email:          inference.Email,
 		}
+		if inference.Name != "" {
+			contactOpts.Name = &inference.Name
+		}
 
 		contact, err := hsds_types.NewContact(contactOpts)
 		if err != nil {
@@ -41,437 +41,369 @@ func CreateNewContactAndPhoneRecords(unmatchedResults []contactInference, org_id
 				Err(err).
 				Interface("inference", inference).
 				Msg("Failed to create new contact")
-			return nil, nil, fmt.Errorf("failed to create new contact: %w", err)
-		}
-
-		// Store the contact in Supabase
-		data, _, err := client.From("contact").
-			Insert(contact, false, "", "representation", "").
-			Execute()
-		if err != nil {
-			log.Error().
-				Err(err).
-				Str("contact_name", inference.Name).
-				Str("response_data", string(data)).
-				Msg("Failed to store new contact")
-			return nil, nil, fmt.Errorf("failed to store new contact: %w", err)
+			return nil, nil, nil, fmt.Errorf("failed to create new contact: %w", err)
 		}
 
 		newContacts = append(newContacts, contact)
-
-		// Create metadata for the new contact
-		metadataInputs = append(metadataInputs,
-			supabase.MetadataInput{
-				ResourceID:       contact.ID,
-				CallID:           call_id,
-				ResourceType:     "contact",
-				LastActionType:   "CREATE",
-				FieldName:        "name",
-				PreviousValue:    "",
-				ReplacementValue: inference.Name,
-			})
-
-		// Create metadata for other non-nil fields
-		if inference.Title != nil {
-			metadataInputs = append(metadataInputs,
-				supabase.MetadataInput{
-					ResourceID:       contact.ID,
-					CallID:           call_id,
-					ResourceType:     "contact",
-					LastActionType:   "CREATE",
-					FieldName:        "title",
-					PreviousValue:    "",
-					ReplacementValue: *inference.Title,
-				})
-		}
-
-		if inference.Department != nil {
-			metadataInputs = append(metadataInputs,
-				supabase.MetadataInput{
-					ResourceID:       contact.ID,
-					CallID:           call_id,
-					ResourceType:     "contact",
-					LastActionType:   "CREATE",
-					FieldName:        "department",
-					PreviousValue:    "",
-					ReplacementValue: *inference.Department,
-				})
-		}
-
-		if inference.Email != nil {
-			metadataInputs = append(metadataInputs,
-				supabase.MetadataInput{
-					ResourceID:       contact.ID,
-					CallID:           call_id,
-					ResourceType:     "contact",
-					LastActionType:   "CREATE",
-					FieldName:        "email",
-					PreviousValue:    "",
-					ReplacementValue: *inference.Email,
-				})
-		}
+		evidence[contact.ID] = pickEvidence(inference.Evidence, map[string]string{
+			"name":       "name",
+			"title":      "title",
+			"department": "department",
+			"email":      "email",
+			"serviceId":  "service_id",
+		})
 
 		// If there's a phone number, create a phone record
 		if inference.Phone != nil {
-			var extension *float64
-			if inference.PhoneExtension != nil {
-				floatVal := float64(*inference.PhoneExtension)
-				extension = &floatVal
-			}
-
-			phoneOpts := &hsds_types.PhoneOptions{
-				OrganizationID: &org_id,
-				ContactID:      &contact.ID,
-				Extension:      extension,
-				Description:    inference.PhoneDescription,
-				Type:           nil, // Could be added to contactInference if needed
-			}
-
-			phone, err := hsds_types.NewPhone(*inference.Phone, phoneOpts)
+			phone, err := newContactPhone(inference, &org_id, contact.ID)
 			if err != nil {
 				log.Error().
 					Err(err).
 					Str("phone_number", *inference.Phone).
 					Msg("Failed to create new phone")
-				return nil, nil, fmt.Errorf("failed to create new phone: %w", err)
-			}
-
-			// Store the phone in Supabase
-			phoneData, _, err := client.From("phone").
-				Insert(phone, false, "", "representation", "").
-				Execute()
-			if err != nil {
-				log.Error().
-					Err(err).
-					Str("phone_number", *inference.Phone).
-					Str("response_data", string(phoneData)).
-					Msg("Failed to store new phone")
-				return nil, nil, fmt.Errorf("failed to store new phone: %w", err)
+				return nil, nil, nil, fmt.Errorf("failed to create new phone: %w", err)
 			}
 
 			newPhones = append(newPhones, phone)
+			evidence[phone.ID] = phoneEvidence(inference)
+		}
+	}
 
-			// Create metadata for the new phone
-			metadataInputs = append(metadataInputs,
-				supabase.MetadataInput{
-					ResourceID:       phone.ID,
-					CallID:           call_id,
-					ResourceType:     "phone",
-					LastActionType:   "CREATE",
-					FieldName:        "number",
-					PreviousValue:    "",
-					ReplacementValue: *inference.Phone,
-				})
-
-			if extension != nil {
-				metadataInputs = append(metadataInputs,
-					supabase.MetadataInput{
-						ResourceID:       phone.ID,
-						CallID:           call_id,
-						ResourceType:     "phone",
-						LastActionType:   "CREATE",
-						FieldName:        "extension",
-						PreviousValue:    "",
-						ReplacementValue: strconv.Itoa(*inference.PhoneExtension),
-					})
-			}
+	log.Info().
+		Int("new_contacts", len(newContacts)).
+		Int("new_phones", len(newPhones)).
+		Msg("Successfully built new contacts and phones")
 
-			if inference.PhoneDescription != nil {
-				metadataInputs = append(metadataInputs,
-					supabase.MetadataInput{
-						ResourceID:       phone.ID,
-						CallID:           call_id,
-						ResourceType:     "phone",
-						LastActionType:   "CREATE",
-						FieldName:        "description",
-						PreviousValue:    "",
-						ReplacementValue: *inference.PhoneDescription,
-					})
-			}
+	return newContacts, newPhones, evidence, nil
+}
+
+// newContactPhone builds the phone record for a mention's number, owned by the given contact
+func newContactPhone(inference contactInference, orgID *string, contactID string) (*hsds_types.Phone, error) {
+	var extension *float64
+	if inference.PhoneExtension != nil {
+		floatVal := float64(*inference.PhoneExtension)
+		extension = &floatVal
+	}
+
+	return hsds_types.NewPhone(*inference.Phone, &hsds_types.PhoneOptions{
+		OrganizationID: orgID,
+		ServiceID:      inference.PhoneServiceID,
+		ContactID:      &contactID,
+		Extension:      extension,
+		Description:    inference.PhoneDescription,
+		Type:           inference.PhoneType,
+	})
+}
+
+// phoneEvidence picks a mention's phone evidence, keyed by phone column
+func phoneEvidence(inference contactInference) map[string]hsds_types.FieldEvidence {
+	return pickEvidence(inference.Evidence, map[string]string{
+		"phone":            "number",
+		"phoneExtension":   "extension",
+		"phoneDescription": "description",
+		"phoneServiceId":   "service_id",
+	})
+}
+
+// pickEvidence copies the evidence for the given inference fields, renamed to their column names
+func pickEvidence(evidence map[string]hsds_types.FieldEvidence, fields map[string]string) map[string]hsds_types.FieldEvidence {
+	picked := make(map[string]hsds_types.FieldEvidence)
+	for inferenceField, column := range fields {
+		if ev, ok := evidence[inferenceField]; ok {
+			picked[column] = ev
 		}
 	}
+	return picked
+}
 
-	// Create metadata entries for all new records
-	if len(metadataInputs) > 0 {
-		if err := supabase.CreateAndStoreMetadata(metadataInputs); err != nil {
-			log.Error().
-				Err(err).
-				Msg("Failed to create metadata entries")
-			return nil, nil, fmt.Errorf("failed to create metadata entries: %w", err)
+// ContactUpdate is what a call changes on a stored contact: the contact, and the phone it was matched on,
+// with the call's values applied. Updates are built during extraction and written by StoreDetails, so
+// validation can correct or withhold them the same way it does new records.
+type ContactUpdate struct {
+	Contact  *hsds_types.Contact
+	Phone    *hsds_types.Phone                   // Set when the call updates the matched phone
+	Evidence map[string]hsds_types.FieldEvidence // Keyed by extracted field name
+
+	storedContact hsds_types.Contact
+	storedPhone   hsds_types.Phone
+}
+
+// newContactUpdate applies a matched mention to copies of the stored contact and phone. A field the
+// transcript doesn't mention keeps its stored value, so silence never clears or unlinks anything.
+func newContactUpdate(match contactMatch) *ContactUpdate {
+	inferred := match.InferredContact
+
+	contact := *match.ExistingContact
+	if inferred.Name != "" {
+		name := inferred.Name
+		contact.Name = &name
+	}
+	if inferred.Title != nil {
+		contact.Title = inferred.Title
+	}
+	if inferred.Department != nil {
+		contact.Department = inferred.Department
+	}
+	if inferred.Email != nil {
+		contact.Email = inferred.Email
+	}
+	if inferred.ServiceID != nil {
+		contact.ServiceID = inferred.ServiceID
+	}
+
+	update := &ContactUpdate{
+		Contact:       &contact,
+		Evidence:      inferred.Evidence,
+		storedContact: *match.ExistingContact,
+	}
+
+	if match.UpdatePhone && match.ExistingPhone != nil && inferred.Phone != nil {
+		phone := *match.ExistingPhone
+		if inferred.PhoneDescription != nil {
+			phone.Description = inferred.PhoneDescription
+		}
+		if inferred.PhoneExtension != nil {
+			extension := float64(*inferred.PhoneExtension)
+			phone.Extension = &extension
 		}
+		if inferred.PhoneType != nil {
+			phone.Type = inferred.PhoneType
+		}
+		if inferred.PhoneServiceID != nil {
+			phone.ServiceID = inferred.PhoneServiceID
+		}
+		update.Phone = &phone
+		update.storedPhone = *match.ExistingPhone
 	}
 
-	log.Info().
-		Int("new_contacts", len(newContacts)).
-		Int("new_phones", len(newPhones)).
-		Msg("Successfully created new contacts and phones")
+	return update
+}
+
+// contactChanges returns the contact columns whose value differs from what is stored
+func (u *ContactUpdate) contactChanges() map[string]interface{} {
+	changes := make(map[string]interface{})
+	setIfChanged(changes, "name", u.storedContact.Name, u.Contact.Name)
+	setIfChanged(changes, "title", u.storedContact.Title, u.Contact.Title)
+	setIfChanged(changes, "department", u.storedContact.Department, u.Contact.Department)
+	setIfChanged(changes, "email", u.storedContact.Email, u.Contact.Email)
+	setIfChanged(changes, "service_id", u.storedContact.ServiceID, u.Contact.ServiceID)
+	return changes
+}
+
+// phoneChanges returns the phone columns whose value differs from what is stored
+func (u *ContactUpdate) phoneChanges() map[string]interface{} {
+	changes := make(map[string]interface{})
+	if u.Phone == nil {
+		return changes
+	}
+	setIfChanged(changes, "description", u.storedPhone.Description, u.Phone.Description)
+	setIfChanged(changes, "type", u.storedPhone.Type, u.Phone.Type)
+	setIfChanged(changes, "service_id", u.storedPhone.ServiceID, u.Phone.ServiceID)
+	if u.Phone.Extension != nil && (u.storedPhone.Extension == nil || *u.storedPhone.Extension != *u.Phone.Extension) {
+		changes["extension"] = *u.Phone.Extension
+	}
+	return changes
+}
+
+func setIfChanged(changes map[string]interface{}, column string, stored, updated *string) {
+	if updated != nil && (stored == nil || *stored != *updated) {
+		changes[column] = *updated
+	}
+}
 
-	return newContacts, newPhones, nil
+// hasChanges reports whether the update would write anything
+func (u *ContactUpdate) hasChanges() bool {
+	return len(u.contactChanges()) > 0 || len(u.phoneChanges()) > 0
 }
 
-// UpdateExistingContact handles updating contact records and associated phone records
-// based on matches found between inferred data and existing database records.
-func UpdateExistingContact(match contactMatch, call_id string) error {
+// plannedActions lists the updates this would make, before anything is written
+func (u *ContactUpdate) plannedActions() []ContactAction {
+	var actions []ContactAction
+	if changes := u.contactChanges(); len(changes) > 0 {
+		actions = append(actions, ContactAction{
+			Action:       "update",
+			ResourceType: "contact",
+			ResourceID:   u.Contact.ID,
+			Fields:       sortedColumns(changes),
+		})
+	}
+	if changes := u.phoneChanges(); len(changes) > 0 {
+		actions = append(actions, ContactAction{
+			Action:       "update",
+			ResourceType: "phone",
+			ResourceID:   u.Phone.ID,
+			Fields:       sortedColumns(changes),
+		})
+	}
+	return actions
+}
+
+func sortedColumns(changes map[string]interface{}) []string {
+	columns := make([]string, 0, len(changes))
+	for column := range changes {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+	return columns
+}
+
+// UpdateExistingContact writes a validated update to the stored contact and phone, with metadata for
+// every changed field. It returns the updates that were applied.
+func UpdateExistingContact(update *ContactUpdate, call_id string) ([]ContactAction, error) {
 	log := logger.Get()
 	log.Info().
-		Str("contact_id", match.ExistingContact.ID).
-		Str("match_type", match.MatchType).
-		Bool("needs_new_phone", match.NeedsNewPhone).
+		Str("contact_id", update.Contact.ID).
+		Bool("updates_phone", update.Phone != nil).
 		Msg("Starting contact update process")
 
 	client, err := supabase.InitSupabaseClient()
 	if err != nil {
-		return fmt.Errorf("failed to initialize Supabase client: %w", err)
+		return nil, fmt.Errorf("failed to initialize Supabase client: %w", err)
 	}
 
 	// Track all metadata changes
 	var metadataInputs []supabase.MetadataInput
+	var actions []ContactAction
 
 	// 1. Handle Contact Updates
-	if updateData := buildContactUpdateData(match); len(updateData) > 0 {
+	if updateData := update.contactChanges(); len(updateData) > 0 {
 		// Prepare metadata for contact changes
-		metadataInputs = append(metadataInputs, buildContactMetadata(match, call_id, updateData)...)
+		metadataInputs = append(metadataInputs, buildContactMetadata(update, call_id, updateData)...)
 
 		// Update contact in database
 		data, _, err := client.From("contact").
 			Update(updateData, "", "").
-			Eq("id", match.ExistingContact.ID).
+			Eq("id", update.Contact.ID).
 			Execute()
 		if err != nil {
 			log.Error().
 				Err(err).
-				Str("contact_id", match.ExistingContact.ID).
+				Str("contact_id", update.Contact.ID).
 				Interface("update_data", updateData).
 				Str("response", string(data)).
 				Msg("Failed to update contact")
-			return fmt.Errorf("failed to update contact %s: %w", match.ExistingContact.ID, err)
+			return actions, fmt.Errorf("failed to update contact %s: %w", update.Contact.ID, err)
 		}
 
+		actions = append(actions, ContactAction{
+			Action:       "update",
+			ResourceType: "contact",
+			ResourceID:   update.Contact.ID,
+			Fields:       sortedColumns(updateData),
+			Applied:      true,
+		})
+
 		log.Info().
-			Str("contact_id", match.ExistingContact.ID).
+			Str("contact_id", update.Contact.ID).
 			Int("fields_updated", len(updateData)).
 			Msg("Successfully updated contact fields")
 	}
 
 	// 2. Handle Phone Record Updates
-	if err := handlePhoneUpdates(match, call_id, &metadataInputs); err != nil {
-		return fmt.Errorf("failed to handle phone updates: %w", err)
-	}
+	if updateData := update.phoneChanges(); len(updateData) > 0 {
+		metadataInputs = append(metadataInputs, buildPhoneMetadata(update, call_id, updateData)...)
 
-	// 3. Create all metadata entries
-	if len(metadataInputs) > 0 {
-		if err := supabase.CreateAndStoreMetadata(metadataInputs); err != nil {
-			return fmt.Errorf("failed to create metadata entries: %w", err)
+		_, _, err := client.From("phone").
+			Update(updateData, "", "").
+			Eq("id", update.Phone.ID).
+			Execute()
+		if err != nil {
+			return actions, fmt.Errorf("failed to update phone record: %w", err)
 		}
-	}
-
-	return nil
-}
-
-// buildContactUpdateData determines which contact fields need to be updated
-func buildContactUpdateData(match contactMatch) map[string]interface{} {
-	updateData := make(map[string]interface{})
 
-	// Check each field for updates
-	if match.InferredContact.Name != "" &&
-		(match.ExistingContact.Name == nil || match.InferredContact.Name != *match.ExistingContact.Name) {
-		updateData["name"] = match.InferredContact.Name
-	}
-
-	if match.InferredContact.Title != nil &&
-		(match.ExistingContact.Title == nil || *match.InferredContact.Title != *match.ExistingContact.Title) {
-		updateData["title"] = *match.InferredContact.Title
-	}
+		actions = append(actions, ContactAction{
+			Action:       "update",
+			ResourceType: "phone",
+			ResourceID:   update.Phone.ID,
+			Fields:       sortedColumns(updateData),
+			Applied:      true,
+		})
 
-	if match.InferredContact.Department != nil &&
-		(match.ExistingContact.Department == nil || *match.InferredContact.Department != *match.ExistingContact.Department) {
-		updateData["department"] = *match.InferredContact.Department
+		log.Info().
+			Str("phone_id", update.Phone.ID).
+			Int("fields_updated", len(updateData)).
+			Msg("Updated existing phone record")
 	}
 
-	if match.InferredContact.Email != nil &&
-		(match.ExistingContact.Email == nil || *match.InferredContact.Email != *match.ExistingContact.Email) {
-		updateData["email"] = *match.InferredContact.Email
+	// 3. Create all metadata entries
+	if len(metadataInputs) > 0 {
+		if err := supabase.CreateAndStoreMetadata(metadataInputs); err != nil {
+			return actions, fmt.Errorf("failed to create metadata entries: %w", err)
+		}
 	}
 
-	return updateData
+	return actions, nil
 }
 
 // buildContactMetadata creates metadata entries for contact field updates
-func buildContactMetadata(match contactMatch, call_id string, updateData map[string]interface{}) []supabase.MetadataInput {
+func buildContactMetadata(update *ContactUpdate, call_id string, updateData map[string]interface{}) []supabase.MetadataInput {
 	var metadataInputs []supabase.MetadataInput
 
-	// Helper to safely get string value from pointer
-	getStringValue := func(s *string) string {
-		if s == nil {
-			return ""
-		}
-		return *s
-	}
-
 	// Create metadata for each updated field
 	for field, newValue := range updateData {
 		var oldValue string
+		evidenceField := field
 		switch field {
 		case "name":
-			oldValue = getStringValue(match.ExistingContact.Name)
+			oldValue = getStringValue(update.storedContact.Name)
 		case "title":
-			oldValue = getStringValue(match.ExistingContact.Title)
+			oldValue = getStringValue(update.storedContact.Title)
 		case "department":
-			oldValue = getStringValue(match.ExistingContact.Department)
+			oldValue = getStringValue(update.storedContact.Department)
 		case "email":
-			oldValue = getStringValue(match.ExistingContact.Email)
+			oldValue = getStringValue(update.storedContact.Email)
+		case "service_id":
+			oldValue = getStringValue(update.storedContact.ServiceID)
+			evidenceField = "serviceId"
 		}
 
 		metadataInputs = append(metadataInputs, supabase.MetadataInput{
-			ResourceID:       match.ExistingContact.ID,
+			ResourceID:       update.Contact.ID,
 			CallID:           call_id,
 			ResourceType:     "contact",
 			LastActionType:   "UPDATE",
 			FieldName:        field,
 			PreviousValue:    oldValue,
 			ReplacementValue: newValue.(string),
+			Evidence:         evidenceFor(update.Evidence, evidenceField),
 		})
 	}
 
 	return metadataInputs
 }
 
-// handlePhoneUpdates manages phone record creation and updates
-func handlePhoneUpdates(match contactMatch, call_id string, metadataInputs *[]supabase.MetadataInput) error {
-	log := logger.Get()
-
-	client, err := supabase.InitSupabaseClient()
-	if err != nil {
-		return fmt.Errorf("failed to initialize Supabase client: %w", err)
-	}
-
-	// Case 1: Update existing phone record with new details
-	if match.UpdatePhone && match.ExistingPhone != nil && match.InferredContact.Phone != nil {
-		updateData := make(map[string]interface{})
-
-		// Update description if provided
-		if match.InferredContact.PhoneDescription != nil {
-			updateData["description"] = *match.InferredContact.PhoneDescription
-			*metadataInputs = append(*metadataInputs, supabase.MetadataInput{
-				ResourceID:       match.ExistingPhone.ID,
-				CallID:           call_id,
-				ResourceType:     "phone",
-				LastActionType:   "UPDATE",
-				FieldName:        "description",
-				PreviousValue:    getStringValue(match.ExistingPhone.Description),
-				ReplacementValue: *match.InferredContact.PhoneDescription,
-			})
-		}
-
-		// Update extension if provided
-		if match.InferredContact.PhoneExtension != nil {
-			floatVal := float64(*match.InferredContact.PhoneExtension)
-			updateData["extension"] = floatVal
-			*metadataInputs = append(*metadataInputs, supabase.MetadataInput{
-				ResourceID:       match.ExistingPhone.ID,
-				CallID:           call_id,
-				ResourceType:     "phone",
-				LastActionType:   "UPDATE",
-				FieldName:        "extension",
-				PreviousValue:    fmt.Sprintf("%v", match.ExistingPhone.Extension),
-				ReplacementValue: fmt.Sprintf("%d", *match.InferredContact.PhoneExtension),
-			})
-		}
-
-		if len(updateData) > 0 {
-			_, _, err := client.From("phone").
-				Update(updateData, "", "").
-				Eq("id", match.ExistingPhone.ID).
-				Execute()
-			if err != nil {
-				return fmt.Errorf("failed to update phone record: %w", err)
-			}
-
-			log.Info().
-				Str("phone_id", match.ExistingPhone.ID).
-				Int("fields_updated", len(updateData)).
-				Msg("Updated existing phone record")
-		}
-	}
-
-	// Case 2: Create new phone record
-	// This handles both NeedsNewPhone=true and shared_phone cases
-	if (match.NeedsNewPhone || match.MatchType == "shared_phone") &&
-		match.InferredContact.Phone != nil {
-
-		var extension *float64
-		if match.InferredContact.PhoneExtension != nil {
-			floatVal := float64(*match.InferredContact.PhoneExtension)
-			extension = &floatVal
-		}
-
-		// Create new phone record
-		phoneOpts := &hsds_types.PhoneOptions{
-			OrganizationID: match.ExistingContact.OrganizationID,
-			ContactID:      &match.ExistingContact.ID,
-			Extension:      extension,
-			Description:    match.InferredContact.PhoneDescription,
-		}
-
-		phone, err := hsds_types.NewPhone(*match.InferredContact.Phone, phoneOpts)
-		if err != nil {
-			return fmt.Errorf("failed to create new phone record: %w", err)
-		}
+// buildPhoneMetadata creates metadata entries for phone field updates
+func buildPhoneMetadata(update *ContactUpdate, call_id string, updateData map[string]interface{}) []supabase.MetadataInput {
+	var metadataInputs []supabase.MetadataInput
 
-		// Store the new phone record
-		data, _, err := client.From("phone").
-			Insert(phone, false, "", "representation", "").
-			Execute()
-		if err != nil {
-			return fmt.Errorf("failed to store new phone record: %w, data: %s", err, string(data))
+	for field, newValue := range updateData {
+		var oldValue interface{}
+		var evidence map[string]hsds_types.FieldEvidence
+		switch field {
+		case "description":
+			oldValue = getStringValue(update.storedPhone.Description)
+			evidence = evidenceFor(update.Evidence, "phoneDescription")
+		case "extension":
+			oldValue = update.storedPhone.Extension
+			evidence = evidenceFor(update.Evidence, "phoneExtension")
+		case "type":
+			oldValue = getStringValue(update.storedPhone.Type)
+		case "service_id":
+			oldValue = getStringValue(update.storedPhone.ServiceID)
+			evidence = evidenceFor(update.Evidence, "phoneServiceId")
 		}
 
-		// Create metadata for the new phone
-		createPhoneMetadata(phone, match.InferredContact, call_id, metadataInputs)
-
-		log.Info().
-			Str("contact_id", match.ExistingContact.ID).
-			Str("phone_id", phone.ID).
-			Str("match_type", match.MatchType).
-			Msg("Created new phone record")
-	}
-
-	return nil
-}
-
-// Helper function to create phone metadata
-func createPhoneMetadata(phone *hsds_types.Phone, inf contactInference, call_id string, metadataInputs *[]supabase.MetadataInput) {
-	*metadataInputs = append(*metadataInputs, supabase.MetadataInput{
-		ResourceID:       phone.ID,
-		CallID:           call_id,
-		ResourceType:     "phone",
-		LastActionType:   "CREATE",
-		FieldName:        "number",
-		PreviousValue:    "",
-		ReplacementValue: *inf.Phone,
-	})
-
-	if inf.PhoneExtension != nil {
-		*metadataInputs = append(*metadataInputs, supabase.MetadataInput{
-			ResourceID:       phone.ID,
+		metadataInputs = append(metadataInputs, supabase.MetadataInput{
+			ResourceID:       update.Phone.ID,
 			CallID:           call_id,
 			ResourceType:     "phone",
-			LastActionType:   "CREATE",
-			FieldName:        "extension",
-			PreviousValue:    "",
-			ReplacementValue: strconv.Itoa(*inf.PhoneExtension),
+			LastActionType:   "UPDATE",
+			FieldName:        field,
+			PreviousValue:    oldValue,
+			ReplacementValue: newValue,
+			Evidence:         evidence,
 		})
 	}
 
-	if inf.PhoneDescription != nil {
-		*metadataInputs = append(*metadataInputs, supabase.MetadataInput{
-			ResourceID:       phone.ID,
-			CallID:           call_id,
-			ResourceType:     "phone",
-			LastActionType:   "CREATE",
-			FieldName:        "description",
-			PreviousValue:    "",
-			ReplacementValue: *inf.PhoneDescription,
-		})
-	}
+	return metadataInputs
 }
@@ -3,37 +3,78 @@ package structOutputs
 import (
 	"encoding/json"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/david-botos/BearHug/services/analysis/internal/hsds_types"
+	"github.com/david-botos/BearHug/services/analysis/internal/phonenumber"
 	"github.com/david-botos/BearHug/services/analysis/internal/processor/inference"
 	"github.com/david-botos/BearHug/services/analysis/internal/supabase"
 	"github.com/david-botos/BearHug/services/analysis/pkg/logger"
 )
 
-func GenerateContactCategoryPrompt(transcript string) (string, inference.ToolInputSchema, error) {
+func GenerateContactCategoryPrompt(transcript string, serviceCtx ServiceContext) (string, inference.ToolInputSchema, error) {
 	log := logger.Get()
-	log.Debug().Msg("Generating service capacity prompt")
+	log.Debug().
+		Int("existing_services", len(serviceCtx.ExistingServices)).
+		Int("new_services", len(serviceCtx.NewServices)).
+		Msg("Generating contact prompt")
+
+	var serviceDesc strings.Builder
+	for _, service := range serviceCtx.ExistingServices {
+		writeServiceDescription(&serviceDesc, *service)
+	}
+	for _, service := range serviceCtx.NewServices {
+		if service != nil {
+			writeServiceDescription(&serviceDesc, *service)
+		}
+	}
+	if serviceDesc.Len() == 0 {
+		serviceDesc.WriteString("None\n")
+	}
 
 	prompt := fmt.Sprintf(`Extract contact information for community organization staff/representatives (not call center agents) from the following transcript. Follow these rules:
 
 	Contact Information Rules:
 	1. Data Requirements:
 	   - Include a contact entry if ANY of these are mentioned: name, email, or phone number
-	   - Phone numbers must be formatted with country code (default to +1 for US)
-	   - Extensions must be in integer format
+	   - Write phone numbers as digits with the country code when you can (default to +1 for US), but a number
+	     copied as it was said (e.g. "five five five, one two three four" or "1-800-FLOWERS") is fine; numbers are
+	     normalized after extraction
+	   - Extensions go in phoneExtension as an integer, not in the phone number
+	   - Set phoneType to fax, text or hotline when the transcript says so, otherwise voice
 	   - Capture contextual information about phone numbers in the phoneDescription field, such as:
 		  * Whether it's a front desk, direct line, or general contact
 		  * Any specific guidance about when to use the number
 		  * Whether it's a personal or shared line
 	
-	2. Scope:
+	2. Services:
+	   - When the transcript makes clear a person works on, or is the contact for, one of the services listed
+	     below, set serviceId to that service's ID
+	   - When a phone number is for a particular service (e.g. "the pantry line"), set phoneServiceId to that
+	     service's ID
+	   - Leave both out when the person or number is for the organization as a whole, or it isn't clear
+	   - Only use IDs from the list below
+
+	3. Scope:
 	   - Only extract contact information for staff/representatives of the community organizations
 	   - Do NOT create entries for call center agents or other 211 staff
+	   - When lines are labelled by speaker, the Agent (AI caller) is never a contact. Take details from what the
+	     Representative says; a number or name the Agent reads back only counts if the Representative confirms it
+
+	4. Evidence:
+	   - For every field you fill in, add an evidence entry quoting the exact words from the transcript that support it
+	   - Include the quote's character offsets and your confidence from 0 to 1
+
+	5. Language:
+%s
 	
+	Services:
+	%s
 	Conversation Transcript:
 	%s
 	
-	IMPORTANT: Respond ONLY with the structured data output. Do not include any additional text, explanations, or notes.`, transcript)
+	IMPORTANT: Respond ONLY with the structured data output. Do not include any additional text, explanations, or notes.`, languageRules, serviceDesc.String(), transcript)
 
 	return prompt, ContactInformationSchema, nil
 }
@@ -74,7 +115,22 @@ var ContactInformationSchema = inference.ToolInputSchema{
 						"type":        "integer",
 						"description": "The contact's phone extension in integer format",
 					},
+					"phoneType": map[string]interface{}{
+						"type":        "string",
+						"enum":        []string{"voice", "fax", "text", "hotline"},
+						"description": "What kind of line the phone number is",
+					},
+					"serviceId": map[string]interface{}{
+						"type":        "string",
+						"description": "The Service ID of the listed service this contact works on, if the transcript says so",
+					},
+					"phoneServiceId": map[string]interface{}{
+						"type":        "string",
+						"description": "The Service ID of the listed service this phone number is for, if the transcript says so",
+					},
+					"evidence": evidenceProperty("name", "title", "department", "email", "phone", "phoneDescription", "phoneExtension", "serviceId", "phoneServiceId"),
 				},
+				"required": []string{"evidence"},
 				"anyOf": []map[string]interface{}{
 					{
 						"required": []string{"name"},
@@ -100,41 +156,60 @@ type contactInference struct {
 	Phone            *string `json:"phone,omitempty"`
 	PhoneDescription *string `json:"phoneDescription,omitempty"`
 	PhoneExtension   *int    `json:"phoneExtension,omitempty"`
+	PhoneType        *string `json:"phoneType,omitempty"`
+	ServiceID        *string `json:"serviceId,omitempty"`
+	PhoneServiceID   *string `json:"phoneServiceId,omitempty"`
+
+	Evidence map[string]hsds_types.FieldEvidence `json:"evidence,omitempty"`
 }
 
 type contactInfOutput struct {
 	Contacts []contactInference `json:"contacts"`
 }
 
-func infToContactsAndPhones(inferenceResult map[string]interface{}, serviceCtx ServiceContext, org_id string, call_id string) ([]*hsds_types.Contact, []*hsds_types.Phone, error) {
+// infToContactsAndPhones reconciles the extracted contacts against the organization's stored ones and plans
+// the new records and updates. The reconciliation report travels with the result and is saved by
+// StoreDetails once it knows what was written.
+func infToContactsAndPhones(inferenceResult map[string]interface{}, transcript string, serviceCtx ServiceContext, org_id string, call_id string) (DetailAnalysisResult, error) {
 	log := logger.Get()
 
 	// Unmarshal inference result
 	jsonData, err := json.Marshal(inferenceResult)
 	if err != nil {
 		log.Error().Err(err).Msg("Failed to marshal inference result")
-		return nil, nil, fmt.Errorf("error marshaling inference result: %w", err)
+		return DetailAnalysisResult{}, fmt.Errorf("error marshaling inference result: %w", err)
 	}
 
 	var mentionedContacts contactInfOutput
 	if err := json.Unmarshal(jsonData, &mentionedContacts); err != nil {
 		log.Error().Err(err).Msg("Failed to unmarshal to structured output")
-		return nil, nil, fmt.Errorf("error unmarshaling to structured output: %w", err)
+		return DetailAnalysisResult{}, fmt.Errorf("error unmarshaling to structured output: %w", err)
 	}
 
+	// Make a simple slice of all the Services for the org
+	services := make([]*hsds_types.Service, 0, len(serviceCtx.ExistingServices)+len(serviceCtx.NewServices))
+	services = append(services, serviceCtx.ExistingServices...)
+	services = append(services, serviceCtx.NewServices...)
+
+	// Drop fields, and whole contacts, that the transcript doesn't support
+	supportedContacts := make([]contactInference, 0, len(mentionedContacts.Contacts))
+	for _, contact := range mentionedContacts.Contacts {
+		if rejectUnsupportedContactFields(transcript, &contact) {
+			normalizeContactPhone(&contact)
+			resolveContactServices(&contact, services)
+			supportedContacts = append(supportedContacts, contact)
+		}
+	}
+	mentionedContacts.Contacts = mergeRepeatedContacts(supportedContacts)
+
 	/* Step 1: Fetch all the relevant data from supabase */
 
 	// Fetch all the contacts for the organization
 	orgContacts, contactFetchErr := supabase.FetchOrgContacts(org_id)
 	if contactFetchErr != nil {
-		return nil, nil, fmt.Errorf("error fetching organization contacts: %w", err)
+		return DetailAnalysisResult{}, fmt.Errorf("error fetching organization contacts: %w", contactFetchErr)
 	}
 
-	// Make a simple slice of all the Services for the org
-	services := make([]*hsds_types.Service, 0, len(serviceCtx.ExistingServices)+len(serviceCtx.NewServices))
-	services = append(services, serviceCtx.ExistingServices...)
-	services = append(services, serviceCtx.NewServices...)
-
 	// Isolate contact ids and service ids so relevant phones can be pulled
 	contactIDs := make([]string, 0, len(orgContacts))
 	serviceIDs := make([]string, 0, len(services))
@@ -150,63 +225,218 @@ func infToContactsAndPhones(inferenceResult map[string]interface{}, serviceCtx S
 	// Go through the Phone table and select any entries linked via foreign key to the organization, contacts, or services
 	relevantPhones, phoneFetchErr := supabase.FetchRelevantPhones(org_id, contactIDs, serviceIDs)
 	if phoneFetchErr != nil {
-		return nil, nil, fmt.Errorf("error fetching relevant phones: %w", phoneFetchErr)
+		return DetailAnalysisResult{}, fmt.Errorf("error fetching relevant phones: %w", phoneFetchErr)
 	}
 
 	/* Step 2: Match observations to existing data */
 	matchResults := FindMatches(mentionedContacts, orgContacts, relevantPhones)
+	for i := range matchResults.Matches {
+		keepExistingServiceLinks(&matchResults.Matches[i].InferredContact, serviceCtx.ExistingServices)
+	}
 
-	/* Step 3: Process Updates */
-	for _, match := range matchResults.Matches {
-		err := UpdateExistingContact(match, call_id)
-		if err != nil {
-			return nil, nil, fmt.Errorf("error when updating existing contact: %w", err)
+	report := ContactReconciliationReport{
+		CallID:         call_id,
+		OrganizationID: org_id,
+		GeneratedAt:    time.Now().UTC(),
+		Decisions:      matchResults.Decisions,
+	}
+
+	/* Step 3: Plan updates to matched contacts. Like new records, they are written by StoreDetails once validated */
+	var updates []*ContactUpdate
+	var matchedPhones []*hsds_types.Phone
+	matchedEvidence := make(map[string]map[string]hsds_types.FieldEvidence)
+	for i, match := range matchResults.Matches {
+		decision := &report.Decisions[matchResults.MatchDecisions[i]]
+
+		if update := newContactUpdate(match); update.hasChanges() {
+			updates = append(updates, update)
+			decision.Actions = append(decision.Actions, update.plannedActions()...)
+		}
+
+		// A number the stored contact doesn't have yet becomes a new phone, including a shared line
+		if (match.NeedsNewPhone || match.MatchType == "shared_phone") && match.InferredContact.Phone != nil {
+			phone, err := newContactPhone(match.InferredContact, match.ExistingContact.OrganizationID, match.ExistingContact.ID)
+			if err != nil {
+				storeContactReconciliationReport(report)
+				return DetailAnalysisResult{}, fmt.Errorf("error creating phone for existing contact: %w", err)
+			}
+			matchedPhones = append(matchedPhones, phone)
+			matchedEvidence[phone.ID] = phoneEvidence(match.InferredContact)
+			decision.Actions = append(decision.Actions, ContactAction{
+				Action:       "create",
+				ResourceType: "phone",
+				ResourceID:   phone.ID,
+			})
 		}
 	}
 
 	/* Step 4: Create new records for unmmatched mentions */
-	newContacts, newPhones, recordCreationErr := CreateNewContactAndPhoneRecords(matchResults.UnmatchedInf, org_id, call_id)
+	newContacts, newPhones, evidence, recordCreationErr := CreateNewContactAndPhoneRecords(matchResults.UnmatchedInf, org_id)
 	if recordCreationErr != nil {
-		return nil, nil, fmt.Errorf("error when creating new records for unmmatched data: %w", err)
+		storeContactReconciliationReport(report)
+		return DetailAnalysisResult{}, fmt.Errorf("error when creating new records for unmmatched data: %w", recordCreationErr)
 	}
 
-	return newContacts, newPhones, nil
+	// New contacts come back in the same order as the unmatched mentions, each followed by its phone
+	phonesByContact := make(map[string]*hsds_types.Phone, len(newPhones))
+	for _, phone := range newPhones {
+		if phone.ContactID != nil {
+			phonesByContact[*phone.ContactID] = phone
+		}
+	}
+	for i, contact := range newContacts {
+		decision := &report.Decisions[matchResults.UnmatchedDecisions[i]]
+		decision.Actions = append(decision.Actions, ContactAction{
+			Action:       "create",
+			ResourceType: "contact",
+			ResourceID:   contact.ID,
+		})
+		if phone, ok := phonesByContact[contact.ID]; ok {
+			decision.Actions = append(decision.Actions, ContactAction{
+				Action:       "create",
+				ResourceType: "phone",
+				ResourceID:   phone.ID,
+			})
+		}
+	}
+
+	newPhones = append(newPhones, matchedPhones...)
+	for id, phoneEvidence := range matchedEvidence {
+		evidence[id] = phoneEvidence
+	}
+
+	result := NewContactCategoryResult(newContacts, newPhones, updates, evidence)
+	result.ContactData.Report = &report
+	return result, nil
 }
 
-func AnalyzeContactCategoryDetails(transcript string, org_id string, serviceCtx ServiceContext, call_id string) (DetailAnalysisResult, error) {
+// normalizeContactPhone rewrites the phone as E.164 (or a short code), moves any extension said with the
+// number into PhoneExtension and classifies the line. A number that doesn't parse is kept as it was
+// said, so the caller's number isn't lost; validation's E.164 rule then flags it for a fix or for review.
+func normalizeContactPhone(contact *contactInference) {
+	if contact.Phone == nil {
+		return
+	}
 	log := logger.Get()
-	log.Debug().Msg("Starting contact details analysis")
 
-	// Generate Prompt and Schema
-	prompt, schema, err := GenerateContactCategoryPrompt(transcript)
-	if err != nil {
-		log.Error().Err(err).Msg("Failed to generate service capacity prompt")
-		return DetailAnalysisResult{}, fmt.Errorf(`failure when generating service capacity prompt: %w`, err)
+	if strings.TrimSpace(*contact.Phone) == "" {
+		contact.Phone = nil
+		return
 	}
 
-	// Declare Claude Inference Client
-	client, err := inference.InitInferenceClient()
+	number, err := phonenumber.Parse(*contact.Phone)
 	if err != nil {
-		log.Error().Err(err).Msg("Failed to initialize inference client")
-		return DetailAnalysisResult{}, fmt.Errorf("failed to initialize inference client: %w", err)
+		log.Warn().
+			Err(err).
+			Str("contact_name", contact.Name).
+			Str("phone", *contact.Phone).
+			Msg("Keeping phone number that could not be parsed for review")
+		raw := strings.TrimSpace(*contact.Phone)
+		contact.Phone = &raw
+		return
+	}
+
+	normalized := number.String()
+	contact.Phone = &normalized
+	if contact.PhoneExtension == nil && number.Extension != nil {
+		contact.PhoneExtension = number.Extension
+	}
+
+	suggested := ""
+	if contact.PhoneType != nil {
+		suggested = *contact.PhoneType
+	}
+	phoneType := phonenumber.Classify(number, suggested, getStringValue(contact.PhoneDescription))
+	contact.PhoneType = &phoneType
+}
+
+// resolveContactServices checks the service IDs Claude gave against the services known for the call.
+// A name is accepted in place of an ID; anything that doesn't resolve is dropped so the contact or
+// phone stays linked to the organization only.
+func resolveContactServices(contact *contactInference, services []*hsds_types.Service) {
+	contact.ServiceID = resolveServiceReference(contact.ServiceID, services, contact.Name, "serviceId")
+	contact.PhoneServiceID = resolveServiceReference(contact.PhoneServiceID, services, contact.Name, "phoneServiceId")
+}
+
+// keepExistingServiceLinks drops links to services found on this call from a mention matched to a stored
+// contact. The stored contact and phone are changed in place, so a link to a new service that validation
+// later withholds would be left pointing at a service that was never stored.
+func keepExistingServiceLinks(contact *contactInference, existingServices []*hsds_types.Service) {
+	existing := func(serviceID *string) bool {
+		for _, service := range existingServices {
+			if service.ID == *serviceID {
+				return true
+			}
+		}
+		return false
+	}
+
+	if contact.ServiceID != nil && !existing(contact.ServiceID) {
+		logger.Get().Info().
+			Str("contact_name", contact.Name).
+			Str("service_id", *contact.ServiceID).
+			Msg("Not relinking a stored contact to a service found on this call")
+		contact.ServiceID = nil
+	}
+	if contact.PhoneServiceID != nil && !existing(contact.PhoneServiceID) {
+		logger.Get().Info().
+			Str("contact_name", contact.Name).
+			Str("service_id", *contact.PhoneServiceID).
+			Msg("Not relinking a stored phone to a service found on this call")
+		contact.PhoneServiceID = nil
+	}
+}
+
+func resolveServiceReference(reference *string, services []*hsds_types.Service, contactName string, field string) *string {
+	if reference == nil {
+		return nil
+	}
+	ref := strings.TrimSpace(*reference)
+	if ref == "" || strings.EqualFold(ref, "none") {
+		return nil
+	}
+
+	for _, service := range services {
+		if service.ID == ref {
+			return &service.ID
+		}
 	}
+	for _, service := range services {
+		if strings.EqualFold(strings.TrimSpace(service.Name), ref) ||
+			(service.AlternateName != nil && strings.EqualFold(strings.TrimSpace(*service.AlternateName), ref)) {
+			return &service.ID
+		}
+	}
+
+	logger.Get().Warn().
+		Str("contact_name", contactName).
+		Str("field", field).
+		Str("service_reference", ref).
+		Msg("Dropping service link that doesn't match a known service")
+	return nil
+}
+
+func AnalyzeContactCategoryDetails(transcript string, org_id string, serviceCtx ServiceContext, call_id string) (DetailAnalysisResult, error) {
+	log := logger.Get()
+	log.Debug().Msg("Starting contact details analysis")
 
-	log.Debug().Msg("Running Claude inference for capacity analysis")
-	// Run Inference
-	unformmattedContactDetails, inferenceErr := client.RunClaudeInference(inference.PromptParams{Prompt: prompt, Schema: schema})
+	log.Debug().Msg("Running Claude inference for contact analysis")
+	// Run inference, per segment for long transcripts
+	unformmattedContactDetails, inferenceErr := runSegmentedExtraction(transcript, "contacts", func(segment string) (string, inference.ToolInputSchema, error) {
+		return GenerateContactCategoryPrompt(segment, serviceCtx)
+	})
 	if inferenceErr != nil {
 		log.Error().Err(inferenceErr).Msg("Error during inference execution")
 		return DetailAnalysisResult{}, fmt.Errorf(`error running inference to extract contact details: %w`, inferenceErr)
 	}
 
 	log.Debug().Msg("Converting inference response to contact and phone objects")
-	contactDetails, phoneDetails, infConvErr := infToContactsAndPhones(unformmattedContactDetails, serviceCtx, org_id, call_id)
+	result, infConvErr := infToContactsAndPhones(unformmattedContactDetails, transcript, serviceCtx, org_id, call_id)
 	if infConvErr != nil {
 		log.Error().Err(infConvErr).Msg("Failed to convert inference response")
 		return DetailAnalysisResult{}, fmt.Errorf(`error while converting the inference response to clean contact and phone objects: %w`, infConvErr)
 	}
 
-	var result DetailAnalysisResult = NewContactCategoryResult(contactDetails, phoneDetails)
-	log.Info().Int("contact_count", len(result.ContactData.Contacts)).Int("phone_count", len(result.ContactData.Phones)).Msg("Contact analysis completed successfully")
+	log.Info().Int("contact_count", len(result.ContactData.Contacts)).Int("phone_count", len(result.ContactData.Phones)).Int("update_count", len(result.ContactData.Updates)).Msg("Contact analysis completed successfully")
 	return result, nil
 }
@@ -0,0 +1,164 @@
+package structOutputs
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/david-botos/BearHug/services/analysis/internal/hsds_types"
+)
+
+func TestKeepExistingServiceLinks(t *testing.T) {
+	existing := []*hsds_types.Service{{ID: "stored-pantry"}}
+	id := func(s string) *string { return &s }
+
+	tests := []struct {
+		name           string
+		serviceID      *string
+		phoneServiceID *string
+		wantService    string
+		wantPhone      string
+	}{
+		{name: "stored services are kept", serviceID: id("stored-pantry"), phoneServiceID: id("stored-pantry"), wantService: "stored-pantry", wantPhone: "stored-pantry"},
+		{name: "new services are dropped", serviceID: id("new-shelter"), phoneServiceID: id("new-shelter")},
+		{name: "only the new link is dropped", serviceID: id("stored-pantry"), phoneServiceID: id("new-shelter"), wantService: "stored-pantry"},
+		{name: "no links"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			contact := contactInference{Name: "Maria", ServiceID: tt.serviceID, PhoneServiceID: tt.phoneServiceID}
+			keepExistingServiceLinks(&contact, existing)
+
+			if got := getStringValue(contact.ServiceID); got != tt.wantService {
+				t.Errorf("ServiceID = %q, want %q", got, tt.wantService)
+			}
+			if got := getStringValue(contact.PhoneServiceID); got != tt.wantPhone {
+				t.Errorf("PhoneServiceID = %q, want %q", got, tt.wantPhone)
+			}
+		})
+	}
+}
+
+func TestNewContactUpdate(t *testing.T) {
+	text := func(s string) *string { return &s }
+	number := func(f float64) *float64 { return &f }
+	extension := func(i int) *int { return &i }
+
+	stored := &hsds_types.Contact{ID: "contact", Name: text("Maria Lopez"), Title: text("Director"), ServiceID: text("pantry")}
+	storedPhone := &hsds_types.Phone{ID: "phone", Number: "+12065550123", Extension: number(12), Description: text("front desk")}
+
+	tests := []struct {
+		name          string
+		match         contactMatch
+		wantContact   []string
+		wantPhone     []string
+		wantNoChanges bool
+	}{
+		{
+			name: "repeated values change nothing",
+			match: contactMatch{
+				InferredContact: contactInference{Name: "Maria Lopez", Title: text("Director"), Phone: text("+12065550123"), PhoneExtension: extension(12)},
+				ExistingContact: stored, ExistingPhone: storedPhone, UpdatePhone: true,
+			},
+			wantNoChanges: true,
+		},
+		{
+			name: "silence keeps stored values",
+			match: contactMatch{
+				InferredContact: contactInference{Email: text("maria@example.org")},
+				ExistingContact: stored,
+			},
+			wantContact: []string{"email"},
+		},
+		{
+			name: "changed contact and phone fields",
+			match: contactMatch{
+				InferredContact: contactInference{
+					Name: "Maria Lopez", Title: text("Program Manager"), ServiceID: text("shelter"),
+					Phone: text("+12065550123"), PhoneExtension: extension(14), PhoneDescription: text("front desk"), PhoneType: text("voice"),
+				},
+				ExistingContact: stored, ExistingPhone: storedPhone, UpdatePhone: true,
+			},
+			wantContact: []string{"service_id", "title"},
+			wantPhone:   []string{"extension", "type"},
+		},
+		{
+			name: "phone is left alone unless the match updates it",
+			match: contactMatch{
+				InferredContact: contactInference{Phone: text("+12065550123"), PhoneExtension: extension(14)},
+				ExistingContact: stored, ExistingPhone: storedPhone,
+			},
+			wantNoChanges: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			update := newContactUpdate(tt.match)
+			if update.hasChanges() == tt.wantNoChanges {
+				t.Fatalf("hasChanges() = %v, want %v", update.hasChanges(), !tt.wantNoChanges)
+			}
+			if got := sortedColumns(update.contactChanges()); !reflect.DeepEqual(got, append([]string{}, tt.wantContact...)) {
+				t.Errorf("contact changes = %v, want %v", got, tt.wantContact)
+			}
+			if got := sortedColumns(update.phoneChanges()); !reflect.DeepEqual(got, append([]string{}, tt.wantPhone...)) {
+				t.Errorf("phone changes = %v, want %v", got, tt.wantPhone)
+			}
+			if *stored.Title != "Director" || *storedPhone.Extension != 12 {
+				t.Errorf("stored records were modified")
+			}
+		})
+	}
+}
+
+func TestNormalizeContactPhone(t *testing.T) {
+	text := func(s string) *string { return &s }
+	extension := func(i int) *int { return &i }
+
+	tests := []struct {
+		name          string
+		contact       contactInference
+		wantPhone     *string
+		wantExtension *int
+		wantType      *string
+	}{
+		{name: "no phone", contact: contactInference{Name: "Maria"}},
+		{name: "blank phone", contact: contactInference{Name: "Maria", Phone: text("  ")}},
+		{
+			name:      "written number",
+			contact:   contactInference{Phone: text("(206) 555-0123")},
+			wantPhone: text("+12065550123"), wantType: text("voice"),
+		},
+		{
+			name:      "extension said with the number",
+			contact:   contactInference{Phone: text("206-555-0123 ext. 12"), PhoneDescription: text("fax line")},
+			wantPhone: text("+12065550123"), wantExtension: extension(12), wantType: text("fax"),
+		},
+		{
+			name:      "stated extension wins",
+			contact:   contactInference{Phone: text("206-555-0123 x12"), PhoneExtension: extension(4)},
+			wantPhone: text("+12065550123"), wantExtension: extension(4), wantType: text("voice"),
+		},
+		{
+			name:      "unparseable number is kept for review",
+			contact:   contactInference{Phone: text(" 555-0123 "), PhoneType: text("text"), PhoneExtension: extension(3)},
+			wantPhone: text("555-0123"), wantExtension: extension(3), wantType: text("text"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			contact := tt.contact
+			normalizeContactPhone(&contact)
+			if !reflect.DeepEqual(contact.Phone, tt.wantPhone) {
+				t.Errorf("Phone = %q, want %q", getStringValue(contact.Phone), getStringValue(tt.wantPhone))
+			}
+			if !reflect.DeepEqual(contact.PhoneExtension, tt.wantExtension) {
+				t.Errorf("PhoneExtension = %v, want %v", contact.PhoneExtension, tt.wantExtension)
+			}
+			if !reflect.DeepEqual(contact.PhoneType, tt.wantType) {
+				t.Errorf("PhoneType = %q, want %q", getStringValue(contact.PhoneType), getStringValue(tt.wantType))
+			}
+		})
+	}
+}
@@ -0,0 +1,286 @@
+package structOutputs
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/david-botos/BearHug/services/analysis/internal/hsds_types"
+	"github.com/david-botos/BearHug/services/analysis/pkg/logger"
+)
+
+// defaultMinEvidenceConfidence is used when EVIDENCE_MIN_CONFIDENCE is not set
+const defaultMinEvidenceConfidence = 0.6
+
+// evidenceProperty builds the schema for the per-field evidence object attached to each extracted item
+func evidenceProperty(fields ...string) map[string]interface{} {
+	properties := make(map[string]interface{}, len(fields))
+	for _, field := range fields {
+		properties[field] = map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"quote": map[string]interface{}{
+					"type":        "string",
+					"description": "The exact words from the transcript that support this value, copied verbatim in the language they were spoken",
+				},
+				"start_offset": map[string]interface{}{
+					"type":        "integer",
+					"description": "Character offset in the transcript where the quote starts",
+				},
+				"end_offset": map[string]interface{}{
+					"type":        "integer",
+					"description": "Character offset in the transcript where the quote ends",
+				},
+				"confidence": map[string]interface{}{
+					"type":        "number",
+					"description": "How confident you are that the quote supports the value, from 0 to 1",
+				},
+			},
+			"required": []string{"quote", "confidence"},
+		}
+	}
+
+	return map[string]interface{}{
+		"type":        "object",
+		"description": "Supporting transcript evidence for every field you filled in, keyed by field name",
+		"properties":  properties,
+	}
+}
+
+// minEvidenceConfidence returns the lowest confidence a field needs before it is written
+func minEvidenceConfidence() float64 {
+	if raw := os.Getenv("EVIDENCE_MIN_CONFIDENCE"); raw != "" {
+		if value, err := strconv.ParseFloat(raw, 64); err == nil {
+			return value
+		}
+	}
+	return defaultMinEvidenceConfidence
+}
+
+// verifyEvidence checks every quote against the transcript, correcting offsets when the
+// quote is found elsewhere and marking it unverified when it is not found at all
+func verifyEvidence(transcript string, evidence map[string]hsds_types.FieldEvidence) map[string]hsds_types.FieldEvidence {
+	verified := make(map[string]hsds_types.FieldEvidence, len(evidence))
+	for field, ev := range evidence {
+		ev.Verified = false
+		if start, end, ok := locateQuote(transcript, ev.Quote, ev.StartOffset, ev.EndOffset); ok {
+			ev.StartOffset = start
+			ev.EndOffset = end
+			ev.Verified = true
+		}
+		verified[field] = ev
+	}
+	return verified
+}
+
+// locateQuote finds the quote in the transcript, preferring the offsets the model gave.
+// Matching falls back to ignoring case and whitespace differences.
+func locateQuote(transcript, quote string, start, end int) (int, int, bool) {
+	quote = strings.TrimSpace(quote)
+	if quote == "" {
+		return 0, 0, false
+	}
+
+	if start >= 0 && end <= len(transcript) && start < end && transcript[start:end] == quote {
+		return start, end, true
+	}
+
+	if idx := strings.Index(transcript, quote); idx >= 0 {
+		return idx, idx + len(quote), true
+	}
+
+	// Collapse whitespace and case while remembering where each character came from
+	var normalized strings.Builder
+	positions := make([]int, 0, len(transcript))
+	lastWasSpace := true
+	for i, r := range transcript {
+		if unicode.IsSpace(r) {
+			if lastWasSpace {
+				continue
+			}
+			r = ' '
+			lastWasSpace = true
+		} else {
+			r = unicode.ToLower(r)
+			lastWasSpace = false
+		}
+		before := normalized.Len()
+		normalized.WriteRune(r)
+		for j := before; j < normalized.Len(); j++ {
+			positions = append(positions, i)
+		}
+	}
+
+	needle := strings.ToLower(strings.Join(strings.Fields(quote), " "))
+	idx := strings.Index(normalized.String(), needle)
+	if idx < 0 {
+		return 0, 0, false
+	}
+
+	lastRuneStart := positions[idx+len(needle)-1]
+	_, size := utf8.DecodeRuneInString(transcript[lastRuneStart:])
+
+	return positions[idx], lastRuneStart + size, true
+}
+
+// fieldSupported reports whether a field has verified evidence above the confidence threshold
+func fieldSupported(evidence map[string]hsds_types.FieldEvidence, field string, minConfidence float64) bool {
+	ev, ok := evidence[field]
+	return ok && ev.Verified && ev.Confidence >= minConfidence
+}
+
+// evidenceFor returns the evidence for a single field in the shape stored on metadata rows
+func evidenceFor(evidence map[string]hsds_types.FieldEvidence, field string) map[string]hsds_types.FieldEvidence {
+	ev, ok := evidence[field]
+	if !ok {
+		return nil
+	}
+	return map[string]hsds_types.FieldEvidence{field: ev}
+}
+
+// rejectUnsupportedServiceFields drops optional fields that lack evidence and reports whether the
+// service itself is supported well enough to keep
+func rejectUnsupportedServiceFields(transcript string, service *ExtractedService) bool {
+	log := logger.Get()
+	minConfidence := minEvidenceConfidence()
+
+	service.Evidence = verifyEvidence(transcript, service.Evidence)
+
+	for _, field := range []string{"name", "description"} {
+		if !fieldSupported(service.Evidence, field, minConfidence) {
+			log.Warn().
+				Str("service_name", service.Name).
+				Str("field", field).
+				Interface("evidence", service.Evidence[field]).
+				Msg("Rejecting extracted service without supporting evidence")
+			return false
+		}
+	}
+
+	if service.Status != hsds_types.ServiceStatusActive && !fieldSupported(service.Evidence, "status", minConfidence) {
+		log.Warn().
+			Str("service_name", service.Name).
+			Str("status", string(service.Status)).
+			Msg("Status lacks supporting evidence, defaulting to active")
+		service.Status = hsds_types.ServiceStatusActive
+	}
+
+	optionalStrings := map[string]**string{
+		"alternate_name":          &service.AlternateName,
+		"url":                     &service.URL,
+		"email":                   &service.Email,
+		"interpretation_services": &service.InterpretationServices,
+		"application_process":     &service.ApplicationProcess,
+		"fees_description":        &service.FeesDescription,
+		"accreditations":          &service.Accreditations,
+		"eligibility_description": &service.EligibilityDescription,
+		"alert":                   &service.Alert,
+		"wait_time":               &service.WaitTime,
+	}
+	for field, value := range optionalStrings {
+		if *value != nil && !fieldSupported(service.Evidence, field, minConfidence) {
+			logRejectedField(service.Name, field, service.Evidence[field])
+			*value = nil
+		}
+	}
+
+	optionalNumbers := map[string]**float64{
+		"minimum_age": &service.MinimumAge,
+		"maximum_age": &service.MaximumAge,
+	}
+	for field, value := range optionalNumbers {
+		if *value != nil && !fieldSupported(service.Evidence, field, minConfidence) {
+			logRejectedField(service.Name, field, service.Evidence[field])
+			*value = nil
+		}
+	}
+
+	if len(service.Languages) > 0 && !fieldSupported(service.Evidence, "languages", minConfidence) {
+		logRejectedField(service.Name, "languages", service.Evidence["languages"])
+		service.Languages = nil
+	}
+
+	return true
+}
+
+// rejectUnsupportedCapacityFields drops optional capacity fields that lack evidence and reports
+// whether the available count itself is supported
+func rejectUnsupportedCapacityFields(transcript string, capacity *capacityInference) bool {
+	minConfidence := minEvidenceConfidence()
+
+	capacity.Evidence = verifyEvidence(transcript, capacity.Evidence)
+
+	if !fieldSupported(capacity.Evidence, "available", minConfidence) {
+		logRejectedField(capacity.ServiceName, "available", capacity.Evidence["available"])
+		return false
+	}
+
+	if capacity.Maximum != nil && !fieldSupported(capacity.Evidence, "maximum", minConfidence) {
+		logRejectedField(capacity.ServiceName, "maximum", capacity.Evidence["maximum"])
+		capacity.Maximum = nil
+	}
+
+	return true
+}
+
+// rejectUnsupportedContactFields clears contact and phone fields that lack evidence and reports
+// whether anything identifying the contact is left
+func rejectUnsupportedContactFields(transcript string, contact *contactInference) bool {
+	minConfidence := minEvidenceConfidence()
+
+	contact.Evidence = verifyEvidence(transcript, contact.Evidence)
+
+	if contact.Name != "" && !fieldSupported(contact.Evidence, "name", minConfidence) {
+		logRejectedField(contact.Name, "name", contact.Evidence["name"])
+		contact.Name = ""
+	}
+
+	optionalStrings := map[string]**string{
+		"title":      &contact.Title,
+		"department": &contact.Department,
+		"email":      &contact.Email,
+		"serviceId":  &contact.ServiceID,
+	}
+	for field, value := range optionalStrings {
+		if *value != nil && !fieldSupported(contact.Evidence, field, minConfidence) {
+			logRejectedField(contact.Name, field, contact.Evidence[field])
+			*value = nil
+		}
+	}
+
+	// Phone details only make sense alongside a supported number
+	if contact.Phone != nil && !fieldSupported(contact.Evidence, "phone", minConfidence) {
+		logRejectedField(contact.Name, "phone", contact.Evidence["phone"])
+		contact.Phone = nil
+		contact.PhoneDescription = nil
+		contact.PhoneExtension = nil
+		contact.PhoneServiceID = nil
+	}
+	if contact.PhoneDescription != nil && !fieldSupported(contact.Evidence, "phoneDescription", minConfidence) {
+		logRejectedField(contact.Name, "phoneDescription", contact.Evidence["phoneDescription"])
+		contact.PhoneDescription = nil
+	}
+	if contact.PhoneExtension != nil && !fieldSupported(contact.Evidence, "phoneExtension", minConfidence) {
+		logRejectedField(contact.Name, "phoneExtension", contact.Evidence["phoneExtension"])
+		contact.PhoneExtension = nil
+	}
+	if contact.PhoneServiceID != nil && !fieldSupported(contact.Evidence, "phoneServiceId", minConfidence) {
+		logRejectedField(contact.Name, "phoneServiceId", contact.Evidence["phoneServiceId"])
+		contact.PhoneServiceID = nil
+	}
+
+	return contact.Name != "" || contact.Email != nil || contact.Phone != nil
+}
+
+func logRejectedField(subject, field string, evidence hsds_types.FieldEvidence) {
+	log := logger.Get()
+	log.Warn().
+		Str("subject", subject).
+		Str("field", field).
+		Str("quote", evidence.Quote).
+		Float64("confidence", evidence.Confidence).
+		Bool("verified", evidence.Verified).
+		Msg("Rejecting extracted field without supporting evidence")
+}
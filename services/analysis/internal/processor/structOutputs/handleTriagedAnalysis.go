@@ -7,14 +7,15 @@ import (
 	"github.com/david-botos/BearHug/services/analysis/pkg/logger"
 )
 
-// HandleTriagedAnalysis takes the triage results and launches appropriate analysis routines
+// HandleTriagedAnalysis takes the triage results and launches appropriate analysis routines.
+// A failing category is reported in its outcome and doesn't stop the others.
 func HandleTriagedAnalysis(
 	org_id string,
 	transcript string,
 	identifiedDetails *IdentifiedDetails,
 	serviceCtx ServiceContext,
 	call_id string,
-) ([]*DetailAnalysisResult, error) {
+) ([]*DetailAnalysisResult, []CategoryOutcome) {
 	log := logger.Get()
 
 	log.Info().Msg("Entered HandleTriagedAnalysis")
@@ -38,7 +39,7 @@ func HandleTriagedAnalysis(
 
 	var wg sync.WaitGroup
 	results := make([]*DetailAnalysisResult, len(detectedCategories))
-	errChan := make(chan error, len(detectedCategories))
+	outcomes := make([]CategoryOutcome, len(detectedCategories))
 
 	// Launch a goroutine for each detected category
 	for i, categoryStr := range detectedCategories {
@@ -74,47 +75,45 @@ func HandleTriagedAnalysis(
 			if err != nil {
 				wrappedErr := fmt.Errorf("error analyzing category %s: %w", cat, err)
 				log.Error().Err(wrappedErr).Msg("Category analysis failed")
-				errChan <- wrappedErr
+				outcomes[index] = CategoryOutcome{
+					Category: DetailCategory(cat),
+					Status:   CategoryFailed,
+					Error:    wrappedErr.Error(),
+				}
 				return
 			}
 
 			log.Debug().Msg("Category analysis completed successfully")
 			results[index] = &result
+			outcomes[index] = CategoryOutcome{Category: DetailCategory(cat)}
 		}(i, categoryStr)
 	}
 
 	// Wait for all goroutines to complete
 	wg.Wait()
-	close(errChan)
 
-	// Check for any errors
-	var errs []error
-	for err := range errChan {
-		errs = append(errs, err)
-	}
-	if len(errs) > 0 {
-		// Combine all errors into a single error message
-		errMsgs := make([]string, len(errs))
-		for i, err := range errs {
-			errMsgs[i] = err.Error()
-		}
-		log.Error().
-			Interface("errors", errMsgs).
-			Msg("Multiple errors occurred during analysis")
-		return nil, fmt.Errorf("multiple errors occurred: %v", errMsgs)
-	}
-
-	// Filter out nil results
+	// Filter out failed categories
 	finalResults := make([]*DetailAnalysisResult, 0, len(results))
-	for _, result := range results {
+	failed := 0
+	for i, result := range results {
 		if result != nil {
 			finalResults = append(finalResults, result)
+		} else if outcomes[i].Status == CategoryFailed {
+			failed++
 		}
 	}
 
-	log.Info().
-		Int("total_results", len(finalResults)).
-		Msg("Triage analysis completed successfully")
+	if failed > 0 {
+		log.Warn().
+			Int("failed_categories", failed).
+			Int("total_results", len(finalResults)).
+			Interface("outcomes", outcomes).
+			Msg("Triage analysis completed with failed categories")
+	} else {
+		log.Info().
+			Int("total_results", len(finalResults)).
+			Msg("Triage analysis completed successfully")
+	}
 
-	return finalResults, nil
+	return finalResults, outcomes
 }
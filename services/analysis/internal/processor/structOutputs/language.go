@@ -0,0 +1,115 @@
+package structOutputs
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/david-botos/BearHug/services/analysis/internal/hsds_types"
+	"github.com/david-botos/BearHug/services/analysis/internal/language"
+	"github.com/david-botos/BearHug/services/analysis/internal/supabase"
+	"github.com/david-botos/BearHug/services/analysis/pkg/logger"
+)
+
+// languageRules is added to every extraction prompt. Calls may be held in Spanish, Vietnamese or another
+// language, but the directory is published in English, so values are translated while quotes are not:
+// evidence must match the transcript as spoken to be verified.
+const languageRules = `   - The conversation may not be in English. Write every value you extract in English, translating as needed
+   - Keep proper names (people, organizations and named programs) as they were said
+   - Evidence quotes are the exception: copy them word for word in the language they were spoken, never translated`
+
+// ExtractedLanguage is a language a service is delivered in
+type ExtractedLanguage struct {
+	Name string  `json:"name"`
+	Note *string `json:"note,omitempty"`
+}
+
+// storeServiceLanguages records the languages mentioned for each service in the HSDS language table,
+// skipping ones already recorded. Services are keyed by their stored service ID.
+func storeServiceLanguages(services map[string]ExtractedService, callID string) error {
+	log := logger.Get()
+	if len(services) == 0 {
+		return nil
+	}
+
+	serviceIDs := make([]string, 0, len(services))
+	for serviceID := range services {
+		serviceIDs = append(serviceIDs, serviceID)
+	}
+	sort.Strings(serviceIDs)
+
+	existing, err := supabase.FetchServiceLanguages(serviceIDs)
+	if err != nil {
+		return fmt.Errorf("failed to fetch existing service languages: %w", err)
+	}
+	recorded := make(map[string]bool, len(existing))
+	for _, existingLanguage := range existing {
+		if existingLanguage.ServiceID == nil {
+			continue
+		}
+		for _, value := range []*string{existingLanguage.Code, existingLanguage.Name} {
+			if value != nil {
+				recorded[*existingLanguage.ServiceID+"|"+languageKey(*value)] = true
+			}
+		}
+	}
+
+	var newLanguages []*hsds_types.Language
+	evidence := make(map[string]map[string]hsds_types.FieldEvidence)
+	for _, serviceID := range serviceIDs {
+		service := services[serviceID]
+		for _, extracted := range service.Languages {
+			key := serviceID + "|" + languageKey(extracted.Name)
+			if strings.TrimSpace(extracted.Name) == "" || recorded[key] {
+				continue
+			}
+			recorded[key] = true
+
+			opts := &hsds_types.LanguageOptions{
+				ServiceID: &serviceID,
+				Note:      extracted.Note,
+			}
+			name := strings.TrimSpace(extracted.Name)
+			if code, englishName, ok := language.Lookup(name); ok {
+				opts.Code = &code
+				name = englishName
+			} else {
+				log.Warn().
+					Str("service_id", serviceID).
+					Str("language", name).
+					Msg("Unrecognized service language, storing it without a code")
+			}
+			opts.Name = &name
+
+			newLanguage, err := hsds_types.NewLanguage(opts)
+			if err != nil {
+				return fmt.Errorf("error converting language '%s' for service %s: %w", name, serviceID, err)
+			}
+			newLanguages = append(newLanguages, newLanguage)
+			if ev, ok := service.Evidence["languages"]; ok {
+				evidence[newLanguage.ID] = map[string]hsds_types.FieldEvidence{"name": ev}
+			}
+		}
+	}
+
+	if len(newLanguages) == 0 {
+		return nil
+	}
+	if err := supabase.StoreNewLanguages(newLanguages, callID, evidence); err != nil {
+		return fmt.Errorf("failed to store service languages: %w", err)
+	}
+
+	log.Info().
+		Int("services", len(serviceIDs)).
+		Int("languages_stored", len(newLanguages)).
+		Msg("Stored service languages")
+	return nil
+}
+
+// languageKey identifies a language whether it was given as a code, an English name or an alias
+func languageKey(nameOrCode string) string {
+	if code, _, ok := language.Lookup(nameOrCode); ok {
+		return code
+	}
+	return strings.ToLower(strings.TrimSpace(nameOrCode))
+}
@@ -0,0 +1,224 @@
+package structOutputs
+
+import (
+	"strings"
+
+	"github.com/david-botos/BearHug/services/analysis/internal/hsds_types"
+	"github.com/david-botos/BearHug/services/analysis/internal/phonenumber"
+	"github.com/david-botos/BearHug/services/analysis/pkg/logger"
+)
+
+// Segments overlap and a service or person is often mentioned more than once, so the same item can be
+// extracted several times. These merges run before reconciliation so each item is matched only once.
+
+// mergeRepeatedServices folds services with the same name (or alternate name) into the first mention,
+// filling any fields it is missing from the later ones
+func mergeRepeatedServices(services []ExtractedService) []ExtractedService {
+	merged := make([]ExtractedService, 0, len(services))
+	byName := make(map[string]int)
+
+	for _, service := range services {
+		keys := []string{strings.ToLower(strings.TrimSpace(service.Name))}
+		if service.AlternateName != nil {
+			keys = append(keys, strings.ToLower(strings.TrimSpace(*service.AlternateName)))
+		}
+
+		existing := -1
+		for _, key := range keys {
+			if idx, ok := byName[key]; ok && key != "" {
+				existing = idx
+				break
+			}
+		}
+		if existing < 0 {
+			merged = append(merged, service)
+			existing = len(merged) - 1
+		} else {
+			fillService(&merged[existing], service)
+		}
+		for _, key := range keys {
+			if key != "" {
+				byName[key] = existing
+			}
+		}
+	}
+
+	logMergedRepeats("service", len(services), len(merged))
+	return merged
+}
+
+func fillService(target *ExtractedService, from ExtractedService) {
+	if target.Evidence == nil {
+		target.Evidence = make(map[string]hsds_types.FieldEvidence)
+	}
+	if len(from.Description) > len(target.Description) {
+		target.Description = from.Description
+		copyEvidence(target.Evidence, from.Evidence, "description")
+	}
+	// Any status other than the default was said deliberately, so it wins
+	if target.Status == hsds_types.ServiceStatusActive && from.Status != hsds_types.ServiceStatusActive && from.Status != "" {
+		target.Status = from.Status
+		copyEvidence(target.Evidence, from.Evidence, "status")
+	}
+
+	optionalStrings := map[string][2]**string{
+		"alternate_name":          {&target.AlternateName, &from.AlternateName},
+		"url":                     {&target.URL, &from.URL},
+		"email":                   {&target.Email, &from.Email},
+		"interpretation_services": {&target.InterpretationServices, &from.InterpretationServices},
+		"application_process":     {&target.ApplicationProcess, &from.ApplicationProcess},
+		"fees_description":        {&target.FeesDescription, &from.FeesDescription},
+		"accreditations":          {&target.Accreditations, &from.Accreditations},
+		"eligibility_description": {&target.EligibilityDescription, &from.EligibilityDescription},
+		"alert":                   {&target.Alert, &from.Alert},
+		"wait_time":               {&target.WaitTime, &from.WaitTime},
+	}
+	for field, values := range optionalStrings {
+		if *values[0] == nil && *values[1] != nil {
+			*values[0] = *values[1]
+			copyEvidence(target.Evidence, from.Evidence, field)
+		}
+	}
+
+	if target.MinimumAge == nil && from.MinimumAge != nil {
+		target.MinimumAge = from.MinimumAge
+		copyEvidence(target.Evidence, from.Evidence, "minimum_age")
+	}
+	if target.MaximumAge == nil && from.MaximumAge != nil {
+		target.MaximumAge = from.MaximumAge
+		copyEvidence(target.Evidence, from.Evidence, "maximum_age")
+	}
+	if len(target.Languages) == 0 && len(from.Languages) > 0 {
+		target.Languages = from.Languages
+		copyEvidence(target.Evidence, from.Evidence, "languages")
+	}
+}
+
+// mergeRepeatedCapacities keeps one capacity per service and unit. The later mention wins, since capacity
+// said later in a call is usually the correction.
+func mergeRepeatedCapacities(capacities []capacityInference) []capacityInference {
+	merged := make([]capacityInference, 0, len(capacities))
+	byKey := make(map[string]int)
+
+	for _, capacity := range capacities {
+		key := strings.ToLower(strings.TrimSpace(capacity.ServiceName)) + "|" + normalizeUnitName(capacity.UnitName)
+		idx, ok := byKey[key]
+		if !ok {
+			byKey[key] = len(merged)
+			merged = append(merged, capacity)
+			continue
+		}
+
+		earlier := merged[idx]
+		if capacity.Maximum == nil && earlier.Maximum != nil {
+			capacity.Maximum = earlier.Maximum
+			if capacity.Evidence == nil {
+				capacity.Evidence = make(map[string]hsds_types.FieldEvidence)
+			}
+			copyEvidence(capacity.Evidence, earlier.Evidence, "maximum")
+		}
+		if capacity.UnitDescription == "" {
+			capacity.UnitDescription = earlier.UnitDescription
+		}
+		merged[idx] = capacity
+	}
+
+	logMergedRepeats("capacity", len(capacities), len(merged))
+	return merged
+}
+
+// mergeRepeatedContacts folds mentions of the same person together: the same phone number, the same email,
+// or compatible names with nothing contradicting them. Missing fields are filled from later mentions.
+func mergeRepeatedContacts(contacts []contactInference) []contactInference {
+	merged := make([]contactInference, 0, len(contacts))
+
+	for _, contact := range contacts {
+		existing := -1
+		for i := range merged {
+			if sameContactMention(merged[i], contact) {
+				existing = i
+				break
+			}
+		}
+		if existing < 0 {
+			merged = append(merged, contact)
+			continue
+		}
+		fillContact(&merged[existing], contact)
+	}
+
+	logMergedRepeats("contact", len(contacts), len(merged))
+	return merged
+}
+
+func sameContactMention(a, b contactInference) bool {
+	samePhone := a.Phone != nil && b.Phone != nil && phonenumber.Key(*a.Phone) == phonenumber.Key(*b.Phone)
+	sameEmail := a.Email != nil && b.Email != nil && strings.EqualFold(*a.Email, *b.Email)
+	namesMatch := namesCompatible(&a.Name, &b.Name)
+
+	switch {
+	case a.Name != "" && b.Name != "" && !namesMatch:
+		// Different people can share a front desk number or a general inbox
+		return false
+	case samePhone || sameEmail:
+		return true
+	case a.Name == "" || b.Name == "":
+		return false
+	}
+
+	phonesConflict := a.Phone != nil && b.Phone != nil && !samePhone
+	emailsConflict := a.Email != nil && b.Email != nil && !sameEmail
+	return !phonesConflict && !emailsConflict
+}
+
+func fillContact(target *contactInference, from contactInference) {
+	if target.Evidence == nil {
+		target.Evidence = make(map[string]hsds_types.FieldEvidence)
+	}
+	if len(from.Name) > len(target.Name) {
+		target.Name = from.Name
+		copyEvidence(target.Evidence, from.Evidence, "name")
+	}
+
+	optionalStrings := map[string][2]**string{
+		"title":      {&target.Title, &from.Title},
+		"department": {&target.Department, &from.Department},
+		"email":      {&target.Email, &from.Email},
+		"serviceId":  {&target.ServiceID, &from.ServiceID},
+	}
+	for field, values := range optionalStrings {
+		if *values[0] == nil && *values[1] != nil {
+			*values[0] = *values[1]
+			copyEvidence(target.Evidence, from.Evidence, field)
+		}
+	}
+
+	// Phone details travel together so a description never ends up on the wrong number
+	if target.Phone == nil && from.Phone != nil {
+		target.Phone = from.Phone
+		target.PhoneDescription = from.PhoneDescription
+		target.PhoneExtension = from.PhoneExtension
+		target.PhoneType = from.PhoneType
+		target.PhoneServiceID = from.PhoneServiceID
+		for _, field := range []string{"phone", "phoneDescription", "phoneExtension", "phoneServiceId"} {
+			copyEvidence(target.Evidence, from.Evidence, field)
+		}
+	}
+}
+
+func copyEvidence(target, from map[string]hsds_types.FieldEvidence, field string) {
+	if ev, ok := from[field]; ok {
+		target[field] = ev
+	}
+}
+
+func logMergedRepeats(kind string, before, after int) {
+	if before == after {
+		return
+	}
+	logger.Get().Debug().
+		Str("kind", kind).
+		Int("extracted", before).
+		Int("after_merge", after).
+		Msg("Merged repeated mentions")
+}
@@ -0,0 +1,141 @@
+package structOutputs
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/david-botos/BearHug/services/analysis/internal/hsds_types"
+)
+
+func TestMergeRepeatedServices(t *testing.T) {
+	text := func(s string) *string { return &s }
+	quote := func(q string) map[string]hsds_types.FieldEvidence {
+		return map[string]hsds_types.FieldEvidence{"description": {Quote: q}, "url": {Quote: q}, "status": {Quote: q}}
+	}
+
+	services := []ExtractedService{
+		{Name: "Food Pantry", Status: hsds_types.ServiceStatusActive, Description: "Groceries", Evidence: quote("first")},
+		{Name: "Shelter", Status: hsds_types.ServiceStatusActive, Description: "Beds"},
+		{Name: " food pantry ", Status: hsds_types.ServiceStatusTemporarilyClosed, Description: "Weekly groceries for families", URL: text("https://example.org/pantry"), Evidence: quote("second")},
+		{Name: "The Pantry", AlternateName: text("Food Pantry"), Status: hsds_types.ServiceStatusActive, Description: "Short", Email: text("pantry@example.org")},
+		{Name: "Emergency Shelter", AlternateName: text("shelter"), Status: hsds_types.ServiceStatusActive},
+	}
+
+	merged := mergeRepeatedServices(services)
+
+	if len(merged) != 2 {
+		t.Fatalf("merged into %d services, want 2: %+v", len(merged), merged)
+	}
+	pantry := merged[0]
+	if pantry.Name != "Food Pantry" || pantry.Description != "Weekly groceries for families" || pantry.Status != hsds_types.ServiceStatusTemporarilyClosed {
+		t.Errorf("pantry = %+v, want the first name with the longest description and the stated status", pantry)
+	}
+	if getStringValue(pantry.URL) != "https://example.org/pantry" || getStringValue(pantry.Email) != "pantry@example.org" || getStringValue(pantry.AlternateName) != "Food Pantry" {
+		t.Errorf("pantry did not take missing fields from later mentions: %+v", pantry)
+	}
+	wantEvidence := map[string]hsds_types.FieldEvidence{"description": {Quote: "second"}, "url": {Quote: "second"}, "status": {Quote: "second"}}
+	if !reflect.DeepEqual(pantry.Evidence, wantEvidence) {
+		t.Errorf("pantry evidence = %+v, want the evidence of the values kept %+v", pantry.Evidence, wantEvidence)
+	}
+	if merged[1].Name != "Shelter" || getStringValue(merged[1].AlternateName) != "shelter" {
+		t.Errorf("shelter = %+v, want it merged with its alternate name mention", merged[1])
+	}
+	if services[0].Description != "Groceries" {
+		t.Errorf("input services were modified")
+	}
+}
+
+func TestMergeRepeatedCapacities(t *testing.T) {
+	maximum := func(f float64) *float64 { return &f }
+
+	capacities := []capacityInference{
+		{ServiceName: "Shelter", UnitName: "beds", Available: 10, Maximum: maximum(40), UnitDescription: "Overnight beds",
+			Evidence: map[string]hsds_types.FieldEvidence{"maximum": {Quote: "forty beds"}}},
+		{ServiceName: "Food Pantry", UnitName: "Food Boxes", Available: 20},
+		{ServiceName: "shelter ", UnitName: "Bed", Available: 4},
+		{ServiceName: "Shelter", UnitName: "cots", Available: 2},
+		{ServiceName: "food pantry", UnitName: "food box", Available: 12, Maximum: maximum(50)},
+	}
+
+	want := []capacityInference{
+		{ServiceName: "shelter ", UnitName: "Bed", Available: 4, Maximum: maximum(40), UnitDescription: "Overnight beds",
+			Evidence: map[string]hsds_types.FieldEvidence{"maximum": {Quote: "forty beds"}}},
+		{ServiceName: "food pantry", UnitName: "food box", Available: 12, Maximum: maximum(50)},
+		{ServiceName: "Shelter", UnitName: "cots", Available: 2},
+	}
+	if got := mergeRepeatedCapacities(capacities); !reflect.DeepEqual(got, want) {
+		t.Errorf("mergeRepeatedCapacities() =\n  %+v\nwant\n  %+v", got, want)
+	}
+}
+
+func TestMergeRepeatedContacts(t *testing.T) {
+	text := func(s string) *string { return &s }
+
+	tests := []struct {
+		name     string
+		contacts []contactInference
+		want     []contactInference
+	}{
+		{
+			name: "first name and full name",
+			contacts: []contactInference{
+				{Name: "Maria", Title: text("Director")},
+				{Name: "Maria Lopez", Email: text("maria@example.org")},
+			},
+			want: []contactInference{
+				{Name: "Maria Lopez", Title: text("Director"), Email: text("maria@example.org"), Evidence: map[string]hsds_types.FieldEvidence{}},
+			},
+		},
+		{
+			name: "unnamed mention of a known phone",
+			contacts: []contactInference{
+				{Name: "Maria Lopez", Phone: text("+12065550123")},
+				{Phone: text("(206) 555-0123"), PhoneExtension: func() *int { i := 12; return &i }(), Department: text("Intake")},
+			},
+			want: []contactInference{
+				{Name: "Maria Lopez", Phone: text("+12065550123"), Department: text("Intake"), Evidence: map[string]hsds_types.FieldEvidence{}},
+			},
+		},
+		{
+			name: "different people on a front desk line",
+			contacts: []contactInference{
+				{Name: "Maria Lopez", Phone: text("+12065550123")},
+				{Name: "Juan Perez", Phone: text("+12065550123")},
+			},
+			want: []contactInference{
+				{Name: "Maria Lopez", Phone: text("+12065550123")},
+				{Name: "Juan Perez", Phone: text("+12065550123")},
+			},
+		},
+		{
+			name: "same name with different emails",
+			contacts: []contactInference{
+				{Name: "Maria Lopez", Email: text("maria@example.org")},
+				{Name: "Maria Lopez", Email: text("mlopez@example.org")},
+			},
+			want: []contactInference{
+				{Name: "Maria Lopez", Email: text("maria@example.org")},
+				{Name: "Maria Lopez", Email: text("mlopez@example.org")},
+			},
+		},
+		{
+			name: "unnamed mentions without a shared detail",
+			contacts: []contactInference{
+				{Title: text("Volunteer")},
+				{Title: text("Volunteer")},
+			},
+			want: []contactInference{
+				{Title: text("Volunteer")},
+				{Title: text("Volunteer")},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mergeRepeatedContacts(tt.contacts); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("mergeRepeatedContacts() =\n  %+v\nwant\n  %+v", got, tt.want)
+			}
+		})
+	}
+}
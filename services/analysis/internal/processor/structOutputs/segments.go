@@ -0,0 +1,158 @@
+package structOutputs
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/david-botos/BearHug/services/analysis/internal/processor/inference"
+	"github.com/david-botos/BearHug/services/analysis/pkg/logger"
+)
+
+const (
+	// defaultSegmentChars is used when TRANSCRIPT_SEGMENT_CHARS is not set. About 3000 tokens of
+	// transcript keeps extraction output for a busy call well under the output limit.
+	defaultSegmentChars = 12000
+	// segmentOverlapChars is repeated from the end of one segment at the start of the next, so facts
+	// said across a boundary are seen whole by at least one segment
+	segmentOverlapChars = 1000
+	// minSegmentChars stops a truncated segment from being split any further
+	minSegmentChars = 2000
+)
+
+// transcriptSegment is a slice of the transcript with its position in the full text
+type transcriptSegment struct {
+	Start int
+	End   int
+	Text  string
+}
+
+// segmentChars returns the longest transcript that is extracted in a single request
+func segmentChars() int {
+	if raw := os.Getenv("TRANSCRIPT_SEGMENT_CHARS"); raw != "" {
+		if value, err := strconv.Atoi(raw); err == nil && value >= minSegmentChars {
+			return value
+		}
+	}
+	return defaultSegmentChars
+}
+
+// segmentTranscript splits a transcript into overlapping segments of at most size characters. Segments end
+// at line breaks, so diarized turns stay whole, falling back to sentence ends and then spaces.
+func segmentTranscript(transcript string, size int, overlap int) []transcriptSegment {
+	if len(transcript) <= size {
+		return []transcriptSegment{{Start: 0, End: len(transcript), Text: transcript}}
+	}
+
+	var segments []transcriptSegment
+	start := 0
+	for start < len(transcript) {
+		end := start + size
+		if end >= len(transcript) {
+			end = len(transcript)
+		} else {
+			end = segmentBoundary(transcript, start, end)
+		}
+		segments = append(segments, transcriptSegment{Start: start, End: end, Text: transcript[start:end]})
+		if end == len(transcript) {
+			break
+		}
+
+		// Back up by the overlap, again to a boundary, but always move forward
+		next := segmentBoundary(transcript, start, end-overlap)
+		if next <= start {
+			next = end
+		}
+		start = next
+	}
+	return segments
+}
+
+// segmentBoundary finds the last line break, sentence end or space in the back half of transcript[start:end]
+func segmentBoundary(transcript string, start, end int) int {
+	if end <= start {
+		return start
+	}
+	floor := start + (end-start)/2
+	window := transcript[floor:end]
+	for _, separator := range []string{"\n", ". ", "? ", "! ", " "} {
+		if idx := strings.LastIndex(window, separator); idx >= 0 {
+			return floor + idx + len(separator)
+		}
+	}
+	return end
+}
+
+// runSegmentedExtraction runs an extraction prompt over each segment of the transcript and concatenates the
+// arrays found under arrayKey. A segment whose output is still truncated at the model's limit is split in
+// half and retried. Results are not deduplicated here; segments overlap, so callers merge repeated items.
+func runSegmentedExtraction(
+	transcript string,
+	arrayKey string,
+	buildPrompt func(segment string) (string, inference.ToolInputSchema, error),
+) (map[string]interface{}, error) {
+	log := logger.Get()
+
+	client, err := inference.InitInferenceClient()
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize inference client: %w", err)
+	}
+
+	segments := segmentTranscript(transcript, segmentChars(), segmentOverlapChars)
+	if len(segments) > 1 {
+		log.Info().
+			Str("extraction", arrayKey).
+			Int("transcript_length", len(transcript)).
+			Int("segment_count", len(segments)).
+			Msg("Transcript is long, extracting per segment")
+	}
+
+	var items []interface{}
+	var extract func(segment transcriptSegment) error
+	extract = func(segment transcriptSegment) error {
+		prompt, schema, err := buildPrompt(segment.Text)
+		if err != nil {
+			return fmt.Errorf("failed to generate prompt: %w", err)
+		}
+
+		result, err := client.RunClaudeInference(inference.PromptParams{Prompt: prompt, Schema: schema})
+		if errors.Is(err, inference.ErrOutputTruncated) && len(segment.Text) > minSegmentChars {
+			halves := segmentTranscript(segment.Text, len(segment.Text)/2+segmentOverlapChars/2, segmentOverlapChars/2)
+			log.Warn().
+				Str("extraction", arrayKey).
+				Int("segment_start", segment.Start).
+				Int("segment_length", len(segment.Text)).
+				Int("split_into", len(halves)).
+				Msg("Segment output was truncated, splitting it")
+			for _, half := range halves {
+				half.Start += segment.Start
+				half.End += segment.Start
+				if err := extract(half); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("segment at %d-%d: %w", segment.Start, segment.End, err)
+		}
+
+		if segmentItems, ok := result[arrayKey].([]interface{}); ok {
+			items = append(items, segmentItems...)
+		}
+		return nil
+	}
+
+	for _, segment := range segments {
+		if err := extract(segment); err != nil {
+			return nil, err
+		}
+	}
+
+	if items == nil {
+		items = []interface{}{}
+	}
+	return map[string]interface{}{arrayKey: items}, nil
+}
@@ -0,0 +1,122 @@
+package structOutputs
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSegmentTranscript(t *testing.T) {
+	// Twenty turns of 20 characters each, including the line break
+	var turns []string
+	for i := 0; i < 20; i++ {
+		turns = append(turns, "Agent: line "+string(rune('a'+i))+" said.")
+	}
+	diarized := strings.Join(turns, "\n") + "\n"
+
+	tests := []struct {
+		name       string
+		transcript string
+		size       int
+		overlap    int
+		wantCount  int  // 0 to skip the count check
+		wantWhole  bool // Every segment ends at a line break or the end of the transcript
+	}{
+		{name: "empty", transcript: "", size: 100, overlap: 10, wantCount: 1},
+		{name: "exactly one segment long", transcript: diarized, size: len(diarized), overlap: 40, wantCount: 1},
+		{name: "one character too long", transcript: diarized, size: len(diarized) - 1, overlap: 40, wantCount: 2, wantWhole: true},
+		{name: "turns stay whole", transcript: diarized, size: 100, overlap: 40, wantWhole: true},
+		{name: "overlap as long as a segment", transcript: diarized, size: 100, overlap: 100, wantWhole: true},
+		{name: "overlap longer than a segment", transcript: diarized, size: 100, overlap: 500, wantWhole: true},
+		{name: "no overlap", transcript: diarized, size: 100, overlap: 0, wantWhole: true},
+		{name: "no boundaries", transcript: strings.Repeat("x", 250), size: 100, overlap: 30, wantCount: 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			segments := segmentTranscript(tt.transcript, tt.size, tt.overlap)
+			if tt.wantCount > 0 && len(segments) != tt.wantCount {
+				t.Errorf("got %d segments, want %d", len(segments), tt.wantCount)
+			}
+
+			if segments[0].Start != 0 || segments[len(segments)-1].End != len(tt.transcript) {
+				t.Fatalf("segments cover %d-%d, want 0-%d", segments[0].Start, segments[len(segments)-1].End, len(tt.transcript))
+			}
+			for i, segment := range segments {
+				if segment.Text != tt.transcript[segment.Start:segment.End] {
+					t.Errorf("segment %d text does not match its offsets", i)
+				}
+				if len(segment.Text) > tt.size {
+					t.Errorf("segment %d is %d characters, longer than %d", i, len(segment.Text), tt.size)
+				}
+				if tt.wantWhole && segment.End != len(tt.transcript) && !strings.HasSuffix(segment.Text, "\n") {
+					t.Errorf("segment %d ends mid-turn: %q", i, segment.Text)
+				}
+				if i == 0 {
+					continue
+				}
+				previous := segments[i-1]
+				if segment.Start <= previous.Start || segment.Start > previous.End {
+					t.Errorf("segment %d starts at %d, want after %d and no later than %d", i, segment.Start, previous.Start, previous.End)
+				}
+			}
+		})
+	}
+}
+
+func TestSegmentTranscriptOverlap(t *testing.T) {
+	transcript := strings.Repeat("Staff: We have ten beds tonight.\n", 10)
+	segments := segmentTranscript(transcript, 100, 40)
+
+	for i := 1; i < len(segments); i++ {
+		if overlap := segments[i-1].End - segments[i].Start; overlap <= 0 {
+			t.Errorf("segments %d and %d do not overlap", i-1, i)
+		}
+	}
+}
+
+func TestSegmentChars(t *testing.T) {
+	tests := []struct {
+		value string
+		want  int
+	}{
+		{"", defaultSegmentChars},
+		{"5000", 5000},
+		{"2000", 2000},
+		{"1999", defaultSegmentChars},
+		{"lots", defaultSegmentChars},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			t.Setenv("TRANSCRIPT_SEGMENT_CHARS", tt.value)
+			if got := segmentChars(); got != tt.want {
+				t.Errorf("segmentChars() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSegmentBoundary(t *testing.T) {
+	transcript := "First turn.\nSecond turn. Still second! Third"
+
+	tests := []struct {
+		name       string
+		start, end int
+		want       string // Text before the boundary
+	}{
+		{name: "line break", start: 0, end: 20, want: "First turn.\n"},
+		{name: "sentence end", start: 12, end: 40, want: "First turn.\nSecond turn. Still second! "},
+		{name: "space", start: 12, end: 22, want: "First turn.\nSecond "},
+		{name: "empty window", start: 10, end: 10, want: "First turn"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := transcript[:segmentBoundary(transcript, tt.start, tt.end)]
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("boundary leaves %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
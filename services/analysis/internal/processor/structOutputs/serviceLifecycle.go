@@ -0,0 +1,356 @@
+package structOutputs
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/david-botos/BearHug/services/analysis/internal/hsds_types"
+	"github.com/david-botos/BearHug/services/analysis/internal/processor/inference"
+	"github.com/david-botos/BearHug/services/analysis/internal/supabase"
+	"github.com/david-botos/BearHug/services/analysis/pkg/logger"
+)
+
+// LifecycleChangeType describes how a service's availability changed according to the transcript
+type LifecycleChangeType string
+
+const (
+	LifecycleDiscontinued LifecycleChangeType = "DISCONTINUED" // Permanently stopped -> defunct
+	LifecyclePaused       LifecycleChangeType = "PAUSED"       // Temporarily stopped -> temporarily closed
+	LifecycleInactive     LifecycleChangeType = "INACTIVE"     // Not currently offered, no return planned -> inactive
+	LifecycleResumed      LifecycleChangeType = "RESUMED"      // Offered again -> active
+)
+
+// targetStatus maps each lifecycle change onto the HSDS service status it results in
+var targetStatus = map[LifecycleChangeType]hsds_types.ServiceStatusEnum{
+	LifecycleDiscontinued: hsds_types.ServiceStatusDefunct,
+	LifecyclePaused:       hsds_types.ServiceStatusTemporarilyClosed,
+	LifecycleInactive:     hsds_types.ServiceStatusInactive,
+	LifecycleResumed:      hsds_types.ServiceStatusActive,
+}
+
+func GenerateServiceLifecyclePrompt(transcript string, existingServices []hsds_types.Service) (string, inference.ToolInputSchema) {
+	var servicesList strings.Builder
+	for _, service := range existingServices {
+		servicesList.WriteString(fmt.Sprintf("Service ID: %s\n", service.ID))
+		servicesList.WriteString(fmt.Sprintf("Name: %s\n", service.Name))
+		if service.AlternateName != nil {
+			servicesList.WriteString(fmt.Sprintf("Also known as: %s\n", *service.AlternateName))
+		}
+		servicesList.WriteString(fmt.Sprintf("Current Status: %s\n", service.Status))
+		if service.Alert != nil {
+			servicesList.WriteString(fmt.Sprintf("Current Alert: %s\n", *service.Alert))
+		}
+		servicesList.WriteString("\n")
+	}
+
+	prompt := fmt.Sprintf(`You are reviewing a conversation with a community organization to detect changes in whether their previously documented services are still being offered.
+
+Today's date is %s.
+
+Previously documented services:
+%s
+Transcript:
+%s
+
+Identify any documented service whose availability changed according to the transcript:
+- DISCONTINUED: the organization permanently stopped offering the service (e.g., "we stopped doing the food pantry last spring")
+- PAUSED: the service is temporarily suspended and expected to come back
+- INACTIVE: the service is not currently offered but there is no indication of whether it will return
+- RESUMED: a service that is not currently active is being offered again
+
+Rules:
+1. Only report services from the list above, using their exact Service ID
+2. Only report a change when the transcript clearly supports it, and quote the supporting words in evidence
+3. For PAUSED services, include the expected return date in YYYY-MM-DD format if one was given, resolving relative dates against today's date
+4. Do not report services whose status is unchanged
+5. Language:
+%s
+
+IMPORTANT: You must ONLY respond by using the service_status_changes tool to output the structured data. Do not provide any explanatory text.`,
+		time.Now().Format("2006-01-02"), servicesList.String(), transcript, languageRules)
+
+	return prompt, ServiceLifecycleSchema
+}
+
+var ServiceLifecycleSchema = inference.ToolInputSchema{
+	Type: "object",
+	Properties: map[string]inference.Property{
+		"status_changes": {
+			Type:        "array",
+			Description: "Services whose availability changed according to the transcript",
+			Items: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"service_id": map[string]interface{}{
+						"type":        "string",
+						"description": "The Service ID of the previously documented service",
+					},
+					"change_type": map[string]interface{}{
+						"type": "string",
+						"enum": []string{
+							string(LifecycleDiscontinued),
+							string(LifecyclePaused),
+							string(LifecycleInactive),
+							string(LifecycleResumed),
+						},
+					},
+					"evidence": map[string]interface{}{
+						"type":        "string",
+						"description": "Quote from the transcript supporting the change",
+					},
+					"reason": map[string]interface{}{
+						"type":        "string",
+						"description": "Short explanation of why the service changed, if given (e.g., 'funding ended')",
+					},
+					"expected_return_date": map[string]interface{}{
+						"type":        "string",
+						"description": "For paused services, the date the service is expected to resume in YYYY-MM-DD format",
+					},
+				},
+				"required": []string{"service_id", "change_type", "evidence"},
+			},
+		},
+	},
+	Required: []string{"status_changes"},
+}
+
+// ServiceStatusChange is a single lifecycle change detected in the transcript
+type ServiceStatusChange struct {
+	ServiceID          string              `json:"service_id"`
+	ChangeType         LifecycleChangeType `json:"change_type"`
+	Evidence           string              `json:"evidence"`
+	Reason             *string             `json:"reason,omitempty"`
+	ExpectedReturnDate *string             `json:"expected_return_date,omitempty"`
+}
+
+type serviceLifecycleOutput struct {
+	StatusChanges []ServiceStatusChange `json:"status_changes"`
+}
+
+// DetectServiceLifecycleChanges asks the model which of the organization's documented services
+// were discontinued, paused or resumed during the call
+func DetectServiceLifecycleChanges(organizationID string, transcript string) ([]ServiceStatusChange, []hsds_types.Service, error) {
+	log := logger.Get()
+	log.Info().
+		Str("organization_id", organizationID).
+		Msg("Starting service lifecycle detection")
+
+	existingServices, err := supabase.FetchOrganizationServices(organizationID)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Str("organization_id", organizationID).
+			Msg("Failed to fetch existing services")
+		return nil, nil, fmt.Errorf("failed to fetch existing services: %w", err)
+	}
+
+	if len(existingServices) == 0 {
+		log.Debug().Msg("No documented services, skipping lifecycle detection")
+		return nil, existingServices, nil
+	}
+
+	prompt, schema := GenerateServiceLifecyclePrompt(transcript, existingServices)
+
+	client, err := inference.InitInferenceClient()
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to initialize inference client")
+		return nil, nil, fmt.Errorf("failed to initialize inference client: %w", err)
+	}
+
+	inferenceResult, err := client.RunClaudeInference(inference.PromptParams{Prompt: prompt, Schema: schema})
+	if err != nil {
+		log.Error().Err(err).Msg("Error during lifecycle inference")
+		return nil, nil, fmt.Errorf("error running lifecycle inference: %w", err)
+	}
+
+	jsonData, err := json.Marshal(inferenceResult)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error marshaling inference result: %w", err)
+	}
+
+	var output serviceLifecycleOutput
+	if err := json.Unmarshal(jsonData, &output); err != nil {
+		log.Error().Err(err).Msg("Failed to unmarshal lifecycle output")
+		return nil, nil, fmt.Errorf("error unmarshaling lifecycle output: %w", err)
+	}
+
+	log.Info().
+		Int("status_changes_count", len(output.StatusChanges)).
+		Msg("Service lifecycle detection completed")
+
+	return output.StatusChanges, existingServices, nil
+}
+
+// ApplyServiceLifecycleChanges writes detected status transitions to the service table, sets or clears
+// the service alert, and records metadata for every changed field. Services in serviceCtx are updated
+// in place so later stages see the new status.
+func ApplyServiceLifecycleChanges(changes []ServiceStatusChange, existingServices []hsds_types.Service, serviceCtx *ServiceContext, callID string) error {
+	log := logger.Get()
+	if len(changes) == 0 {
+		return nil
+	}
+
+	serviceByID := make(map[string]*hsds_types.Service, len(existingServices))
+	for i := range existingServices {
+		serviceByID[existingServices[i].ID] = &existingServices[i]
+	}
+
+	client, err := supabase.InitSupabaseClient()
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to initialize Supabase client")
+		return fmt.Errorf("failed to initialize Supabase client: %w", err)
+	}
+
+	for _, change := range changes {
+		existing, ok := serviceByID[change.ServiceID]
+		if !ok {
+			log.Warn().
+				Str("service_id", change.ServiceID).
+				Str("change_type", string(change.ChangeType)).
+				Msg("Lifecycle change references unknown service, skipping")
+			continue
+		}
+
+		newStatus, ok := targetStatus[change.ChangeType]
+		if !ok {
+			log.Warn().
+				Str("service_id", change.ServiceID).
+				Str("change_type", string(change.ChangeType)).
+				Msg("Unknown lifecycle change type, skipping")
+			continue
+		}
+
+		updateData, metadataInputs := buildLifecycleUpdate(existing, change, newStatus, callID)
+		if len(updateData) == 0 {
+			log.Debug().
+				Str("service_id", existing.ID).
+				Str("status", string(existing.Status)).
+				Msg("Service already has the detected status")
+			continue
+		}
+		updateData["last_modified"] = time.Now()
+
+		data, _, err := client.From("service").
+			Update(updateData, "", "").
+			Eq("id", existing.ID).
+			Execute()
+		if err != nil {
+			log.Error().
+				Err(err).
+				Str("service_id", existing.ID).
+				Str("response_data", string(data)).
+				Msg("Failed to update service status")
+			return fmt.Errorf("failed to update status for service %s: %w", existing.ID, err)
+		}
+
+		if err := supabase.CreateAndStoreMetadata(metadataInputs); err != nil {
+			log.Error().
+				Err(err).
+				Str("service_id", existing.ID).
+				Msg("Failed to create lifecycle metadata")
+			return fmt.Errorf("failed to create lifecycle metadata for service %s: %w", existing.ID, err)
+		}
+
+		syncServiceContextStatus(serviceCtx, existing.ID, newStatus, updateData)
+
+		log.Info().
+			Str("service_id", existing.ID).
+			Str("service_name", existing.Name).
+			Str("previous_status", string(existing.Status)).
+			Str("new_status", string(newStatus)).
+			Str("evidence", change.Evidence).
+			Msg("Applied service lifecycle change")
+	}
+
+	return nil
+}
+
+// buildLifecycleUpdate works out the status and alert changes for a single service
+func buildLifecycleUpdate(existing *hsds_types.Service, change ServiceStatusChange, newStatus hsds_types.ServiceStatusEnum, callID string) (map[string]interface{}, []supabase.MetadataInput) {
+	updateData := make(map[string]interface{})
+	var metadataInputs []supabase.MetadataInput
+
+	if existing.Status != newStatus {
+		updateData["status"] = newStatus
+		metadataInputs = append(metadataInputs, supabase.MetadataInput{
+			ResourceID:       existing.ID,
+			CallID:           callID,
+			ResourceType:     "service",
+			FieldName:        "status",
+			PreviousValue:    string(existing.Status),
+			ReplacementValue: string(newStatus),
+			LastActionType:   "UPDATE",
+		})
+	}
+
+	newAlert := lifecycleAlert(change)
+	previousAlert := getStringValue(existing.Alert)
+	switch {
+	case newAlert != nil && *newAlert != previousAlert:
+		updateData["alert"] = *newAlert
+		metadataInputs = append(metadataInputs, supabase.MetadataInput{
+			ResourceID:       existing.ID,
+			CallID:           callID,
+			ResourceType:     "service",
+			FieldName:        "alert",
+			PreviousValue:    previousAlert,
+			ReplacementValue: *newAlert,
+			LastActionType:   "UPDATE",
+		})
+	case change.ChangeType == LifecycleResumed && existing.Alert != nil:
+		// The suspension notice no longer applies once the service is back
+		updateData["alert"] = nil
+		metadataInputs = append(metadataInputs, supabase.MetadataInput{
+			ResourceID:       existing.ID,
+			CallID:           callID,
+			ResourceType:     "service",
+			FieldName:        "alert",
+			PreviousValue:    previousAlert,
+			ReplacementValue: nil,
+			LastActionType:   "UPDATE",
+		})
+	}
+
+	return updateData, metadataInputs
+}
+
+// lifecycleAlert builds the alert text for a paused service. Other changes don't set an alert.
+func lifecycleAlert(change ServiceStatusChange) *string {
+	if change.ChangeType != LifecyclePaused {
+		return nil
+	}
+
+	alert := "Service temporarily suspended"
+	if change.Reason != nil && strings.TrimSpace(*change.Reason) != "" {
+		alert += " (" + strings.TrimSpace(*change.Reason) + ")"
+	}
+	if change.ExpectedReturnDate != nil {
+		if returnDate, err := time.Parse("2006-01-02", strings.TrimSpace(*change.ExpectedReturnDate)); err == nil {
+			alert += fmt.Sprintf(", expected to resume %s", returnDate.Format("January 2, 2006"))
+		}
+	}
+	alert += "."
+	return &alert
+}
+
+// syncServiceContextStatus mirrors an applied lifecycle update onto the matching service in the context
+func syncServiceContextStatus(serviceCtx *ServiceContext, serviceID string, newStatus hsds_types.ServiceStatusEnum, updateData map[string]interface{}) {
+	if serviceCtx == nil {
+		return
+	}
+	for _, service := range serviceCtx.ExistingServices {
+		if service.ID != serviceID {
+			continue
+		}
+		service.Status = newStatus
+		if alert, ok := updateData["alert"]; ok {
+			if alertStr, isStr := alert.(string); isStr {
+				service.Alert = &alertStr
+			} else {
+				service.Alert = nil
+			}
+		}
+	}
+}
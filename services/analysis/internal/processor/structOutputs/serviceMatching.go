@@ -0,0 +1,504 @@
+package structOutputs
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/david-botos/BearHug/services/analysis/internal/hsds_types"
+	"github.com/david-botos/BearHug/services/analysis/internal/processor/inference"
+	"github.com/david-botos/BearHug/services/analysis/internal/supabase"
+	"github.com/david-botos/BearHug/services/analysis/pkg/logger"
+)
+
+// ServiceMatchOutcome is the verdict a ServiceMatcher reaches for a single candidate
+type ServiceMatchOutcome string
+
+const (
+	ServiceMatchConfirmed ServiceMatchOutcome = "MATCH"     // Candidate is the same service as Best
+	ServiceMatchAmbiguous ServiceMatchOutcome = "AMBIGUOUS" // Needs a human to decide
+	ServiceMatchNone      ServiceMatchOutcome = "NONE"      // Candidate is a new service
+)
+
+// ServiceMatchCandidate is the information available about a service mentioned in a transcript
+type ServiceMatchCandidate struct {
+	Name          string
+	AlternateName *string
+	Description   string
+}
+
+// ServiceMatchScore records how strongly a candidate resembles one documented service
+type ServiceMatchScore struct {
+	Service          *hsds_types.Service
+	NameScore        float64
+	DescriptionScore *float64 // Nil when either side has no description
+	Confidence       float64
+}
+
+// ServiceMatchDecision is the result of matching a candidate against a list of services
+type ServiceMatchDecision struct {
+	Candidate  ServiceMatchCandidate
+	Outcome    ServiceMatchOutcome
+	Best       *hsds_types.Service // Set when Outcome is ServiceMatchConfirmed
+	Confidence float64
+	Scores     []ServiceMatchScore // All scored services, highest confidence first
+	Reason     string
+}
+
+// ServiceMatcher decides whether a mentioned service is one the organization already has documented
+type ServiceMatcher interface {
+	MatchService(candidate ServiceMatchCandidate, services []*hsds_types.Service) (ServiceMatchDecision, error)
+}
+
+// HybridServiceMatcher scores services by normalized name tokens and description similarity.
+// Descriptions are compared with a local embedding model when one is configured and by word
+// overlap otherwise. Scores that land between the thresholds are sent to the Adjudicator, or
+// surfaced as ambiguous when no adjudicator is configured.
+type HybridServiceMatcher struct {
+	Embedder    *inference.EmbeddingClient
+	Adjudicator *LLMServiceAdjudicator
+
+	MatchThreshold float64 // Confidence at or above which a match is accepted
+	NoneThreshold  float64 // Confidence below which the candidate is treated as new
+	MinMargin      float64 // Required gap between the best and second best score
+}
+
+// NewServiceMatcher builds the matcher used by the pipeline from the environment.
+// EMBEDDING_API_URL enables embedding similarity and SERVICE_MATCH_ADJUDICATION=llm enables
+// Claude adjudication of borderline scores.
+func NewServiceMatcher() ServiceMatcher {
+	log := logger.Get()
+
+	matcher := &HybridServiceMatcher{
+		MatchThreshold: 0.75,
+		NoneThreshold:  0.45,
+		MinMargin:      0.1,
+	}
+
+	embedder, err := inference.InitEmbeddingClient()
+	if err != nil {
+		log.Warn().Err(err).Msg("Embedding client unavailable, falling back to lexical description similarity")
+	}
+	matcher.Embedder = embedder
+
+	if strings.EqualFold(os.Getenv("SERVICE_MATCH_ADJUDICATION"), "llm") {
+		matcher.Adjudicator = &LLMServiceAdjudicator{}
+	}
+
+	return matcher
+}
+
+func (m *HybridServiceMatcher) MatchService(candidate ServiceMatchCandidate, services []*hsds_types.Service) (ServiceMatchDecision, error) {
+	log := logger.Get()
+
+	decision := ServiceMatchDecision{
+		Candidate: candidate,
+		Outcome:   ServiceMatchNone,
+	}
+	if len(services) == 0 {
+		decision.Reason = "no documented services to compare against"
+		return decision, nil
+	}
+
+	descScores, err := m.descriptionScores(candidate, services)
+	if err != nil {
+		return ServiceMatchDecision{}, err
+	}
+
+	scores := make([]ServiceMatchScore, 0, len(services))
+	for i, svc := range services {
+		nameScore := serviceNameScore(candidate, svc)
+		score := ServiceMatchScore{
+			Service:          svc,
+			NameScore:        nameScore,
+			DescriptionScore: descScores[i],
+			Confidence:       nameScore,
+		}
+		// Exact name matches are trusted regardless of description drift
+		if nameScore < 1 && descScores[i] != nil {
+			score.Confidence = 0.55*nameScore + 0.45*(*descScores[i])
+		}
+		scores = append(scores, score)
+	}
+
+	sort.SliceStable(scores, func(i, j int) bool {
+		return scores[i].Confidence > scores[j].Confidence
+	})
+	decision.Scores = scores
+	decision.Confidence = scores[0].Confidence
+
+	best := scores[0]
+	margin := best.Confidence
+	if len(scores) > 1 {
+		margin = best.Confidence - scores[1].Confidence
+	}
+
+	switch {
+	case best.Confidence < m.NoneThreshold:
+		decision.Reason = fmt.Sprintf("best score %.2f below %.2f", best.Confidence, m.NoneThreshold)
+	case best.Confidence >= m.MatchThreshold && margin >= m.MinMargin:
+		decision.Outcome = ServiceMatchConfirmed
+		decision.Best = best.Service
+		decision.Reason = fmt.Sprintf("score %.2f with margin %.2f", best.Confidence, margin)
+	default:
+		decision.Outcome = ServiceMatchAmbiguous
+		decision.Reason = fmt.Sprintf("score %.2f with margin %.2f is inconclusive", best.Confidence, margin)
+		if m.Adjudicator != nil {
+			adjudicated, err := m.Adjudicator.Adjudicate(candidate, topScores(scores, 3))
+			if err != nil {
+				// Leave the decision ambiguous so it still goes to review
+				log.Warn().
+					Err(err).
+					Str("service_name", candidate.Name).
+					Msg("Service match adjudication failed")
+			} else {
+				decision = adjudicated
+				decision.Scores = scores
+			}
+		}
+	}
+
+	log.Debug().
+		Str("service_name", candidate.Name).
+		Str("outcome", string(decision.Outcome)).
+		Float64("confidence", decision.Confidence).
+		Str("reason", decision.Reason).
+		Msg("Service match decision")
+
+	return decision, nil
+}
+
+// descriptionScores returns a similarity per service, or nil where a description is missing
+func (m *HybridServiceMatcher) descriptionScores(candidate ServiceMatchCandidate, services []*hsds_types.Service) ([]*float64, error) {
+	log := logger.Get()
+	scores := make([]*float64, len(services))
+
+	candidateDesc := strings.TrimSpace(candidate.Description)
+	if candidateDesc == "" {
+		return scores, nil
+	}
+
+	if m.Embedder != nil {
+		texts := []string{candidateDesc}
+		indexes := make([]int, 0, len(services))
+		for i, svc := range services {
+			if svc.Description != nil && strings.TrimSpace(*svc.Description) != "" {
+				texts = append(texts, *svc.Description)
+				indexes = append(indexes, i)
+			}
+		}
+
+		vectors, err := m.Embedder.Embed(texts)
+		if err == nil {
+			for j, serviceIdx := range indexes {
+				sim := math.Max(0, cosineSimilarity(vectors[0], vectors[j+1]))
+				scores[serviceIdx] = &sim
+			}
+			return scores, nil
+		}
+		log.Warn().Err(err).Msg("Embedding request failed, falling back to lexical description similarity")
+	}
+
+	candidateTokens := tokenCounts(candidateDesc)
+	for i, svc := range services {
+		if svc.Description == nil || strings.TrimSpace(*svc.Description) == "" {
+			continue
+		}
+		sim := countCosine(candidateTokens, tokenCounts(*svc.Description))
+		scores[i] = &sim
+	}
+	return scores, nil
+}
+
+// qualifierTokens are words that distinguish otherwise similar services for different populations
+// or delivery modes, e.g. "Youth Counseling" vs "Adult Counseling"
+var qualifierTokens = map[string]bool{
+	"youth": true, "adult": true, "child": true, "children": true, "kid": true, "teen": true,
+	"senior": true, "elder": true, "men": true, "women": true, "family": true, "individual": true,
+	"group": true, "veteran": true, "infant": true, "parent": true, "student": true,
+	"online": true, "virtual": true, "inperson": true, "emergency": true, "transitional": true,
+	"permanent": true, "day": true, "overnight": true, "residential": true, "outpatient": true,
+}
+
+// serviceStopwords are dropped before comparing names since they appear in most service names
+var serviceStopwords = map[string]bool{
+	"the": true, "and": true, "of": true, "for": true, "a": true, "an": true, "to": true,
+	"in": true, "at": true, "with": true, "service": true, "program": true, "assistance": true,
+}
+
+// serviceNameScore compares the candidate's names to the service's names and returns the best score
+func serviceNameScore(candidate ServiceMatchCandidate, svc *hsds_types.Service) float64 {
+	candidateNames := []string{candidate.Name}
+	if candidate.AlternateName != nil {
+		candidateNames = append(candidateNames, *candidate.AlternateName)
+	}
+	serviceNames := []string{svc.Name}
+	if svc.AlternateName != nil {
+		serviceNames = append(serviceNames, *svc.AlternateName)
+	}
+
+	best := 0.0
+	for _, a := range candidateNames {
+		for _, b := range serviceNames {
+			if s := nameSimilarity(a, b); s > best {
+				best = s
+			}
+		}
+	}
+	return best
+}
+
+// nameSimilarity blends token overlap with character level similarity and penalizes names that
+// carry conflicting population or delivery qualifiers
+func nameSimilarity(a, b string) float64 {
+	tokensA := normalizeServiceTokens(a)
+	tokensB := normalizeServiceTokens(b)
+	if len(tokensA) == 0 || len(tokensB) == 0 {
+		return 0
+	}
+
+	joinedA := strings.Join(tokensA, " ")
+	joinedB := strings.Join(tokensB, " ")
+	if joinedA == joinedB {
+		return 1
+	}
+
+	setA := toSet(tokensA)
+	setB := toSet(tokensB)
+	shared := 0
+	for t := range setA {
+		if setB[t] {
+			shared++
+		}
+	}
+	dice := 2 * float64(shared) / float64(len(setA)+len(setB))
+	charSim := calculateStringSimilarity(joinedA, joinedB)
+	score := 0.6*dice + 0.4*charSim
+
+	if hasConflictingQualifiers(setA, setB) {
+		score *= 0.5
+	}
+	return score
+}
+
+func hasConflictingQualifiers(a, b map[string]bool) bool {
+	onlyA, onlyB := false, false
+	for t := range a {
+		if qualifierTokens[t] && !b[t] {
+			onlyA = true
+		}
+	}
+	for t := range b {
+		if qualifierTokens[t] && !a[t] {
+			onlyB = true
+		}
+	}
+	return onlyA && onlyB
+}
+
+// normalizeServiceTokens lowercases, strips punctuation, drops stopwords and naive-stems plurals
+func normalizeServiceTokens(s string) []string {
+	s = strings.ToLower(s)
+	for _, spelling := range []string{"in-person", "in person"} {
+		s = strings.ReplaceAll(s, spelling, "inperson")
+	}
+	fields := strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	tokens := make([]string, 0, len(fields))
+	for _, f := range fields {
+		f = stemToken(f)
+		if serviceStopwords[f] {
+			continue
+		}
+		tokens = append(tokens, f)
+	}
+	return tokens
+}
+
+func stemToken(t string) string {
+	switch {
+	case len(t) > 4 && strings.HasSuffix(t, "ies"):
+		return t[:len(t)-3] + "y"
+	case len(t) > 3 && strings.HasSuffix(t, "s") && !strings.HasSuffix(t, "ss"):
+		return t[:len(t)-1]
+	}
+	return t
+}
+
+func toSet(tokens []string) map[string]bool {
+	set := make(map[string]bool, len(tokens))
+	for _, t := range tokens {
+		set[t] = true
+	}
+	return set
+}
+
+func tokenCounts(s string) map[string]float64 {
+	counts := make(map[string]float64)
+	for _, t := range normalizeServiceTokens(s) {
+		if len(t) > 2 {
+			counts[t]++
+		}
+	}
+	return counts
+}
+
+func countCosine(a, b map[string]float64) float64 {
+	var dot, normA, normB float64
+	for t, v := range a {
+		dot += v * b[t]
+		normA += v * v
+	}
+	for _, v := range b {
+		normB += v * v
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
+
+func cosineSimilarity(a, b []float64) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += a[i] * b[i]
+		normA += a[i] * a[i]
+		normB += b[i] * b[i]
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
+
+// reviewCandidates lists the services a reviewer should choose between for an ambiguous decision
+func reviewCandidates(decision ServiceMatchDecision) []supabase.ServiceMatchCandidateInput {
+	candidates := make([]supabase.ServiceMatchCandidateInput, 0, len(decision.Scores))
+	for _, score := range topScores(decision.Scores, 3) {
+		candidates = append(candidates, supabase.ServiceMatchCandidateInput{
+			ServiceID:   score.Service.ID,
+			ServiceName: score.Service.Name,
+			Confidence:  score.Confidence,
+			NameScore:   score.NameScore,
+			DescScore:   score.DescriptionScore,
+		})
+	}
+	return candidates
+}
+
+func topScores(scores []ServiceMatchScore, n int) []ServiceMatchScore {
+	if len(scores) < n {
+		return scores
+	}
+	return scores[:n]
+}
+
+// LLMServiceAdjudicator asks Claude to settle borderline service matches
+type LLMServiceAdjudicator struct{}
+
+var ServiceAdjudicationSchema = inference.ToolInputSchema{
+	Type: "object",
+	Properties: map[string]inference.Property{
+		"matched_service_id": {
+			Type:        "string",
+			Description: "The Service ID of the documented service that is the same service as the mentioned one, or 'NONE' if it is a different service, or 'UNSURE' if it cannot be determined",
+		},
+		"confidence": {
+			Type:        "number",
+			Description: "Confidence between 0.0 and 1.0 in the decision",
+		},
+		"reasoning": {
+			Type:        "string",
+			Description: "Short explanation of the decision",
+		},
+	},
+	Required: []string{"matched_service_id", "confidence", "reasoning"},
+}
+
+type serviceAdjudication struct {
+	MatchedServiceID string  `json:"matched_service_id"`
+	Confidence       float64 `json:"confidence"`
+	Reasoning        string  `json:"reasoning"`
+}
+
+func (a *LLMServiceAdjudicator) Adjudicate(candidate ServiceMatchCandidate, scores []ServiceMatchScore) (ServiceMatchDecision, error) {
+	var candidatesText strings.Builder
+	for _, score := range scores {
+		writeServiceDescription(&candidatesText, *score.Service)
+	}
+
+	alternateName := "none"
+	if candidate.AlternateName != nil {
+		alternateName = *candidate.AlternateName
+	}
+
+	prompt := fmt.Sprintf(`A community organization mentioned a service during a phone call. Decide whether it is the same service as one of their previously documented services.
+
+Mentioned service:
+Name: %s
+Also known as: %s
+Description: %s
+
+Documented services:
+%s
+Two services are the same only if they serve the same population in the same way. Services for different populations (e.g., youth vs adult) or with different delivery (e.g., group vs individual) are different services even if their names are similar. Differently named services can be the same if the descriptions show they are the same offering.
+
+IMPORTANT: You must ONLY respond by using the service_adjudication tool to output the structured data.`,
+		candidate.Name, alternateName, candidate.Description, candidatesText.String())
+
+	client, err := inference.InitInferenceClient()
+	if err != nil {
+		return ServiceMatchDecision{}, fmt.Errorf("failed to initialize inference client: %w", err)
+	}
+
+	result, err := client.RunClaudeInference(inference.PromptParams{Prompt: prompt, Schema: ServiceAdjudicationSchema})
+	if err != nil {
+		return ServiceMatchDecision{}, fmt.Errorf("error running adjudication inference: %w", err)
+	}
+
+	jsonData, err := json.Marshal(result)
+	if err != nil {
+		return ServiceMatchDecision{}, fmt.Errorf("error marshaling adjudication result: %w", err)
+	}
+	var adjudication serviceAdjudication
+	if err := json.Unmarshal(jsonData, &adjudication); err != nil {
+		return ServiceMatchDecision{}, fmt.Errorf("error unmarshaling adjudication result: %w", err)
+	}
+
+	decision := ServiceMatchDecision{
+		Candidate:  candidate,
+		Outcome:    ServiceMatchAmbiguous,
+		Confidence: adjudication.Confidence,
+		Reason:     "llm: " + adjudication.Reasoning,
+	}
+
+	// Low confidence verdicts still go to review
+	if adjudication.Confidence < 0.7 {
+		return decision, nil
+	}
+
+	switch adjudication.MatchedServiceID {
+	case "NONE":
+		decision.Outcome = ServiceMatchNone
+	case "UNSURE", "":
+	default:
+		for _, score := range scores {
+			if score.Service.ID == adjudication.MatchedServiceID {
+				decision.Outcome = ServiceMatchConfirmed
+				decision.Best = score.Service
+				break
+			}
+		}
+	}
+
+	return decision, nil
+}
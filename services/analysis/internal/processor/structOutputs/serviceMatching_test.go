@@ -0,0 +1,241 @@
+package structOutputs
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/david-botos/BearHug/services/analysis/internal/hsds_types"
+)
+
+func serviceFixture(id, name, description string) *hsds_types.Service {
+	service := &hsds_types.Service{ID: id, Name: name}
+	if description != "" {
+		service.Description = &description
+	}
+	return service
+}
+
+func TestMatchService(t *testing.T) {
+	// No embedder or adjudicator, so descriptions are compared by word overlap and borderline scores stay ambiguous
+	matcher := &HybridServiceMatcher{MatchThreshold: 0.75, NoneThreshold: 0.45, MinMargin: 0.1}
+
+	tests := []struct {
+		name      string
+		candidate ServiceMatchCandidate
+		services  []*hsds_types.Service
+		want      ServiceMatchOutcome
+		wantBest  string
+	}{
+		{
+			name: "differently named service with the same description goes to review",
+			candidate: ServiceMatchCandidate{
+				Name:        "Food Pantry",
+				Description: "Weekly groceries for families in need, pick up canned food and produce",
+			},
+			services: []*hsds_types.Service{
+				serviceFixture("food-box", "Emergency Food Box", "Boxes of canned food and produce for families in need, pick up weekly"),
+			},
+			want: ServiceMatchAmbiguous,
+		},
+		{
+			name:      "different populations are not merged",
+			candidate: ServiceMatchCandidate{Name: "Youth Counseling", Description: "Counseling sessions for teens"},
+			services:  []*hsds_types.Service{serviceFixture("adult", "Adult Counseling", "Counseling sessions for adults")},
+			want:      ServiceMatchAmbiguous,
+		},
+		{
+			name:      "different populations without descriptions are new",
+			candidate: ServiceMatchCandidate{Name: "Youth Counseling"},
+			services:  []*hsds_types.Service{serviceFixture("adult", "Adult Counseling", "")},
+			want:      ServiceMatchNone,
+		},
+		{
+			name:      "plural name",
+			candidate: ServiceMatchCandidate{Name: "Food Pantries"},
+			services: []*hsds_types.Service{
+				serviceFixture("pantry", "Food Pantry", ""),
+				serviceFixture("legal", "Legal Aid Clinic", ""),
+			},
+			want:     ServiceMatchConfirmed,
+			wantBest: "pantry",
+		},
+		{
+			name:      "alternate name",
+			candidate: ServiceMatchCandidate{Name: "The Pantry Program"},
+			services: []*hsds_types.Service{
+				{ID: "pantry", Name: "Community Food Distribution", AlternateName: func() *string { s := "Pantry"; return &s }()},
+			},
+			want:     ServiceMatchConfirmed,
+			wantBest: "pantry",
+		},
+		{
+			name:      "two services with the same name",
+			candidate: ServiceMatchCandidate{Name: "Food Pantry"},
+			services: []*hsds_types.Service{
+				serviceFixture("pantry-1", "Food Pantry", ""),
+				serviceFixture("pantry-2", "Food Pantry", ""),
+			},
+			want: ServiceMatchAmbiguous,
+		},
+		{
+			name:      "unrelated service",
+			candidate: ServiceMatchCandidate{Name: "Legal Aid Clinic"},
+			services:  []*hsds_types.Service{serviceFixture("pantry", "Food Pantry", "")},
+			want:      ServiceMatchNone,
+		},
+		{name: "no services", candidate: ServiceMatchCandidate{Name: "Food Pantry"}, want: ServiceMatchNone},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decision, err := matcher.MatchService(tt.candidate, tt.services)
+			if err != nil {
+				t.Fatalf("MatchService(): %v", err)
+			}
+			if decision.Outcome != tt.want {
+				t.Errorf("Outcome = %s (%s), want %s", decision.Outcome, decision.Reason, tt.want)
+			}
+			var best string
+			if decision.Best != nil {
+				best = decision.Best.ID
+			}
+			if best != tt.wantBest {
+				t.Errorf("Best = %q, want %q", best, tt.wantBest)
+			}
+		})
+	}
+}
+
+func TestNameSimilarity(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		min, max float64
+	}{
+		{"Food Pantry", "food pantries", 1, 1},
+		{"The Food Pantry Program", "Food Pantry", 1, 1},
+		{"In-Person Counseling", "in person counseling", 1, 1},
+		{"Food Pantry", "Mobile Food Pantry", 0.6, 0.9},
+		{"Youth Counseling", "Adult Counseling", 0, 0.35},
+		{"Youth Counseling", "Youth Group Counseling", 0.6, 0.9},
+		{"Food Pantry", "Emergency Food Box", 0, 0.45},
+		{"Food Pantry", "Legal Aid Clinic", 0, 0.2},
+		{"The Program", "Food Pantry", 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+"/"+tt.b, func(t *testing.T) {
+			got := nameSimilarity(tt.a, tt.b)
+			if got < tt.min || got > tt.max {
+				t.Errorf("nameSimilarity(%q, %q) = %.2f, want between %.2f and %.2f", tt.a, tt.b, got, tt.min, tt.max)
+			}
+			if reverse := nameSimilarity(tt.b, tt.a); reverse != got {
+				t.Errorf("nameSimilarity is not symmetric: %.2f and %.2f", got, reverse)
+			}
+		})
+	}
+}
+
+func TestHasConflictingQualifiers(t *testing.T) {
+	set := func(name string) map[string]bool { return toSet(normalizeServiceTokens(name)) }
+
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"Youth Counseling", "Adult Counseling", true},
+		{"Group Therapy", "Individual Therapy", true},
+		{"Online Classes", "In-Person Classes", true},
+		{"Online Classes", "In Person Classes", true},
+		{"Youth Counseling", "Counseling", false},
+		{"Youth Counseling", "Youth Group Counseling", false},
+		{"Youth Counseling", "Youth Counseling", false},
+		{"Food Pantry", "Emergency Food Box", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+"/"+tt.b, func(t *testing.T) {
+			if got := hasConflictingQualifiers(set(tt.a), set(tt.b)); got != tt.want {
+				t.Errorf("hasConflictingQualifiers(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReleaseDuplicateClaims(t *testing.T) {
+	pantry := serviceFixture("pantry", "Food Pantry", "")
+	shelter := serviceFixture("shelter", "Shelter", "")
+	confirmed := func(name string, service *hsds_types.Service, confidence float64) ServiceMatchDecision {
+		return ServiceMatchDecision{
+			Candidate:  ServiceMatchCandidate{Name: name},
+			Outcome:    ServiceMatchConfirmed,
+			Best:       service,
+			Confidence: confidence,
+		}
+	}
+
+	decisions := []ServiceMatchDecision{
+		confirmed("Pantry", pantry, 0.8),
+		confirmed("Food Pantry", pantry, 1),
+		confirmed("Shelter Beds", shelter, 0.9),
+		{Candidate: ServiceMatchCandidate{Name: "Clinic"}, Outcome: ServiceMatchNone},
+		confirmed("Food Pantries", pantry, 0.95),
+	}
+
+	releaseDuplicateClaims(decisions)
+
+	var outcomes []ServiceMatchOutcome
+	for _, decision := range decisions {
+		outcomes = append(outcomes, decision.Outcome)
+	}
+	want := []ServiceMatchOutcome{ServiceMatchAmbiguous, ServiceMatchConfirmed, ServiceMatchConfirmed, ServiceMatchNone, ServiceMatchAmbiguous}
+	if !reflect.DeepEqual(outcomes, want) {
+		t.Fatalf("outcomes = %v, want %v", outcomes, want)
+	}
+	if decisions[0].Best != nil || !strings.Contains(decisions[0].Reason, `"Food Pantry" matched`) {
+		t.Errorf("released decision = %+v, want no service and a reason naming the winner", decisions[0])
+	}
+	if decisions[1].Best != pantry || decisions[2].Best != shelter {
+		t.Errorf("kept decisions lost their service")
+	}
+}
+
+// fixedMatcher returns the same decision for every candidate
+type fixedMatcher struct {
+	decision ServiceMatchDecision
+}
+
+func (m fixedMatcher) MatchService(candidate ServiceMatchCandidate, services []*hsds_types.Service) (ServiceMatchDecision, error) {
+	return m.decision, nil
+}
+
+func TestFindMatchingService(t *testing.T) {
+	pantry := serviceFixture("pantry", "Food Pantry", "")
+	services := []*hsds_types.Service{pantry, serviceFixture("shelter", "Shelter", "")}
+	ambiguous := ServiceMatchDecision{Outcome: ServiceMatchAmbiguous, Reason: "score 0.60 with margin 0.02 is inconclusive"}
+
+	tests := []struct {
+		name          string
+		serviceName   string
+		decision      ServiceMatchDecision
+		wantService   *hsds_types.Service
+		wantAmbiguous bool
+	}{
+		{name: "exact name skips the matcher", serviceName: " food pantry", decision: ambiguous, wantService: pantry},
+		{name: "confirmed match", serviceName: "Pantry", decision: ServiceMatchDecision{Outcome: ServiceMatchConfirmed, Best: pantry}, wantService: pantry},
+		{name: "ambiguous match is returned for review", serviceName: "Beds", decision: ambiguous, wantAmbiguous: true},
+		{name: "no match", serviceName: "Clinic", decision: ServiceMatchDecision{Outcome: ServiceMatchNone}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, decision := findMatchingService(fixedMatcher{tt.decision}, capacityInference{ServiceName: tt.serviceName}, services)
+			if service != tt.wantService {
+				t.Errorf("service = %v, want %v", service, tt.wantService)
+			}
+			if (decision != nil) != tt.wantAmbiguous {
+				t.Errorf("ambiguous decision = %v, want %v", decision, tt.wantAmbiguous)
+			}
+		})
+	}
+}
@@ -0,0 +1,304 @@
+package structOutputs
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/david-botos/BearHug/services/analysis/internal/hsds_types"
+	"github.com/david-botos/BearHug/services/analysis/internal/processor/inference"
+	"github.com/david-botos/BearHug/services/analysis/pkg/logger"
+)
+
+// FieldMergeDecision is how newly extracted information relates to the stored value of a field
+type FieldMergeDecision string
+
+const (
+	MergeSame        FieldMergeDecision = "SAME"        // No new information, keep the stored value
+	MergeAdds        FieldMergeDecision = "ADDS"        // New information complements the stored value
+	MergeCorrects    FieldMergeDecision = "CORRECTS"    // New information supersedes the stored value
+	MergeContradicts FieldMergeDecision = "CONTRADICTS" // Conflicting information, needs review
+)
+
+// FieldMergeResult is the outcome of merging a single field
+type FieldMergeResult struct {
+	Field         string             `json:"field"`
+	Decision      FieldMergeDecision `json:"decision"`
+	StoredValue   interface{}        `json:"stored_value,omitempty"`
+	ProposedValue interface{}        `json:"proposed_value,omitempty"`
+	MergedValue   interface{}        `json:"merged_value,omitempty"`
+	Rationale     string             `json:"rationale"`
+}
+
+// ServiceMergePlan holds the field updates for a matched service and the conflicts left for review
+type ServiceMergePlan struct {
+	Changes    map[string]interface{}
+	Rationales map[string]string
+	Conflicts  []FieldMergeResult
+	Confirmed  []string // Fields the call mentioned with the value already stored
+}
+
+// textFieldPair links a service column to its stored and extracted text values
+type textFieldPair struct {
+	field     string
+	stored    *string
+	extracted *string
+}
+
+// planServiceMerge decides per field how the extracted service should update the stored one.
+// Fields the transcript didn't mention are never touched, empty stored fields are filled in, and
+// differing text is sent to Claude to decide whether it adds to, corrects or contradicts the stored value.
+func planServiceMerge(existing *hsds_types.Service, extracted *ExtractedService) ServiceMergePlan {
+	log := logger.Get()
+
+	plan := ServiceMergePlan{
+		Changes:    make(map[string]interface{}),
+		Rationales: make(map[string]string),
+	}
+	if extracted.Status != "" && extracted.Status == existing.Status {
+		plan.Confirmed = append(plan.Confirmed, "status")
+	}
+
+	description := extracted.Description
+	textFields := []textFieldPair{
+		{"description", existing.Description, &description},
+		{"alternate_name", existing.AlternateName, extracted.AlternateName},
+		{"interpretation_services", existing.InterpretationServices, extracted.InterpretationServices},
+		{"application_process", existing.ApplicationProcess, extracted.ApplicationProcess},
+		{"fees_description", existing.FeesDescription, extracted.FeesDescription},
+		{"accreditations", existing.Accreditations, extracted.Accreditations},
+		{"eligibility_description", existing.EligibilityDescription, extracted.EligibilityDescription},
+		{"wait_time", existing.WaitTime, extracted.WaitTime},
+		{"alert", existing.Alert, extracted.Alert},
+	}
+
+	// Identifiers are either right or wrong, so a new value replaces the old one outright
+	exactFields := []textFieldPair{
+		{"url", existing.URL, extracted.URL},
+		{"email", existing.Email, extracted.Email},
+	}
+
+	var toAdjudicate []textFieldPair
+	for _, pair := range textFields {
+		if pair.extracted == nil || strings.TrimSpace(*pair.extracted) == "" {
+			continue
+		}
+		switch {
+		case pair.stored == nil || strings.TrimSpace(*pair.stored) == "":
+			plan.Changes[pair.field] = *pair.extracted
+			plan.Rationales[pair.field] = "field was previously empty"
+		case normalizeForCompare(*pair.stored) != normalizeForCompare(*pair.extracted):
+			toAdjudicate = append(toAdjudicate, pair)
+		default:
+			plan.Confirmed = append(plan.Confirmed, pair.field)
+		}
+	}
+
+	for _, pair := range exactFields {
+		if pair.extracted == nil {
+			continue
+		}
+		if pair.stored == nil {
+			plan.Changes[pair.field] = *pair.extracted
+			plan.Rationales[pair.field] = "field was previously empty"
+		} else if !strings.EqualFold(*pair.stored, *pair.extracted) {
+			plan.Changes[pair.field] = *pair.extracted
+			plan.Rationales[pair.field] = fmt.Sprintf("caller gave a different %s", pair.field)
+		} else {
+			plan.Confirmed = append(plan.Confirmed, pair.field)
+		}
+	}
+
+	mergeAge := func(field string, stored, extracted *float64) {
+		if extracted == nil {
+			return
+		}
+		if stored == nil {
+			plan.Changes[field] = *extracted
+			plan.Rationales[field] = "field was previously empty"
+		} else if *stored != *extracted {
+			plan.Changes[field] = *extracted
+			plan.Rationales[field] = fmt.Sprintf("caller stated %v instead of %v", *extracted, *stored)
+		} else {
+			plan.Confirmed = append(plan.Confirmed, field)
+		}
+	}
+	mergeAge("minimum_age", existing.MinimumAge, extracted.MinimumAge)
+	mergeAge("maximum_age", existing.MaximumAge, extracted.MaximumAge)
+
+	if len(toAdjudicate) == 0 {
+		return plan
+	}
+
+	results, err := adjudicateFieldMerges(existing.Name, toAdjudicate)
+	if err != nil {
+		log.Warn().
+			Err(err).
+			Str("service_id", existing.ID).
+			Msg("Merge adjudication failed, falling back to containment rules")
+		results = fallbackFieldMerges(toAdjudicate)
+	}
+
+	for _, result := range results {
+		switch result.Decision {
+		case MergeAdds, MergeCorrects:
+			merged, ok := result.MergedValue.(string)
+			if !ok || strings.TrimSpace(merged) == "" {
+				log.Warn().
+					Str("service_id", existing.ID).
+					Str("field", result.Field).
+					Msg("Merge result had no merged value, leaving field unchanged")
+				continue
+			}
+			plan.Changes[result.Field] = merged
+			plan.Rationales[result.Field] = fmt.Sprintf("%s: %s", strings.ToLower(string(result.Decision)), result.Rationale)
+		case MergeContradicts:
+			plan.Conflicts = append(plan.Conflicts, result)
+		case MergeSame:
+			plan.Confirmed = append(plan.Confirmed, result.Field)
+		}
+	}
+
+	return plan
+}
+
+var ServiceMergeSchema = inference.ToolInputSchema{
+	Type: "object",
+	Properties: map[string]inference.Property{
+		"field_decisions": {
+			Type: "array",
+			Items: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"field": map[string]interface{}{
+						"type": "string",
+					},
+					"decision": map[string]interface{}{
+						"type": "string",
+						"enum": []string{
+							string(MergeSame),
+							string(MergeAdds),
+							string(MergeCorrects),
+							string(MergeContradicts),
+						},
+					},
+					"merged_value": map[string]interface{}{
+						"type":        "string",
+						"description": "The value to store for ADDS and CORRECTS decisions",
+					},
+					"rationale": map[string]interface{}{
+						"type":        "string",
+						"description": "One sentence explaining the decision",
+					},
+				},
+				"required": []string{"field", "decision", "rationale"},
+			},
+		},
+	},
+	Required: []string{"field_decisions"},
+}
+
+type fieldMergeOutput struct {
+	FieldDecisions []struct {
+		Field       string             `json:"field"`
+		Decision    FieldMergeDecision `json:"decision"`
+		MergedValue string             `json:"merged_value"`
+		Rationale   string             `json:"rationale"`
+	} `json:"field_decisions"`
+}
+
+func adjudicateFieldMerges(serviceName string, pairs []textFieldPair) ([]FieldMergeResult, error) {
+	var fieldsText strings.Builder
+	for _, pair := range pairs {
+		fieldsText.WriteString(fmt.Sprintf("Field: %s\nStored value: %s\nNew value from the latest call: %s\n\n", pair.field, *pair.stored, *pair.extracted))
+	}
+
+	prompt := fmt.Sprintf(`You maintain a directory of human services. A phone call with the organization produced new values for some fields of the service "%s". For each field decide how the new value relates to the stored value:
+
+- SAME: the new value says the same thing in different words, keep the stored value
+- ADDS: the new value contributes details missing from the stored value; write a merged_value that keeps every still-accurate stored detail and adds the new ones
+- CORRECTS: the new value updates or replaces outdated stored information; write the corrected merged_value
+- CONTRADICTS: the values conflict and it is unclear which is right; do not write a merged_value
+
+%s
+Keep merged values concise and in the same style as the stored value. Never drop stored details unless the new value explicitly replaces them.
+
+IMPORTANT: You must ONLY respond by using the service_merge tool to output the structured data.`, serviceName, fieldsText.String())
+
+	client, err := inference.InitInferenceClient()
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize inference client: %w", err)
+	}
+
+	result, err := client.RunClaudeInference(inference.PromptParams{Prompt: prompt, Schema: ServiceMergeSchema})
+	if err != nil {
+		return nil, fmt.Errorf("error running merge inference: %w", err)
+	}
+
+	jsonData, err := json.Marshal(result)
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling merge result: %w", err)
+	}
+	var output fieldMergeOutput
+	if err := json.Unmarshal(jsonData, &output); err != nil {
+		return nil, fmt.Errorf("error unmarshaling merge result: %w", err)
+	}
+
+	pairByField := make(map[string]textFieldPair, len(pairs))
+	for _, pair := range pairs {
+		pairByField[pair.field] = pair
+	}
+
+	results := make([]FieldMergeResult, 0, len(output.FieldDecisions))
+	for _, decision := range output.FieldDecisions {
+		pair, ok := pairByField[decision.Field]
+		if !ok {
+			continue
+		}
+		result := FieldMergeResult{
+			Field:         decision.Field,
+			Decision:      decision.Decision,
+			StoredValue:   *pair.stored,
+			ProposedValue: *pair.extracted,
+			Rationale:     decision.Rationale,
+		}
+		if decision.MergedValue != "" {
+			result.MergedValue = decision.MergedValue
+		}
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// fallbackFieldMerges applies simple containment rules when adjudication is unavailable
+func fallbackFieldMerges(pairs []textFieldPair) []FieldMergeResult {
+	results := make([]FieldMergeResult, 0, len(pairs))
+	for _, pair := range pairs {
+		stored := normalizeForCompare(*pair.stored)
+		proposed := normalizeForCompare(*pair.extracted)
+		result := FieldMergeResult{
+			Field:         pair.field,
+			StoredValue:   *pair.stored,
+			ProposedValue: *pair.extracted,
+		}
+		switch {
+		case strings.Contains(stored, proposed):
+			result.Decision = MergeSame
+			result.Rationale = "stored value already contains the new value"
+		case strings.Contains(proposed, stored):
+			result.Decision = MergeAdds
+			result.MergedValue = *pair.extracted
+			result.Rationale = "new value extends the stored value"
+		default:
+			result.Decision = MergeContradicts
+			result.Rationale = "values differ and could not be adjudicated"
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+func normalizeForCompare(s string) string {
+	return strings.Join(strings.Fields(strings.ToLower(s)), " ")
+}
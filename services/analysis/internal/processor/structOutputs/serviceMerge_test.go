@@ -0,0 +1,77 @@
+package structOutputs
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/david-botos/BearHug/services/analysis/internal/hsds_types"
+)
+
+func TestPlanServiceMergeConfirmed(t *testing.T) {
+	text := func(s string) *string { return &s }
+	number := func(f float64) *float64 { return &f }
+
+	existing := &hsds_types.Service{
+		ID:          "svc",
+		Name:        "Food Pantry",
+		Status:      hsds_types.ServiceStatusActive,
+		Description: text("Weekly groceries for families"),
+		Email:       text("pantry@example.org"),
+		MinimumAge:  number(18),
+	}
+
+	tests := []struct {
+		name          string
+		extracted     ExtractedService
+		wantConfirmed []string
+		wantChanges   []string
+	}{
+		{
+			name: "repeated values are confirmed",
+			extracted: ExtractedService{
+				Name:        "Food Pantry",
+				Status:      hsds_types.ServiceStatusActive,
+				Description: "weekly  groceries for FAMILIES",
+				Email:       text("Pantry@Example.org"),
+				MinimumAge:  number(18),
+			},
+			wantConfirmed: []string{"description", "email", "minimum_age", "status"},
+			wantChanges:   []string{},
+		},
+		{
+			name: "new and different values are changes",
+			extracted: ExtractedService{
+				Name:            "Food Pantry",
+				Status:          hsds_types.ServiceStatusInactive,
+				Description:     "Weekly groceries for families",
+				Email:           text("food@example.org"),
+				MinimumAge:      number(21),
+				FeesDescription: text("Free"),
+			},
+			wantConfirmed: []string{"description"},
+			wantChanges:   []string{"email", "fees_description", "minimum_age"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan := planServiceMerge(existing, &tt.extracted)
+
+			confirmed := append([]string{}, plan.Confirmed...)
+			sort.Strings(confirmed)
+			if !reflect.DeepEqual(confirmed, tt.wantConfirmed) {
+				t.Errorf("confirmed = %v, want %v", confirmed, tt.wantConfirmed)
+			}
+
+			changes := []string{}
+			for field := range plan.Changes {
+				changes = append(changes, field)
+			}
+			sort.Strings(changes)
+			if !reflect.DeepEqual(changes, tt.wantChanges) {
+				t.Errorf("changes = %v, want %v", changes, tt.wantChanges)
+			}
+		})
+	}
+}
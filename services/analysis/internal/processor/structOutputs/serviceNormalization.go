@@ -0,0 +1,163 @@
+package structOutputs
+
+import (
+	"fmt"
+	"net/mail"
+	"net/url"
+	"strings"
+
+	"github.com/david-botos/BearHug/services/analysis/pkg/logger"
+)
+
+// normalizeExtractedService cleans up the optional fields of an extracted service in place.
+// Blank strings become nil, URLs and emails are normalized, and values that fail validation
+// are dropped so they never reach the service table.
+func normalizeExtractedService(svc *ExtractedService) {
+	log := logger.Get()
+
+	svc.Name = strings.TrimSpace(svc.Name)
+	svc.Description = strings.TrimSpace(svc.Description)
+
+	svc.AlternateName = trimOptional(svc.AlternateName)
+	svc.InterpretationServices = trimOptional(svc.InterpretationServices)
+	svc.ApplicationProcess = trimOptional(svc.ApplicationProcess)
+	svc.FeesDescription = trimOptional(svc.FeesDescription)
+	svc.Accreditations = trimOptional(svc.Accreditations)
+	svc.EligibilityDescription = trimOptional(svc.EligibilityDescription)
+	svc.Alert = trimOptional(svc.Alert)
+	svc.WaitTime = trimOptional(svc.WaitTime)
+
+	if svc.URL = trimOptional(svc.URL); svc.URL != nil {
+		normalized, err := normalizeServiceURL(*svc.URL)
+		if err != nil {
+			log.Warn().
+				Err(err).
+				Str("service_name", svc.Name).
+				Str("url", *svc.URL).
+				Msg("Dropping invalid service URL")
+			svc.URL = nil
+		} else {
+			svc.URL = &normalized
+		}
+	}
+
+	if svc.Email = trimOptional(svc.Email); svc.Email != nil {
+		normalized, err := normalizeServiceEmail(*svc.Email)
+		if err != nil {
+			log.Warn().
+				Err(err).
+				Str("service_name", svc.Name).
+				Str("email", *svc.Email).
+				Msg("Dropping invalid service email")
+			svc.Email = nil
+		} else {
+			svc.Email = &normalized
+		}
+	}
+
+	if svc.MinimumAge != nil && (*svc.MinimumAge < 0 || *svc.MinimumAge > 120) {
+		log.Warn().
+			Str("service_name", svc.Name).
+			Float64("minimum_age", *svc.MinimumAge).
+			Msg("Dropping out of range minimum age")
+		svc.MinimumAge = nil
+	}
+	if svc.MaximumAge != nil && (*svc.MaximumAge < 0 || *svc.MaximumAge > 120) {
+		log.Warn().
+			Str("service_name", svc.Name).
+			Float64("maximum_age", *svc.MaximumAge).
+			Msg("Dropping out of range maximum age")
+		svc.MaximumAge = nil
+	}
+	if svc.MinimumAge != nil && svc.MaximumAge != nil && *svc.MinimumAge > *svc.MaximumAge {
+		log.Warn().
+			Str("service_name", svc.Name).
+			Float64("minimum_age", *svc.MinimumAge).
+			Float64("maximum_age", *svc.MaximumAge).
+			Msg("Swapping inverted age range")
+		svc.MinimumAge, svc.MaximumAge = svc.MaximumAge, svc.MinimumAge
+	}
+}
+
+// normalizeServiceURL lowercases the host, defaults the scheme to https and rejects anything
+// that doesn't look like a public web address
+func normalizeServiceURL(raw string) (string, error) {
+	candidate := strings.TrimSpace(raw)
+	candidate = strings.TrimRight(candidate, ".,;")
+	if candidate == "" {
+		return "", fmt.Errorf("url is empty")
+	}
+	if strings.ContainsAny(candidate, " \t\n") {
+		return "", fmt.Errorf("url contains whitespace")
+	}
+
+	if !strings.Contains(candidate, "://") {
+		candidate = "https://" + candidate
+	}
+
+	parsed, err := url.Parse(candidate)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse url: %w", err)
+	}
+
+	scheme := strings.ToLower(parsed.Scheme)
+	if scheme != "http" && scheme != "https" {
+		return "", fmt.Errorf("unsupported url scheme: %s", parsed.Scheme)
+	}
+
+	host := strings.ToLower(parsed.Hostname())
+	if host == "" || !strings.Contains(host, ".") || strings.HasPrefix(host, ".") || strings.HasSuffix(host, ".") {
+		return "", fmt.Errorf("url host is not a domain: %q", host)
+	}
+
+	parsed.Scheme = scheme
+	if port := parsed.Port(); port != "" {
+		parsed.Host = host + ":" + port
+	} else {
+		parsed.Host = host
+	}
+	if parsed.Path == "/" {
+		parsed.Path = ""
+	}
+
+	return parsed.String(), nil
+}
+
+// normalizeServiceEmail validates the address syntax and lowercases the domain part
+func normalizeServiceEmail(raw string) (string, error) {
+	candidate := strings.TrimSpace(raw)
+	candidate = strings.TrimPrefix(candidate, "mailto:")
+	candidate = strings.TrimRight(candidate, ".,;")
+	if candidate == "" {
+		return "", fmt.Errorf("email is empty")
+	}
+
+	addr, err := mail.ParseAddress(candidate)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse email: %w", err)
+	}
+	// Reject display-name forms like "Jane <jane@org.org>" so we only store bare addresses
+	if addr.Address != candidate {
+		return "", fmt.Errorf("email contains more than an address")
+	}
+
+	at := strings.LastIndex(addr.Address, "@")
+	local, domain := addr.Address[:at], strings.ToLower(addr.Address[at+1:])
+	if !strings.Contains(domain, ".") || strings.HasSuffix(domain, ".") {
+		return "", fmt.Errorf("email domain is not valid: %q", domain)
+	}
+
+	return local + "@" + domain, nil
+}
+
+// trimOptional trims an optional string and collapses blank values to nil
+func trimOptional(s *string) *string {
+	if s == nil {
+		return nil
+	}
+	trimmed := strings.TrimSpace(*s)
+	if trimmed == "" {
+		return nil
+	}
+	return &trimmed
+}
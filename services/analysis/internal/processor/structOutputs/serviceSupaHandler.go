@@ -3,12 +3,10 @@ package structOutputs
 
 import (
 	"fmt"
-	"math"
 	"reflect"
 	"strings"
 	"time"
 
-	"github.com/agnivade/levenshtein"
 	"github.com/david-botos/BearHug/services/analysis/internal/hsds_types"
 	"github.com/david-botos/BearHug/services/analysis/internal/supabase"
 	"github.com/david-botos/BearHug/services/analysis/pkg/logger"
@@ -20,13 +18,25 @@ type ServiceVerificationResult struct {
 	IsNew            bool                   // True if this is a new service
 	HasChanges       bool                   // True if existing service needs updates
 	Changes          map[string]interface{} // Fields that differ between existing and extracted
+	Rationales       map[string]string      // Why each field in Changes is being written
+	Conflicts        []FieldMergeResult     // Fields where the call contradicts the stored value
+	Confirmed        []string               // Fields the call repeated the stored value for
 }
 
 type ServiceVerificationResults struct {
 	NewServices       []ExtractedService          // Services to be created
 	UpdateServices    []ServiceVerificationResult // Services that need updating
 	UnchangedServices []*hsds_types.Service
-	Error             error // Any error that occurred during verification
+	MatchedServices   map[string]ExtractedService // Extracted details of every confirmed match, keyed by existing service ID
+	ConfirmedServices []ServiceVerificationResult // Confirmed matches that repeated stored values, changed or not
+	AmbiguousServices []AmbiguousServiceMatch     // Services that need a human to decide
+	Error             error                       // Any error that occurred during verification
+}
+
+// AmbiguousServiceMatch is an extracted service that couldn't be confidently matched or ruled out
+type AmbiguousServiceMatch struct {
+	ExtractedService ExtractedService
+	Decision         ServiceMatchDecision
 }
 
 func VerifyServiceUniqueness(services ServicesExtracted, organizationID string) (ServiceVerificationResults, error) {
@@ -54,120 +64,96 @@ func VerifyServiceUniqueness(services ServicesExtracted, organizationID string)
 		NewServices:       make([]ExtractedService, 0),
 		UpdateServices:    make([]ServiceVerificationResult, 0),
 		UnchangedServices: make([]*hsds_types.Service, 0),
-	}
-
-	// Helper function to check if two strings are similar
-	isSimilarString := func(s1, s2 string) bool {
-		// Convert both strings to lowercase for comparison
-		s1 = strings.ToLower(strings.TrimSpace(s1))
-		s2 = strings.ToLower(strings.TrimSpace(s2))
-
-		// Direct match
-		if s1 == s2 {
-			return true
-		}
-
-		// Levenshtein distance check for similar names
-		distance := levenshtein.ComputeDistance(s1, s2)
-		maxLen := math.Max(float64(len(s1)), float64(len(s2)))
-		// Allow for some fuzzy matching (e.g., if strings are 80% similar)
-		return float64(distance) <= maxLen*0.2
-	}
-
-	// Helper function to detect changes between existing and extracted service
-	detectChanges := func(existing *hsds_types.Service, extracted *ExtractedService) map[string]interface{} {
-		changes := make(map[string]interface{})
-
-		// Compare fields and record changes
-		if existing.Description != nil && *existing.Description != extracted.Description {
-			changes["description"] = extracted.Description
-		}
-		if !reflect.DeepEqual(existing.AlternateName, extracted.AlternateName) {
-			changes["alternate_name"] = extracted.AlternateName
-		}
-		if !reflect.DeepEqual(existing.URL, extracted.URL) {
-			changes["url"] = extracted.URL
-		}
-		if !reflect.DeepEqual(existing.Email, extracted.Email) {
-			changes["email"] = extracted.Email
-		}
-		if !reflect.DeepEqual(existing.InterpretationServices, extracted.InterpretationServices) {
-			changes["interpretation_services"] = extracted.InterpretationServices
-		}
-		if !reflect.DeepEqual(existing.ApplicationProcess, extracted.ApplicationProcess) {
-			changes["application_process"] = extracted.ApplicationProcess
-		}
-		if !reflect.DeepEqual(existing.FeesDescription, extracted.FeesDescription) {
-			changes["fees_description"] = extracted.FeesDescription
-		}
-		if !reflect.DeepEqual(existing.Accreditations, extracted.Accreditations) {
-			changes["accreditations"] = extracted.Accreditations
-		}
-		if !reflect.DeepEqual(existing.EligibilityDescription, extracted.EligibilityDescription) {
-			changes["eligibility_description"] = extracted.EligibilityDescription
-		}
-		if !reflect.DeepEqual(existing.MinimumAge, extracted.MinimumAge) {
-			changes["minimum_age"] = extracted.MinimumAge
-		}
-		if !reflect.DeepEqual(existing.MaximumAge, extracted.MaximumAge) {
-			changes["maximum_age"] = extracted.MaximumAge
-		}
-		if !reflect.DeepEqual(existing.Alert, extracted.Alert) {
-			changes["alert"] = extracted.Alert
-		}
-
-		return changes
+		MatchedServices:   make(map[string]ExtractedService),
 	}
 
 	// Create a map to track which existing services have been processed
 	processedServices := make(map[string]bool)
 
-	// Check each extracted service against existing services
-	for _, extractedService := range services.NewServices {
-		found := false
+	candidates := make([]*hsds_types.Service, len(existingServices))
+	for i := range existingServices {
+		candidates[i] = &existingServices[i]
+	}
+	matcher := NewServiceMatcher()
 
+	// Check each extracted service against existing services
+	decisions := make([]ServiceMatchDecision, len(services.NewServices))
+	for i, extractedService := range services.NewServices {
 		log.Debug().
 			Str("service_name", extractedService.Name).
 			Msg("Checking service for uniqueness")
 
-		for _, existingService := range existingServices {
-			// Check if service names are similar
-			if isSimilarString(existingService.Name, extractedService.Name) {
-				found = true
-				processedServices[existingService.ID] = true
-
-				// Detect what fields have changed
-				changes := detectChanges(&existingService, &extractedService)
-
-				if len(changes) > 0 {
-					log.Info().
-						Str("service_id", existingService.ID).
-						Str("service_name", existingService.Name).
-						Int("changes_count", len(changes)).
-						Interface("changes", changes).
-						Msg("Service needs updates")
-
-					results.UpdateServices = append(results.UpdateServices, ServiceVerificationResult{
-						ExistingService:  &existingService,
-						ExtractedService: extractedService,
-						IsNew:            false,
-						HasChanges:       true,
-						Changes:          changes,
-					})
-				} else {
-					log.Debug().
-						Str("service_id", existingService.ID).
-						Str("service_name", existingService.Name).
-						Msg("Service matched but no changes needed")
-
-					// Add to UnchangedServices since we found a match with no changes
-					results.UnchangedServices = append(results.UnchangedServices, &existingService)
-				}
-				break
-			}
+		decision, err := matcher.MatchService(ServiceMatchCandidate{
+			Name:          extractedService.Name,
+			AlternateName: extractedService.AlternateName,
+			Description:   extractedService.Description,
+		}, candidates)
+		if err != nil {
+			return ServiceVerificationResults{}, fmt.Errorf("failed to match service '%s': %w", extractedService.Name, err)
 		}
+		decisions[i] = decision
+	}
+	releaseDuplicateClaims(decisions)
+
+	for i, extractedService := range services.NewServices {
+		decision := decisions[i]
+		switch decision.Outcome {
+		case ServiceMatchConfirmed:
+			existingService := decision.Best
+			processedServices[existingService.ID] = true
+			results.MatchedServices[existingService.ID] = extractedService
+
+			// Decide per field how the extracted information merges into the stored service
+			plan := planServiceMerge(existingService, &extractedService)
+			if len(plan.Confirmed) > 0 {
+				results.ConfirmedServices = append(results.ConfirmedServices, ServiceVerificationResult{
+					ExistingService:  existingService,
+					ExtractedService: extractedService,
+					Confirmed:        plan.Confirmed,
+				})
+			}
+
+			if len(plan.Changes) > 0 || len(plan.Conflicts) > 0 {
+				log.Info().
+					Str("service_id", existingService.ID).
+					Str("service_name", existingService.Name).
+					Float64("match_confidence", decision.Confidence).
+					Int("changes_count", len(plan.Changes)).
+					Int("conflicts_count", len(plan.Conflicts)).
+					Interface("changes", plan.Changes).
+					Msg("Service needs updates")
+
+				results.UpdateServices = append(results.UpdateServices, ServiceVerificationResult{
+					ExistingService:  existingService,
+					ExtractedService: extractedService,
+					IsNew:            false,
+					HasChanges:       len(plan.Changes) > 0,
+					Changes:          plan.Changes,
+					Rationales:       plan.Rationales,
+					Conflicts:        plan.Conflicts,
+				})
+			} else {
+				log.Debug().
+					Str("service_id", existingService.ID).
+					Str("service_name", existingService.Name).
+					Msg("Service matched but no changes needed")
+
+				// Add to UnchangedServices since we found a match with no changes
+				results.UnchangedServices = append(results.UnchangedServices, existingService)
+			}
 
-		if !found {
+		case ServiceMatchAmbiguous:
+			log.Warn().
+				Str("service_name", extractedService.Name).
+				Float64("match_confidence", decision.Confidence).
+				Str("reason", decision.Reason).
+				Msg("Ambiguous service match, flagging for review")
+			results.AmbiguousServices = append(results.AmbiguousServices, AmbiguousServiceMatch{
+				ExtractedService: extractedService,
+				Decision:         decision,
+			})
+
+		default:
 			log.Info().
 				Str("service_name", extractedService.Name).
 				Msg("New service identified")
@@ -176,13 +162,13 @@ func VerifyServiceUniqueness(services ServicesExtracted, organizationID string)
 	}
 
 	// Add any existing services that weren't processed (weren't part of the extraction)
-	for _, existingService := range existingServices {
+	for _, existingService := range candidates {
 		if !processedServices[existingService.ID] {
 			log.Debug().
 				Str("service_id", existingService.ID).
 				Str("service_name", existingService.Name).
 				Msg("Adding unprocessed existing service to unchanged services")
-			results.UnchangedServices = append(results.UnchangedServices, &existingService)
+			results.UnchangedServices = append(results.UnchangedServices, existingService)
 		}
 	}
 
@@ -190,11 +176,39 @@ func VerifyServiceUniqueness(services ServicesExtracted, organizationID string)
 		Int("new_services", len(results.NewServices)).
 		Int("updated_services", len(results.UpdateServices)).
 		Int("unchanged_services", len(results.UnchangedServices)).
+		Int("ambiguous_services", len(results.AmbiguousServices)).
 		Msg("Service verification completed")
 
 	return results, nil
 }
 
+// releaseDuplicateClaims keeps only the most confident of several extracted services confirmed as the same
+// stored service. The others become ambiguous so a reviewer decides, rather than one extraction silently
+// replacing the other's changes.
+func releaseDuplicateClaims(decisions []ServiceMatchDecision) {
+	claimant := make(map[string]int)
+	for i, decision := range decisions {
+		if decision.Outcome != ServiceMatchConfirmed {
+			continue
+		}
+		if j, claimed := claimant[decision.Best.ID]; !claimed || decision.Confidence > decisions[j].Confidence {
+			claimant[decision.Best.ID] = i
+		}
+	}
+
+	for i := range decisions {
+		decision := &decisions[i]
+		if decision.Outcome != ServiceMatchConfirmed {
+			continue
+		}
+		if j := claimant[decision.Best.ID]; j != i {
+			decision.Reason = fmt.Sprintf("%q matched %q more confidently (%s)", decisions[j].Candidate.Name, decision.Best.Name, decision.Reason)
+			decision.Outcome = ServiceMatchAmbiguous
+			decision.Best = nil
+		}
+	}
+}
+
 func UpdateExistingServices(services []ServiceVerificationResult, callID string) error {
 	log := logger.Get()
 	log.Info().
@@ -210,6 +224,13 @@ func UpdateExistingServices(services []ServiceVerificationResult, callID string)
 	}
 
 	for _, service := range services {
+		// Contradictions are never written, only recorded so a reviewer can settle them
+		if len(service.Conflicts) > 0 {
+			if err := recordServiceConflicts(service, callID); err != nil {
+				return err
+			}
+		}
+
 		if !service.HasChanges {
 			continue
 		}
@@ -231,11 +252,12 @@ func UpdateExistingServices(services []ServiceVerificationResult, callID string)
 			updateData[field] = newValue
 
 			// Get previous value from existing service
-			var previousValue string
-			if existingValue, ok := getFieldValue(service.ExistingService, field); ok {
-				previousValue = fmt.Sprintf("%v", existingValue)
-			} else {
-				previousValue = "new data"
+			previousValue, ok := getFieldValue(service.ExistingService, field)
+			if !ok {
+				log.Warn().
+					Str("service_id", service.ExistingService.ID).
+					Str("field", field).
+					Msg("Changed field is not a service column, previous value not recorded")
 			}
 
 			metadataInput := supabase.MetadataInput{
@@ -244,8 +266,10 @@ func UpdateExistingServices(services []ServiceVerificationResult, callID string)
 				ResourceType:     "service",
 				FieldName:        field,
 				PreviousValue:    previousValue,
-				ReplacementValue: fmt.Sprintf("%v", newValue),
+				ReplacementValue: newValue,
 				LastActionType:   "UPDATE",
+				Rationale:        service.Rationales[field],
+				Evidence:         evidenceFor(service.ExtractedService.Evidence, field),
 			}
 			metadataInputs = append(metadataInputs, metadataInput)
 		}
@@ -289,12 +313,92 @@ func UpdateExistingServices(services []ServiceVerificationResult, callID string)
 	return nil
 }
 
-// Helper function to get field value from service using reflection
+// recordServiceConflicts writes a CONFLICT metadata row for each contradicted field without
+// touching the service itself
+func recordServiceConflicts(service ServiceVerificationResult, callID string) error {
+	log := logger.Get()
+
+	metadataInputs := make([]supabase.MetadataInput, 0, len(service.Conflicts))
+	for _, conflict := range service.Conflicts {
+		metadataInputs = append(metadataInputs, supabase.MetadataInput{
+			ResourceID:       service.ExistingService.ID,
+			CallID:           callID,
+			ResourceType:     "service",
+			FieldName:        conflict.Field,
+			PreviousValue:    conflict.StoredValue,
+			ReplacementValue: conflict.ProposedValue,
+			LastActionType:   "CONFLICT",
+			Rationale:        conflict.Rationale,
+			Evidence:         evidenceFor(service.ExtractedService.Evidence, conflict.Field),
+		})
+	}
+
+	if err := supabase.CreateAndStoreMetadata(metadataInputs); err != nil {
+		log.Error().
+			Err(err).
+			Str("service_id", service.ExistingService.ID).
+			Msg("Failed to record service conflicts")
+		return fmt.Errorf("failed to record conflicts for service %s: %w", service.ExistingService.ID, err)
+	}
+
+	log.Warn().
+		Str("service_id", service.ExistingService.ID).
+		Int("conflicts_count", len(metadataInputs)).
+		Msg("Recorded contradicting service fields for review")
+
+	return nil
+}
+
+// recordServiceConfirmations writes a CONFIRM metadata row for each field a call repeated unchanged, so
+// freshness scoring knows the stored value was checked even though nothing was written to the service.
+// A failed write is only logged: it costs freshness dates, not data.
+func recordServiceConfirmations(services []ServiceVerificationResult, callID string) {
+	log := logger.Get()
+
+	var metadataInputs []supabase.MetadataInput
+	for _, service := range services {
+		for _, field := range service.Confirmed {
+			value, _ := getFieldValue(service.ExistingService, field)
+			metadataInputs = append(metadataInputs, supabase.MetadataInput{
+				ResourceID:       service.ExistingService.ID,
+				CallID:           callID,
+				ResourceType:     "service",
+				FieldName:        field,
+				PreviousValue:    value,
+				ReplacementValue: value,
+				LastActionType:   "CONFIRM",
+				Rationale:        "caller repeated the stored value",
+				Evidence:         evidenceFor(service.ExtractedService.Evidence, field),
+			})
+		}
+	}
+	if len(metadataInputs) == 0 {
+		return
+	}
+
+	if err := supabase.CreateAndStoreMetadata(metadataInputs); err != nil {
+		log.Error().
+			Err(err).
+			Str("call_id", callID).
+			Int("confirmations_count", len(metadataInputs)).
+			Msg("Failed to record service confirmations; the confirmed fields keep their older freshness dates")
+		return
+	}
+
+	log.Debug().
+		Int("confirmations_count", len(metadataInputs)).
+		Msg("Recorded confirmed service fields")
+}
+
+// Helper function to get field value from service using reflection. fieldName is the column name,
+// matched against the struct's json tags.
 func getFieldValue(service *hsds_types.Service, fieldName string) (interface{}, bool) {
 	val := reflect.ValueOf(service).Elem()
-	field := val.FieldByName(fieldName)
-	if !field.IsValid() {
-		return nil, false
+	for i := 0; i < val.NumField(); i++ {
+		tag, _, _ := strings.Cut(val.Type().Field(i).Tag.Get("json"), ",")
+		if tag == fieldName {
+			return val.Field(i).Interface(), true
+		}
 	}
-	return field.Interface(), true
+	return nil, false
 }
@@ -85,12 +85,27 @@ IMPORTANT EXTRACTION RULES:
 2. For each individual service:
    - Name it specifically (e.g., "Brain Trauma Individual Coaching" instead of just "Brain Trauma Services")
    - Include only confirmed details from the transcript
+   - When lines are labelled by speaker, details must come from the Representative (organization staff); the Agent (AI caller) only asks questions and reads back answers
    - Default status to "active" unless otherwise indicated
    - Keep descriptions focused on that specific service only
 
 3. Do NOT combine multiple services into a single entry, even if they serve similar populations
 
-Only respond using the new_services tool to output the structured data. Do not provide any additional text.`, orgName, transcript, orgName, servicesText)
+4. Optional fields:
+   - Fill in alternate names, websites, emails, interpretation services, accreditations, age limits and alerts only when they are stated for that specific service
+   - Spell out websites and emails in their written form (e.g., "food bank dot org" becomes "foodbank.org")
+   - Ages must be numbers of years
+   - List in languages only the languages staff deliver the service in; an interpreter or language line belongs in interpretation services
+
+5. Evidence:
+   - For every field you fill in, add an evidence entry under the same field name
+   - The quote must be copied word for word from the transcript, along with its character offsets
+   - Give a confidence from 0 to 1; leave a field out entirely rather than guessing
+
+6. Language:
+%s
+
+Only respond using the new_services tool to output the structured data. Do not provide any additional text.`, orgName, transcript, orgName, servicesText, languageRules)
 
 	return prompt, ServicesSchema, nil
 }
@@ -128,8 +143,63 @@ var ServicesSchema = inference.ToolInputSchema{
 						"type":        "string",
 						"description": "Current wait time for service access",
 					},
+					"alternate_name": map[string]interface{}{
+						"type":        "string",
+						"description": "Another name the service is known by, such as an acronym or program nickname",
+					},
+					"url": map[string]interface{}{
+						"type":        "string",
+						"description": "Website address specific to this service, exactly as stated in the conversation",
+					},
+					"email": map[string]interface{}{
+						"type":        "string",
+						"description": "Email address used to reach or apply for this service",
+					},
+					"interpretation_services": map[string]interface{}{
+						"type":        "string",
+						"description": "Languages or interpretation supports available for this service",
+					},
+					"languages": map[string]interface{}{
+						"type":        "array",
+						"description": "Languages staff deliver this service in",
+						"items": map[string]interface{}{
+							"type": "object",
+							"properties": map[string]interface{}{
+								"name": map[string]interface{}{
+									"type":        "string",
+									"description": "English name of the language, e.g. Spanish",
+								},
+								"note": map[string]interface{}{
+									"type":        "string",
+									"description": "Any limits on it, e.g. only on weekday mornings",
+								},
+							},
+							"required": []string{"name"},
+						},
+					},
+					"accreditations": map[string]interface{}{
+						"type":        "string",
+						"description": "Accreditations or certifications held for this service",
+					},
+					"minimum_age": map[string]interface{}{
+						"type":        "number",
+						"description": "Youngest age in years eligible for the service",
+					},
+					"maximum_age": map[string]interface{}{
+						"type":        "number",
+						"description": "Oldest age in years eligible for the service",
+					},
+					"alert": map[string]interface{}{
+						"type":        "string",
+						"description": "Short-term notice about the service such as a temporary closure or change in availability",
+					},
+					"evidence": evidenceProperty(
+						"name", "status", "description", "application_process", "fees_description",
+						"eligibility_description", "wait_time", "alternate_name", "url", "email",
+						"interpretation_services", "languages", "accreditations", "minimum_age", "maximum_age", "alert",
+					),
 				},
-				"required": []string{"name", "status", "description"},
+				"required": []string{"name", "status", "description", "evidence"},
 			},
 		},
 	},
@@ -155,6 +225,12 @@ type ExtractedService struct {
 	MaximumAge             *float64 `json:"maximum_age,omitempty"`
 	Alert                  *string  `json:"alert,omitempty"`
 	WaitTime               *string  `json:"wait_time,omitempty"`
+
+	// Languages the service is delivered in, stored in the language table rather than on the service
+	Languages []ExtractedLanguage `json:"languages,omitempty"`
+
+	// Transcript evidence for each filled in field, keyed by field name
+	Evidence map[string]hsds_types.FieldEvidence `json:"evidence,omitempty"`
 }
 type ServicesExtracted struct {
 	NewServices []ExtractedService `json:"new_services"`
@@ -167,25 +243,10 @@ func ServicesExtraction(org_id string, transcript string) (ServicesExtracted, er
 		Int("transcript_length", len(transcript)).
 		Msg("Starting services extraction")
 
-	servicesPrompt, servicesSchema, promptErr := GenerateServicesPrompt(org_id, transcript)
-	if promptErr != nil {
-		log.Error().
-			Err(promptErr).
-			Str("organization_id", org_id).
-			Msg("Failed to generate services prompt")
-		return ServicesExtracted{}, fmt.Errorf("failed to generate services prompt: %w", promptErr)
-	}
-
-	client, err := inference.InitInferenceClient()
-	if err != nil {
-		log.Error().
-			Err(err).
-			Msg("Failed to initialize inference client")
-		return ServicesExtracted{}, fmt.Errorf("failed to initialize inference client: %w", err)
-	}
-
 	log.Debug().Msg("Running Claude inference for services extraction")
-	servicesInferenceResult, servicesInferenceResultErr := client.RunClaudeInference(inference.PromptParams{Prompt: servicesPrompt, Schema: servicesSchema})
+	servicesInferenceResult, servicesInferenceResultErr := runSegmentedExtraction(transcript, "new_services", func(segment string) (string, inference.ToolInputSchema, error) {
+		return GenerateServicesPrompt(org_id, segment)
+	})
 	if servicesInferenceResultErr != nil {
 		log.Error().
 			Err(servicesInferenceResultErr).
@@ -209,8 +270,19 @@ func ServicesExtraction(org_id string, transcript string) (ServicesExtracted, er
 		return ServicesExtracted{}, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
+	// Only keep services and fields the transcript actually supports
+	supportedServices := make([]ExtractedService, 0, len(servicesExtracted.NewServices))
+	for _, service := range servicesExtracted.NewServices {
+		if rejectUnsupportedServiceFields(transcript, &service) {
+			supportedServices = append(supportedServices, service)
+		}
+	}
+	rejectedCount := len(servicesExtracted.NewServices) - len(supportedServices)
+	servicesExtracted.NewServices = mergeRepeatedServices(supportedServices)
+
 	log.Info().
 		Int("extracted_services_count", len(servicesExtracted.NewServices)).
+		Int("rejected_services_count", rejectedCount).
 		Msg("Successfully completed services extraction")
 
 	return servicesExtracted, nil
@@ -223,55 +295,55 @@ func HandleExtractedServices(extractedServices ServicesExtracted, organizationID
 		Int("services_count", len(extractedServices.NewServices)).
 		Msg("Starting to handle extracted services")
 
+	// Clean up contact fields before they are compared against or written to the DB
+	for i := range extractedServices.NewServices {
+		normalizeExtractedService(&extractedServices.NewServices[i])
+	}
+
 	verificationResults, err := VerifyServiceUniqueness(extractedServices, organizationID)
 	if err != nil {
 		return ServiceContext{}, fmt.Errorf("failed to verify service uniqueness: %w", err)
 	}
 
 	serviceContext := ServiceContext{
-		ExistingServices: make([]*hsds_types.Service, 0),
-		NewServices:      make([]*hsds_types.Service, 0),
+		ExistingServices:  make([]*hsds_types.Service, 0),
+		NewServices:       make([]*hsds_types.Service, 0),
+		NewServiceSources: make(map[string]ExtractedService),
 	}
 
-	// Convert new services to HSDS format
-	if len(verificationResults.NewServices) > 0 {
-		for _, extractedService := range verificationResults.NewServices {
-			opts := &hsds_types.ServiceOptions{
-				Description:            &extractedService.Description,
-				ApplicationProcess:     extractedService.ApplicationProcess,
-				FeesDescription:        extractedService.FeesDescription,
-				EligibilityDescription: extractedService.EligibilityDescription,
-				WaitTime:               extractedService.WaitTime,
-			}
-
-			service, err := hsds_types.NewService(
-				organizationID,
-				extractedService.Name,
-				extractedService.Status,
-				opts,
-			)
-			if err != nil {
-				return ServiceContext{}, fmt.Errorf("error converting new service '%s': %w", extractedService.Name, err)
-			}
-
-			hsdsService := &hsds_types.Service{
-				ID:                     service.ID,
-				OrganizationID:         service.OrganizationID,
-				Name:                   service.Name,
-				Status:                 service.Status,
-				Description:            service.Description,
-				ApplicationProcess:     service.ApplicationProcess,
-				FeesDescription:        service.FeesDescription,
-				EligibilityDescription: service.EligibilityDescription,
-				WaitTime:               service.WaitTime,
-				CreatedAt:              service.CreatedAt,
-			}
-			serviceContext.NewServices = append(serviceContext.NewServices, hsdsService)
+	// Services with languages mentioned, keyed by stored service ID
+	languagesByService := make(map[string]ExtractedService)
+
+	// Convert new services to HSDS format. They are stored by StoreNewServices once validated.
+	for _, extractedService := range verificationResults.NewServices {
+		opts := &hsds_types.ServiceOptions{
+			AlternateName:          extractedService.AlternateName,
+			Description:            &extractedService.Description,
+			URL:                    extractedService.URL,
+			Email:                  extractedService.Email,
+			InterpretationServices: extractedService.InterpretationServices,
+			ApplicationProcess:     extractedService.ApplicationProcess,
+			FeesDescription:        extractedService.FeesDescription,
+			Accreditations:         extractedService.Accreditations,
+			EligibilityDescription: extractedService.EligibilityDescription,
+			MinimumAge:             extractedService.MinimumAge,
+			MaximumAge:             extractedService.MaximumAge,
+			Alert:                  extractedService.Alert,
+			WaitTime:               extractedService.WaitTime,
 		}
 
-		if err := supabase.StoreNewServices(serviceContext.NewServices, callID); err != nil {
-			return ServiceContext{}, fmt.Errorf("failed to store new services: %w", err)
+		hsdsService, err := hsds_types.NewService(
+			organizationID,
+			extractedService.Name,
+			extractedService.Status,
+			opts,
+		)
+		if err != nil {
+			return ServiceContext{}, fmt.Errorf("error converting new service '%s': %w", extractedService.Name, err)
 		}
+
+		serviceContext.NewServices = append(serviceContext.NewServices, hsdsService)
+		serviceContext.NewServiceSources[hsdsService.ID] = extractedService
 	}
 
 	// Handle existing service updates
@@ -286,5 +358,64 @@ func HandleExtractedServices(extractedServices ServicesExtracted, organizationID
 
 	serviceContext.ExistingServices = append(serviceContext.ExistingServices, verificationResults.UnchangedServices...)
 
+	// Confirmations only refresh freshness dates, and the updates above are already applied, so a failure
+	// here is logged rather than failing the transcript
+	recordServiceConfirmations(verificationResults.ConfirmedServices, callID)
+
+	for serviceID, matched := range verificationResults.MatchedServices {
+		if len(matched.Languages) > 0 {
+			languagesByService[serviceID] = matched
+		}
+	}
+	if err := storeServiceLanguages(languagesByService, callID); err != nil {
+		return ServiceContext{}, err
+	}
+
+	// Park ambiguous matches for review rather than guessing
+	if len(verificationResults.AmbiguousServices) > 0 {
+		reviews := make([]supabase.ServiceMatchReviewInput, 0, len(verificationResults.AmbiguousServices))
+		for _, ambiguous := range verificationResults.AmbiguousServices {
+			reviews = append(reviews, supabase.ServiceMatchReviewInput{
+				CallID:               callID,
+				OrganizationID:       organizationID,
+				ExtractedName:        ambiguous.ExtractedService.Name,
+				ExtractedDescription: ambiguous.ExtractedService.Description,
+				ExtractedService:     ambiguous.ExtractedService,
+				Candidates:           reviewCandidates(ambiguous.Decision),
+				Confidence:           ambiguous.Decision.Confidence,
+				Reason:               ambiguous.Decision.Reason,
+			})
+		}
+		if err := supabase.StoreServiceMatchReviews(reviews); err != nil {
+			return ServiceContext{}, fmt.Errorf("failed to store ambiguous service matches: %w", err)
+		}
+	}
+
 	return serviceContext, nil
 }
+
+// StoreNewServices stores the new services left in serviceCtx, with their evidence and languages. It runs
+// after validation so services withheld for review are never written.
+func StoreNewServices(serviceCtx ServiceContext, callID string) error {
+	if len(serviceCtx.NewServices) == 0 {
+		return nil
+	}
+
+	evidence := make(map[string]map[string]hsds_types.FieldEvidence, len(serviceCtx.NewServices))
+	languagesByService := make(map[string]ExtractedService)
+	for _, service := range serviceCtx.NewServices {
+		source, ok := serviceCtx.NewServiceSources[service.ID]
+		if !ok {
+			continue
+		}
+		evidence[service.ID] = source.Evidence
+		if len(source.Languages) > 0 {
+			languagesByService[service.ID] = source
+		}
+	}
+
+	if err := supabase.StoreNewServices(serviceCtx.NewServices, callID, evidence); err != nil {
+		return fmt.Errorf("failed to store new services: %w", err)
+	}
+	return storeServiceLanguages(languagesByService, callID)
+}
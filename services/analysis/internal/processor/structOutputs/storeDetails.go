@@ -7,51 +7,133 @@ import (
 	"github.com/david-botos/BearHug/services/analysis/pkg/logger"
 )
 
-// TODO: If the unit is new its uuid needs to be referenced by new capacity objects before those are uploaded...?
-func StoreDetails(extractedDetails []*DetailAnalysisResult, callID string) error {
-	log := logger.Get()
+// StoreFailure reports what failed while storing a category. Records and unresolved observations are
+// written independently, so one failing doesn't lose the other.
+type StoreFailure struct {
+	Records error // The category's records weren't all stored
+	Parking error // Observations that couldn't be resolved weren't parked for review
+}
+
+// StoreDetails stores each category's details, parks what couldn't be resolved and returns the categories
+// where either failed. A failure in one category doesn't stop the others from being stored.
+func StoreDetails(extractedDetails []*DetailAnalysisResult, callID string) map[DetailCategory]StoreFailure {
+	failures := make(map[DetailCategory]StoreFailure)
 	for _, detail := range extractedDetails {
-		if detail.Category == "CAPACITY" {
-			if len(detail.CapacityData.Units) > 0 {
-				unitStorageErr := supabase.StoreNewUnits(detail.CapacityData.Units, callID)
-				if unitStorageErr != nil {
-					log.Error().
-						Err(unitStorageErr).
-						Msg("Failed to store unit details in supa")
-					return fmt.Errorf("error storing unit details: %w", unitStorageErr)
-				}
-			}
-			if len(detail.CapacityData.Capacities) > 0 {
-				capacityStorageErr := supabase.StoreNewCapacity(detail.CapacityData.Capacities, callID)
-				if capacityStorageErr != nil {
-					log.Error().
-						Err(capacityStorageErr).
-						Msg("Failed to store capacity details in supa")
-					return fmt.Errorf("error storing capacity details: %w", capacityStorageErr)
-				}
-			}
+		failure := StoreFailure{
+			Records: storeCategoryDetails(detail, callID),
+			Parking: parkUnresolved(detail, callID),
 		}
-		if detail.Category == "CONTACT" {
-			if len(detail.ContactData.Contacts) > 0 {
-				contactStorageErr := supabase.StoreNewContacts(detail.ContactData.Contacts, callID)
-				if contactStorageErr != nil {
-					log.Error().
-						Err(contactStorageErr).
-						Msg("Failed to store contact details in supa")
-					return fmt.Errorf("error storing contact details: %w", contactStorageErr)
-				}
+		if failure.Records != nil || failure.Parking != nil {
+			failures[detail.Category] = failure
+		}
+	}
+	return failures
+}
+
+// parkUnresolved saves observations that couldn't be tied to a record so a person can place them
+func parkUnresolved(detail *DetailAnalysisResult, callID string) error {
+	if detail.CapacityData == nil || len(detail.CapacityData.Unresolved) == 0 {
+		return nil
+	}
+
+	observations := make([]supabase.UnresolvedObservationInput, 0, len(detail.CapacityData.Unresolved))
+	for _, unresolved := range detail.CapacityData.Unresolved {
+		observations = append(observations, supabase.UnresolvedObservationInput{
+			CallID:      callID,
+			Category:    string(CapacityCategory),
+			ServiceName: unresolved.ServiceName,
+			Observation: unresolved,
+			Evidence:    unresolved.Evidence,
+			Reason:      unresolved.Reason,
+		})
+	}
+	if err := supabase.StoreUnresolvedObservations(observations); err != nil {
+		logger.Get().Error().
+			Err(err).
+			Msg("Failed to park unresolved capacities in supa")
+		return fmt.Errorf("error storing unresolved capacities: %w", err)
+	}
+	return nil
+}
+
+func storeCategoryDetails(detail *DetailAnalysisResult, callID string) error {
+	log := logger.Get()
+	if detail.Category == "CAPACITY" {
+		// New units are stored first so the capacity rows that reference them satisfy the foreign key
+		if len(detail.CapacityData.Units) > 0 {
+			unitStorageErr := supabase.StoreNewUnits(detail.CapacityData.Units, callID)
+			if unitStorageErr != nil {
+				log.Error().
+					Err(unitStorageErr).
+					Msg("Failed to store unit details in supa")
+				return fmt.Errorf("error storing unit details: %w", unitStorageErr)
 			}
-			if len(detail.ContactData.Phones) > 0 {
-				phoneStorageErr := supabase.StoreNewPhones(detail.ContactData.Phones, callID)
-				if phoneStorageErr != nil {
-					log.Error().
-						Err(phoneStorageErr).
-						Msg("Failed to store phone details in supa")
-					return fmt.Errorf("error storing phone details: %w", phoneStorageErr)
-				}
+		}
+		if len(detail.CapacityData.Capacities) > 0 {
+			capacityStorageErr := supabase.StoreNewCapacity(detail.CapacityData.Capacities, callID, detail.CapacityData.Evidence)
+			if capacityStorageErr != nil {
+				log.Error().
+					Err(capacityStorageErr).
+					Msg("Failed to store capacity details in supa")
+				return fmt.Errorf("error storing capacity details: %w", capacityStorageErr)
 			}
 		}
-		// TODO: else if ... other detail categories
+	}
+	if detail.Category == "CONTACT" {
+		return storeContactDetails(detail.ContactData, callID)
+	}
+	// TODO: else if ... other detail categories
+	return nil
+}
+
+// storeContactDetails writes the new contacts and phones and the updates to stored ones, then saves the
+// call's reconciliation report with what was actually written
+func storeContactDetails(data *ContactResult, callID string) error {
+	var written []ContactAction
+	err := writeContactDetails(data, callID, &written)
+	if data.Report != nil {
+		data.Report.recordOutcomes(data, written)
+		storeContactReconciliationReport(*data.Report)
+	}
+	return err
+}
+
+func writeContactDetails(data *ContactResult, callID string, written *[]ContactAction) error {
+	log := logger.Get()
+	if len(data.Contacts) > 0 {
+		contactStorageErr := supabase.StoreNewContacts(data.Contacts, callID, data.Evidence)
+		if contactStorageErr != nil {
+			log.Error().
+				Err(contactStorageErr).
+				Msg("Failed to store contact details in supa")
+			return fmt.Errorf("error storing contact details: %w", contactStorageErr)
+		}
+		for _, contact := range data.Contacts {
+			*written = append(*written, ContactAction{Action: "create", ResourceType: "contact", ResourceID: contact.ID, Applied: true})
+		}
+	}
+	if len(data.Phones) > 0 {
+		phoneStorageErr := supabase.StoreNewPhones(data.Phones, callID, data.Evidence)
+		if phoneStorageErr != nil {
+			log.Error().
+				Err(phoneStorageErr).
+				Msg("Failed to store phone details in supa")
+			return fmt.Errorf("error storing phone details: %w", phoneStorageErr)
+		}
+		for _, phone := range data.Phones {
+			*written = append(*written, ContactAction{Action: "create", ResourceType: "phone", ResourceID: phone.ID, Applied: true})
+		}
+	}
+	for _, update := range data.Updates {
+		actions, err := UpdateExistingContact(update, callID)
+		*written = append(*written, actions...)
+		if err != nil {
+			log.Error().
+				Err(err).
+				Str("contact_id", update.Contact.ID).
+				Msg("Failed to update existing contact in supa")
+			return fmt.Errorf("error updating existing contact: %w", err)
+		}
 	}
 	return nil
 }
@@ -0,0 +1,362 @@
+package structOutputs
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/david-botos/BearHug/services/analysis/internal/hsds_types"
+	"github.com/david-botos/BearHug/services/analysis/internal/supabase"
+	"github.com/david-botos/BearHug/services/analysis/pkg/logger"
+)
+
+const (
+	UnitSchemeUCUM    = "UCUM"
+	UnitSchemeBearHug = "BearHug"
+
+	// unitFuzzyThreshold is the similarity a name needs to reuse a unit it doesn't match exactly
+	unitFuzzyThreshold = 0.8
+)
+
+// CanonicalUnit is a unit BearHug knows how to recognize under several names
+type CanonicalUnit struct {
+	Name       string
+	Scheme     string
+	Identifier string
+	Synonyms   []string
+}
+
+// canonicalUnits lists the units that come up on calls. Synonyms are matched after
+// normalization, so plurals and casing don't need their own entries.
+var canonicalUnits = []CanonicalUnit{
+	{Name: "Bed", Scheme: UnitSchemeBearHug, Identifier: "bed", Synonyms: []string{"shelter bed", "emergency bed", "cot", "bunk", "bed space", "sleeping mat"}},
+	{Name: "Person", Scheme: UnitSchemeBearHug, Identifier: "person", Synonyms: []string{"people", "individual", "client", "participant", "guest"}},
+	{Name: "Household", Scheme: UnitSchemeBearHug, Identifier: "household", Synonyms: []string{"family"}},
+	{Name: "Meal", Scheme: UnitSchemeBearHug, Identifier: "meal", Synonyms: []string{"hot meal", "plate", "serving"}},
+	{Name: "Appointment", Scheme: UnitSchemeBearHug, Identifier: "appointment", Synonyms: []string{"appointment slot", "time slot", "slot"}},
+	{Name: "Food Box", Scheme: UnitSchemeBearHug, Identifier: "food_box", Synonyms: []string{"grocery box", "food package", "food bag"}},
+	{Name: "Room", Scheme: UnitSchemeBearHug, Identifier: "room", Synonyms: []string{"motel room", "hotel room"}},
+	{Name: "Voucher", Scheme: UnitSchemeBearHug, Identifier: "voucher", Synonyms: []string{"motel voucher", "hotel voucher"}},
+	{Name: "Hour", Scheme: UnitSchemeUCUM, Identifier: "h", Synonyms: []string{"hr", "hrs"}},
+	{Name: "Day", Scheme: UnitSchemeUCUM, Identifier: "d"},
+	{Name: "Week", Scheme: UnitSchemeUCUM, Identifier: "wk"},
+	{Name: "Pound", Scheme: UnitSchemeUCUM, Identifier: "[lb_av]", Synonyms: []string{"lb", "lbs"}},
+}
+
+// irregularPlurals covers nouns that the suffix rules in singularize get wrong
+var irregularPlurals = map[string]string{
+	"people":   "person",
+	"children": "child",
+	"men":      "man",
+	"women":    "woman",
+	"feet":     "foot",
+}
+
+// normalizeUnitName lowercases, strips punctuation and singularizes every word
+func normalizeUnitName(name string) string {
+	words := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for i, word := range words {
+		words[i] = singularize(word)
+	}
+	return strings.Join(words, " ")
+}
+
+func singularize(word string) string {
+	if singular, ok := irregularPlurals[word]; ok {
+		return singular
+	}
+	if len(word) <= 3 {
+		return word
+	}
+	switch {
+	case strings.HasSuffix(word, "ies"):
+		return strings.TrimSuffix(word, "ies") + "y"
+	case strings.HasSuffix(word, "sses"), strings.HasSuffix(word, "shes"), strings.HasSuffix(word, "ches"),
+		strings.HasSuffix(word, "xes"), strings.HasSuffix(word, "zes"):
+		return strings.TrimSuffix(word, "es")
+	case strings.HasSuffix(word, "ss"), strings.HasSuffix(word, "us"), strings.HasSuffix(word, "is"):
+		return word
+	case strings.HasSuffix(word, "s"):
+		return strings.TrimSuffix(word, "s")
+	}
+	return word
+}
+
+// UnitRegistry resolves unit names from transcripts to a single unit row, preferring canonical
+// units, then stored units and their synonyms, then close spellings of either
+type UnitRegistry struct {
+	canonicalByKey map[string]*CanonicalUnit
+	unitsByKey     map[string]*hsds_types.Unit
+	units          []*hsds_types.Unit
+}
+
+// NewUnitRegistry builds a registry over the stored units and their synonyms
+func NewUnitRegistry(units []hsds_types.Unit, synonyms []supabase.UnitSynonym) *UnitRegistry {
+	registry := &UnitRegistry{
+		canonicalByKey: make(map[string]*CanonicalUnit),
+		unitsByKey:     make(map[string]*hsds_types.Unit),
+	}
+
+	for i := range canonicalUnits {
+		canonical := &canonicalUnits[i]
+		registry.canonicalByKey[normalizeUnitName(canonical.Name)] = canonical
+		for _, synonym := range canonical.Synonyms {
+			registry.canonicalByKey[normalizeUnitName(synonym)] = canonical
+		}
+	}
+
+	unitsByID := make(map[string]*hsds_types.Unit, len(units))
+	for i := range units {
+		unit := units[i]
+		registry.add(&unit)
+		unitsByID[unit.ID] = &unit
+	}
+	for _, synonym := range synonyms {
+		if unit, ok := unitsByID[synonym.UnitID]; ok {
+			key := normalizeUnitName(synonym.Synonym)
+			if _, taken := registry.unitsByKey[key]; !taken {
+				registry.unitsByKey[key] = unit
+			}
+		}
+	}
+
+	return registry
+}
+
+// LoadUnitRegistry builds a registry from the units and synonyms in Supabase
+func LoadUnitRegistry() (*UnitRegistry, error) {
+	units, err := supabase.FetchUnits()
+	if err != nil {
+		return nil, fmt.Errorf("error fetching existing units: %w", err)
+	}
+	synonyms, err := supabase.FetchUnitSynonyms()
+	if err != nil {
+		return nil, fmt.Errorf("error fetching unit synonyms: %w", err)
+	}
+	return NewUnitRegistry(units, synonyms), nil
+}
+
+func (r *UnitRegistry) add(unit *hsds_types.Unit) {
+	r.units = append(r.units, unit)
+	key := normalizeUnitName(unit.Name)
+	if _, taken := r.unitsByKey[key]; !taken {
+		r.unitsByKey[key] = unit
+	}
+}
+
+// Resolve returns the unit for a name, creating one when nothing matches. The bool reports
+// whether the unit is new and still needs to be stored.
+func (r *UnitRegistry) Resolve(name string) (*hsds_types.Unit, bool, error) {
+	log := logger.Get()
+	key := normalizeUnitName(name)
+
+	if canonical := r.canonicalFor(key); canonical != nil {
+		return r.resolveCanonical(canonical, name)
+	}
+
+	if unit, ok := r.unitsByKey[key]; ok {
+		log.Debug().
+			Str("unit_name", name).
+			Str("unit_id", unit.ID).
+			Msg("Using existing unit")
+		return unit, false, nil
+	}
+
+	if unit := r.closestUnit(key); unit != nil {
+		log.Debug().
+			Str("unit_name", name).
+			Str("matched_unit", unit.Name).
+			Str("unit_id", unit.ID).
+			Msg("Found fuzzy unit match")
+		return unit, false, nil
+	}
+
+	newUnit, err := hsds_types.NewUnit(strings.TrimSpace(name), &hsds_types.UnitOptions{})
+	if err != nil {
+		return nil, false, fmt.Errorf("error creating unit for %s: %w", name, err)
+	}
+	r.add(newUnit)
+
+	log.Debug().
+		Str("unit_name", name).
+		Str("unit_id", newUnit.ID).
+		Msg("Created new unit")
+	return newUnit, true, nil
+}
+
+// canonicalFor finds the canonical unit for a normalized name, allowing small misspellings
+func (r *UnitRegistry) canonicalFor(key string) *CanonicalUnit {
+	if canonical, ok := r.canonicalByKey[key]; ok {
+		return canonical
+	}
+	// A stored unit or synonym with this exact name takes priority over a fuzzy canonical match
+	if _, ok := r.unitsByKey[key]; ok {
+		return nil
+	}
+
+	var best *CanonicalUnit
+	highestSimilarity := 0.0
+	for _, candidate := range sortedUnitKeys(r.canonicalByKey) {
+		similarity := calculateStringSimilarity(key, candidate)
+		if similarity > unitFuzzyThreshold && similarity > highestSimilarity {
+			highestSimilarity = similarity
+			best = r.canonicalByKey[candidate]
+		}
+	}
+	if best != nil {
+		return best
+	}
+
+	// Fall back to a close stored unit, which may itself be canonical
+	if unit := r.closestUnit(key); unit != nil {
+		return r.canonicalByKey[normalizeUnitName(unit.Name)]
+	}
+	return nil
+}
+
+// resolveCanonical returns the stored row for a canonical unit, creating it on first use
+func (r *UnitRegistry) resolveCanonical(canonical *CanonicalUnit, name string) (*hsds_types.Unit, bool, error) {
+	log := logger.Get()
+
+	for _, unit := range r.units {
+		if r.isCanonical(unit, canonical) {
+			log.Debug().
+				Str("unit_name", name).
+				Str("canonical_unit", canonical.Name).
+				Str("unit_id", unit.ID).
+				Msg("Using canonical unit")
+			return unit, false, nil
+		}
+	}
+
+	scheme, identifier := canonical.Scheme, canonical.Identifier
+	newUnit, err := hsds_types.NewUnit(canonical.Name, &hsds_types.UnitOptions{
+		Scheme:     &scheme,
+		Identifier: &identifier,
+	})
+	if err != nil {
+		return nil, false, fmt.Errorf("error creating canonical unit %s: %w", canonical.Name, err)
+	}
+	r.add(newUnit)
+
+	log.Debug().
+		Str("unit_name", name).
+		Str("canonical_unit", canonical.Name).
+		Str("unit_id", newUnit.ID).
+		Msg("Created canonical unit")
+	return newUnit, true, nil
+}
+
+// isCanonical reports whether a stored unit is the row for a canonical unit, by scheme and
+// identifier when it has them and by name otherwise
+func (r *UnitRegistry) isCanonical(unit *hsds_types.Unit, canonical *CanonicalUnit) bool {
+	if unit.Scheme != nil && unit.Identifier != nil {
+		return *unit.Scheme == canonical.Scheme && *unit.Identifier == canonical.Identifier
+	}
+	return r.canonicalByKey[normalizeUnitName(unit.Name)] == canonical
+}
+
+// closestUnit returns the stored unit whose name is most similar to the key, if any is close enough
+func (r *UnitRegistry) closestUnit(key string) *hsds_types.Unit {
+	var best *hsds_types.Unit
+	highestSimilarity := 0.0
+	for _, candidate := range sortedUnitKeys(r.unitsByKey) {
+		similarity := calculateStringSimilarity(key, candidate)
+		if similarity > unitFuzzyThreshold && similarity > highestSimilarity {
+			highestSimilarity = similarity
+			best = r.unitsByKey[candidate]
+		}
+	}
+	return best
+}
+
+// sortedUnitKeys returns the keys in order so equally similar candidates resolve to the same
+// unit on every run, the lexically first one winning
+func sortedUnitKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// UnitMergeProposal is a group of stored units that look like the same unit
+type UnitMergeProposal struct {
+	Target     hsds_types.Unit   `json:"target"`
+	Duplicates []hsds_types.Unit `json:"duplicates"`
+	Reason     string            `json:"reason"`
+}
+
+// ProposeUnitMerges groups stored units that resolve to the same canonical unit or normalized name.
+// Nothing is changed; an admin approves a proposal by calling the merge endpoint.
+func (r *UnitRegistry) ProposeUnitMerges() []UnitMergeProposal {
+	type group struct {
+		canonical *CanonicalUnit
+		units     []*hsds_types.Unit
+	}
+	groups := make(map[string]*group)
+	var keys []string
+
+	for _, unit := range r.units {
+		nameKey := normalizeUnitName(unit.Name)
+		groupKey := "name:" + nameKey
+		canonical := r.canonicalByKey[nameKey]
+		if canonical == nil && unit.Scheme != nil && unit.Identifier != nil {
+			for i := range canonicalUnits {
+				if r.isCanonical(unit, &canonicalUnits[i]) {
+					canonical = &canonicalUnits[i]
+					break
+				}
+			}
+		}
+		if canonical != nil {
+			groupKey = "canonical:" + canonical.Scheme + ":" + canonical.Identifier
+		}
+
+		g, ok := groups[groupKey]
+		if !ok {
+			g = &group{canonical: canonical}
+			groups[groupKey] = g
+			keys = append(keys, groupKey)
+		}
+		g.units = append(g.units, unit)
+	}
+
+	sort.Strings(keys)
+	var proposals []UnitMergeProposal
+	for _, key := range keys {
+		g := groups[key]
+		if len(g.units) < 2 {
+			continue
+		}
+
+		target := g.units[0]
+		if g.canonical != nil {
+			for _, unit := range g.units {
+				if unit.Scheme != nil && unit.Identifier != nil && r.isCanonical(unit, g.canonical) {
+					target = unit
+					break
+				}
+			}
+		}
+
+		proposal := UnitMergeProposal{Target: *target}
+		for _, unit := range g.units {
+			if unit != target {
+				proposal.Duplicates = append(proposal.Duplicates, *unit)
+			}
+		}
+		if g.canonical != nil {
+			proposal.Reason = fmt.Sprintf("All resolve to the canonical unit %q (%s %s)", g.canonical.Name, g.canonical.Scheme, g.canonical.Identifier)
+		} else {
+			proposal.Reason = "Names match after normalizing case, punctuation and plurals"
+		}
+		proposals = append(proposals, proposal)
+	}
+
+	return proposals
+}
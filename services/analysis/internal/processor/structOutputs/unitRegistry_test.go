@@ -0,0 +1,198 @@
+package structOutputs
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/david-botos/BearHug/services/analysis/internal/hsds_types"
+	"github.com/david-botos/BearHug/services/analysis/internal/supabase"
+)
+
+func TestNormalizeUnitName(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"Beds", "bed"},
+		{"People", "person"},
+		{"Children", "child"},
+		{"Food Boxes", "food box"},
+		{"hot-meals!", "hot meal"},
+		{"Families", "family"},
+		{"Classes", "class"},
+		{"Status", "status"},
+		{"hrs", "hrs"},
+		{"  Shelter   Beds ", "shelter bed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if got := normalizeUnitName(tt.input); got != tt.want {
+				t.Errorf("normalizeUnitName(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func unitFixture(id, name, scheme, identifier string) hsds_types.Unit {
+	unit := hsds_types.Unit{ID: id, Name: name}
+	if scheme != "" {
+		unit.Scheme = &scheme
+		unit.Identifier = &identifier
+	}
+	return unit
+}
+
+func TestUnitRegistryResolve(t *testing.T) {
+	build := func() *UnitRegistry {
+		return NewUnitRegistry(
+			[]hsds_types.Unit{
+				unitFixture("unit-bed", "Bed", UnitSchemeBearHug, "bed"),
+				unitFixture("unit-meal", "Meals", "", ""),
+				unitFixture("unit-diaper", "Diaper Pack", "", ""),
+			},
+			[]supabase.UnitSynonym{
+				{ID: "syn-1", UnitID: "unit-diaper", Synonym: "pack of diapers"},
+				{ID: "syn-2", UnitID: "missing-unit", Synonym: "crate"},
+			},
+		)
+	}
+
+	tests := []struct {
+		name           string
+		input          string
+		wantID         string // empty when a new unit is expected
+		wantName       string
+		wantScheme     string
+		wantIdentifier string
+		wantNew        bool
+	}{
+		{name: "canonical synonym uses stored canonical row", input: "cots", wantID: "unit-bed"},
+		{name: "multi-word canonical synonym", input: "Shelter Beds", wantID: "unit-bed"},
+		{name: "canonical matched by stored name", input: "plate", wantID: "unit-meal"},
+		{name: "stored name", input: "diaper packs", wantID: "unit-diaper"},
+		{name: "stored synonym", input: "Pack of Diapers", wantID: "unit-diaper"},
+		{name: "misspelled stored name", input: "Diaper Pak", wantID: "unit-diaper"},
+		{
+			name: "canonical created on first use", input: "Hours",
+			wantName: "Hour", wantScheme: UnitSchemeUCUM, wantIdentifier: "h", wantNew: true,
+		},
+		{
+			name: "misspelled canonical", input: "apointments",
+			wantName: "Appointment", wantScheme: UnitSchemeBearHug, wantIdentifier: "appointment", wantNew: true,
+		},
+		{name: "synonym for a missing unit is ignored", input: "crate", wantName: "crate", wantNew: true},
+		{name: "unknown name", input: " Hygiene Kit ", wantName: "Hygiene Kit", wantNew: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			unit, isNew, err := build().Resolve(tt.input)
+			if err != nil {
+				t.Fatalf("Resolve(%q): %v", tt.input, err)
+			}
+			if isNew != tt.wantNew {
+				t.Errorf("isNew = %v, want %v", isNew, tt.wantNew)
+			}
+			if tt.wantID != "" {
+				if unit.ID != tt.wantID {
+					t.Errorf("unit = %s (%q), want %s", unit.ID, unit.Name, tt.wantID)
+				}
+				return
+			}
+
+			if unit.Name != tt.wantName {
+				t.Errorf("Name = %q, want %q", unit.Name, tt.wantName)
+			}
+			var scheme, identifier string
+			if unit.Scheme != nil {
+				scheme = *unit.Scheme
+			}
+			if unit.Identifier != nil {
+				identifier = *unit.Identifier
+			}
+			if scheme != tt.wantScheme || identifier != tt.wantIdentifier {
+				t.Errorf("scheme/identifier = %q/%q, want %q/%q", scheme, identifier, tt.wantScheme, tt.wantIdentifier)
+			}
+		})
+	}
+}
+
+func TestUnitRegistryResolveReusesNewUnits(t *testing.T) {
+	registry := NewUnitRegistry(nil, nil)
+
+	for _, pair := range [][2]string{{"Hygiene Kit", "hygiene kits"}, {"family", "Households"}} {
+		first, isNew, err := registry.Resolve(pair[0])
+		if err != nil || !isNew {
+			t.Fatalf("Resolve(%q) = new %v, err %v; want a new unit", pair[0], isNew, err)
+		}
+		second, isNew, err := registry.Resolve(pair[1])
+		if err != nil {
+			t.Fatalf("Resolve(%q): %v", pair[1], err)
+		}
+		if isNew || second.ID != first.ID {
+			t.Errorf("Resolve(%q) = %s (new %v), want %s from %q", pair[1], second.ID, isNew, first.ID, pair[0])
+		}
+	}
+}
+
+func TestProposeUnitMerges(t *testing.T) {
+	registry := NewUnitRegistry([]hsds_types.Unit{
+		unitFixture("bed-plural", "beds", "", ""),
+		unitFixture("bed-canonical", "Bed", UnitSchemeBearHug, "bed"),
+		unitFixture("bed-cot", "Cot", "", ""),
+		unitFixture("hour-abbrev", "hr", "", ""),
+		unitFixture("hour-canonical", "Hours", UnitSchemeUCUM, "h"),
+		unitFixture("kit-1", "Hygiene Kit", "", ""),
+		unitFixture("kit-2", "hygiene-kits", "", ""),
+		unitFixture("voucher", "Voucher", "", ""),
+	}, nil)
+
+	type proposal struct {
+		target     string
+		duplicates []string
+		canonical  bool
+	}
+	want := []proposal{
+		{target: "bed-canonical", duplicates: []string{"bed-plural", "bed-cot"}, canonical: true},
+		{target: "hour-canonical", duplicates: []string{"hour-abbrev"}, canonical: true},
+		{target: "kit-1", duplicates: []string{"kit-2"}},
+	}
+
+	var got []proposal
+	for _, p := range registry.ProposeUnitMerges() {
+		duplicates := []string{}
+		for _, unit := range p.Duplicates {
+			duplicates = append(duplicates, unit.ID)
+		}
+		got = append(got, proposal{
+			target:     p.Target.ID,
+			duplicates: duplicates,
+			canonical:  p.Reason != "Names match after normalizing case, punctuation and plurals",
+		})
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ProposeUnitMerges() = %+v, want %+v", got, want)
+	}
+}
+
+func TestUnitRegistryClosestUnitBreaksTies(t *testing.T) {
+	units := []hsds_types.Unit{
+		unitFixture("unit-b", "widgetb", "", ""),
+		unitFixture("unit-a", "widgeta", "", ""),
+		unitFixture("unit-d", "widgetd", "", ""),
+	}
+
+	// Every stored name is one edit away, so the lexically first must win whatever the order
+	for i := 0; i < len(units); i++ {
+		rotated := append(append([]hsds_types.Unit{}, units[i:]...), units[:i]...)
+		registry := NewUnitRegistry(rotated, nil)
+		for run := 0; run < 20; run++ {
+			unit := registry.closestUnit("widgetc")
+			if unit == nil || unit.ID != "unit-a" {
+				t.Fatalf("closestUnit(%q) = %v, want unit-a", "widgetc", unit)
+			}
+		}
+	}
+}
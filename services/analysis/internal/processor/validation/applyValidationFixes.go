@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"reflect"
 	"strconv"
+	"strings"
 
 	"github.com/david-botos/BearHug/services/analysis/internal/hsds_types"
 	"github.com/david-botos/BearHug/services/analysis/internal/processor/structOutputs"
@@ -14,6 +15,8 @@ func applyFixes(
 	fixes FixOutput,
 	affectedServices map[string]*hsds_types.Service,
 	affectedCapacities map[string]*hsds_types.ServiceCapacity,
+	affectedContacts map[string]*hsds_types.Contact,
+	affectedPhones map[string]*hsds_types.Phone,
 	details []*structOutputs.DetailAnalysisResult,
 	serviceCtx structOutputs.ServiceContext,
 ) ([]*structOutputs.DetailAnalysisResult, structOutputs.ServiceContext, error) {
@@ -22,8 +25,9 @@ func applyFixes(
 	copy(newDetails, details)
 
 	newServiceCtx := structOutputs.ServiceContext{
-		ExistingServices: make([]*hsds_types.Service, len(serviceCtx.ExistingServices)),
-		NewServices:      make([]*hsds_types.Service, len(serviceCtx.NewServices)),
+		ExistingServices:  make([]*hsds_types.Service, len(serviceCtx.ExistingServices)),
+		NewServices:       make([]*hsds_types.Service, len(serviceCtx.NewServices)),
+		NewServiceSources: serviceCtx.NewServiceSources,
 	}
 	copy(newServiceCtx.ExistingServices, serviceCtx.ExistingServices)
 	copy(newServiceCtx.NewServices, serviceCtx.NewServices)
@@ -37,12 +41,12 @@ func applyFixes(
 			}
 
 		case "MODIFY":
-			if err := applyModifyFix(fix, affectedServices, affectedCapacities, &newDetails, &newServiceCtx); err != nil {
+			if err := applyModifyFix(fix, affectedServices, affectedCapacities, affectedContacts, affectedPhones, &newDetails, &newServiceCtx); err != nil {
 				return nil, structOutputs.ServiceContext{}, fmt.Errorf("error applying modify fix: %w", err)
 			}
 
 		case "MERGE":
-			if err := applyMergeFix(fix, affectedServices, affectedCapacities, &newDetails, &newServiceCtx); err != nil {
+			if err := applyMergeFix(fix, affectedServices, affectedCapacities, affectedContacts, affectedPhones, &newDetails, &newServiceCtx); err != nil {
 				return nil, structOutputs.ServiceContext{}, fmt.Errorf("error applying merge fix: %w", err)
 			}
 		}
@@ -61,31 +65,81 @@ func applyRemoveFix(
 	case "HALLUCINATION":
 		// Remove the specified objects by ID
 		for _, id := range fix.ObjectIDs {
-			// Remove from details if it's a capacity
-			for _, detail := range *details {
-				if detail.CapacityData != nil {
-					// Filter out the capacity
-					newCapacities := make([]*hsds_types.ServiceCapacity, 0)
-					for _, cap := range detail.CapacityData.Capacities {
-						if cap.ID != id {
-							newCapacities = append(newCapacities, cap)
-						}
-					}
-					detail.CapacityData.Capacities = newCapacities
+			removeObject(id, details, serviceCtx)
+		}
+	}
+	return nil
+}
+
+// removeObject drops a new service, capacity, contact or phone by ID, or withholds the update to a
+// stored contact or phone. Phones belonging to a removed contact are dropped with it, since they were
+// extracted together, and so is anything extracted for a removed new service, since it is never stored.
+func removeObject(id string, details *[]*structOutputs.DetailAnalysisResult, serviceCtx *structOutputs.ServiceContext) {
+	newService := false
+	for _, svc := range serviceCtx.NewServices {
+		if svc.ID == id {
+			newService = true
+		}
+	}
+	belongsToRemoved := func(serviceID *string) bool {
+		return newService && serviceID != nil && *serviceID == id
+	}
+
+	for _, detail := range *details {
+		if detail.CapacityData != nil {
+			// Filter out the capacity
+			newCapacities := make([]*hsds_types.ServiceCapacity, 0)
+			for _, cap := range detail.CapacityData.Capacities {
+				if cap.ID != id && !belongsToRemoved(&cap.ServiceID) {
+					newCapacities = append(newCapacities, cap)
+				}
+			}
+			detail.CapacityData.Capacities = newCapacities
+		}
+
+		if detail.ContactData != nil {
+			removedContacts := map[string]bool{id: true}
+			newContacts := make([]*hsds_types.Contact, 0)
+			for _, contact := range detail.ContactData.Contacts {
+				if contact.ID == id || belongsToRemoved(contact.ServiceID) {
+					removedContacts[contact.ID] = true
+					continue
+				}
+				newContacts = append(newContacts, contact)
+			}
+			detail.ContactData.Contacts = newContacts
+
+			newPhones := make([]*hsds_types.Phone, 0)
+			for _, phone := range detail.ContactData.Phones {
+				if phone.ID == id || (phone.ContactID != nil && removedContacts[*phone.ContactID]) || belongsToRemoved(phone.ServiceID) {
+					continue
 				}
+				newPhones = append(newPhones, phone)
 			}
+			detail.ContactData.Phones = newPhones
 
-			// Remove from services if it's a service
-			newServices := make([]*hsds_types.Service, 0)
-			for _, svc := range serviceCtx.NewServices {
-				if svc.ID != id {
-					newServices = append(newServices, svc)
+			newUpdates := make([]*structOutputs.ContactUpdate, 0)
+			for _, update := range detail.ContactData.Updates {
+				if update.Contact.ID == id {
+					continue
+				}
+				if update.Phone != nil && update.Phone.ID == id {
+					update.Phone = nil
 				}
+				newUpdates = append(newUpdates, update)
 			}
-			serviceCtx.NewServices = newServices
+			detail.ContactData.Updates = newUpdates
 		}
 	}
-	return nil
+
+	// Remove from services if it's a service
+	newServices := make([]*hsds_types.Service, 0)
+	for _, svc := range serviceCtx.NewServices {
+		if svc.ID != id {
+			newServices = append(newServices, svc)
+		}
+	}
+	serviceCtx.NewServices = newServices
 }
 
 // applyModifyFix handles modification of incorrect information
@@ -93,6 +147,8 @@ func applyModifyFix(
 	fix Fix,
 	affectedServices map[string]*hsds_types.Service,
 	affectedCapacities map[string]*hsds_types.ServiceCapacity,
+	affectedContacts map[string]*hsds_types.Contact,
+	affectedPhones map[string]*hsds_types.Phone,
 	details *[]*structOutputs.DetailAnalysisResult,
 	serviceCtx *structOutputs.ServiceContext,
 ) error {
@@ -116,6 +172,20 @@ func applyModifyFix(
 					return fmt.Errorf("error modifying capacity field: %w", err)
 				}
 			}
+
+			// Modify contact field
+			if contact, exists := affectedContacts[id]; exists {
+				if err := setContactField(contact, fix.Modification.Field, fix.Modification.NewValue); err != nil {
+					return fmt.Errorf("error modifying contact field: %w", err)
+				}
+			}
+
+			// Modify phone field
+			if phone, exists := affectedPhones[id]; exists {
+				if err := setPhoneField(phone, fix.Modification.Field, fix.Modification.NewValue); err != nil {
+					return fmt.Errorf("error modifying phone field: %w", err)
+				}
+			}
 		}
 	}
 	return nil
@@ -126,6 +196,8 @@ func applyMergeFix(
 	fix Fix,
 	affectedServices map[string]*hsds_types.Service,
 	affectedCapacities map[string]*hsds_types.ServiceCapacity,
+	affectedContacts map[string]*hsds_types.Contact,
+	affectedPhones map[string]*hsds_types.Phone,
 	details *[]*structOutputs.DetailAnalysisResult,
 	serviceCtx *structOutputs.ServiceContext,
 ) error {
@@ -136,11 +208,19 @@ func applyMergeFix(
 	switch fix.IssueType {
 	case "DUPLICATE":
 		// Apply field resolutions to the kept record
-		if service, exists := affectedServices[fix.KeepID]; exists {
-			for _, resolution := range fix.FieldResolutions {
-				if err := setServiceField(service, resolution.Field, resolution.Value); err != nil {
-					return fmt.Errorf("error applying field resolution: %w", err)
-				}
+		for _, resolution := range fix.FieldResolutions {
+			var err error
+			if service, exists := affectedServices[fix.KeepID]; exists {
+				err = setServiceField(service, resolution.Field, resolution.Value)
+			} else if capacity, exists := affectedCapacities[fix.KeepID]; exists {
+				err = setCapacityField(capacity, resolution.Field, resolution.Value)
+			} else if contact, exists := affectedContacts[fix.KeepID]; exists {
+				err = setContactField(contact, resolution.Field, resolution.Value)
+			} else if phone, exists := affectedPhones[fix.KeepID]; exists {
+				err = setPhoneField(phone, resolution.Field, resolution.Value)
+			}
+			if err != nil {
+				return fmt.Errorf("error applying field resolution: %w", err)
 			}
 		}
 
@@ -150,16 +230,7 @@ func applyMergeFix(
 				continue
 			}
 
-			// Remove from new services
-			newServices := make([]*hsds_types.Service, 0)
-			for _, svc := range serviceCtx.NewServices {
-				if svc.ID != id {
-					newServices = append(newServices, svc)
-				}
-			}
-			serviceCtx.NewServices = newServices
-
-			// Update any references in capacities
+			// Update any references to the duplicate before it is removed
 			for _, detail := range *details {
 				if detail.CapacityData != nil {
 					for _, cap := range detail.CapacityData.Capacities {
@@ -168,7 +239,17 @@ func applyMergeFix(
 						}
 					}
 				}
+				if detail.ContactData != nil {
+					for _, phone := range detail.ContactData.Phones {
+						if phone.ContactID != nil && *phone.ContactID == id {
+							keepID := fix.KeepID
+							phone.ContactID = &keepID
+						}
+					}
+				}
 			}
+
+			removeObject(id, details, serviceCtx)
 		}
 	}
 	return nil
@@ -176,13 +257,49 @@ func applyMergeFix(
 
 // setServiceField sets a field on a Service object using reflection
 func setServiceField(service *hsds_types.Service, fieldName, value string) error {
-	v := reflect.ValueOf(service).Elem()
+	return setStructField(service, fieldName, value)
+}
+
+// setCapacityField sets a field on a ServiceCapacity object using reflection
+func setCapacityField(capacity *hsds_types.ServiceCapacity, fieldName, value string) error {
+	return setStructField(capacity, fieldName, value)
+}
+
+// setContactField sets a field on a Contact object using reflection
+func setContactField(contact *hsds_types.Contact, fieldName, value string) error {
+	return setStructField(contact, fieldName, value)
+}
+
+// setPhoneField sets a field on a Phone object using reflection
+func setPhoneField(phone *hsds_types.Phone, fieldName, value string) error {
+	return setStructField(phone, fieldName, value)
+}
+
+// setStructField sets a field by Go name or json tag, allocating optional pointer fields as needed
+func setStructField(obj interface{}, fieldName, value string) error {
+	v := reflect.ValueOf(obj).Elem()
 	field := v.FieldByName(fieldName)
+	if !field.IsValid() {
+		field = fieldByJSONTag(v, fieldName)
+	}
 
 	if !field.IsValid() {
 		return fmt.Errorf("invalid field name: %s", fieldName)
 	}
 
+	if field.Kind() == reflect.Ptr {
+		ptr := reflect.New(field.Type().Elem())
+		if err := setScalar(ptr.Elem(), value); err != nil {
+			return err
+		}
+		field.Set(ptr)
+		return nil
+	}
+
+	return setScalar(field, value)
+}
+
+func setScalar(field reflect.Value, value string) error {
 	switch field.Kind() {
 	case reflect.String:
 		field.SetString(value)
@@ -200,28 +317,13 @@ func setServiceField(service *hsds_types.Service, fieldName, value string) error
 	return nil
 }
 
-// setCapacityField sets a field on a ServiceCapacity object using reflection
-func setCapacityField(capacity *hsds_types.ServiceCapacity, fieldName, value string) error {
-	v := reflect.ValueOf(capacity).Elem()
-	field := v.FieldByName(fieldName)
-
-	if !field.IsValid() {
-		return fmt.Errorf("invalid field name: %s", fieldName)
-	}
-
-	switch field.Kind() {
-	case reflect.Float64:
-		f, err := strconv.ParseFloat(value, 64)
-		if err != nil {
-			return fmt.Errorf("invalid float value: %s", value)
+func fieldByJSONTag(v reflect.Value, tag string) reflect.Value {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if name == tag {
+			return v.Field(i)
 		}
-		field.SetFloat(f)
-	case reflect.String:
-		field.SetString(value)
-	// Add other types as needed
-	default:
-		return fmt.Errorf("unsupported field type: %v", field.Kind())
 	}
-
-	return nil
+	return reflect.Value{}
 }
@@ -0,0 +1,173 @@
+package validation
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/david-botos/BearHug/services/analysis/internal/hsds_types"
+	"github.com/david-botos/BearHug/services/analysis/internal/processor/structOutputs"
+)
+
+func TestRemoveObject(t *testing.T) {
+	newServiceID, existingServiceID := "new-service", "existing-service"
+	contactID := "contact-1"
+
+	build := func() ([]*structOutputs.DetailAnalysisResult, structOutputs.ServiceContext) {
+		details := []*structOutputs.DetailAnalysisResult{
+			{
+				Category: structOutputs.CapacityCategory,
+				CapacityData: &structOutputs.CapacityResult{
+					Capacities: []*hsds_types.ServiceCapacity{
+						{ID: "capacity-new", ServiceID: newServiceID},
+						{ID: "capacity-existing", ServiceID: existingServiceID},
+					},
+				},
+			},
+			{
+				Category: structOutputs.ContactCategory,
+				ContactData: &structOutputs.ContactResult{
+					Contacts: []*hsds_types.Contact{
+						{ID: contactID, ServiceID: &newServiceID},
+						{ID: "contact-2", ServiceID: &existingServiceID},
+					},
+					Phones: []*hsds_types.Phone{
+						{ID: "phone-contact", ContactID: &contactID},
+						{ID: "phone-new", ServiceID: &newServiceID},
+						{ID: "phone-existing", ServiceID: &existingServiceID},
+					},
+				},
+			},
+		}
+		serviceCtx := structOutputs.ServiceContext{
+			ExistingServices: []*hsds_types.Service{{ID: existingServiceID}},
+			NewServices:      []*hsds_types.Service{{ID: newServiceID}},
+		}
+		return details, serviceCtx
+	}
+
+	tests := []struct {
+		name           string
+		id             string
+		wantServices   []string
+		wantCapacities []string
+		wantContacts   []string
+		wantPhones     []string
+	}{
+		{
+			name:           "new service takes what was extracted for it",
+			id:             newServiceID,
+			wantServices:   []string{},
+			wantCapacities: []string{"capacity-existing"},
+			wantContacts:   []string{"contact-2"},
+			wantPhones:     []string{"phone-existing"},
+		},
+		{
+			name:           "existing service keeps its details",
+			id:             existingServiceID,
+			wantServices:   []string{newServiceID},
+			wantCapacities: []string{"capacity-new", "capacity-existing"},
+			wantContacts:   []string{contactID, "contact-2"},
+			wantPhones:     []string{"phone-contact", "phone-new", "phone-existing"},
+		},
+		{
+			name:           "contact takes its phones",
+			id:             contactID,
+			wantServices:   []string{newServiceID},
+			wantCapacities: []string{"capacity-new", "capacity-existing"},
+			wantContacts:   []string{"contact-2"},
+			wantPhones:     []string{"phone-new", "phone-existing"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			details, serviceCtx := build()
+			removeObject(tt.id, &details, &serviceCtx)
+
+			services := []string{}
+			for _, service := range serviceCtx.NewServices {
+				services = append(services, service.ID)
+			}
+			capacities := []string{}
+			for _, capacity := range details[0].CapacityData.Capacities {
+				capacities = append(capacities, capacity.ID)
+			}
+			contacts := []string{}
+			for _, contact := range details[1].ContactData.Contacts {
+				contacts = append(contacts, contact.ID)
+			}
+			phones := []string{}
+			for _, phone := range details[1].ContactData.Phones {
+				phones = append(phones, phone.ID)
+			}
+
+			for _, check := range []struct {
+				name      string
+				got, want []string
+			}{
+				{"new services", services, tt.wantServices},
+				{"capacities", capacities, tt.wantCapacities},
+				{"contacts", contacts, tt.wantContacts},
+				{"phones", phones, tt.wantPhones},
+			} {
+				if !reflect.DeepEqual(check.got, check.want) {
+					t.Errorf("%s = %v, want %v", check.name, check.got, check.want)
+				}
+			}
+		})
+	}
+}
+
+func TestRemoveObjectWithholdsContactUpdates(t *testing.T) {
+	build := func() []*structOutputs.DetailAnalysisResult {
+		return []*structOutputs.DetailAnalysisResult{{
+			Category: structOutputs.ContactCategory,
+			ContactData: &structOutputs.ContactResult{
+				Phones: []*hsds_types.Phone{{ID: "phone-added", ContactID: strPtr("stored-1")}},
+				Updates: []*structOutputs.ContactUpdate{
+					{Contact: &hsds_types.Contact{ID: "stored-1"}, Phone: &hsds_types.Phone{ID: "stored-phone-1"}},
+					{Contact: &hsds_types.Contact{ID: "stored-2"}, Phone: &hsds_types.Phone{ID: "stored-phone-2"}},
+				},
+			},
+		}}
+	}
+
+	tests := []struct {
+		name        string
+		id          string
+		wantUpdates []string // contact ID, then phone ID when the phone update is kept
+		wantPhones  []string
+	}{
+		{name: "stored contact", id: "stored-1", wantUpdates: []string{"stored-2", "stored-phone-2"}, wantPhones: []string{}},
+		{name: "stored phone", id: "stored-phone-2", wantUpdates: []string{"stored-1", "stored-phone-1", "stored-2"}, wantPhones: []string{"phone-added"}},
+		{name: "phone added to a stored contact", id: "phone-added", wantUpdates: []string{"stored-1", "stored-phone-1", "stored-2", "stored-phone-2"}, wantPhones: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			details := build()
+			removeObject(tt.id, &details, &structOutputs.ServiceContext{})
+
+			updates := []string{}
+			for _, update := range details[0].ContactData.Updates {
+				updates = append(updates, update.Contact.ID)
+				if update.Phone != nil {
+					updates = append(updates, update.Phone.ID)
+				}
+			}
+			phones := []string{}
+			for _, phone := range details[0].ContactData.Phones {
+				phones = append(phones, phone.ID)
+			}
+
+			if !reflect.DeepEqual(updates, tt.wantUpdates) {
+				t.Errorf("updates = %v, want %v", updates, tt.wantUpdates)
+			}
+			if !reflect.DeepEqual(phones, tt.wantPhones) {
+				t.Errorf("phones = %v, want %v", phones, tt.wantPhones)
+			}
+		})
+	}
+}
+
+func strPtr(s string) *string { return &s }
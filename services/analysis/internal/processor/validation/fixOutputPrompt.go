@@ -10,7 +10,7 @@ import (
 )
 
 func generateFixPrompt(currentState, issuesSummary, transcript string) (string, inference.ToolInputSchema, error) {
-	const promptTemplate = `You are helping to fix issues identified in service capacity information extracted from a transcript. You will be given the current state of the data, identified issues, and the original transcript. Your task is to provide specific fixes for each issue.
+	const promptTemplate = `You are helping to fix issues identified in service, capacity and contact information extracted from a transcript. You will be given the current state of the data, identified issues, and the original transcript. Your task is to provide specific fixes for each issue.
 
 Original Transcript:
 ---
@@ -155,13 +155,17 @@ type Fix struct {
 	} `json:"field_resolutions,omitempty"`
 }
 
-// ContextMaps holds lookup maps for quick reference to services, units, and capacities
+// ContextMaps holds lookup maps for quick reference to services, units, capacities, contacts and phones
 type ContextMaps struct {
-	ServiceMap   map[string]*hsds_types.Service
-	UnitMap      map[string]*hsds_types.Unit
-	CapacityMap  map[string]*hsds_types.ServiceCapacity
-	AffectedSvcs map[string]bool
-	AffectedCaps map[string]bool
+	ServiceMap       map[string]*hsds_types.Service
+	UnitMap          map[string]*hsds_types.Unit
+	CapacityMap      map[string]*hsds_types.ServiceCapacity
+	ContactMap       map[string]*hsds_types.Contact
+	PhoneMap         map[string]*hsds_types.Phone
+	AffectedSvcs     map[string]bool
+	AffectedCaps     map[string]bool
+	AffectedContacts map[string]bool
+	AffectedPhones   map[string]bool
 }
 
 // buildContextMaps creates lookup maps for all objects and marks which ones are affected by issues
@@ -171,11 +175,15 @@ func buildContextMaps(
 	issues []ValidationItem,
 ) *ContextMaps {
 	ctx := &ContextMaps{
-		ServiceMap:   make(map[string]*hsds_types.Service),
-		UnitMap:      make(map[string]*hsds_types.Unit),
-		CapacityMap:  make(map[string]*hsds_types.ServiceCapacity),
-		AffectedSvcs: make(map[string]bool),
-		AffectedCaps: make(map[string]bool),
+		ServiceMap:       make(map[string]*hsds_types.Service),
+		UnitMap:          make(map[string]*hsds_types.Unit),
+		CapacityMap:      make(map[string]*hsds_types.ServiceCapacity),
+		ContactMap:       make(map[string]*hsds_types.Contact),
+		PhoneMap:         make(map[string]*hsds_types.Phone),
+		AffectedSvcs:     make(map[string]bool),
+		AffectedCaps:     make(map[string]bool),
+		AffectedContacts: make(map[string]bool),
+		AffectedPhones:   make(map[string]bool),
 	}
 
 	// Map existing services
@@ -187,7 +195,7 @@ func buildContextMaps(
 		ctx.ServiceMap[service.ID] = service
 	}
 
-	// Map units and capacities
+	// Map units, capacities, contacts and phones
 	for _, detail := range details {
 		if detail.CapacityData != nil {
 			for _, unit := range detail.CapacityData.Units {
@@ -197,6 +205,21 @@ func buildContextMaps(
 				ctx.CapacityMap[capacity.ID] = capacity
 			}
 		}
+		if detail.ContactData != nil {
+			for _, contact := range detail.ContactData.Contacts {
+				ctx.ContactMap[contact.ID] = contact
+			}
+			for _, phone := range detail.ContactData.Phones {
+				ctx.PhoneMap[phone.ID] = phone
+			}
+			// Updated stored records can be corrected the same way as new ones
+			for _, update := range detail.ContactData.Updates {
+				ctx.ContactMap[update.Contact.ID] = update.Contact
+				if update.Phone != nil {
+					ctx.PhoneMap[update.Phone.ID] = update.Phone
+				}
+			}
+		}
 	}
 
 	// Mark affected items from issues
@@ -213,6 +236,16 @@ func buildContextMaps(
 				}
 			case "CAPACITY":
 				ctx.AffectedCaps[id] = true
+			case "CONTACT":
+				ctx.AffectedContacts[id] = true
+				// Also mark phones belonging to affected contacts
+				for phoneID, phone := range ctx.PhoneMap {
+					if phone.ContactID != nil && *phone.ContactID == id {
+						ctx.AffectedPhones[phoneID] = true
+					}
+				}
+			case "PHONE":
+				ctx.AffectedPhones[id] = true
 			}
 		}
 	}
@@ -271,6 +304,44 @@ func buildFixContext(
 		currentState.WriteString("\n")
 	}
 
+	// Add contacts and their phones
+	if len(ctx.ContactMap) > 0 || len(ctx.PhoneMap) > 0 {
+		currentState.WriteString("=== Contacts ===\n")
+		writtenPhones := make(map[string]bool)
+		for contactID, contact := range ctx.ContactMap {
+			prefix := ""
+			if ctx.AffectedContacts[contactID] {
+				prefix = "* "
+			}
+			writeContact(&currentState, prefix, contact)
+
+			for phoneID, phone := range ctx.PhoneMap {
+				if phone.ContactID != nil && *phone.ContactID == contactID {
+					prefix := "- "
+					if ctx.AffectedPhones[phoneID] {
+						prefix = "* - "
+					}
+					writePhone(&currentState, prefix, phone)
+					writtenPhones[phoneID] = true
+				}
+			}
+			currentState.WriteString("\n")
+		}
+
+		// Phones that aren't tied to a contact in this extraction
+		for phoneID, phone := range ctx.PhoneMap {
+			if writtenPhones[phoneID] {
+				continue
+			}
+			prefix := "- "
+			if ctx.AffectedPhones[phoneID] {
+				prefix = "* - "
+			}
+			writePhone(&currentState, prefix, phone)
+		}
+		currentState.WriteString("\n")
+	}
+
 	// Build issues context (same as before)
 	issuesSummary.WriteString("=== Identified Issues ===\n")
 
@@ -315,10 +386,50 @@ func buildFixContext(
 	return currentState.String(), issuesSummary.String()
 }
 
+// writeContact writes a single contact line for the fix and validation context
+func writeContact(builder *strings.Builder, prefix string, contact *hsds_types.Contact) {
+	builder.WriteString(fmt.Sprintf("%sContact: %s (ID: %s)\n", prefix, stringOrUnknown(contact.Name), contact.ID))
+	if contact.Title != nil {
+		builder.WriteString(fmt.Sprintf("Title: %s\n", *contact.Title))
+	}
+	if contact.Department != nil {
+		builder.WriteString(fmt.Sprintf("Department: %s\n", *contact.Department))
+	}
+	if contact.Email != nil {
+		builder.WriteString(fmt.Sprintf("Email: %s\n", *contact.Email))
+	}
+}
+
+// writePhone writes a single phone line for the fix and validation context
+func writePhone(builder *strings.Builder, prefix string, phone *hsds_types.Phone) {
+	builder.WriteString(fmt.Sprintf("%sPhone: %s (ID: %s)", prefix, phone.Number, phone.ID))
+	if phone.Extension != nil {
+		builder.WriteString(fmt.Sprintf(" ext. %.0f", *phone.Extension))
+	}
+	if phone.Description != nil {
+		builder.WriteString(fmt.Sprintf(" - %s", *phone.Description))
+	}
+	builder.WriteString("\n")
+}
+
+func stringOrUnknown(s *string) string {
+	if s == nil || *s == "" {
+		return "(name not given)"
+	}
+	return *s
+}
+
 // getAffectedItems now just returns relevant subsets from the context maps
-func getAffectedItems(ctx *ContextMaps) (affectedServices map[string]*hsds_types.Service, affectedCapacities map[string]*hsds_types.ServiceCapacity) {
+func getAffectedItems(ctx *ContextMaps) (
+	affectedServices map[string]*hsds_types.Service,
+	affectedCapacities map[string]*hsds_types.ServiceCapacity,
+	affectedContacts map[string]*hsds_types.Contact,
+	affectedPhones map[string]*hsds_types.Phone,
+) {
 	affectedServices = make(map[string]*hsds_types.Service)
 	affectedCapacities = make(map[string]*hsds_types.ServiceCapacity)
+	affectedContacts = make(map[string]*hsds_types.Contact)
+	affectedPhones = make(map[string]*hsds_types.Phone)
 
 	for id := range ctx.AffectedSvcs {
 		if service, exists := ctx.ServiceMap[id]; exists {
@@ -332,5 +443,17 @@ func getAffectedItems(ctx *ContextMaps) (affectedServices map[string]*hsds_types
 		}
 	}
 
-	return affectedServices, affectedCapacities
+	for id := range ctx.AffectedContacts {
+		if contact, exists := ctx.ContactMap[id]; exists {
+			affectedContacts[id] = contact
+		}
+	}
+
+	for id := range ctx.AffectedPhones {
+		if phone, exists := ctx.PhoneMap[id]; exists {
+			affectedPhones[id] = phone
+		}
+	}
+
+	return affectedServices, affectedCapacities, affectedContacts, affectedPhones
 }
@@ -0,0 +1,362 @@
+package validation
+
+import (
+	"fmt"
+	"net/mail"
+	"strings"
+	"sync"
+
+	"github.com/david-botos/BearHug/services/analysis/internal/hsds_types"
+	"github.com/david-botos/BearHug/services/analysis/internal/phonenumber"
+	"github.com/david-botos/BearHug/services/analysis/internal/processor/structOutputs"
+)
+
+// Rule is a deterministic validator that checks extracted details without running inference.
+// Rules report problems as ValidationItems so they go through the same fix loop as the LLM validator.
+type Rule interface {
+	Name() string
+	Check(details []*structOutputs.DetailAnalysisResult, serviceCtx structOutputs.ServiceContext) []ValidationItem
+}
+
+// RuleFunc adapts a plain function into a Rule
+type RuleFunc struct {
+	RuleName string
+	CheckFn  func(details []*structOutputs.DetailAnalysisResult, serviceCtx structOutputs.ServiceContext) []ValidationItem
+}
+
+func (r RuleFunc) Name() string { return r.RuleName }
+
+func (r RuleFunc) Check(details []*structOutputs.DetailAnalysisResult, serviceCtx structOutputs.ServiceContext) []ValidationItem {
+	return r.CheckFn(details, serviceCtx)
+}
+
+var (
+	ruleRegistryMu sync.RWMutex
+	ruleRegistry   = []Rule{
+		RuleFunc{"capacity_available_exceeds_maximum", checkAvailableWithinMaximum},
+		RuleFunc{"capacity_negative", checkNonNegativeCapacity},
+		RuleFunc{"phone_not_e164", checkPhoneE164},
+		RuleFunc{"email_invalid", checkEmailSyntax},
+		RuleFunc{"contact_duplicate", checkDuplicateContacts},
+		RuleFunc{"service_description_empty", checkServiceDescriptions},
+	}
+)
+
+// RegisterRule adds a rule to the registry used by RunRules
+func RegisterRule(rule Rule) {
+	ruleRegistryMu.Lock()
+	defer ruleRegistryMu.Unlock()
+	ruleRegistry = append(ruleRegistry, rule)
+}
+
+// RunRules runs every registered rule and tags each item with the rule that produced it
+func RunRules(details []*structOutputs.DetailAnalysisResult, serviceCtx structOutputs.ServiceContext) []ValidationItem {
+	ruleRegistryMu.RLock()
+	rules := append([]Rule(nil), ruleRegistry...)
+	ruleRegistryMu.RUnlock()
+
+	var items []ValidationItem
+	for _, rule := range rules {
+		for _, item := range rule.Check(details, serviceCtx) {
+			item.Rule = rule.Name()
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// ruleViolation builds a full-confidence issue for a value that breaks a hard rule
+func ruleViolation(objectType DetailObjectType, id, snippet, reasoning, correction string) ValidationItem {
+	return ValidationItem{
+		Type:                HallucinationFlag,
+		ObjectType:          objectType,
+		IDs:                 []string{id},
+		IdentifiedSnippet:   snippet,
+		Reasoning:           reasoning,
+		ConfidenceLevel:     1.0,
+		SuggestedCorrection: correction,
+	}
+}
+
+func checkAvailableWithinMaximum(details []*structOutputs.DetailAnalysisResult, _ structOutputs.ServiceContext) []ValidationItem {
+	var items []ValidationItem
+	for _, capacity := range allCapacities(details) {
+		if capacity.Maximum != nil && capacity.Available > *capacity.Maximum {
+			items = append(items, ruleViolation(
+				ServiceCapacityObj,
+				capacity.ID,
+				fmt.Sprintf("available %.2f, maximum %.2f", capacity.Available, *capacity.Maximum),
+				"Available capacity cannot be greater than the maximum capacity",
+				"Check the transcript for which number is the current availability and which is the maximum",
+			))
+		}
+	}
+	return items
+}
+
+func checkNonNegativeCapacity(details []*structOutputs.DetailAnalysisResult, _ structOutputs.ServiceContext) []ValidationItem {
+	var items []ValidationItem
+	for _, capacity := range allCapacities(details) {
+		if capacity.Available < 0 {
+			items = append(items, ruleViolation(
+				ServiceCapacityObj,
+				capacity.ID,
+				fmt.Sprintf("available %.2f", capacity.Available),
+				"Available capacity cannot be negative",
+				"Use 0 if the service is full, otherwise remove the capacity",
+			))
+		}
+		if capacity.Maximum != nil && *capacity.Maximum < 0 {
+			items = append(items, ruleViolation(
+				ServiceCapacityObj,
+				capacity.ID,
+				fmt.Sprintf("maximum %.2f", *capacity.Maximum),
+				"Maximum capacity cannot be negative",
+				"Remove the maximum unless the transcript states one",
+			))
+		}
+	}
+	return items
+}
+
+func checkPhoneE164(details []*structOutputs.DetailAnalysisResult, _ structOutputs.ServiceContext) []ValidationItem {
+	var items []ValidationItem
+	for _, phone := range allPhones(details) {
+		if phonenumber.IsE164(phone.Number) {
+			continue
+		}
+		// Service codes like 211 have no E.164 form and are stored as dialed
+		if parsed, err := phonenumber.Parse(phone.Number); err == nil && parsed.ShortCode != "" {
+			continue
+		}
+		correction := "Rewrite the number in E.164 format, e.g. +12065550123"
+		if suggested := suggestE164(phone.Number); suggested != "" {
+			correction = suggested
+		}
+		items = append(items, ruleViolation(
+			PhoneObj,
+			phone.ID,
+			phone.Number,
+			"Phone numbers must be stored in E.164 format",
+			correction,
+		))
+	}
+	return items
+}
+
+// suggestE164 returns the E.164 form of the number when it parses
+func suggestE164(number string) string {
+	parsed, err := phonenumber.Parse(number)
+	if err != nil || parsed.E164 == "" {
+		return ""
+	}
+	return parsed.E164
+}
+
+func checkEmailSyntax(details []*structOutputs.DetailAnalysisResult, serviceCtx structOutputs.ServiceContext) []ValidationItem {
+	var items []ValidationItem
+	for _, contact := range allContacts(details) {
+		if contact.Email != nil && !validEmail(*contact.Email) {
+			items = append(items, ruleViolation(
+				ContactObj,
+				contact.ID,
+				*contact.Email,
+				"Email address is not valid syntax",
+				"Correct the email from the transcript or remove it",
+			))
+		}
+	}
+	for _, service := range serviceCtx.NewServices {
+		if service.Email != nil && !validEmail(*service.Email) {
+			items = append(items, ruleViolation(
+				ServiceObj,
+				service.ID,
+				*service.Email,
+				"Email address is not valid syntax",
+				"Correct the email from the transcript or remove it",
+			))
+		}
+	}
+	return items
+}
+
+func validEmail(email string) bool {
+	address, err := mail.ParseAddress(email)
+	return err == nil && address.Address == email && strings.Contains(email[strings.LastIndex(email, "@"):], ".")
+}
+
+func checkDuplicateContacts(details []*structOutputs.DetailAnalysisResult, _ structOutputs.ServiceContext) []ValidationItem {
+	contacts := allContacts(details)
+
+	phonesByContact := make(map[string][]string)
+	for _, phone := range allPhones(details) {
+		if phone.ContactID != nil {
+			phonesByContact[*phone.ContactID] = append(phonesByContact[*phone.ContactID], phone.Number)
+		}
+	}
+
+	// Group contacts that share a name, email or phone number. Groups are joined transitively, so a
+	// contact sharing a phone with one and an email with another puts all three in one group.
+	parent := make([]int, len(contacts))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+
+	firstWithKey := make(map[string]int)
+	for i, contact := range contacts {
+		var keys []string
+		if contact.Name != nil && strings.TrimSpace(*contact.Name) != "" {
+			keys = append(keys, "name:"+strings.Join(strings.Fields(strings.ToLower(*contact.Name)), " "))
+		}
+		if contact.Email != nil && *contact.Email != "" {
+			keys = append(keys, "email:"+strings.ToLower(*contact.Email))
+		}
+		for _, number := range phonesByContact[contact.ID] {
+			keys = append(keys, "phone:"+number)
+		}
+
+		for _, key := range keys {
+			first, ok := firstWithKey[key]
+			if !ok {
+				firstWithKey[key] = i
+				continue
+			}
+			if rootA, rootB := find(first), find(i); rootA != rootB {
+				parent[rootB] = rootA
+			}
+		}
+	}
+
+	// Groups keep the order their first contact was extracted in
+	groupIndex := make(map[int]int)
+	var groups [][]*hsds_types.Contact
+	for i, contact := range contacts {
+		root := find(i)
+		idx, ok := groupIndex[root]
+		if !ok {
+			idx = len(groups)
+			groupIndex[root] = idx
+			groups = append(groups, nil)
+		}
+		groups[idx] = append(groups[idx], contact)
+	}
+
+	var items []ValidationItem
+	for _, group := range groups {
+		if len(group) < 2 {
+			continue
+		}
+
+		ids := make([]string, 0, len(group))
+		preferred := group[0]
+		for _, contact := range group {
+			ids = append(ids, contact.ID)
+			if filledContactFields(contact) > filledContactFields(preferred) {
+				preferred = contact
+			}
+		}
+
+		items = append(items, ValidationItem{
+			Type:              DuplicateFlag,
+			ObjectType:        ContactObj,
+			IDs:               ids,
+			Name:              getName(preferred),
+			PreferredID:       preferred.ID,
+			ConflictingFields: conflictingContactFields(group),
+		})
+	}
+	return items
+}
+
+func filledContactFields(contact *hsds_types.Contact) int {
+	count := 0
+	for _, field := range []*string{contact.Name, contact.Title, contact.Department, contact.Email} {
+		if field != nil && *field != "" {
+			count++
+		}
+	}
+	return count
+}
+
+func conflictingContactFields(group []*hsds_types.Contact) []string {
+	fields := map[string]func(*hsds_types.Contact) *string{
+		"name":       func(c *hsds_types.Contact) *string { return c.Name },
+		"title":      func(c *hsds_types.Contact) *string { return c.Title },
+		"department": func(c *hsds_types.Contact) *string { return c.Department },
+		"email":      func(c *hsds_types.Contact) *string { return c.Email },
+	}
+
+	var conflicting []string
+	for _, field := range []string{"name", "title", "department", "email"} {
+		seen := make(map[string]bool)
+		for _, contact := range group {
+			if value := fields[field](contact); value != nil && *value != "" {
+				seen[strings.Join(strings.Fields(strings.ToLower(*value)), " ")] = true
+			}
+		}
+		if len(seen) > 1 {
+			conflicting = append(conflicting, field)
+		}
+	}
+	return conflicting
+}
+
+func getName(contact *hsds_types.Contact) string {
+	if contact.Name == nil {
+		return ""
+	}
+	return *contact.Name
+}
+
+func checkServiceDescriptions(_ []*structOutputs.DetailAnalysisResult, serviceCtx structOutputs.ServiceContext) []ValidationItem {
+	var items []ValidationItem
+	for _, service := range serviceCtx.NewServices {
+		if service.Description == nil || strings.TrimSpace(*service.Description) == "" {
+			items = append(items, ruleViolation(
+				ServiceObj,
+				service.ID,
+				service.Name,
+				"New services need a description of what they offer",
+				"Describe the service using what the transcript says about it",
+			))
+		}
+	}
+	return items
+}
+
+func allCapacities(details []*structOutputs.DetailAnalysisResult) []*hsds_types.ServiceCapacity {
+	var capacities []*hsds_types.ServiceCapacity
+	for _, detail := range details {
+		if detail.CapacityData != nil {
+			capacities = append(capacities, detail.CapacityData.Capacities...)
+		}
+	}
+	return capacities
+}
+
+func allContacts(details []*structOutputs.DetailAnalysisResult) []*hsds_types.Contact {
+	var contacts []*hsds_types.Contact
+	for _, detail := range details {
+		if detail.ContactData != nil {
+			contacts = append(contacts, detail.ContactData.Contacts...)
+		}
+	}
+	return contacts
+}
+
+func allPhones(details []*structOutputs.DetailAnalysisResult) []*hsds_types.Phone {
+	var phones []*hsds_types.Phone
+	for _, detail := range details {
+		if detail.ContactData != nil {
+			phones = append(phones, detail.ContactData.Phones...)
+		}
+	}
+	return phones
+}
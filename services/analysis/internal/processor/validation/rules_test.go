@@ -0,0 +1,244 @@
+package validation
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/david-botos/BearHug/services/analysis/internal/hsds_types"
+	"github.com/david-botos/BearHug/services/analysis/internal/processor/structOutputs"
+)
+
+func capacityDetails(capacities ...*hsds_types.ServiceCapacity) []*structOutputs.DetailAnalysisResult {
+	return []*structOutputs.DetailAnalysisResult{{
+		Category:     structOutputs.CapacityCategory,
+		CapacityData: &structOutputs.CapacityResult{Capacities: capacities},
+	}}
+}
+
+func contactDetails(contacts []*hsds_types.Contact, phones []*hsds_types.Phone) []*structOutputs.DetailAnalysisResult {
+	return []*structOutputs.DetailAnalysisResult{{
+		Category:    structOutputs.ContactCategory,
+		ContactData: &structOutputs.ContactResult{Contacts: contacts, Phones: phones},
+	}}
+}
+
+// flaggedIDs lists the IDs of each item, one entry per item
+func flaggedIDs(items []ValidationItem) [][]string {
+	var ids [][]string
+	for _, item := range items {
+		ids = append(ids, item.IDs)
+	}
+	return ids
+}
+
+func floatPtr(f float64) *float64 { return &f }
+
+func TestCheckAvailableWithinMaximum(t *testing.T) {
+	tests := []struct {
+		name       string
+		capacities []*hsds_types.ServiceCapacity
+		want       [][]string
+	}{
+		{name: "within maximum", capacities: []*hsds_types.ServiceCapacity{{ID: "beds", Available: 4, Maximum: floatPtr(10)}}},
+		{name: "at maximum", capacities: []*hsds_types.ServiceCapacity{{ID: "beds", Available: 10, Maximum: floatPtr(10)}}},
+		{name: "no maximum", capacities: []*hsds_types.ServiceCapacity{{ID: "beds", Available: 40}}},
+		{
+			name:       "over maximum",
+			capacities: []*hsds_types.ServiceCapacity{{ID: "beds", Available: 12, Maximum: floatPtr(10)}, {ID: "meals", Available: 1}},
+			want:       [][]string{{"beds"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := flaggedIDs(checkAvailableWithinMaximum(capacityDetails(tt.capacities...), structOutputs.ServiceContext{}))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("flagged %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckNonNegativeCapacity(t *testing.T) {
+	tests := []struct {
+		name       string
+		capacities []*hsds_types.ServiceCapacity
+		want       [][]string
+	}{
+		{name: "full", capacities: []*hsds_types.ServiceCapacity{{ID: "beds", Available: 0, Maximum: floatPtr(0)}}},
+		{name: "negative available", capacities: []*hsds_types.ServiceCapacity{{ID: "beds", Available: -2}}, want: [][]string{{"beds"}}},
+		{
+			name:       "negative available and maximum",
+			capacities: []*hsds_types.ServiceCapacity{{ID: "beds", Available: -2, Maximum: floatPtr(-1)}},
+			want:       [][]string{{"beds"}, {"beds"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := flaggedIDs(checkNonNegativeCapacity(capacityDetails(tt.capacities...), structOutputs.ServiceContext{}))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("flagged %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckPhoneE164(t *testing.T) {
+	tests := []struct {
+		name           string
+		number         string
+		wantFlagged    bool
+		wantCorrection string
+	}{
+		{name: "e164", number: "+12065550123"},
+		{name: "service code", number: "211"},
+		{name: "written number", number: "(206) 555-0123", wantFlagged: true, wantCorrection: "+12065550123"},
+		{name: "unparseable", number: "555-0123", wantFlagged: true, wantCorrection: "Rewrite the number in E.164 format, e.g. +12065550123"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			items := checkPhoneE164(contactDetails(nil, []*hsds_types.Phone{{ID: "phone", Number: tt.number}}), structOutputs.ServiceContext{})
+			if (len(items) > 0) != tt.wantFlagged {
+				t.Fatalf("checkPhoneE164(%q) = %+v, want flagged %v", tt.number, items, tt.wantFlagged)
+			}
+			if tt.wantFlagged && items[0].SuggestedCorrection != tt.wantCorrection {
+				t.Errorf("SuggestedCorrection = %q, want %q", items[0].SuggestedCorrection, tt.wantCorrection)
+			}
+		})
+	}
+}
+
+func TestCheckEmailSyntax(t *testing.T) {
+	tests := []struct {
+		email string
+		valid bool
+	}{
+		{"maria@example.org", true},
+		{"maria.lopez+intake@food-bank.example.org", true},
+		{"maria@localhost", false},
+		{"Maria Lopez <maria@example.org>", false},
+		{"maria at example dot org", false},
+		{"maria@", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.email, func(t *testing.T) {
+			details := contactDetails([]*hsds_types.Contact{{ID: "contact", Email: strPtr(tt.email)}}, nil)
+			serviceCtx := structOutputs.ServiceContext{NewServices: []*hsds_types.Service{{ID: "service", Email: strPtr(tt.email)}}}
+
+			var want [][]string
+			if !tt.valid {
+				want = [][]string{{"contact"}, {"service"}}
+			}
+			if got := flaggedIDs(checkEmailSyntax(details, serviceCtx)); !reflect.DeepEqual(got, want) {
+				t.Errorf("flagged %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestCheckDuplicateContacts(t *testing.T) {
+	contact := func(id, name, email string) *hsds_types.Contact {
+		c := &hsds_types.Contact{ID: id}
+		if name != "" {
+			c.Name = strPtr(name)
+		}
+		if email != "" {
+			c.Email = strPtr(email)
+		}
+		return c
+	}
+	phone := func(contactID, number string) *hsds_types.Phone {
+		return &hsds_types.Phone{ID: "phone-" + contactID, ContactID: strPtr(contactID), Number: number}
+	}
+
+	tests := []struct {
+		name            string
+		contacts        []*hsds_types.Contact
+		phones          []*hsds_types.Phone
+		want            [][]string
+		wantPreferred   []string
+		wantConflicting [][]string
+	}{
+		{
+			name:     "distinct contacts",
+			contacts: []*hsds_types.Contact{contact("a", "Maria Lopez", "maria@example.org"), contact("b", "Juan Perez", "")},
+		},
+		{
+			name:            "same name in different case",
+			contacts:        []*hsds_types.Contact{contact("a", "Maria  Lopez", ""), contact("b", "maria lopez", "maria@example.org")},
+			want:            [][]string{{"a", "b"}},
+			wantPreferred:   []string{"b"},
+			wantConflicting: [][]string{nil},
+		},
+		{
+			name: "chained through email and phone",
+			contacts: []*hsds_types.Contact{
+				contact("a", "Maria Lopez", "maria@example.org"),
+				contact("b", "M. Lopez", ""),
+				contact("c", "Front Desk", "MARIA@example.org"),
+				contact("d", "Juan Perez", ""),
+			},
+			phones:          []*hsds_types.Phone{phone("b", "+12065550123"), phone("c", "+12065550123")},
+			want:            [][]string{{"a", "b", "c"}},
+			wantPreferred:   []string{"a"},
+			wantConflicting: [][]string{{"name"}},
+		},
+		{
+			name: "bridge found after both groups started",
+			contacts: []*hsds_types.Contact{
+				contact("a", "Maria Lopez", ""),
+				contact("b", "Intake", "intake@example.org"),
+				contact("c", "Maria Lopez", "intake@example.org"),
+			},
+			want:            [][]string{{"a", "b", "c"}},
+			wantPreferred:   []string{"b"},
+			wantConflicting: [][]string{{"name"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			items := checkDuplicateContacts(contactDetails(tt.contacts, tt.phones), structOutputs.ServiceContext{})
+			if got := flaggedIDs(items); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("grouped %v, want %v", got, tt.want)
+			}
+			for i, item := range items {
+				if item.Type != DuplicateFlag || item.PreferredID != tt.wantPreferred[i] {
+					t.Errorf("item %d = %s preferring %q, want DUPLICATE preferring %q", i, item.Type, item.PreferredID, tt.wantPreferred[i])
+				}
+				if !reflect.DeepEqual(item.ConflictingFields, tt.wantConflicting[i]) {
+					t.Errorf("item %d ConflictingFields = %v, want %v", i, item.ConflictingFields, tt.wantConflicting[i])
+				}
+			}
+		})
+	}
+}
+
+func TestCheckServiceDescriptions(t *testing.T) {
+	serviceCtx := structOutputs.ServiceContext{
+		NewServices: []*hsds_types.Service{
+			{ID: "described", Description: strPtr("Weekly groceries")},
+			{ID: "blank", Description: strPtr("  ")},
+			{ID: "missing"},
+		},
+		ExistingServices: []*hsds_types.Service{{ID: "stored"}},
+	}
+
+	got := flaggedIDs(checkServiceDescriptions(nil, serviceCtx))
+	if want := [][]string{{"blank"}, {"missing"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("flagged %v, want %v", got, want)
+	}
+}
+
+func TestRunRulesTagsItems(t *testing.T) {
+	details := capacityDetails(&hsds_types.ServiceCapacity{ID: "beds", Available: -1})
+	for _, item := range RunRules(details, structOutputs.ServiceContext{}) {
+		if item.Rule != "capacity_negative" {
+			t.Errorf("item for %v tagged %q, want capacity_negative", item.IDs, item.Rule)
+		}
+	}
+}
@@ -11,7 +11,7 @@ func SubmitValidatedOutput(validatedDetails []*structOutputs.DetailAnalysisResul
 		switch item.Category {
 		case "CAPACITY":
 			if len(item.CapacityData.Capacities) > 0 {
-				supabase.StoreNewCapacity(item.CapacityData.Capacities, callID)
+				supabase.StoreNewCapacity(item.CapacityData.Capacities, callID, item.CapacityData.Evidence)
 			}
 			if len(item.CapacityData.Units) > 0 {
 				supabase.StoreNewUnits(item.CapacityData.Units, callID)
@@ -7,10 +7,10 @@ import (
 )
 
 func generateValidationPrompt(extractedDetails string, transcript string) (string, inference.ToolInputSchema, error) {
-	prompt := fmt.Sprintf(`You are validating information extracted from a transcript about service capacity information. Your task is to identify potential errors in two categories:
+	prompt := fmt.Sprintf(`You are validating information extracted from a transcript about services, their capacity and the contacts and phone numbers for the organization. Your task is to identify potential errors in two categories:
 
 1. Hallucinations: Information that appears in our extraction but isn't supported by the transcript
-2. Duplicates: Services or capacities that appear to be referring to the same thing
+2. Duplicates: Services, capacities, contacts or phones that appear to be referring to the same thing
 
 Here is the transcript from which information was extracted:
 ---
@@ -23,7 +23,7 @@ Here is the information that was extracted:
 ---
 
 For each potential HALLUCINATION you identify, provide:
-- The specific IDs and object type involved (SERVICE, CAPACITY, or UNIT)
+- The specific IDs and object type involved (SERVICE, CAPACITY, UNIT, CONTACT or PHONE)
 - The problematic snippet of extracted information
 - Your explanation of why this appears to be a hallucination
 - Your confidence level (0.0-1.0) that this is a hallucination
@@ -42,7 +42,7 @@ Respond in the following JSON format:
     "validation": [
         {
             "type": "HALLUCINATION",
-            "object_type": "SERVICE|CAPACITY|UNIT",
+            "object_type": "SERVICE|CAPACITY|UNIT|CONTACT|PHONE",
             "ids": ["id1"],
             "identified_snippet": "the problematic text",
             "reasoning": "detailed explanation",
@@ -51,7 +51,7 @@ Respond in the following JSON format:
         },
         {
             "type": "DUPLICATE",
-            "object_type": "SERVICE|CAPACITY|UNIT",
+            "object_type": "SERVICE|CAPACITY|UNIT|CONTACT|PHONE",
             "ids": ["id1", "id2"],
             "name": "name of duplicated entity",
             "preferred_id": "id1",
@@ -76,6 +76,8 @@ const (
 	ServiceObj         DetailObjectType = "SERVICE"
 	ServiceCapacityObj DetailObjectType = "CAPACITY"
 	CapacityUnitObj    DetailObjectType = "UNIT"
+	ContactObj         DetailObjectType = "CONTACT"
+	PhoneObj           DetailObjectType = "PHONE"
 	// TODO: Add more as more details are implemented
 )
 
@@ -104,7 +106,7 @@ var validationItemSchema = map[string]interface{}{
 		},
 		"object_type": map[string]interface{}{
 			"type": "string",
-			"enum": []string{"SERVICE", "CAPACITY", "UNIT"},
+			"enum": []string{"SERVICE", "CAPACITY", "UNIT", "CONTACT", "PHONE"},
 		},
 		"ids": map[string]interface{}{
 			"type": "array",
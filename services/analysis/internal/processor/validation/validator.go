@@ -3,12 +3,16 @@ package validation
 import (
 	"encoding/json"
 	"fmt"
+	"os"
 	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/david-botos/BearHug/services/analysis/internal/hsds_types"
 	"github.com/david-botos/BearHug/services/analysis/internal/processor/inference"
 	"github.com/david-botos/BearHug/services/analysis/internal/processor/structOutputs"
+	"github.com/david-botos/BearHug/services/analysis/internal/supabase"
+	"github.com/david-botos/BearHug/services/analysis/pkg/logger"
 )
 
 type ValidationItemType string
@@ -21,7 +25,7 @@ const (
 // ValidationItem represents a single validation issue identified by the inference
 type ValidationItem struct {
 	Type       ValidationItemType `json:"type"`        // "HALLUCINATION" or "DUPLICATE"
-	ObjectType DetailObjectType   `json:"object_type"` // "SERVICE", "CAPACITY", or "UNIT"
+	ObjectType DetailObjectType   `json:"object_type"` // "SERVICE", "CAPACITY", "UNIT", "CONTACT" or "PHONE"
 	IDs        []string           `json:"ids"`
 	// Fields for Hallucinations
 	IdentifiedSnippet   string  `json:"identified_snippet,omitempty"`
@@ -32,6 +36,8 @@ type ValidationItem struct {
 	Name              string   `json:"name,omitempty"`
 	PreferredID       string   `json:"preferred_id,omitempty"`
 	ConflictingFields []string `json:"conflicting_fields,omitempty"`
+	// Set when the issue came from a rule rather than the LLM validator
+	Rule string `json:"rule,omitempty"`
 }
 
 type ValidationOutput struct {
@@ -40,140 +46,242 @@ type ValidationOutput struct {
 }
 
 type FixAttempt struct {
-	ValidationItems []ValidationItem
-	FixedItems      []ValidationItem
-	Successful      bool
-	Iteration       int
+	ValidationItems []ValidationItem `json:"validation_items"` // Issues the fix round tried to address
+	FixedItems      []ValidationItem `json:"fixed_items"`      // Issues that no longer appear after the fix
+	RemainingItems  []ValidationItem `json:"remaining_items"`  // Issues found when re-validating the fixed output
+	Successful      bool             `json:"successful"`
+	Iteration       int              `json:"iteration"`
+	Error           string           `json:"error,omitempty"`
 }
 
-func ValidateExtractedInfo(extractedDetails []*structOutputs.DetailAnalysisResult, serviceCtx structOutputs.ServiceContext, transcript string, callID string) (bool, error) {
+// ValidationReport is the outcome of the validate and fix loop
+type ValidationReport struct {
+	Details          []*structOutputs.DetailAnalysisResult // Details that are safe to store
+	ServiceCtx       structOutputs.ServiceContext
+	IsValid          bool
+	Attempts         []FixAttempt
+	UnresolvedIssues []ValidationItem // Blocking issues left after the last round
+	RoutedToReview   bool
+}
+
+const (
+	defaultMaxValidationRounds       = 3
+	defaultValidationReviewThreshold = 0.7
+)
 
-	submitValidatedOutputRes, submitValidatedOutputErr := SubmitValidatedOutput(extractedDetails, callID)
-	if submitValidatedOutputErr != nil {
-		return false, fmt.Errorf(`Error occurred when submitting validated output in supa: %w`, submitValidatedOutputErr)
+// validationMaxRounds returns how many validate and fix rounds to run, from VALIDATION_MAX_ROUNDS
+func validationMaxRounds() int {
+	if raw := os.Getenv("VALIDATION_MAX_ROUNDS"); raw != "" {
+		if value, err := strconv.Atoi(raw); err == nil && value >= 0 {
+			return value
+		}
 	}
-	return submitValidatedOutputRes, nil
+	return defaultMaxValidationRounds
+}
 
-	/*
-			// Extract the most valuable information to identify potential hallucinations and duplicates
-			extractedDetailStrings := buildValidationString(extractedDetails, serviceCtx)
+// validationReviewThreshold is the hallucination confidence at or above which an unresolved
+// issue blocks storage, from VALIDATION_REVIEW_THRESHOLD
+func validationReviewThreshold() float64 {
+	if raw := os.Getenv("VALIDATION_REVIEW_THRESHOLD"); raw != "" {
+		if value, err := strconv.ParseFloat(raw, 64); err == nil {
+			return value
+		}
+	}
+	return defaultValidationReviewThreshold
+}
 
-			// Create a prompt and schema for looping validation runs
-			validationPrompt, validationSchema, validationPromptGenErr := generateValidationPrompt(extractedDetailStrings, transcript)
-			if validationPromptGenErr != nil {
-				return false, fmt.Errorf(`Error when generating the validation prompt: %w`, validationPromptGenErr)
-			}
+// ValidateExtractedInfo checks the extracted details for hallucinations and duplicates and asks Claude to fix
+// them, repeating until the output is valid, stops improving or runs out of rounds. Issues that are still
+// blocking afterwards are routed to review and the affected items withheld, or fail the run when
+// VALIDATION_ON_UNRESOLVED is set to "fail".
+func ValidateExtractedInfo(extractedDetails []*structOutputs.DetailAnalysisResult, serviceCtx structOutputs.ServiceContext, transcript string, callID string) (ValidationReport, error) {
+	log := logger.Get()
 
-		// Declare Claude Inference Client
-		client, err := inference.InitInferenceClient()
+	report := ValidationReport{
+		Details:    extractedDetails,
+		ServiceCtx: serviceCtx,
+	}
 
-			// Run inference
-			validationOutput, validationInfErr := client.RunClaudeInference(inference.PromptParams{Prompt: validationPrompt, Schema: validationSchema})
-			if validationInfErr != nil {
-				return false, fmt.Errorf(`Error when running validation inference: %w`, validationInfErr)
-			}
+	// Declare Claude Inference Client
+	client, err := inference.InitInferenceClient()
+	if err != nil {
+		return report, fmt.Errorf("failed to initialize inference client: %w", err)
+	}
 
-			// First, get the raw JSON bytes from the inference output
-			jsonData, err := json.Marshal(validationOutput)
-			if err != nil {
-				return false, fmt.Errorf("error marshaling validation output: %w", err)
-			}
+	typedOutput, err := runValidation(client, extractedDetails, serviceCtx, transcript)
+	if err != nil {
+		return report, fmt.Errorf("error when running validation inference: %w", err)
+	}
 
-			// Create our ValidationOutput struct
-			var typedOutput ValidationOutput
+	maxRounds := validationMaxRounds()
+	for iteration := 0; !isValidOutput(typedOutput) && iteration < maxRounds; iteration++ {
+		attempt := FixAttempt{
+			ValidationItems: typedOutput.Validation,
+			Iteration:       iteration,
+		}
 
-			// Unmarshal the JSON into our struct
-			if err := json.Unmarshal(jsonData, &typedOutput); err != nil {
-				return false, fmt.Errorf("error unmarshaling validation output: %w", err)
-			}
+		fixedDetails, fixedServiceCtx, fixErr := fixOutputWithInference(
+			report.Details,
+			report.ServiceCtx,
+			prioritizeIssues(typedOutput.Validation),
+			transcript,
+			client,
+		)
+		if fixErr != nil {
+			log.Warn().
+				Err(fixErr).
+				Int("iteration", iteration).
+				Msg("Fix attempt failed")
+			attempt.Error = fixErr.Error()
+			report.Attempts = append(report.Attempts, attempt)
+			break
+		}
 
-			if typedOutput.IsValid {
-				submitValidatedOutputRes, submitValidatedOutputErr := SubmitValidatedOutput(extractedDetails)
-				if submitValidatedOutputErr != nil {
-					return false, fmt.Errorf(`Error occurred when submitting validated output in supa: %w`, &submitValidatedOutputErr)
-				}
-				return submitValidatedOutputRes, nil
-			} else {
-				var fixAttempts []FixAttempt
-				maxIterations := 3
-				currentIteration := 0
-				currentDetails := extractedDetails
-				currentServiceCtx := serviceCtx
-
-				for currentIteration < maxIterations {
-					attempt := FixAttempt{
-						ValidationItems: typedOutput.Validation,
-						Iteration:       currentIteration,
-					}
+		// Validate the fixed output
+		newTypedOutput, valErr := runValidation(client, fixedDetails, fixedServiceCtx, transcript)
+		if valErr != nil {
+			return report, fmt.Errorf("error validating fixed output in round %d: %w", iteration, valErr)
+		}
 
-					sortedIssues := prioritizeIssues(typedOutput.Validation)
-
-					fixedDetails, fixedServiceCtx, fixErr := fixOutputWithInference(
-						currentDetails,
-						currentServiceCtx,
-						sortedIssues,
-						transcript,
-						client,
-					)
-					if fixErr != nil {
-						return false, fmt.Errorf("error during fix attempt %d: %w", currentIteration, fixErr)
-					}
+		attempt.RemainingItems = newTypedOutput.Validation
+		attempt.FixedItems = resolvedItems(typedOutput.Validation, newTypedOutput.Validation)
+		attempt.Successful = isValidOutput(newTypedOutput)
+		report.Attempts = append(report.Attempts, attempt)
+
+		log.Info().
+			Int("iteration", iteration).
+			Int("issues_before", len(typedOutput.Validation)).
+			Int("issues_after", len(newTypedOutput.Validation)).
+			Bool("successful", attempt.Successful).
+			Msg("Completed validation fix round")
+
+		// Keep the previous output when the fix didn't make things better
+		if !attempt.Successful && len(newTypedOutput.Validation) >= len(typedOutput.Validation) {
+			break
+		}
 
-					// Validate the fixed output
-					fixedDetailString := buildValidationString(fixedDetails, fixedServiceCtx)
-					validationPrompt, validationSchema, err := generateValidationPrompt(fixedDetailString, transcript)
-					if err != nil {
-						return false, fmt.Errorf("error generating validation prompt after fix: %w", err)
-					}
-					// Run validation on fixed output
-					newValidationOutput, valErr := client.RunClaudeInference(inference.PromptParams{
-						Prompt: validationPrompt,
-						Schema: validationSchema,
-					})
-					if valErr != nil {
-						return false, fmt.Errorf("error validating fixed output: %w", valErr)
-					}
+		report.Details = fixedDetails
+		report.ServiceCtx = fixedServiceCtx
+		typedOutput = newTypedOutput
+	}
 
-					// Parse validation results
-					var newTypedOutput ValidationOutput
-					newJsonData, _ := json.Marshal(newValidationOutput)
-					if err := json.Unmarshal(newJsonData, &newTypedOutput); err != nil {
-						return false, fmt.Errorf("error parsing validation after fix: %w", err)
-					}
+	if isValidOutput(typedOutput) {
+		report.IsValid = true
+		return report, nil
+	}
 
-					// Check if we've improved
-					if newTypedOutput.IsValid {
-						submitValidatedOutputRes, submitValidatedOutputErr := SubmitValidatedOutput(fixedDetails)
-						if submitValidatedOutputErr != nil {
-							return false, fmt.Errorf(`Error occurred when submitting validated output in supa: %w`, submitValidatedOutputErr)
-						}
-						return submitValidatedOutputRes, nil
-					}
+	// Low confidence leftovers are only logged, everything else needs a person to look at it
+	threshold := validationReviewThreshold()
+	for _, item := range typedOutput.Validation {
+		if item.Type == HallucinationFlag && item.ConfidenceLevel < threshold {
+			log.Info().
+				Strs("ids", item.IDs).
+				Float64("confidence", item.ConfidenceLevel).
+				Str("reasoning", item.Reasoning).
+				Msg("Ignoring low confidence validation issue")
+			continue
+		}
+		report.UnresolvedIssues = append(report.UnresolvedIssues, item)
+	}
 
-					// Check if we've made progress
-					if len(newTypedOutput.Validation) >= len(typedOutput.Validation) {
-						// We haven't improved or have made things worse
-						// TODO: Break the loop and flag for human review
-						break
-					}
+	if len(report.UnresolvedIssues) == 0 {
+		report.IsValid = true
+		return report, nil
+	}
 
-					// Update for next iteration
-					currentDetails = fixedDetails
-					currentServiceCtx = fixedServiceCtx
-					typedOutput = newTypedOutput
-					currentIteration++
+	if os.Getenv("VALIDATION_ON_UNRESOLVED") == "fail" {
+		return report, fmt.Errorf("%d validation issues remain after %d fix rounds", len(report.UnresolvedIssues), len(report.Attempts))
+	}
 
-					// Store attempt results
-					attempt.FixedItems = newTypedOutput.Validation
-					attempt.Successful = false
-					fixAttempts = append(fixAttempts, attempt)
-				}
-				// If we get here, we've exceeded max iterations or haven't improved
-				// Flag for human review
-				// TODO: Store fixAttempts history for human review
-				return false, nil
-			}
-	*/
+	if err := supabase.StoreValidationReview(supabase.ValidationReviewInput{
+		CallID:      callID,
+		Issues:      report.UnresolvedIssues,
+		FixAttempts: report.Attempts,
+	}); err != nil {
+		return report, fmt.Errorf("failed to route unresolved validation issues to review: %w", err)
+	}
+
+	// Withhold the flagged items so only validated details are stored
+	for _, item := range report.UnresolvedIssues {
+		for _, id := range item.IDs {
+			removeObject(id, &report.Details, &report.ServiceCtx)
+		}
+	}
+	report.RoutedToReview = true
+
+	log.Warn().
+		Str("call_id", callID).
+		Int("unresolved_issues", len(report.UnresolvedIssues)).
+		Int("fix_rounds", len(report.Attempts)).
+		Msg("Routed unresolved validation issues to review")
+
+	return report, nil
+}
+
+// runValidation asks Claude to flag hallucinations and duplicates in the extracted details and adds
+// anything caught by the registered rules
+func runValidation(
+	client *inference.ClaudeClient,
+	details []*structOutputs.DetailAnalysisResult,
+	serviceCtx structOutputs.ServiceContext,
+	transcript string,
+) (ValidationOutput, error) {
+	// Extract the most valuable information to identify potential hallucinations and duplicates
+	detailString := buildValidationString(details, serviceCtx)
+
+	validationPrompt, validationSchema, err := generateValidationPrompt(detailString, transcript)
+	if err != nil {
+		return ValidationOutput{}, fmt.Errorf("error when generating the validation prompt: %w", err)
+	}
+
+	validationOutput, err := client.RunClaudeInference(inference.PromptParams{Prompt: validationPrompt, Schema: validationSchema})
+	if err != nil {
+		return ValidationOutput{}, fmt.Errorf("error when running validation inference: %w", err)
+	}
+
+	// First, get the raw JSON bytes from the inference output
+	jsonData, err := json.Marshal(validationOutput)
+	if err != nil {
+		return ValidationOutput{}, fmt.Errorf("error marshaling validation output: %w", err)
+	}
+
+	var typedOutput ValidationOutput
+	if err := json.Unmarshal(jsonData, &typedOutput); err != nil {
+		return ValidationOutput{}, fmt.Errorf("error unmarshaling validation output: %w", err)
+	}
+
+	if ruleItems := RunRules(details, serviceCtx); len(ruleItems) > 0 {
+		typedOutput.Validation = append(typedOutput.Validation, ruleItems...)
+		typedOutput.IsValid = false
+	}
+
+	return typedOutput, nil
+}
+
+func isValidOutput(output ValidationOutput) bool {
+	return output.IsValid || len(output.Validation) == 0
+}
+
+// resolvedItems returns the issues from before a fix that are no longer reported after it
+func resolvedItems(before, after []ValidationItem) []ValidationItem {
+	remaining := make(map[string]bool, len(after))
+	for _, item := range after {
+		remaining[validationItemKey(item)] = true
+	}
+
+	var resolved []ValidationItem
+	for _, item := range before {
+		if !remaining[validationItemKey(item)] {
+			resolved = append(resolved, item)
+		}
+	}
+	return resolved
+}
+
+func validationItemKey(item ValidationItem) string {
+	ids := append([]string(nil), item.IDs...)
+	sort.Strings(ids)
+	return fmt.Sprintf("%s|%s|%s", item.Type, item.ObjectType, strings.Join(ids, ","))
 }
 
 func buildValidationString(extractedDetails []*structOutputs.DetailAnalysisResult, serviceCtx structOutputs.ServiceContext) string {
@@ -274,6 +382,54 @@ func buildValidationString(extractedDetails []*structOutputs.DetailAnalysisResul
 		builder.WriteString("\n")
 	}
 
+	// Add new contacts and phones
+	var contacts []*hsds_types.Contact
+	var phones []*hsds_types.Phone
+	for _, detail := range extractedDetails {
+		if detail.ContactData != nil {
+			contacts = append(contacts, detail.ContactData.Contacts...)
+			phones = append(phones, detail.ContactData.Phones...)
+		}
+	}
+
+	var updates []*structOutputs.ContactUpdate
+	for _, detail := range extractedDetails {
+		if detail.ContactData != nil {
+			updates = append(updates, detail.ContactData.Updates...)
+		}
+	}
+	if len(updates) > 0 {
+		builder.WriteString("=== Stored Contacts Updated From the Transcript ===\n")
+		for _, update := range updates {
+			writeContact(&builder, "", update.Contact)
+			if update.Phone != nil {
+				writePhone(&builder, "- ", update.Phone)
+			}
+			builder.WriteString("\n")
+		}
+	}
+
+	if len(contacts) > 0 || len(phones) > 0 {
+		builder.WriteString("=== Newly Mentioned Contacts ===\n")
+		writtenPhones := make(map[string]bool)
+		for _, contact := range contacts {
+			writeContact(&builder, "", contact)
+			for _, phone := range phones {
+				if phone.ContactID != nil && *phone.ContactID == contact.ID {
+					writePhone(&builder, "- ", phone)
+					writtenPhones[phone.ID] = true
+				}
+			}
+			builder.WriteString("\n")
+		}
+		for _, phone := range phones {
+			if !writtenPhones[phone.ID] {
+				writePhone(&builder, "- ", phone)
+			}
+		}
+		builder.WriteString("\n")
+	}
+
 	return builder.String()
 }
 
@@ -306,6 +462,9 @@ func fixOutputWithInference(
 	transcript string,
 	client *inference.ClaudeClient,
 ) ([]*structOutputs.DetailAnalysisResult, structOutputs.ServiceContext, error) {
+	// Work on copies so a fix that doesn't help can be thrown away
+	details, serviceCtx = cloneDetails(details, serviceCtx)
+
 	// 1. Build shared context maps
 	contextMaps := buildContextMaps(details, serviceCtx, issues)
 
@@ -338,13 +497,76 @@ func fixOutputWithInference(
 	}
 
 	// 6. Get maps of affected items using shared context
-	affectedServices, affectedCapacities := getAffectedItems(contextMaps)
+	affectedServices, affectedCapacities, affectedContacts, affectedPhones := getAffectedItems(contextMaps)
 
 	// 7. Apply the fixes using our lookup maps
-	newDetails, newServiceCtx, err := applyFixes(fixes, affectedServices, affectedCapacities, details, serviceCtx)
+	newDetails, newServiceCtx, err := applyFixes(fixes, affectedServices, affectedCapacities, affectedContacts, affectedPhones, details, serviceCtx)
 	if err != nil {
 		return nil, structOutputs.ServiceContext{}, fmt.Errorf("error applying fixes: %w", err)
 	}
 
 	return newDetails, newServiceCtx, nil
 }
+
+// cloneDetails copies the extracted details and service context deeply enough that fixes can
+// modify them without touching the originals
+func cloneDetails(
+	details []*structOutputs.DetailAnalysisResult,
+	serviceCtx structOutputs.ServiceContext,
+) ([]*structOutputs.DetailAnalysisResult, structOutputs.ServiceContext) {
+	clonedDetails := make([]*structOutputs.DetailAnalysisResult, 0, len(details))
+	for _, detail := range details {
+		cloned := *detail
+		if detail.CapacityData != nil {
+			capacityData := *detail.CapacityData
+			capacityData.Capacities = make([]*hsds_types.ServiceCapacity, 0, len(detail.CapacityData.Capacities))
+			for _, capacity := range detail.CapacityData.Capacities {
+				capacityCopy := *capacity
+				capacityData.Capacities = append(capacityData.Capacities, &capacityCopy)
+			}
+			capacityData.Units = append([]*hsds_types.Unit(nil), detail.CapacityData.Units...)
+			cloned.CapacityData = &capacityData
+		}
+		if detail.ContactData != nil {
+			contactData := *detail.ContactData
+			contactData.Contacts = make([]*hsds_types.Contact, 0, len(detail.ContactData.Contacts))
+			for _, contact := range detail.ContactData.Contacts {
+				contactCopy := *contact
+				contactData.Contacts = append(contactData.Contacts, &contactCopy)
+			}
+			contactData.Phones = make([]*hsds_types.Phone, 0, len(detail.ContactData.Phones))
+			for _, phone := range detail.ContactData.Phones {
+				phoneCopy := *phone
+				contactData.Phones = append(contactData.Phones, &phoneCopy)
+			}
+			contactData.Updates = make([]*structOutputs.ContactUpdate, 0, len(detail.ContactData.Updates))
+			for _, update := range detail.ContactData.Updates {
+				updateCopy := *update
+				contactCopy := *update.Contact
+				updateCopy.Contact = &contactCopy
+				if update.Phone != nil {
+					phoneCopy := *update.Phone
+					updateCopy.Phone = &phoneCopy
+				}
+				contactData.Updates = append(contactData.Updates, &updateCopy)
+			}
+			cloned.ContactData = &contactData
+		}
+		clonedDetails = append(clonedDetails, &cloned)
+	}
+
+	cloneServices := func(services []*hsds_types.Service) []*hsds_types.Service {
+		clonedServices := make([]*hsds_types.Service, 0, len(services))
+		for _, service := range services {
+			serviceCopy := *service
+			clonedServices = append(clonedServices, &serviceCopy)
+		}
+		return clonedServices
+	}
+
+	return clonedDetails, structOutputs.ServiceContext{
+		ExistingServices:  cloneServices(serviceCtx.ExistingServices),
+		NewServices:       cloneServices(serviceCtx.NewServices),
+		NewServiceSources: serviceCtx.NewServiceSources,
+	}
+}
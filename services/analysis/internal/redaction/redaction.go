@@ -0,0 +1,375 @@
+// Package redaction masks personal information about the people an organization serves before a
+// transcript is logged, stored or sent for inference. Detection is rule based and deliberately
+// conservative: most rules only fire next to a cue ("born on", "lives at", "my client"), so the
+// organization's own details and staff contact info are left for HSDS records.
+package redaction
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/david-botos/BearHug/services/analysis/internal/types"
+)
+
+type EntityType string
+
+const (
+	EntitySSN                EntityType = "ssn"
+	EntityDOB                EntityType = "dob"
+	EntityClientName         EntityType = "client_name"
+	EntityResidentialAddress EntityType = "residential_address"
+	EntityCaseNumber         EntityType = "case_number"
+)
+
+var allEntities = []EntityType{EntitySSN, EntityDOB, EntityClientName, EntityResidentialAddress, EntityCaseNumber}
+
+// Policy controls which entities are redacted
+type Policy struct {
+	Enabled  bool         `json:"enabled"`
+	Entities []EntityType `json:"entities"`
+}
+
+// LoadPolicy reads the policy from the environment. REDACTION_ENABLED=false turns redaction off and
+// REDACTION_ENTITIES limits it to a comma separated list of entity types; by default everything is redacted.
+func LoadPolicy() Policy {
+	policy := Policy{Enabled: true, Entities: allEntities}
+
+	if raw := strings.TrimSpace(os.Getenv("REDACTION_ENABLED")); strings.EqualFold(raw, "false") || raw == "0" {
+		policy.Enabled = false
+	}
+
+	if raw := strings.TrimSpace(os.Getenv("REDACTION_ENTITIES")); raw != "" {
+		policy.Entities = nil
+		for _, name := range strings.Split(raw, ",") {
+			entity := EntityType(strings.ToLower(strings.TrimSpace(name)))
+			for _, known := range allEntities {
+				if entity == known {
+					policy.Entities = append(policy.Entities, entity)
+				}
+			}
+		}
+	}
+
+	return policy
+}
+
+func (p Policy) covers(entity EntityType) bool {
+	for _, e := range p.Entities {
+		if e == entity {
+			return true
+		}
+	}
+	return false
+}
+
+// Entry records one masked span. Offsets are into the original text (of the turn, for diarized
+// transcripts). The original value is never kept; Fingerprint is an HMAC of it when
+// REDACTION_HASH_KEY is set, so auditors can confirm a suspected value without it being stored.
+type Entry struct {
+	Placeholder string     `json:"placeholder"`
+	Entity      EntityType `json:"entity"`
+	Rule        string     `json:"rule"`
+	Turn        *int       `json:"turn,omitempty"`
+	Start       int        `json:"start"`
+	End         int        `json:"end"`
+	Fingerprint string     `json:"fingerprint,omitempty"`
+}
+
+// Map is the audit record of everything redacted from one transcript
+type Map struct {
+	Policy  Policy  `json:"policy"`
+	Entries []Entry `json:"entries"`
+}
+
+type rule struct {
+	name   string
+	entity EntityType
+	// pattern must have a group named "value", which is the span that gets masked
+	pattern *regexp.Regexp
+}
+
+const (
+	monthNames  = `(?:jan(?:uary)?|feb(?:ruary)?|mar(?:ch)?|apr(?:il)?|may|june?|july?|aug(?:ust)?|sep(?:t(?:ember)?)?|oct(?:ober)?|nov(?:ember)?|dec(?:ember)?)\.?`
+	dateValue   = `(?:\d{1,2}[/-]\d{1,2}[/-]\d{2,4}|` + monthNames + `\s+\d{1,2}(?:st|nd|rd|th)?,?\s+\d{4}|\d{1,2}(?:st|nd|rd|th)?\s+(?:of\s+)?` + monthNames + `,?\s+\d{4})`
+	streetValue = `\d{1,6}\s+(?:[A-Za-z0-9'.-]+\s+){1,4}(?:street|st|avenue|ave|road|rd|boulevard|blvd|lane|ln|drive|dr|court|ct|place|pl|way|terrace|ter|circle|cir|highway|hwy)\b\.?(?:,?\s*(?:apt|apartment|unit|#)\.?\s*[A-Za-z0-9-]+)?`
+	personName  = `(?:(?:Mr|Mrs|Ms|Miss|Dr)\.?\s+)?[A-Z][a-z'-]+(?:\s+[A-Z][a-z'-]+){0,2}`
+)
+
+var rules = []rule{
+	{
+		// Only the dashed form stands on its own; spaced or bare digit runs are too often other numbers
+		name:    "ssn_formatted",
+		entity:  EntitySSN,
+		pattern: regexp.MustCompile(`\b(?P<value>\d{3}-\d{2}-\d{4})\b`),
+	},
+	{
+		name:    "ssn_after_cue",
+		entity:  EntitySSN,
+		pattern: regexp.MustCompile(`(?i)\b(?:social security(?: number)?|ssn|social)\b[^0-9]{0,20}(?P<value>\d{3}[- ]?\d{2}[- ]?\d{4})\b`),
+	},
+	{
+		name:    "dob_after_cue",
+		entity:  EntityDOB,
+		pattern: regexp.MustCompile(`(?i)\b(?:born(?: on)?|date of birth|dob|d\.o\.b\.?|birthday(?: is)?)\b[^0-9a-z]{0,5}(?:is\s+|was\s+|on\s+)?(?P<value>` + dateValue + `)`),
+	},
+	{
+		name:    "residential_address_after_cue",
+		entity:  EntityResidentialAddress,
+		pattern: regexp.MustCompile(`(?i)\b(?:lives?|living|resides?|residing|staying|stays)\s+(?:at|on)\s+(?P<value>` + streetValue + `)`),
+	},
+	{
+		name:    "home_address_after_cue",
+		entity:  EntityResidentialAddress,
+		pattern: regexp.MustCompile(`(?i)\b(?:home address|(?:his|her|their|my) address|residence|home)\s+(?:is\s+|at\s+|is at\s+)?(?P<value>` + streetValue + `)`),
+	},
+	clientNameRule,
+	{
+		name:    "case_number_after_cue",
+		entity:  EntityCaseNumber,
+		pattern: regexp.MustCompile(`(?i)\b(?:case|file|record|member|medicaid|medicare|client)\s*(?:number|no\.?|#|id)\s*(?:is\s*)?:?\s*(?P<value>[A-Z0-9][A-Z0-9-]{3,})`),
+	},
+}
+
+// clientNameRule only counts names after a word for someone being served. The name part is case
+// sensitive so "the client said" doesn't match.
+var clientNameRule = rule{
+	name:    "client_name_after_cue",
+	entity:  EntityClientName,
+	pattern: regexp.MustCompile(`\b(?i:client|patient|participant|resident|tenant|consumer|customer|guest)(?:'s name is|\s+named|\s+called|\s+by the name of|,)?\s+(?P<value>` + personName + `)`),
+}
+
+// clientNameRepeatRule marks later mentions of a name clientNameRule found
+var clientNameRepeatRule = rule{name: "client_name_repeat", entity: EntityClientName}
+
+// nonNameWords start phrases that follow "client" or "patient" without being a name, including the
+// days and months in schedules like "every client Monday through Friday"
+var nonNameWords = map[string]bool{
+	"services": true, "service": true, "intake": true, "portal": true, "advocate": true, "advocates": true,
+	"coordinator": true, "support": true, "center": true, "program": true, "department": true,
+	"assistance": true, "care": true, "relations": true, "manager": true, "line": true, "navigator": true,
+
+	"monday": true, "tuesday": true, "wednesday": true, "thursday": true, "friday": true, "saturday": true,
+	"sunday": true, "mondays": true, "tuesdays": true, "wednesdays": true, "thursdays": true, "fridays": true,
+	"saturdays": true, "sundays": true,
+
+	"january": true, "february": true, "march": true, "april": true, "may": true, "june": true, "july": true,
+	"august": true, "september": true, "october": true, "november": true, "december": true,
+}
+
+// nameTitles are dropped from protected names so "Dr. Maria Lopez" also protects "Maria Lopez"
+var nameTitles = map[string]bool{"mr": true, "mrs": true, "ms": true, "miss": true, "dr": true}
+
+type match struct {
+	start, end int
+	rule       rule
+}
+
+// Redactor masks text under a policy. One redactor is used per transcript so the same value gets the
+// same placeholder everywhere in it.
+type Redactor struct {
+	policy       Policy
+	protected    []*regexp.Regexp // Full staff names; only a whole name is protected, not its parts
+	placeholders map[string]string
+	counts       map[EntityType]int
+	hashKey      []byte
+	entries      []Entry
+	clientNames  map[string]bool // Names found after a cue, masked wherever else they appear
+}
+
+// NewRedactor creates a redactor. Protected names, such as the organization's staff contacts, are never
+// masked as client names. A protected name only covers the full name, so a client who shares a staff
+// member's first name is still masked.
+func NewRedactor(policy Policy, protectedNames ...string) *Redactor {
+	var protected []*regexp.Regexp
+	for _, name := range protectedNames {
+		var tokens []string
+		for _, token := range strings.Fields(name) {
+			token = strings.Trim(token, ".,")
+			if token == "" || (len(tokens) == 0 && nameTitles[strings.ToLower(token)]) {
+				continue
+			}
+			tokens = append(tokens, regexp.QuoteMeta(token))
+		}
+		if len(tokens) > 0 {
+			protected = append(protected, regexp.MustCompile(`(?i)\b`+strings.Join(tokens, `\s+`)+`\b`))
+		}
+	}
+	return &Redactor{
+		policy:       policy,
+		protected:    protected,
+		placeholders: make(map[string]string),
+		counts:       make(map[EntityType]int),
+		hashKey:      []byte(os.Getenv("REDACTION_HASH_KEY")),
+		clientNames:  make(map[string]bool),
+	}
+}
+
+// LearnClientNames records client names in text without masking anything, so a name introduced late
+// in a call is also masked where it came up earlier
+func (r *Redactor) LearnClientNames(text string) {
+	if !r.policy.Enabled || !r.policy.covers(EntityClientName) {
+		return
+	}
+	protectedSpans := r.protectedSpans(text)
+	valueIdx := clientNameRule.pattern.SubexpIndex("value")
+	for _, loc := range clientNameRule.pattern.FindAllStringSubmatchIndex(text, -1) {
+		start, end := loc[2*valueIdx], loc[2*valueIdx+1]
+		if start >= 0 && !r.notClientName(text[start:end]) && !overlapsAny(start, end, protectedSpans) {
+			r.clientNames[text[start:end]] = true
+		}
+	}
+}
+
+// Redact masks one piece of text. turn is the index of the diarized turn the text came from, or nil.
+func (r *Redactor) Redact(text string, turn *int) string {
+	if !r.policy.Enabled || text == "" {
+		return text
+	}
+	r.LearnClientNames(text)
+	protectedSpans := r.protectedSpans(text)
+
+	var matches []match
+	for _, rl := range rules {
+		if !r.policy.covers(rl.entity) {
+			continue
+		}
+		valueIdx := rl.pattern.SubexpIndex("value")
+		for _, loc := range rl.pattern.FindAllStringSubmatchIndex(text, -1) {
+			start, end := loc[2*valueIdx], loc[2*valueIdx+1]
+			if start < 0 {
+				continue
+			}
+			if rl.entity == EntityClientName && (r.notClientName(text[start:end]) || overlapsAny(start, end, protectedSpans)) {
+				continue
+			}
+			matches = append(matches, match{start: start, end: end, rule: rl})
+		}
+	}
+	for name := range r.clientNames {
+		pattern := regexp.MustCompile(`\b` + regexp.QuoteMeta(name) + `\b`)
+		for _, loc := range pattern.FindAllStringIndex(text, -1) {
+			if overlapsAny(loc[0], loc[1], protectedSpans) {
+				continue
+			}
+			matches = append(matches, match{start: loc[0], end: loc[1], rule: clientNameRepeatRule})
+		}
+	}
+	if len(matches) == 0 {
+		return text
+	}
+
+	// Earliest span first; where spans overlap the longer one wins
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].start != matches[j].start {
+			return matches[i].start < matches[j].start
+		}
+		return matches[i].end > matches[j].end
+	})
+
+	var out strings.Builder
+	last := 0
+	for _, m := range matches {
+		if m.start < last {
+			continue
+		}
+		value := text[m.start:m.end]
+		placeholder := r.placeholderFor(m.rule.entity, value)
+
+		out.WriteString(text[last:m.start])
+		out.WriteString(placeholder)
+		last = m.end
+
+		r.entries = append(r.entries, Entry{
+			Placeholder: placeholder,
+			Entity:      m.rule.entity,
+			Rule:        m.rule.name,
+			Turn:        turn,
+			Start:       m.start,
+			End:         m.end,
+			Fingerprint: r.fingerprint(value),
+		})
+	}
+	out.WriteString(text[last:])
+
+	return out.String()
+}
+
+// Map returns the audit record of everything this redactor has masked
+func (r *Redactor) Map() Map {
+	entries := r.entries
+	if entries == nil {
+		entries = []Entry{}
+	}
+	return Map{Policy: r.policy, Entries: entries}
+}
+
+// notClientName reports whether a client name match is really a title-case phrase like "Client Services"
+func (r *Redactor) notClientName(value string) bool {
+	tokens := strings.Fields(value)
+	return len(tokens) == 0 || nonNameWords[strings.ToLower(tokens[0])]
+}
+
+// protectedSpans returns where protected staff names appear in text
+func (r *Redactor) protectedSpans(text string) [][]int {
+	var spans [][]int
+	for _, pattern := range r.protected {
+		spans = append(spans, pattern.FindAllStringIndex(text, -1)...)
+	}
+	return spans
+}
+
+func overlapsAny(start, end int, spans [][]int) bool {
+	for _, span := range spans {
+		if start < span[1] && span[0] < end {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Redactor) placeholderFor(entity EntityType, value string) string {
+	key := string(entity) + "|" + strings.ToLower(strings.Join(strings.Fields(value), " "))
+	if placeholder, ok := r.placeholders[key]; ok {
+		return placeholder
+	}
+	r.counts[entity]++
+	placeholder := fmt.Sprintf("[%s_%d]", strings.ToUpper(string(entity)), r.counts[entity])
+	r.placeholders[key] = placeholder
+	return placeholder
+}
+
+func (r *Redactor) fingerprint(value string) string {
+	if len(r.hashKey) == 0 {
+		return ""
+	}
+	mac := hmac.New(sha256.New, r.hashKey)
+	mac.Write([]byte(strings.ToLower(strings.Join(strings.Fields(value), " "))))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// RedactTranscript masks a transcript, turn by turn when it is diarized, and returns the audit map
+func RedactTranscript(transcript types.Transcript, policy Policy, protectedNames ...string) (types.Transcript, Map) {
+	redactor := NewRedactor(policy, protectedNames...)
+	redactor.LearnClientNames(transcript.Text)
+	for _, turn := range transcript.Turns {
+		redactor.LearnClientNames(turn.Text)
+	}
+
+	redacted := types.Transcript{Text: redactor.Redact(transcript.Text, nil)}
+	if transcript.IsDiarized() {
+		redacted.Turns = make([]types.TranscriptTurn, len(transcript.Turns))
+		for i, turn := range transcript.Turns {
+			idx := i
+			turn.Text = redactor.Redact(turn.Text, &idx)
+			redacted.Turns[i] = turn
+		}
+	}
+
+	return redacted, redactor.Map()
+}
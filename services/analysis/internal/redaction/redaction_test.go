@@ -0,0 +1,172 @@
+package redaction
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/david-botos/BearHug/services/analysis/internal/types"
+)
+
+func TestRedactTranscript(t *testing.T) {
+	all := Policy{Enabled: true, Entities: allEntities}
+
+	tests := []struct {
+		name      string
+		policy    Policy
+		protected []string
+		input     string
+		want      string
+	}{
+		{
+			name:      "client sharing a staff first name",
+			policy:    all,
+			protected: []string{"Maria Lopez"},
+			input:     "I have a client, Maria Garcia, born on 3/4/1980, and Maria Lopez is her caseworker.",
+			want:      "I have a client, [CLIENT_NAME_1], born on [DOB_1], and Maria Lopez is her caseworker.",
+		},
+		{
+			name:      "staff full name after a cue",
+			policy:    all,
+			protected: []string{"Maria Lopez"},
+			input:     "Our client Maria Lopez handles intake.",
+			want:      "Our client Maria Lopez handles intake.",
+		},
+		{
+			name:      "learned first name is kept inside a staff name",
+			policy:    all,
+			protected: []string{"Maria Lopez"},
+			input:     "My client Maria needs a bed, so ask Maria Lopez. Maria can come tonight.",
+			want:      "My client [CLIENT_NAME_1] needs a bed, so ask Maria Lopez. [CLIENT_NAME_1] can come tonight.",
+		},
+		{
+			name:      "staff title is ignored",
+			policy:    all,
+			protected: []string{"Dr. Ana Ruiz"},
+			input:     "Every guest Ana Ruiz sees gets a referral.",
+			want:      "Every guest Ana Ruiz sees gets a referral.",
+		},
+		{
+			name:   "repeated client name",
+			policy: all,
+			input:  "The client named John Smith came in. John Smith needs a bed.",
+			want:   "The client named [CLIENT_NAME_1] came in. [CLIENT_NAME_1] needs a bed.",
+		},
+		{
+			name:   "weekdays after a cue",
+			policy: all,
+			input:  "We serve every client Monday through Friday.",
+			want:   "We serve every client Monday through Friday.",
+		},
+		{
+			name:   "months after a cue",
+			policy: all,
+			input:  "Each resident March through May gets a voucher.",
+			want:   "Each resident March through May gets a voucher.",
+		},
+		{
+			name:   "title-case program name",
+			policy: all,
+			input:  "Call Client Services for help.",
+			want:   "Call Client Services for help.",
+		},
+		{
+			name:   "spaced digits without a cue",
+			policy: all,
+			input:  "The front desk is 555 12 3456.",
+			want:   "The front desk is 555 12 3456.",
+		},
+		{
+			name:   "dashed ssn",
+			policy: all,
+			input:  "Her number is 123-45-6789.",
+			want:   "Her number is [SSN_1].",
+		},
+		{
+			name:   "spaced ssn after a cue",
+			policy: all,
+			input:  "His social security number is 123 45 6789.",
+			want:   "His social security number is [SSN_1].",
+		},
+		{
+			name:   "residential address",
+			policy: all,
+			input:  "She lives at 123 Main Street and needs food.",
+			want:   "She lives at [RESIDENTIAL_ADDRESS_1] and needs food.",
+		},
+		{
+			name:   "entity outside the policy",
+			policy: Policy{Enabled: true, Entities: []EntityType{EntityDOB}},
+			input:  "The client named John Smith was born on 3/4/1980.",
+			want:   "The client named John Smith was born on [DOB_1].",
+		},
+		{
+			name:   "disabled",
+			policy: Policy{Enabled: false, Entities: allEntities},
+			input:  "Her number is 123-45-6789.",
+			want:   "Her number is 123-45-6789.",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _ := RedactTranscript(types.Transcript{Text: tt.input}, tt.policy, tt.protected...)
+			if got.Text != tt.want {
+				t.Errorf("RedactTranscript() =\n  %q\nwant\n  %q", got.Text, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedactTranscriptTurns(t *testing.T) {
+	transcript := types.Transcript{Turns: []types.TranscriptTurn{
+		{Speaker: types.SpeakerRepresentative, Text: "Is this about John Smith?"},
+		{Speaker: types.SpeakerAgent, Text: "Yes, the client named John Smith."},
+	}}
+
+	got, redactionMap := RedactTranscript(transcript, Policy{Enabled: true, Entities: allEntities})
+
+	wantTexts := []string{"Is this about [CLIENT_NAME_1]?", "Yes, the client named [CLIENT_NAME_1]."}
+	for i, turn := range got.Turns {
+		if turn.Text != wantTexts[i] {
+			t.Errorf("turn %d = %q, want %q", i, turn.Text, wantTexts[i])
+		}
+	}
+
+	var turns []int
+	for _, entry := range redactionMap.Entries {
+		if entry.Entity != EntityClientName || entry.Turn == nil {
+			t.Fatalf("unexpected entry %+v", entry)
+		}
+		turns = append(turns, *entry.Turn)
+	}
+	if !reflect.DeepEqual(turns, []int{0, 1}) {
+		t.Errorf("entry turns = %v, want [0 1]", turns)
+	}
+}
+
+func TestLoadPolicy(t *testing.T) {
+	tests := []struct {
+		name     string
+		enabled  string
+		entities string
+		want     Policy
+	}{
+		{name: "default", want: Policy{Enabled: true, Entities: allEntities}},
+		{name: "disabled", enabled: "false", want: Policy{Enabled: false, Entities: allEntities}},
+		{
+			name:     "entity list drops unknown names",
+			entities: " SSN, dob ,phone",
+			want:     Policy{Enabled: true, Entities: []EntityType{EntitySSN, EntityDOB}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("REDACTION_ENABLED", tt.enabled)
+			t.Setenv("REDACTION_ENTITIES", tt.entities)
+			if got := LoadPolicy(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("LoadPolicy() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
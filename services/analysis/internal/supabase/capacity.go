@@ -0,0 +1,225 @@
+package supabase
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/david-botos/BearHug/services/analysis/internal/hsds_types"
+	"github.com/david-botos/BearHug/services/analysis/pkg/logger"
+	"github.com/google/uuid"
+	"github.com/supabase-community/postgrest-go"
+	"github.com/supabase-community/supabase-go"
+)
+
+// CapacityObservation is a single capacity reading for a service and unit
+type CapacityObservation struct {
+	ServiceCapacityID string    `json:"service_capacity_id"`
+	ServiceID         string    `json:"service_id"`
+	UnitID            string    `json:"unit_id"`
+	Available         float64   `json:"available"`
+	Maximum           *float64  `json:"maximum,omitempty"`
+	Description       *string   `json:"description,omitempty"`
+	ObservedAt        time.Time `json:"observed_at"`
+	Current           bool      `json:"current"` // True for the live service_capacity row
+}
+
+// CapacitySeries is the capacity trend for one unit of a service, oldest reading first
+type CapacitySeries struct {
+	UnitID       string                `json:"unit_id"`
+	UnitName     string                `json:"unit_name"`
+	Observations []CapacityObservation `json:"observations"`
+}
+
+// FetchCallObservedAt returns when the call happened, which is when its capacity readings were observed
+func FetchCallObservedAt(callID string) (time.Time, error) {
+	client, err := InitSupabaseClient()
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to initialize Supabase client: %w", err)
+	}
+
+	data, _, err := client.From("calls").
+		Select("created_at", "", false).
+		Eq("id", callID).
+		Execute()
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to fetch call %s: %w", callID, err)
+	}
+
+	var calls []struct {
+		CreatedAt time.Time `json:"created_at"`
+	}
+	if err := hsds_types.UnmarshalJSONWithTime(data, &calls); err != nil {
+		return time.Time{}, fmt.Errorf("failed to unmarshal call: %w", err)
+	}
+	if len(calls) == 0 {
+		return time.Time{}, fmt.Errorf("call %s not found", callID)
+	}
+
+	return calls[0].CreatedAt, nil
+}
+
+// fetchLatestCapacity returns the live capacity row for a service and unit, or nil if there isn't one
+func fetchLatestCapacity(client *supabase.Client, serviceID, unitID string) (*hsds_types.ServiceCapacity, error) {
+	data, _, err := client.From("service_capacity").
+		Select("id, service_id, unit_id, available, maximum, description, updated", "", false).
+		Eq("service_id", serviceID).
+		Eq("unit_id", unitID).
+		Order("updated", &postgrest.OrderOpts{Ascending: false}).
+		Limit(1, "").
+		Execute()
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch latest capacity: %w", err)
+	}
+
+	var capacities []hsds_types.ServiceCapacity
+	if err := hsds_types.UnmarshalJSONWithTime(data, &capacities); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal capacity: %w", err)
+	}
+	if len(capacities) == 0 {
+		return nil, nil
+	}
+
+	return &capacities[0], nil
+}
+
+// archiveCapacityReading copies a capacity reading into service_capacity_history
+func archiveCapacityReading(client *supabase.Client, serviceCapacityID string, reading *hsds_types.ServiceCapacity, callID string) error {
+	record := map[string]interface{}{
+		"id":                  uuid.New().String(),
+		"service_capacity_id": serviceCapacityID,
+		"service_id":          reading.ServiceID,
+		"unit_id":             reading.UnitID,
+		"available":           reading.Available,
+		"observed_at":         reading.Updated,
+	}
+	if callID != "" {
+		record["archived_by_call_fk"] = callID
+	}
+	if reading.Maximum != nil {
+		record["maximum"] = *reading.Maximum
+	}
+	if reading.Description != nil {
+		record["description"] = *reading.Description
+	}
+
+	data, _, err := client.From("service_capacity_history").
+		Insert(record, false, "", "representation", "").
+		Execute()
+	if err != nil {
+		return fmt.Errorf("failed to archive capacity reading: %w, data: %s", err, string(data))
+	}
+
+	return nil
+}
+
+// FetchCapacityHistory returns every capacity reading for a service, grouped by unit
+func FetchCapacityHistory(serviceID string) ([]CapacitySeries, error) {
+	log := logger.Get()
+
+	client, err := InitSupabaseClient()
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize Supabase client: %w", err)
+	}
+
+	currentData, _, err := client.From("service_capacity").
+		Select("id, service_id, unit_id, available, maximum, description, updated", "", false).
+		Eq("service_id", serviceID).
+		Execute()
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch current capacity: %w", err)
+	}
+
+	var current []hsds_types.ServiceCapacity
+	if err := hsds_types.UnmarshalJSONWithTime(currentData, &current); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal current capacity: %w", err)
+	}
+
+	historyData, _, err := client.From("service_capacity_history").
+		Select("service_capacity_id, service_id, unit_id, available, maximum, description, observed_at", "", false).
+		Eq("service_id", serviceID).
+		Execute()
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch capacity history: %w", err)
+	}
+
+	var history []CapacityObservation
+	if err := hsds_types.UnmarshalJSONWithTime(historyData, &history); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal capacity history: %w", err)
+	}
+
+	for _, capacity := range current {
+		history = append(history, CapacityObservation{
+			ServiceCapacityID: capacity.ID,
+			ServiceID:         capacity.ServiceID,
+			UnitID:            capacity.UnitID,
+			Available:         capacity.Available,
+			Maximum:           capacity.Maximum,
+			Description:       capacity.Description,
+			ObservedAt:        capacity.Updated,
+			Current:           true,
+		})
+	}
+
+	units, err := FetchUnits()
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch units: %w", err)
+	}
+	unitNames := make(map[string]string, len(units))
+	for _, unit := range units {
+		unitNames[unit.ID] = unit.Name
+	}
+
+	seriesByUnit := make(map[string]*CapacitySeries)
+	var order []string
+	for _, observation := range history {
+		series, ok := seriesByUnit[observation.UnitID]
+		if !ok {
+			series = &CapacitySeries{UnitID: observation.UnitID, UnitName: unitNames[observation.UnitID]}
+			seriesByUnit[observation.UnitID] = series
+			order = append(order, observation.UnitID)
+		}
+		series.Observations = append(series.Observations, observation)
+	}
+
+	sort.Strings(order)
+	result := make([]CapacitySeries, 0, len(order))
+	for _, unitID := range order {
+		series := seriesByUnit[unitID]
+		sort.SliceStable(series.Observations, func(i, j int) bool {
+			return series.Observations[i].ObservedAt.Before(series.Observations[j].ObservedAt)
+		})
+		result = append(result, *series)
+	}
+
+	log.Debug().
+		Str("service_id", serviceID).
+		Int("series_count", len(result)).
+		Int("observation_count", len(history)).
+		Msg("Fetched capacity history")
+
+	return result, nil
+}
+
+// capacityChanges lists the fields that differ between the stored and newly observed reading, as
+// their stored and observed values
+func capacityChanges(stored, observed *hsds_types.ServiceCapacity) map[string][2]interface{} {
+	changes := make(map[string][2]interface{})
+	if stored.Available != observed.Available {
+		changes["available"] = [2]interface{}{stored.Available, observed.Available}
+	}
+	if formatOptional(stored.Maximum) != formatOptional(observed.Maximum) && observed.Maximum != nil {
+		changes["maximum"] = [2]interface{}{stored.Maximum, observed.Maximum}
+	}
+	if observed.Description != nil && (stored.Description == nil || *stored.Description != *observed.Description) {
+		changes["description"] = [2]interface{}{stored.Description, observed.Description}
+	}
+	return changes
+}
+
+func formatOptional[T any](value *T) string {
+	if value == nil {
+		return ""
+	}
+	return fmt.Sprintf("%v", *value)
+}
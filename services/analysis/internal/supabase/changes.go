@@ -0,0 +1,275 @@
+package supabase
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/david-botos/BearHug/services/analysis/internal/hsds_types"
+	"github.com/supabase-community/postgrest-go"
+	"github.com/supabase-community/supabase-go"
+)
+
+// changeTimeLayout matches how metadata.created_at is stored, in UTC without a zone
+const changeTimeLayout = "2006-01-02T15:04:05.999999"
+
+// changeCommitLag is how old a change must be before the feed returns it. seq is assigned when a row is
+// inserted, not when it commits, so a concurrent write can still make a lower seq visible after a higher
+// one was read. Metadata is written one PostgREST request at a time, which commits well within this.
+const changeCommitLag = 10 * time.Second
+
+// Change is one metadata row in the change feed, linked back to the call and transcript that produced it
+type Change struct {
+	ID               string                              `json:"id"`
+	Seq              int64                               `json:"seq"`
+	ChangedAt        time.Time                           `json:"created_at"`
+	RecordedAt       time.Time                           `json:"recorded_at"`
+	ResourceID       string                              `json:"resource_id"`
+	ResourceType     string                              `json:"resource_type"`
+	ActionType       string                              `json:"last_action_type"`
+	FieldName        string                              `json:"field_name"`
+	PreviousValue    string                              `json:"previous_value"`
+	ReplacementValue string                              `json:"replacement_value"`
+	UpdatedBy        string                              `json:"updated_by"`
+	Rationale        *string                             `json:"rationale,omitempty"`
+	Evidence         map[string]hsds_types.FieldEvidence `json:"evidence,omitempty"`
+	CallID           *string                             `json:"call_fk,omitempty"`
+	TranscriptID     *string                             `json:"transcript_id,omitempty"`
+	OrganizationID   *string                             `json:"organization_id,omitempty"`
+
+	// Typed values are only recorded on rows with a change set ID
+	PreviousValueJSON    json.RawMessage `json:"previous_value_json,omitempty"`
+	ReplacementValueJSON json.RawMessage `json:"replacement_value_json,omitempty"`
+	ChangeSetID          *string         `json:"change_set_id,omitempty"`
+}
+
+// HasTypedValues reports whether the change recorded JSON-encoded values, rather than only the text
+// columns written before typed values existed
+func (c Change) HasTypedValues() bool {
+	return c.ChangeSetID != nil && *c.ChangeSetID != ""
+}
+
+// ChangeCursor is the position of a change in the feed: its metadata.seq, which the database assigns in
+// insert order. created_at can't be used, since writers set it rounded to the second.
+type ChangeCursor struct {
+	Seq int64
+}
+
+// String encodes the cursor for use as a query parameter or SSE event ID
+func (c ChangeCursor) String() string {
+	return strconv.FormatInt(c.Seq, 10)
+}
+
+// ParseChangeCursor decodes a cursor returned by the feed
+func ParseChangeCursor(s string) (ChangeCursor, error) {
+	seq, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || seq < 0 {
+		return ChangeCursor{}, fmt.Errorf("malformed change cursor")
+	}
+	return ChangeCursor{Seq: seq}, nil
+}
+
+// ChangeFeedParams filter the change feed. After takes precedence over Since.
+type ChangeFeedParams struct {
+	Since          *time.Time
+	After          *ChangeCursor
+	ResourceType   string
+	OrganizationID string // Only changes made on this organization's calls
+	Limit          int
+}
+
+// FetchChanges returns metadata rows oldest first, with each change's call linked to its transcript
+// and organization. Changes recorded within changeCommitLag are held back, along with everything after
+// them, so a page never gets ahead of a write that hasn't committed yet.
+func FetchChanges(params ChangeFeedParams) ([]Change, error) {
+	client, err := InitSupabaseClient()
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize Supabase client: %w", err)
+	}
+	cutoff := time.Now().Add(-changeCommitLag)
+
+	query := func() *postgrest.FilterBuilder {
+		query := client.From("metadata").Select("*", "", false)
+		if params.After != nil {
+			query = query.Gt("seq", params.After.String())
+		} else if params.Since != nil {
+			query = query.Gte("created_at", params.Since.UTC().Format(changeTimeLayout))
+		}
+		if params.ResourceType != "" {
+			query = query.Eq("resource_type", params.ResourceType)
+		}
+		return query
+	}
+
+	// Calls are filtered in chunks to keep the URL short. Each chunk's first Limit rows include every row
+	// of the overall first Limit that belongs to it.
+	filters := [][]string{nil}
+	if params.OrganizationID != "" {
+		callIDs, err := fetchOrganizationCallIDs(client, params.OrganizationID)
+		if err != nil {
+			return nil, err
+		}
+		if len(callIDs) == 0 {
+			return []Change{}, nil
+		}
+		filters = filters[:0]
+		for start := 0; start < len(callIDs); start += exportFilterChunk {
+			filters = append(filters, callIDs[start:min(start+exportFilterChunk, len(callIDs))])
+		}
+	}
+
+	var changes []Change
+	for _, callIDs := range filters {
+		page := query()
+		if callIDs != nil {
+			page = page.In("call_fk", callIDs)
+		}
+		data, _, err := page.
+			Order("seq", &postgrest.OrderOpts{Ascending: true}).
+			Limit(params.Limit, "").
+			Execute()
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch changes: %w", err)
+		}
+
+		var chunk []Change
+		if err := hsds_types.UnmarshalJSONWithTime(data, &chunk); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal changes: %w", err)
+		}
+		changes = append(changes, chunk...)
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Seq < changes[j].Seq
+	})
+	if len(changes) > params.Limit {
+		changes = changes[:params.Limit]
+	}
+	changes = committedChanges(changes, cutoff)
+
+	if err := linkChangesToCalls(client, changes); err != nil {
+		return nil, err
+	}
+
+	return changes, nil
+}
+
+// committedChanges returns the changes, in seq order, up to the first one recorded after the cutoff
+func committedChanges(changes []Change, cutoff time.Time) []Change {
+	for i, change := range changes {
+		if change.RecordedAt.After(cutoff) {
+			return changes[:i]
+		}
+	}
+	return changes
+}
+
+// LatestChangeCursor returns the cursor of the newest change older than changeCommitLag, so a feed can
+// start after everything recorded so far. Changes still within the lag are sent by the feed, since rows
+// below them may not have committed yet.
+func LatestChangeCursor() (ChangeCursor, error) {
+	client, err := InitSupabaseClient()
+	if err != nil {
+		return ChangeCursor{}, fmt.Errorf("failed to initialize Supabase client: %w", err)
+	}
+
+	data, _, err := client.From("metadata").
+		Select("seq", "", false).
+		Lt("recorded_at", time.Now().Add(-changeCommitLag).UTC().Format(time.RFC3339Nano)).
+		Order("seq", &postgrest.OrderOpts{Ascending: false}).
+		Limit(1, "").
+		Execute()
+	if err != nil {
+		return ChangeCursor{}, fmt.Errorf("failed to fetch latest change: %w", err)
+	}
+
+	var rows []struct {
+		Seq int64 `json:"seq"`
+	}
+	if err := json.Unmarshal(data, &rows); err != nil {
+		return ChangeCursor{}, fmt.Errorf("failed to unmarshal latest change: %w", err)
+	}
+	if len(rows) == 0 {
+		return ChangeCursor{}, nil
+	}
+	return ChangeCursor{Seq: rows[0].Seq}, nil
+}
+
+// fetchOrganizationCallIDs returns the IDs of every call made to an organization
+func fetchOrganizationCallIDs(client *supabase.Client, organizationID string) ([]string, error) {
+	data, _, err := client.From("calls").
+		Select("id", "", false).
+		Eq("fk_organization", organizationID).
+		Execute()
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch calls for organization %s: %w", organizationID, err)
+	}
+
+	var calls []struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(data, &calls); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal calls: %w", err)
+	}
+
+	ids := make([]string, 0, len(calls))
+	for _, call := range calls {
+		ids = append(ids, call.ID)
+	}
+	return ids, nil
+}
+
+// linkChangesToCalls fills in the transcript and organization of each change's call
+func linkChangesToCalls(client *supabase.Client, changes []Change) error {
+	callIDs := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, change := range changes {
+		if change.CallID != nil && *change.CallID != "" && !seen[*change.CallID] {
+			seen[*change.CallID] = true
+			callIDs = append(callIDs, *change.CallID)
+		}
+	}
+	if len(callIDs) == 0 {
+		return nil
+	}
+
+	type callLink struct {
+		ID             string  `json:"id"`
+		TranscriptID   *string `json:"fk_transcript"`
+		OrganizationID *string `json:"fk_organization"`
+	}
+	var calls []callLink
+	for start := 0; start < len(callIDs); start += exportFilterChunk {
+		data, _, err := client.From("calls").
+			Select("id,fk_transcript,fk_organization", "", false).
+			In("id", callIDs[start:min(start+exportFilterChunk, len(callIDs))]).
+			Execute()
+		if err != nil {
+			return fmt.Errorf("failed to fetch calls for changes: %w", err)
+		}
+
+		var chunk []callLink
+		if err := json.Unmarshal(data, &chunk); err != nil {
+			return fmt.Errorf("failed to unmarshal calls for changes: %w", err)
+		}
+		calls = append(calls, chunk...)
+	}
+
+	byID := make(map[string]int, len(calls))
+	for i, call := range calls {
+		byID[call.ID] = i
+	}
+	for i, change := range changes {
+		if change.CallID == nil {
+			continue
+		}
+		if j, ok := byID[*change.CallID]; ok {
+			changes[i].TranscriptID = calls[j].TranscriptID
+			changes[i].OrganizationID = calls[j].OrganizationID
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,90 @@
+package supabase
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseChangeCursor(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    ChangeCursor
+		wantErr bool
+	}{
+		{name: "sequence", input: "42", want: ChangeCursor{Seq: 42}},
+		{name: "start of feed", input: "0", want: ChangeCursor{Seq: 0}},
+		{name: "largest sequence", input: "9223372036854775807", want: ChangeCursor{Seq: 9223372036854775807}},
+		{name: "empty", input: "", wantErr: true},
+		{name: "negative", input: "-1", wantErr: true},
+		{name: "timestamp cursor", input: "2026-03-01T10:00:00Z|b7c1", wantErr: true},
+		{name: "not a number", input: "abc", wantErr: true},
+		{name: "overflow", input: "9223372036854775808", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseChangeCursor(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseChangeCursor(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("ParseChangeCursor(%q) = %+v, want %+v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChangeCursorRoundTrip(t *testing.T) {
+	for _, seq := range []int64{0, 1, 1000, 1 << 40} {
+		cursor := ChangeCursor{Seq: seq}
+		parsed, err := ParseChangeCursor(cursor.String())
+		if err != nil {
+			t.Fatalf("ParseChangeCursor(%q): %v", cursor.String(), err)
+		}
+		if parsed != cursor {
+			t.Errorf("round trip of %+v = %+v", cursor, parsed)
+		}
+	}
+}
+
+func TestCommittedChanges(t *testing.T) {
+	cutoff := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	change := func(seq int64, recorded time.Duration) Change {
+		return Change{Seq: seq, RecordedAt: cutoff.Add(recorded)}
+	}
+	seqs := func(changes []Change) []int64 {
+		out := []int64{}
+		for _, change := range changes {
+			out = append(out, change.Seq)
+		}
+		return out
+	}
+
+	tests := []struct {
+		name    string
+		changes []Change
+		want    []int64
+	}{
+		{name: "empty", want: []int64{}},
+		{name: "all committed", changes: []Change{change(1, -time.Minute), change(2, -time.Second)}, want: []int64{1, 2}},
+		{name: "at the cutoff", changes: []Change{change(1, 0)}, want: []int64{1}},
+		{name: "recent changes held back", changes: []Change{change(1, -time.Minute), change(2, time.Second), change(3, 2*time.Second)}, want: []int64{1}},
+		{
+			// A later seq recorded earlier must not be sent ahead of the change before it
+			name:    "everything after a recent change waits",
+			changes: []Change{change(1, -time.Minute), change(2, time.Second), change(3, -time.Second)},
+			want:    []int64{1},
+		},
+		{name: "nothing committed", changes: []Change{change(1, time.Second)}, want: []int64{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := seqs(committedChanges(tt.changes, cutoff)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("committedChanges() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
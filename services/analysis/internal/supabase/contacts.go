@@ -0,0 +1,221 @@
+package supabase
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/david-botos/BearHug/services/analysis/internal/hsds_types"
+	"github.com/david-botos/BearHug/services/analysis/pkg/logger"
+)
+
+// ContactMergeInput describes an approved merge of duplicate contacts into a survivor
+type ContactMergeInput struct {
+	SurvivorID   string   `json:"survivor_id"`
+	DuplicateIDs []string `json:"duplicate_ids"`
+	Reason       string   `json:"reason,omitempty"`
+	RequestedBy  string   `json:"requested_by,omitempty"`
+}
+
+// ContactMergeResult reports what a contact merge touched
+type ContactMergeResult struct {
+	SurvivorID        string   `json:"survivor_id"`
+	MergedContactIDs  []string `json:"merged_contact_ids"`
+	RepointedPhoneIDs []string `json:"repointed_phone_ids"`
+	FilledFields      []string `json:"filled_fields"` // Survivor fields copied from a duplicate because they were empty
+}
+
+// MergeContacts moves the duplicates' phones to the survivor, copies over any fields the survivor is
+// missing and soft-deletes the duplicates, pointing them at the survivor. Like unit merges there is no
+// transaction, so each duplicate is merged fully before the next and a failure leaves earlier ones merged.
+func MergeContacts(input ContactMergeInput) (*ContactMergeResult, error) {
+	log := logger.Get()
+
+	if !hsds_types.ValidateUUID(input.SurvivorID) {
+		return nil, fmt.Errorf("invalid survivor contact ID: %s", input.SurvivorID)
+	}
+	if len(input.DuplicateIDs) == 0 {
+		return nil, fmt.Errorf("no duplicate contacts to merge")
+	}
+	for _, duplicateID := range input.DuplicateIDs {
+		if duplicateID == input.SurvivorID {
+			return nil, fmt.Errorf("contact %s cannot be merged into itself", duplicateID)
+		}
+		if !hsds_types.ValidateUUID(duplicateID) {
+			return nil, fmt.Errorf("invalid duplicate contact ID: %s", duplicateID)
+		}
+	}
+
+	updatedBy := input.RequestedBy
+	if updatedBy == "" {
+		updatedBy = "admin"
+	}
+
+	client, err := InitSupabaseClient()
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize Supabase client: %w", err)
+	}
+
+	data, _, err := client.From("contact").
+		Select("id, organization_id, service_id, service_at_location_id, location_id, name, title, department, email, created_at, updated_at", "", false).
+		In("id", append([]string{input.SurvivorID}, input.DuplicateIDs...)).
+		Is("deleted_at", "null").
+		Execute()
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch contacts to merge: %w", err)
+	}
+
+	var contacts []hsds_types.Contact
+	if err := hsds_types.UnmarshalJSONWithTime(data, &contacts); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal contacts: %w", err)
+	}
+	contactsByID := make(map[string]hsds_types.Contact, len(contacts))
+	for _, contact := range contacts {
+		contactsByID[contact.ID] = contact
+	}
+
+	survivor, ok := contactsByID[input.SurvivorID]
+	if !ok {
+		return nil, fmt.Errorf("survivor contact %s not found or already merged", input.SurvivorID)
+	}
+	for _, duplicateID := range input.DuplicateIDs {
+		duplicate, ok := contactsByID[duplicateID]
+		if !ok {
+			return nil, fmt.Errorf("duplicate contact %s not found or already merged", duplicateID)
+		}
+		if getString(duplicate.OrganizationID) != getString(survivor.OrganizationID) {
+			return nil, fmt.Errorf("contact %s belongs to a different organization than the survivor", duplicateID)
+		}
+	}
+
+	result := &ContactMergeResult{SurvivorID: survivor.ID}
+	var metadataInputs []MetadataInput
+
+	rationale := fmt.Sprintf("Duplicate contacts merged into %q", getString(survivor.Name))
+	if input.Reason != "" {
+		rationale += ": " + input.Reason
+	}
+
+	// Fill gaps on the survivor from the duplicates, in the order they were given
+	fillData := make(map[string]interface{})
+	survivorFields := map[string]*string{
+		"name":       survivor.Name,
+		"title":      survivor.Title,
+		"department": survivor.Department,
+		"email":      survivor.Email,
+		"service_id": survivor.ServiceID,
+	}
+	for _, duplicateID := range input.DuplicateIDs {
+		duplicate := contactsByID[duplicateID]
+		duplicateFields := map[string]*string{
+			"name":       duplicate.Name,
+			"title":      duplicate.Title,
+			"department": duplicate.Department,
+			"email":      duplicate.Email,
+			"service_id": duplicate.ServiceID,
+		}
+		for field, value := range duplicateFields {
+			if _, filled := fillData[field]; filled || getString(survivorFields[field]) != "" || getString(value) == "" {
+				continue
+			}
+			fillData[field] = *value
+			metadataInputs = append(metadataInputs, MetadataInput{
+				ResourceID:       survivor.ID,
+				ResourceType:     "contact",
+				FieldName:        field,
+				PreviousValue:    "",
+				ReplacementValue: *value,
+				LastActionType:   "UPDATE",
+				Rationale:        fmt.Sprintf("Copied from merged contact %s. %s", duplicate.ID, rationale),
+			})
+			result.FilledFields = append(result.FilledFields, field)
+		}
+	}
+	sort.Strings(result.FilledFields)
+	if len(fillData) > 0 {
+		if _, _, err := client.From("contact").
+			Update(fillData, "", "").
+			Eq("id", survivor.ID).
+			Execute(); err != nil {
+			return result, fmt.Errorf("failed to fill survivor contact %s: %w", survivor.ID, err)
+		}
+	}
+
+	for _, duplicateID := range input.DuplicateIDs {
+		phoneData, err := fetchPhones("contact_id", duplicateID)
+		if err != nil {
+			return result, fmt.Errorf("failed to fetch phones for contact %s: %w", duplicateID, err)
+		}
+		var phones []hsds_types.Phone
+		if err := hsds_types.UnmarshalJSONWithTime(phoneData, &phones); err != nil {
+			return result, fmt.Errorf("failed to unmarshal phones for contact %s: %w", duplicateID, err)
+		}
+
+		for _, phone := range phones {
+			if _, _, err := client.From("phone").
+				Update(map[string]interface{}{"contact_id": survivor.ID}, "", "").
+				Eq("id", phone.ID).
+				Execute(); err != nil {
+				return result, fmt.Errorf("failed to repoint phone %s: %w", phone.ID, err)
+			}
+			result.RepointedPhoneIDs = append(result.RepointedPhoneIDs, phone.ID)
+			metadataInputs = append(metadataInputs, MetadataInput{
+				ResourceID:       phone.ID,
+				ResourceType:     "phone",
+				FieldName:        "contact_id",
+				PreviousValue:    duplicateID,
+				ReplacementValue: survivor.ID,
+				LastActionType:   "UPDATE",
+				Rationale:        rationale,
+			})
+		}
+
+		softDelete := map[string]interface{}{
+			"deleted_at":     time.Now().UTC(),
+			"merged_into_id": survivor.ID,
+		}
+		if _, _, err := client.From("contact").
+			Update(softDelete, "", "").
+			Eq("id", duplicateID).
+			Execute(); err != nil {
+			return result, fmt.Errorf("failed to soft-delete merged contact %s: %w", duplicateID, err)
+		}
+		result.MergedContactIDs = append(result.MergedContactIDs, duplicateID)
+
+		metadataInputs = append(metadataInputs, MetadataInput{
+			ResourceID:       duplicateID,
+			ResourceType:     "contact",
+			FieldName:        "id",
+			PreviousValue:    duplicateID,
+			ReplacementValue: survivor.ID,
+			LastActionType:   "MERGE",
+			Rationale:        rationale,
+		})
+
+		log.Info().
+			Str("duplicate_contact_id", duplicateID).
+			Str("survivor_contact_id", survivor.ID).
+			Int("phones_repointed", len(phones)).
+			Msg("Merged contact")
+	}
+
+	for i := range metadataInputs {
+		metadataInputs[i].UpdatedBy = updatedBy
+	}
+	if err := CreateAndStoreMetadata(metadataInputs); err != nil {
+		log.Error().
+			Err(err).
+			Int("metadata_count", len(metadataInputs)).
+			Msg("Failed to create metadata for contact merge")
+		return result, fmt.Errorf("failed to create metadata for contact merge: %w", err)
+	}
+
+	return result, nil
+}
+
+func getString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,91 @@
+package supabase
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/supabase-community/postgrest-go"
+)
+
+const (
+	// exportPageSize stays under PostgREST's default maximum of 1000 rows per response
+	exportPageSize = 1000
+	// exportFilterChunk keeps IN filters short enough for the request URL
+	exportFilterChunk = 100
+)
+
+// FetchTableRows returns the given columns of a table's rows as raw JSON objects. When filterColumn is set
+// only rows whose filterColumn is one of values are returned. Rows are read a page at a time so large tables
+// come back complete.
+func FetchTableRows(table string, columns []string, filterColumn string, values []string) ([]json.RawMessage, error) {
+	if filterColumn != "" && len(values) == 0 {
+		return nil, nil
+	}
+
+	client, err := InitSupabaseClient()
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize Supabase client: %w", err)
+	}
+
+	chunks := [][]string{nil}
+	if filterColumn != "" {
+		chunks = nil
+		for start := 0; start < len(values); start += exportFilterChunk {
+			end := min(start+exportFilterChunk, len(values))
+			chunks = append(chunks, values[start:end])
+		}
+	}
+
+	var rows []json.RawMessage
+	for _, chunk := range chunks {
+		for from := 0; ; from += exportPageSize {
+			query := client.From(table).Select(strings.Join(columns, ","), "", false)
+			if filterColumn != "" {
+				query = query.In(filterColumn, chunk)
+			}
+			if softDeletedTables[table] {
+				query = query.Is("deleted_at", "null")
+			}
+			data, _, err := query.
+				Order("id", &postgrest.OrderOpts{Ascending: true}).
+				Range(from, from+exportPageSize-1, "").
+				Execute()
+			if err != nil {
+				return nil, fmt.Errorf("failed to fetch %s rows: %w", table, err)
+			}
+
+			var page []json.RawMessage
+			if err := json.Unmarshal(data, &page); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal %s rows: %w", table, err)
+			}
+			rows = append(rows, page...)
+			if len(page) < exportPageSize {
+				break
+			}
+		}
+	}
+
+	return rows, nil
+}
+
+// FetchChangedResourceIDs returns the IDs of every resource with a metadata entry on or after since
+func FetchChangedResourceIDs(since time.Time) (map[string]bool, error) {
+	client, err := InitSupabaseClient()
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize Supabase client: %w", err)
+	}
+
+	changedByType, err := changedResources(client, since)
+	if err != nil {
+		return nil, err
+	}
+	changed := make(map[string]bool)
+	for _, ids := range changedByType {
+		for id := range ids {
+			changed[id] = true
+		}
+	}
+	return changed, nil
+}
@@ -136,6 +136,7 @@ func FetchUnits() ([]hsds_types.Unit, error) {
 	return units, nil
 }
 
+// FetchOrgContacts returns the organization's contacts, leaving out duplicates that were merged away
 func FetchOrgContacts(org_id string) ([]hsds_types.Contact, error) {
 	log := logger.Get()
 	log.Info().Str("org_id", org_id).Msg("Fetching organization contacts")
@@ -163,7 +164,7 @@ func FetchOrgContacts(org_id string) ([]hsds_types.Contact, error) {
 		email,
 		created_at,
     	updated_at
-	`, "", false).Eq("organization_id", org_id).Order("name", order).Execute()
+	`, "", false).Eq("organization_id", org_id).Is("deleted_at", "null").Order("name", order).Execute()
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch orgContacts from supa: %w", err)
 	}
@@ -241,3 +242,30 @@ func FetchRelevantPhones(org_id string, contactIDs []string, serviceIDs []string
 		[][]byte{orgPhones, contactPhones, servicePhones},
 	)
 }
+
+// FetchServiceLanguages returns the languages recorded for the given services
+func FetchServiceLanguages(serviceIDs []string) ([]hsds_types.Language, error) {
+	if len(serviceIDs) == 0 {
+		return nil, nil
+	}
+
+	client, err := InitSupabaseClient()
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize Supabase client: %w", err)
+	}
+
+	data, _, err := client.From("language").
+		Select("id, service_id, location_id, phone_id, name, code, note", "", false).
+		In("service_id", serviceIDs).
+		Execute()
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch service languages: %w", err)
+	}
+
+	var languages []hsds_types.Language
+	if err := hsds_types.UnmarshalJSONWithTime(data, &languages); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal service languages: %w", err)
+	}
+
+	return languages, nil
+}
@@ -0,0 +1,215 @@
+package supabase
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/david-botos/BearHug/services/analysis/internal/hsds_types"
+	"github.com/google/uuid"
+)
+
+// FreshnessService is a service along with when it last changed, which hsds_types.Service leaves out
+type FreshnessService struct {
+	hsds_types.Service
+	LastModified *time.Time `json:"last_modified,omitempty"`
+}
+
+// FreshnessData is what freshness scoring needs to know about one organization
+type FreshnessData struct {
+	Organization hsds_types.Organization
+	Services     []FreshnessService
+	Phones       []hsds_types.Phone // The organization's phones and its services' phones
+	// Per resource, when a call last confirmed each field. Resources created on a call are keyed
+	// CallVerifiedAllFields.
+	Confirmations map[string]map[string]time.Time
+	LastCallAt    *time.Time
+	LastDialAt    *time.Time // Last re-verification call requested from go-bot, whether or not it connected
+}
+
+// ReverificationDialInput records a re-verification call requested from go-bot
+type ReverificationDialInput struct {
+	OrganizationID    string
+	PhoneNumber       string
+	ServiceCategories []string
+	Status            string // "dialed" or "failed"
+	Response          string // go-bot's response body, or the error when the request failed
+}
+
+// FetchFreshnessData loads freshness inputs for the given organizations, or for every organization when
+// organizationIDs is empty. Organizations that don't exist are left out.
+func FetchFreshnessData(organizationIDs []string) ([]FreshnessData, error) {
+	filterColumn := ""
+	if len(organizationIDs) > 0 {
+		filterColumn = "id"
+	}
+	organizations, err := fetchTableRowsAs[hsds_types.Organization]("organization", filterColumn, organizationIDs)
+	if err != nil {
+		return nil, err
+	}
+	if len(organizations) == 0 {
+		return nil, nil
+	}
+
+	orgIDs := make([]string, 0, len(organizations))
+	for _, organization := range organizations {
+		orgIDs = append(orgIDs, organization.ID)
+	}
+
+	services, err := fetchTableRowsAs[FreshnessService]("service", "organization_id", orgIDs)
+	if err != nil {
+		return nil, err
+	}
+	serviceIDs := make([]string, 0, len(services))
+	serviceOrganization := make(map[string]string, len(services))
+	for _, service := range services {
+		serviceIDs = append(serviceIDs, service.ID)
+		serviceOrganization[service.ID] = service.OrganizationID
+	}
+
+	orgPhones, err := fetchTableRowsAs[hsds_types.Phone]("phone", "organization_id", orgIDs)
+	if err != nil {
+		return nil, err
+	}
+	servicePhones, err := fetchTableRowsAs[hsds_types.Phone]("phone", "service_id", serviceIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := InitSupabaseClient()
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize Supabase client: %w", err)
+	}
+	changes, err := fetchChangesBy(client, "resource_id", append(append([]string{}, orgIDs...), serviceIDs...))
+	if err != nil {
+		return nil, err
+	}
+	confirmations := callConfirmations(changes)
+
+	type callRow struct {
+		OrganizationID *string   `json:"fk_organization"`
+		CreatedAt      time.Time `json:"created_at"`
+	}
+	calls, err := fetchTableRowsAs[callRow]("calls", "fk_organization", orgIDs)
+	if err != nil {
+		return nil, err
+	}
+	type dialRow struct {
+		OrganizationID *string   `json:"organization_id"`
+		CreatedAt      time.Time `json:"created_at"`
+	}
+	dials, err := fetchTableRowsAs[dialRow]("reverification_dial", "organization_id", orgIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[string]*FreshnessData, len(organizations))
+	result := make([]FreshnessData, len(organizations))
+	for i, organization := range organizations {
+		result[i] = FreshnessData{
+			Organization:  organization,
+			Confirmations: make(map[string]map[string]time.Time),
+		}
+		byID[organization.ID] = &result[i]
+		if confirmed, ok := confirmations[organization.ID]; ok {
+			result[i].Confirmations[organization.ID] = confirmed
+		}
+	}
+	for _, service := range services {
+		data := byID[service.OrganizationID]
+		data.Services = append(data.Services, service)
+		if confirmed, ok := confirmations[service.ID]; ok {
+			data.Confirmations[service.ID] = confirmed
+		}
+	}
+	for _, phone := range orgPhones {
+		data := byID[getString(phone.OrganizationID)]
+		data.Phones = append(data.Phones, phone)
+	}
+	for _, phone := range servicePhones {
+		// Already included when the phone belongs to the organization as well
+		if phone.OrganizationID != nil && byID[*phone.OrganizationID] != nil {
+			continue
+		}
+		if data := byID[serviceOrganization[getString(phone.ServiceID)]]; data != nil {
+			data.Phones = append(data.Phones, phone)
+		}
+	}
+	latest := func(current *time.Time, at time.Time) *time.Time {
+		if current == nil || at.After(*current) {
+			return &at
+		}
+		return current
+	}
+	for _, call := range calls {
+		if data := byID[getString(call.OrganizationID)]; data != nil {
+			data.LastCallAt = latest(data.LastCallAt, call.CreatedAt)
+		}
+	}
+	for _, dial := range dials {
+		if data := byID[getString(dial.OrganizationID)]; data != nil {
+			data.LastDialAt = latest(data.LastDialAt, dial.CreatedAt)
+		}
+	}
+
+	return result, nil
+}
+
+// FetchServiceOrganizationID returns the organization a service belongs to, or "" if there is no such service
+func FetchServiceOrganizationID(serviceID string) (string, error) {
+	services, err := fetchTableRowsAs[hsds_types.Service]("service", "id", []string{serviceID})
+	if err != nil {
+		return "", err
+	}
+	if len(services) == 0 {
+		return "", nil
+	}
+	return services[0].OrganizationID, nil
+}
+
+// StoreReverificationDial records a re-verification call request, which also rate limits the next one
+func StoreReverificationDial(input ReverificationDialInput) error {
+	client, err := InitSupabaseClient()
+	if err != nil {
+		return fmt.Errorf("failed to initialize Supabase client: %w", err)
+	}
+
+	record := map[string]interface{}{
+		"id":                 uuid.New().String(),
+		"organization_id":    input.OrganizationID,
+		"phone_number":       input.PhoneNumber,
+		"service_categories": input.ServiceCategories,
+		"status":             input.Status,
+		"response":           input.Response,
+	}
+
+	data, _, err := client.From("reverification_dial").
+		Insert(record, false, "", "minimal", "").
+		Execute()
+	if err != nil {
+		return fmt.Errorf("failed to store re-verification dial: %w, data: %s", err, string(data))
+	}
+
+	return nil
+}
+
+// fetchTableRowsAs reads rows with FetchTableRows and decodes them, converting timestamps
+func fetchTableRowsAs[T any](table string, filterColumn string, values []string) ([]T, error) {
+	raw, err := FetchTableRows(table, []string{"*"}, filterColumn, values)
+	if err != nil {
+		return nil, err
+	}
+	if len(raw) == 0 {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s rows: %w", table, err)
+	}
+	var rows []T
+	if err := hsds_types.UnmarshalJSONWithTime(data, &rows); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal %s rows: %w", table, err)
+	}
+	return rows, nil
+}
@@ -0,0 +1,900 @@
+package supabase
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/david-botos/BearHug/services/analysis/internal/hsds_types"
+	"github.com/supabase-community/postgrest-go"
+	"github.com/supabase-community/supabase-go"
+)
+
+// HSDSListParams are the paging and filter parameters shared by the HSDS list endpoints
+type HSDSListParams struct {
+	Page           int // 1-based
+	PerPage        int
+	ModifiedAfter  *time.Time // Only rows with a metadata entry on or after this, for them or anything nested in them
+	Search         string     // Matched against name, case-insensitively
+	OrganizationID string
+}
+
+// hsdsRelation is a table nested in an HSDS response and the column linking it to the resource it is
+// nested in
+type hsdsRelation struct {
+	Table    string
+	Column   string
+	OnParent bool // The column is on the resource, pointing at the nested row
+	Nested   []hsdsRelation
+}
+
+// These mirror what the build*Details functions nest, so a change to a nested row marks the resource it is
+// shown in as modified
+var (
+	contactNesting = []hsdsRelation{
+		{Table: "phone", Column: "contact_id"},
+	}
+	locationNesting = []hsdsRelation{
+		{Table: "address", Column: "location_id"},
+		{Table: "phone", Column: "location_id"},
+		{Table: "schedule", Column: "location_id"},
+		{Table: "language", Column: "location_id"},
+		{Table: "accessibility", Column: "location_id"},
+	}
+	serviceAtLocationNesting = []hsdsRelation{
+		{Table: "location", Column: "location_id", OnParent: true, Nested: locationNesting},
+		{Table: "phone", Column: "service_at_location_id"},
+		{Table: "schedule", Column: "service_at_location_id"},
+		{Table: "contact", Column: "service_at_location_id", Nested: contactNesting},
+	}
+	serviceNesting = []hsdsRelation{
+		{Table: "organization", Column: "organization_id", OnParent: true},
+		{Table: "program", Column: "program_id", OnParent: true},
+		{Table: "url", Column: "service_id"},
+		{Table: "phone", Column: "service_id"},
+		{Table: "schedule", Column: "service_id"},
+		{Table: "service_area", Column: "service_id"},
+		{Table: "language", Column: "service_id"},
+		{Table: "funding", Column: "service_id"},
+		{Table: "cost_option", Column: "service_id"},
+		{Table: "required_document", Column: "service_id"},
+		{Table: "contact", Column: "service_id", Nested: contactNesting},
+		{Table: "service_capacity", Column: "service_id", Nested: []hsdsRelation{
+			{Table: "unit", Column: "unit_id", OnParent: true},
+		}},
+		{Table: "attribute", Column: "link_id", Nested: []hsdsRelation{
+			{Table: "taxonomy_term", Column: "taxonomy_term_id", OnParent: true},
+		}},
+	}
+)
+
+// hsdsListNesting is what each list endpoint nests. Services are only nested in organizations on request,
+// so they don't count toward an organization's changes.
+var hsdsListNesting = map[string][]hsdsRelation{
+	"service": append(serviceNesting, hsdsRelation{Table: "service_at_location", Column: "service_id", Nested: serviceAtLocationNesting}),
+	"organization": {
+		{Table: "url", Column: "organization_id"},
+		{Table: "funding", Column: "organization_id"},
+		{Table: "phone", Column: "organization_id"},
+		{Table: "program", Column: "organization_id"},
+		{Table: "organization_identifier", Column: "organization_id"},
+		{Table: "contact", Column: "organization_id", Nested: contactNesting},
+		{Table: "location", Column: "organization_id", Nested: locationNesting},
+	},
+	"service_at_location": append(serviceAtLocationNesting, hsdsRelation{Table: "service", Column: "service_id", OnParent: true, Nested: serviceNesting}),
+}
+
+// softDeletedTables hide rows that were merged away
+var softDeletedTables = map[string]bool{
+	"contact": true,
+}
+
+// fetchRows returns the rows of table whose column is one of values
+func fetchRows[T any](client *supabase.Client, table string, column string, values []string) ([]T, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+
+	query := client.From(table).Select("*", "", false).In(column, values)
+	if softDeletedTables[table] {
+		query = query.Is("deleted_at", "null")
+	}
+	data, _, err := query.Execute()
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", table, err)
+	}
+
+	var rows []T
+	if err := hsds_types.UnmarshalJSONWithTime(data, &rows); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal %s: %w", table, err)
+	}
+	return rows, nil
+}
+
+// fetchPage returns one page of table ordered by orderColumn, along with the total number of matching rows
+func fetchPage[T any](client *supabase.Client, table string, orderColumn string, params HSDSListParams) ([]T, int, error) {
+	if params.ModifiedAfter != nil {
+		return fetchModifiedPage[T](client, table, orderColumn, params)
+	}
+
+	from := (params.Page - 1) * params.PerPage
+	data, count, err := applyListFilters(client.From(table).Select("*", "exact", false), params).
+		Order(orderColumn, &postgrest.OrderOpts{Ascending: true}).
+		Range(from, from+params.PerPage-1, "").
+		Execute()
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to fetch %s page: %w", table, err)
+	}
+
+	var rows []T
+	if err := hsds_types.UnmarshalJSONWithTime(data, &rows); err != nil {
+		return nil, 0, fmt.Errorf("failed to unmarshal %s: %w", table, err)
+	}
+	return rows, int(count), nil
+}
+
+// applyListFilters adds the search and organization filters of a list request
+func applyListFilters(query *postgrest.FilterBuilder, params HSDSListParams) *postgrest.FilterBuilder {
+	if params.Search != "" {
+		query = query.Ilike("name", "%"+params.Search+"%")
+	}
+	if params.OrganizationID != "" {
+		query = query.Eq("organization_id", params.OrganizationID)
+	}
+	return query
+}
+
+// fetchModifiedPage returns one page of the rows of table modified on or after params.ModifiedAfter. HSDS
+// tables don't record when a row changed, but every change writes a metadata entry. There can be too many
+// modified rows for one filter, so they are fetched in chunks and paged here.
+func fetchModifiedPage[T any](client *supabase.Client, table string, orderColumn string, params HSDSListParams) ([]T, int, error) {
+	changed, err := changedResources(client, *params.ModifiedAfter)
+	if err != nil {
+		return nil, 0, err
+	}
+	modified, err := modifiedIDs(table, hsdsListNesting[table], changed, func(table, selectColumn, filterColumn string, values []string) ([]string, error) {
+		return linkedIDs(client, table, selectColumn, filterColumn, values)
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	ids := make([]string, 0, len(modified))
+	for id := range modified {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	var entries []pageEntry[T]
+	for start := 0; start < len(ids); start += exportFilterChunk {
+		end := min(start+exportFilterChunk, len(ids))
+		data, _, err := applyListFilters(client.From(table).Select("*", "", false).In("id", ids[start:end]), params).Execute()
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to fetch modified %s: %w", table, err)
+		}
+
+		var rows []T
+		if err := hsds_types.UnmarshalJSONWithTime(data, &rows); err != nil {
+			return nil, 0, fmt.Errorf("failed to unmarshal %s: %w", table, err)
+		}
+		var keys []map[string]interface{}
+		if err := json.Unmarshal(data, &keys); err != nil {
+			return nil, 0, fmt.Errorf("failed to unmarshal %s: %w", table, err)
+		}
+		for i, row := range rows {
+			entries = append(entries, pageEntry[T]{ID: fmt.Sprint(keys[i]["id"]), Order: fmt.Sprint(keys[i][orderColumn]), Row: row})
+		}
+	}
+
+	rows, total := pageRows(entries, params.Page, params.PerPage)
+	return rows, total, nil
+}
+
+// pageEntry is a row of a page built outside the database, with the values it is ordered by
+type pageEntry[T any] struct {
+	ID    string
+	Order string
+	Row   T
+}
+
+// pageRows orders entries by their order value, then ID, and returns one page of them with the total count
+func pageRows[T any](entries []pageEntry[T], page, perPage int) ([]T, int) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].Order != entries[j].Order {
+			return entries[i].Order < entries[j].Order
+		}
+		return entries[i].ID < entries[j].ID
+	})
+
+	rows := []T{}
+	from := (page - 1) * perPage
+	for i := from; i >= 0 && i < len(entries) && i < from+perPage; i++ {
+		rows = append(rows, entries[i].Row)
+	}
+	return rows, len(entries)
+}
+
+// changedResources returns the IDs of every resource with a metadata entry on or after since, by resource type
+func changedResources(client *supabase.Client, since time.Time) (map[string]map[string]bool, error) {
+	changed := make(map[string]map[string]bool)
+	for from := 0; ; from += exportPageSize {
+		data, _, err := client.From("metadata").
+			Select("resource_id,resource_type", "", false).
+			Gte("created_at", since.UTC().Format(changeTimeLayout)).
+			Order("seq", &postgrest.OrderOpts{Ascending: true}).
+			Range(from, from+exportPageSize-1, "").
+			Execute()
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch changed resources: %w", err)
+		}
+
+		var page []struct {
+			ResourceID   string `json:"resource_id"`
+			ResourceType string `json:"resource_type"`
+		}
+		if err := json.Unmarshal(data, &page); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal changed resources: %w", err)
+		}
+		for _, row := range page {
+			if changed[row.ResourceType] == nil {
+				changed[row.ResourceType] = make(map[string]bool)
+			}
+			changed[row.ResourceType][row.ResourceID] = true
+		}
+		if len(page) < exportPageSize {
+			break
+		}
+	}
+	return changed, nil
+}
+
+// linkFunc returns the distinct values of selectColumn in the rows of table whose filterColumn is one of values
+type linkFunc func(table, selectColumn, filterColumn string, values []string) ([]string, error)
+
+// modifiedIDs returns the IDs of the rows of table that changed themselves or have a changed row nested in
+// them, following nesting down to the rows that changed
+func modifiedIDs(table string, nesting []hsdsRelation, changed map[string]map[string]bool, link linkFunc) (map[string]bool, error) {
+	ids := make(map[string]bool, len(changed[table]))
+	for id := range changed[table] {
+		ids[id] = true
+	}
+
+	for _, relation := range nesting {
+		nested, err := modifiedIDs(relation.Table, relation.Nested, changed, link)
+		if err != nil {
+			return nil, err
+		}
+		if len(nested) == 0 {
+			continue
+		}
+		nestedIDs := make([]string, 0, len(nested))
+		for id := range nested {
+			nestedIDs = append(nestedIDs, id)
+		}
+		sort.Strings(nestedIDs)
+
+		var linked []string
+		if relation.OnParent {
+			linked, err = link(table, "id", relation.Column, nestedIDs)
+		} else {
+			linked, err = link(relation.Table, relation.Column, "id", nestedIDs)
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range linked {
+			ids[id] = true
+		}
+	}
+	return ids, nil
+}
+
+// linkedIDs is the linkFunc backed by the database, filtering in chunks
+func linkedIDs(client *supabase.Client, table, selectColumn, filterColumn string, values []string) ([]string, error) {
+	seen := make(map[string]bool)
+	var ids []string
+	for start := 0; start < len(values); start += exportFilterChunk {
+		end := min(start+exportFilterChunk, len(values))
+		for from := 0; ; from += exportPageSize {
+			data, _, err := client.From(table).
+				Select(selectColumn, "", false).
+				In(filterColumn, values[start:end]).
+				Order("id", &postgrest.OrderOpts{Ascending: true}).
+				Range(from, from+exportPageSize-1, "").
+				Execute()
+			if err != nil {
+				return nil, fmt.Errorf("failed to fetch %s links: %w", table, err)
+			}
+
+			var page []map[string]interface{}
+			if err := json.Unmarshal(data, &page); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal %s links: %w", table, err)
+			}
+			for _, row := range page {
+				if id, ok := row[selectColumn].(string); ok && id != "" && !seen[id] {
+					seen[id] = true
+					ids = append(ids, id)
+				}
+			}
+			if len(page) < exportPageSize {
+				break
+			}
+		}
+	}
+	return ids, nil
+}
+
+// groupBy indexes items by the ID returned by key, skipping items without one
+func groupBy[T any](items []T, key func(T) string) map[string][]T {
+	groups := make(map[string][]T)
+	for _, item := range items {
+		if id := key(item); id != "" {
+			groups[id] = append(groups[id], item)
+		}
+	}
+	return groups
+}
+
+// nonNil keeps empty relationships as [] rather than null in responses
+func nonNil[T any](items []T) []T {
+	if items == nil {
+		return []T{}
+	}
+	return items
+}
+
+// FetchHSDSServices returns a page of services with their related objects nested
+func FetchHSDSServices(params HSDSListParams) (hsds_types.Page[hsds_types.ServiceDetail], error) {
+	client, err := InitSupabaseClient()
+	if err != nil {
+		return hsds_types.Page[hsds_types.ServiceDetail]{}, fmt.Errorf("failed to initialize Supabase client: %w", err)
+	}
+
+	services, total, err := fetchPage[hsds_types.Service](client, "service", "name", params)
+	if err != nil {
+		return hsds_types.Page[hsds_types.ServiceDetail]{}, err
+	}
+
+	details, err := buildServiceDetails(client, services, true, true)
+	if err != nil {
+		return hsds_types.Page[hsds_types.ServiceDetail]{}, err
+	}
+
+	return hsds_types.NewPage(details, total, params.Page, params.PerPage), nil
+}
+
+// FetchHSDSService returns one service with its related objects nested, or nil if it does not exist
+func FetchHSDSService(serviceID string) (*hsds_types.ServiceDetail, error) {
+	client, err := InitSupabaseClient()
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize Supabase client: %w", err)
+	}
+
+	services, err := fetchRows[hsds_types.Service](client, "service", "id", []string{serviceID})
+	if err != nil {
+		return nil, err
+	}
+	if len(services) == 0 {
+		return nil, nil
+	}
+
+	details, err := buildServiceDetails(client, services, true, true)
+	if err != nil {
+		return nil, err
+	}
+	return &details[0], nil
+}
+
+// FetchHSDSOrganizations returns a page of organizations with their related objects nested. Services are
+// only nested when fullServices is set, since they make the response much larger.
+func FetchHSDSOrganizations(params HSDSListParams, fullServices bool) (hsds_types.Page[hsds_types.OrganizationDetail], error) {
+	client, err := InitSupabaseClient()
+	if err != nil {
+		return hsds_types.Page[hsds_types.OrganizationDetail]{}, fmt.Errorf("failed to initialize Supabase client: %w", err)
+	}
+
+	// Organizations are the top of the tree, so the organization filter does not apply
+	params.OrganizationID = ""
+	organizations, total, err := fetchPage[hsds_types.Organization](client, "organization", "name", params)
+	if err != nil {
+		return hsds_types.Page[hsds_types.OrganizationDetail]{}, err
+	}
+
+	details, err := buildOrganizationDetails(client, organizations, fullServices)
+	if err != nil {
+		return hsds_types.Page[hsds_types.OrganizationDetail]{}, err
+	}
+
+	return hsds_types.NewPage(details, total, params.Page, params.PerPage), nil
+}
+
+// FetchHSDSOrganization returns one organization with its services and other related objects nested,
+// or nil if it does not exist
+func FetchHSDSOrganization(organizationID string) (*hsds_types.OrganizationDetail, error) {
+	client, err := InitSupabaseClient()
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize Supabase client: %w", err)
+	}
+
+	organizations, err := fetchRows[hsds_types.Organization](client, "organization", "id", []string{organizationID})
+	if err != nil {
+		return nil, err
+	}
+	if len(organizations) == 0 {
+		return nil, nil
+	}
+
+	details, err := buildOrganizationDetails(client, organizations, true)
+	if err != nil {
+		return nil, err
+	}
+	return &details[0], nil
+}
+
+// FetchHSDSServiceAtLocations returns a page of service at locations with their service and location nested
+func FetchHSDSServiceAtLocations(params HSDSListParams) (hsds_types.Page[hsds_types.ServiceAtLocationDetail], error) {
+	client, err := InitSupabaseClient()
+	if err != nil {
+		return hsds_types.Page[hsds_types.ServiceAtLocationDetail]{}, fmt.Errorf("failed to initialize Supabase client: %w", err)
+	}
+
+	// service_at_location has neither a name nor an organization to filter on
+	params.Search = ""
+	params.OrganizationID = ""
+	serviceAtLocations, total, err := fetchPage[hsds_types.ServiceAtLocation](client, "service_at_location", "id", params)
+	if err != nil {
+		return hsds_types.Page[hsds_types.ServiceAtLocationDetail]{}, err
+	}
+
+	details, err := buildServiceAtLocationDetails(client, serviceAtLocations)
+	if err != nil {
+		return hsds_types.Page[hsds_types.ServiceAtLocationDetail]{}, err
+	}
+
+	serviceIDs := make([]string, 0, len(serviceAtLocations))
+	for _, serviceAtLocation := range serviceAtLocations {
+		serviceIDs = append(serviceIDs, serviceAtLocation.ServiceID)
+	}
+	services, err := fetchRows[hsds_types.Service](client, "service", "id", serviceIDs)
+	if err != nil {
+		return hsds_types.Page[hsds_types.ServiceAtLocationDetail]{}, err
+	}
+	serviceDetails, err := buildServiceDetails(client, services, true, false)
+	if err != nil {
+		return hsds_types.Page[hsds_types.ServiceAtLocationDetail]{}, err
+	}
+	servicesByID := make(map[string]*hsds_types.ServiceDetail, len(serviceDetails))
+	for i := range serviceDetails {
+		servicesByID[serviceDetails[i].ID] = &serviceDetails[i]
+	}
+	for i := range details {
+		details[i].Service = servicesByID[details[i].ServiceID]
+	}
+
+	return hsds_types.NewPage(details, total, params.Page, params.PerPage), nil
+}
+
+// FetchHSDSTaxonomies returns a page of taxonomies
+func FetchHSDSTaxonomies(params HSDSListParams) (hsds_types.Page[hsds_types.Taxonomy], error) {
+	client, err := InitSupabaseClient()
+	if err != nil {
+		return hsds_types.Page[hsds_types.Taxonomy]{}, fmt.Errorf("failed to initialize Supabase client: %w", err)
+	}
+
+	params.OrganizationID = ""
+	taxonomies, total, err := fetchPage[hsds_types.Taxonomy](client, "taxonomy", "name", params)
+	if err != nil {
+		return hsds_types.Page[hsds_types.Taxonomy]{}, err
+	}
+
+	return hsds_types.NewPage(taxonomies, total, params.Page, params.PerPage), nil
+}
+
+// buildServiceDetails nests each service's related objects, fetching each related table once for all of
+// them. The organization is left out when the services are nested inside it, and service at locations
+// are left out when the service is nested inside one.
+func buildServiceDetails(client *supabase.Client, services []hsds_types.Service, withOrganization bool, withLocations bool) ([]hsds_types.ServiceDetail, error) {
+	ids := make([]string, 0, len(services))
+	var organizationIDs, programIDs []string
+	for _, service := range services {
+		ids = append(ids, service.ID)
+		organizationIDs = append(organizationIDs, service.OrganizationID)
+		if service.ProgramID != nil {
+			programIDs = append(programIDs, *service.ProgramID)
+		}
+	}
+
+	organizationsByID := make(map[string]*hsds_types.Organization)
+	if withOrganization {
+		organizations, err := fetchRows[hsds_types.Organization](client, "organization", "id", organizationIDs)
+		if err != nil {
+			return nil, err
+		}
+		for i := range organizations {
+			organizationsByID[organizations[i].ID] = &organizations[i]
+		}
+	}
+
+	programs, err := fetchRows[hsds_types.Program](client, "program", "id", programIDs)
+	if err != nil {
+		return nil, err
+	}
+	programsByID := make(map[string]*hsds_types.Program, len(programs))
+	for i := range programs {
+		programsByID[programs[i].ID] = &programs[i]
+	}
+
+	urls, err := fetchRows[hsds_types.URL](client, "url", "service_id", ids)
+	if err != nil {
+		return nil, err
+	}
+	phones, err := fetchRows[hsds_types.Phone](client, "phone", "service_id", ids)
+	if err != nil {
+		return nil, err
+	}
+	schedules, err := fetchRows[hsds_types.Schedule](client, "schedule", "service_id", ids)
+	if err != nil {
+		return nil, err
+	}
+	serviceAreas, err := fetchRows[hsds_types.ServiceArea](client, "service_area", "service_id", ids)
+	if err != nil {
+		return nil, err
+	}
+	languages, err := fetchRows[hsds_types.Language](client, "language", "service_id", ids)
+	if err != nil {
+		return nil, err
+	}
+	funding, err := fetchRows[hsds_types.Funding](client, "funding", "service_id", ids)
+	if err != nil {
+		return nil, err
+	}
+	costOptions, err := fetchRows[hsds_types.CostOption](client, "cost_option", "service_id", ids)
+	if err != nil {
+		return nil, err
+	}
+	requiredDocuments, err := fetchRows[hsds_types.RequiredDocument](client, "required_document", "service_id", ids)
+	if err != nil {
+		return nil, err
+	}
+	contacts, err := fetchRows[hsds_types.Contact](client, "contact", "service_id", ids)
+	if err != nil {
+		return nil, err
+	}
+	contactDetails, err := buildContactDetails(client, contacts)
+	if err != nil {
+		return nil, err
+	}
+	capacities, err := buildCapacityDetails(client, ids)
+	if err != nil {
+		return nil, err
+	}
+	attributes, err := buildAttributeDetails(client, "service", ids)
+	if err != nil {
+		return nil, err
+	}
+
+	serviceAtLocationsByService := make(map[string][]hsds_types.ServiceAtLocationDetail)
+	if withLocations {
+		serviceAtLocations, err := fetchRows[hsds_types.ServiceAtLocation](client, "service_at_location", "service_id", ids)
+		if err != nil {
+			return nil, err
+		}
+		serviceAtLocationDetails, err := buildServiceAtLocationDetails(client, serviceAtLocations)
+		if err != nil {
+			return nil, err
+		}
+		serviceAtLocationsByService = groupBy(serviceAtLocationDetails, func(s hsds_types.ServiceAtLocationDetail) string { return s.ServiceID })
+	}
+
+	urlsByService := groupBy(urls, func(u hsds_types.URL) string { return getString(u.ServiceID) })
+	phonesByService := groupBy(phones, func(p hsds_types.Phone) string { return getString(p.ServiceID) })
+	schedulesByService := groupBy(schedules, func(s hsds_types.Schedule) string { return getString(s.ServiceID) })
+	areasByService := groupBy(serviceAreas, func(a hsds_types.ServiceArea) string { return getString(a.ServiceID) })
+	languagesByService := groupBy(languages, func(l hsds_types.Language) string { return getString(l.ServiceID) })
+	fundingByService := groupBy(funding, func(f hsds_types.Funding) string { return getString(f.ServiceID) })
+	costsByService := groupBy(costOptions, func(c hsds_types.CostOption) string { return c.ServiceID })
+	documentsByService := groupBy(requiredDocuments, func(d hsds_types.RequiredDocument) string { return getString(d.ServiceID) })
+	contactsByService := groupBy(contactDetails, func(c hsds_types.ContactDetail) string { return getString(c.ServiceID) })
+	capacitiesByService := groupBy(capacities, func(c hsds_types.ServiceCapacityDetail) string { return c.ServiceID })
+	attributesByService := groupBy(attributes, func(a hsds_types.AttributeDetail) string { return a.LinkID })
+
+	details := make([]hsds_types.ServiceDetail, 0, len(services))
+	for _, service := range services {
+		detail := hsds_types.ServiceDetail{
+			Service:            service,
+			Organization:       organizationsByID[service.OrganizationID],
+			AdditionalURLs:     nonNil(urlsByService[service.ID]),
+			Phones:             nonNil(phonesByService[service.ID]),
+			Schedules:          nonNil(schedulesByService[service.ID]),
+			ServiceAreas:       nonNil(areasByService[service.ID]),
+			ServiceAtLocations: nonNil(serviceAtLocationsByService[service.ID]),
+			Languages:          nonNil(languagesByService[service.ID]),
+			Funding:            nonNil(fundingByService[service.ID]),
+			CostOptions:        nonNil(costsByService[service.ID]),
+			RequiredDocuments:  nonNil(documentsByService[service.ID]),
+			Contacts:           nonNil(contactsByService[service.ID]),
+			Capacities:         nonNil(capacitiesByService[service.ID]),
+			Attributes:         nonNil(attributesByService[service.ID]),
+		}
+		if service.ProgramID != nil {
+			detail.Program = programsByID[*service.ProgramID]
+		}
+		details = append(details, detail)
+	}
+	return details, nil
+}
+
+// buildOrganizationDetails nests each organization's related objects, and its services when fullServices is set
+func buildOrganizationDetails(client *supabase.Client, organizations []hsds_types.Organization, fullServices bool) ([]hsds_types.OrganizationDetail, error) {
+	ids := make([]string, 0, len(organizations))
+	for _, organization := range organizations {
+		ids = append(ids, organization.ID)
+	}
+
+	urls, err := fetchRows[hsds_types.URL](client, "url", "organization_id", ids)
+	if err != nil {
+		return nil, err
+	}
+	funding, err := fetchRows[hsds_types.Funding](client, "funding", "organization_id", ids)
+	if err != nil {
+		return nil, err
+	}
+	phones, err := fetchRows[hsds_types.Phone](client, "phone", "organization_id", ids)
+	if err != nil {
+		return nil, err
+	}
+	programs, err := fetchRows[hsds_types.Program](client, "program", "organization_id", ids)
+	if err != nil {
+		return nil, err
+	}
+	identifiers, err := fetchRows[hsds_types.OrganizationIdentifier](client, "organization_identifier", "organization_id", ids)
+	if err != nil {
+		return nil, err
+	}
+	contacts, err := fetchRows[hsds_types.Contact](client, "contact", "organization_id", ids)
+	if err != nil {
+		return nil, err
+	}
+	contactDetails, err := buildContactDetails(client, contacts)
+	if err != nil {
+		return nil, err
+	}
+	locations, err := fetchRows[hsds_types.Location](client, "location", "organization_id", ids)
+	if err != nil {
+		return nil, err
+	}
+	locationDetails, err := buildLocationDetails(client, locations)
+	if err != nil {
+		return nil, err
+	}
+
+	var serviceDetails []hsds_types.ServiceDetail
+	if fullServices {
+		services, err := fetchRows[hsds_types.Service](client, "service", "organization_id", ids)
+		if err != nil {
+			return nil, err
+		}
+		serviceDetails, err = buildServiceDetails(client, services, false, true)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	urlsByOrganization := groupBy(urls, func(u hsds_types.URL) string { return getString(u.OrganizationID) })
+	fundingByOrganization := groupBy(funding, func(f hsds_types.Funding) string { return getString(f.OrganizationID) })
+	phonesByOrganization := groupBy(phones, func(p hsds_types.Phone) string { return getString(p.OrganizationID) })
+	programsByOrganization := groupBy(programs, func(p hsds_types.Program) string { return p.OrganizationID })
+	identifiersByOrganization := groupBy(identifiers, func(i hsds_types.OrganizationIdentifier) string { return i.OrganizationID })
+	contactsByOrganization := groupBy(contactDetails, func(c hsds_types.ContactDetail) string { return getString(c.OrganizationID) })
+	locationsByOrganization := groupBy(locationDetails, func(l hsds_types.LocationDetail) string { return getString(l.OrganizationID) })
+	servicesByOrganization := groupBy(serviceDetails, func(s hsds_types.ServiceDetail) string { return s.OrganizationID })
+
+	details := make([]hsds_types.OrganizationDetail, 0, len(organizations))
+	for _, organization := range organizations {
+		detail := hsds_types.OrganizationDetail{
+			Organization:            organization,
+			AdditionalURLs:          nonNil(urlsByOrganization[organization.ID]),
+			Funding:                 nonNil(fundingByOrganization[organization.ID]),
+			Contacts:                nonNil(contactsByOrganization[organization.ID]),
+			Phones:                  nonNil(phonesByOrganization[organization.ID]),
+			Locations:               nonNil(locationsByOrganization[organization.ID]),
+			Programs:                nonNil(programsByOrganization[organization.ID]),
+			OrganizationIdentifiers: nonNil(identifiersByOrganization[organization.ID]),
+		}
+		if fullServices {
+			detail.Services = nonNil(servicesByOrganization[organization.ID])
+		}
+		details = append(details, detail)
+	}
+	return details, nil
+}
+
+// buildServiceAtLocationDetails nests each service at location's location, phones, schedules and contacts
+func buildServiceAtLocationDetails(client *supabase.Client, serviceAtLocations []hsds_types.ServiceAtLocation) ([]hsds_types.ServiceAtLocationDetail, error) {
+	ids := make([]string, 0, len(serviceAtLocations))
+	locationIDs := make([]string, 0, len(serviceAtLocations))
+	for _, serviceAtLocation := range serviceAtLocations {
+		ids = append(ids, serviceAtLocation.ID)
+		locationIDs = append(locationIDs, serviceAtLocation.LocationID)
+	}
+
+	locations, err := fetchRows[hsds_types.Location](client, "location", "id", locationIDs)
+	if err != nil {
+		return nil, err
+	}
+	locationDetails, err := buildLocationDetails(client, locations)
+	if err != nil {
+		return nil, err
+	}
+	locationsByID := make(map[string]*hsds_types.LocationDetail, len(locationDetails))
+	for i := range locationDetails {
+		locationsByID[locationDetails[i].ID] = &locationDetails[i]
+	}
+
+	phones, err := fetchRows[hsds_types.Phone](client, "phone", "service_at_location_id", ids)
+	if err != nil {
+		return nil, err
+	}
+	schedules, err := fetchRows[hsds_types.Schedule](client, "schedule", "service_at_location_id", ids)
+	if err != nil {
+		return nil, err
+	}
+	contacts, err := fetchRows[hsds_types.Contact](client, "contact", "service_at_location_id", ids)
+	if err != nil {
+		return nil, err
+	}
+	contactDetails, err := buildContactDetails(client, contacts)
+	if err != nil {
+		return nil, err
+	}
+
+	phonesBySAL := groupBy(phones, func(p hsds_types.Phone) string { return getString(p.ServiceAtLocationID) })
+	schedulesBySAL := groupBy(schedules, func(s hsds_types.Schedule) string { return getString(s.ServiceAtLocationID) })
+	contactsBySAL := groupBy(contactDetails, func(c hsds_types.ContactDetail) string { return getString(c.ServiceAtLocationID) })
+
+	details := make([]hsds_types.ServiceAtLocationDetail, 0, len(serviceAtLocations))
+	for _, serviceAtLocation := range serviceAtLocations {
+		details = append(details, hsds_types.ServiceAtLocationDetail{
+			ServiceAtLocation: serviceAtLocation,
+			Location:          locationsByID[serviceAtLocation.LocationID],
+			Phones:            nonNil(phonesBySAL[serviceAtLocation.ID]),
+			Schedules:         nonNil(schedulesBySAL[serviceAtLocation.ID]),
+			Contacts:          nonNil(contactsBySAL[serviceAtLocation.ID]),
+		})
+	}
+	return details, nil
+}
+
+// buildLocationDetails nests each location's addresses, phones, schedules, languages and accessibility
+func buildLocationDetails(client *supabase.Client, locations []hsds_types.Location) ([]hsds_types.LocationDetail, error) {
+	ids := make([]string, 0, len(locations))
+	for _, location := range locations {
+		ids = append(ids, location.ID)
+	}
+
+	addresses, err := fetchRows[hsds_types.Address](client, "address", "location_id", ids)
+	if err != nil {
+		return nil, err
+	}
+	phones, err := fetchRows[hsds_types.Phone](client, "phone", "location_id", ids)
+	if err != nil {
+		return nil, err
+	}
+	schedules, err := fetchRows[hsds_types.Schedule](client, "schedule", "location_id", ids)
+	if err != nil {
+		return nil, err
+	}
+	languages, err := fetchRows[hsds_types.Language](client, "language", "location_id", ids)
+	if err != nil {
+		return nil, err
+	}
+	accessibility, err := fetchRows[hsds_types.Accessibility](client, "accessibility", "location_id", ids)
+	if err != nil {
+		return nil, err
+	}
+
+	addressesByLocation := groupBy(addresses, func(a hsds_types.Address) string { return getString(a.LocationID) })
+	phonesByLocation := groupBy(phones, func(p hsds_types.Phone) string { return getString(p.LocationID) })
+	schedulesByLocation := groupBy(schedules, func(s hsds_types.Schedule) string { return getString(s.LocationID) })
+	languagesByLocation := groupBy(languages, func(l hsds_types.Language) string { return getString(l.LocationID) })
+	accessibilityByLocation := groupBy(accessibility, func(a hsds_types.Accessibility) string { return getString(a.LocationID) })
+
+	details := make([]hsds_types.LocationDetail, 0, len(locations))
+	for _, location := range locations {
+		details = append(details, hsds_types.LocationDetail{
+			Location:      location,
+			Addresses:     nonNil(addressesByLocation[location.ID]),
+			Phones:        nonNil(phonesByLocation[location.ID]),
+			Schedules:     nonNil(schedulesByLocation[location.ID]),
+			Languages:     nonNil(languagesByLocation[location.ID]),
+			Accessibility: nonNil(accessibilityByLocation[location.ID]),
+		})
+	}
+	return details, nil
+}
+
+// buildContactDetails nests each contact's phones
+func buildContactDetails(client *supabase.Client, contacts []hsds_types.Contact) ([]hsds_types.ContactDetail, error) {
+	ids := make([]string, 0, len(contacts))
+	for _, contact := range contacts {
+		ids = append(ids, contact.ID)
+	}
+
+	phones, err := fetchRows[hsds_types.Phone](client, "phone", "contact_id", ids)
+	if err != nil {
+		return nil, err
+	}
+	phonesByContact := groupBy(phones, func(p hsds_types.Phone) string { return getString(p.ContactID) })
+
+	details := make([]hsds_types.ContactDetail, 0, len(contacts))
+	for _, contact := range contacts {
+		details = append(details, hsds_types.ContactDetail{
+			Contact: contact,
+			Phones:  nonNil(phonesByContact[contact.ID]),
+		})
+	}
+	return details, nil
+}
+
+// buildCapacityDetails returns the current capacity of each service with its unit nested
+func buildCapacityDetails(client *supabase.Client, serviceIDs []string) ([]hsds_types.ServiceCapacityDetail, error) {
+	capacities, err := fetchRows[hsds_types.ServiceCapacity](client, "service_capacity", "service_id", serviceIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	unitIDs := make([]string, 0, len(capacities))
+	for _, capacity := range capacities {
+		unitIDs = append(unitIDs, capacity.UnitID)
+	}
+	units, err := fetchRows[hsds_types.Unit](client, "unit", "id", unitIDs)
+	if err != nil {
+		return nil, err
+	}
+	unitsByID := make(map[string]*hsds_types.Unit, len(units))
+	for i := range units {
+		unitsByID[units[i].ID] = &units[i]
+	}
+
+	details := make([]hsds_types.ServiceCapacityDetail, 0, len(capacities))
+	for _, capacity := range capacities {
+		details = append(details, hsds_types.ServiceCapacityDetail{
+			ServiceCapacity: capacity,
+			Unit:            unitsByID[capacity.UnitID],
+		})
+	}
+	return details, nil
+}
+
+// buildAttributeDetails returns the attributes linked to the given records with their taxonomy term nested
+func buildAttributeDetails(client *supabase.Client, linkEntity string, linkIDs []string) ([]hsds_types.AttributeDetail, error) {
+	attributes, err := fetchRows[hsds_types.Attribute](client, "attribute", "link_id", linkIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	termIDs := make([]string, 0, len(attributes))
+	for _, attribute := range attributes {
+		termIDs = append(termIDs, attribute.TaxonomyTermID)
+	}
+	terms, err := fetchRows[hsds_types.TaxonomyTerm](client, "taxonomy_term", "id", termIDs)
+	if err != nil {
+		return nil, err
+	}
+	termsByID := make(map[string]*hsds_types.TaxonomyTerm, len(terms))
+	for i := range terms {
+		termsByID[terms[i].ID] = &terms[i]
+	}
+
+	details := make([]hsds_types.AttributeDetail, 0, len(attributes))
+	for _, attribute := range attributes {
+		if attribute.LinkEntity != linkEntity {
+			continue
+		}
+		details = append(details, hsds_types.AttributeDetail{
+			Attribute:    attribute,
+			TaxonomyTerm: termsByID[attribute.TaxonomyTermID],
+		})
+	}
+	return details, nil
+}
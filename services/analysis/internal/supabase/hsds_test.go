@@ -0,0 +1,113 @@
+package supabase
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestModifiedIDs(t *testing.T) {
+	// Each table's rows and the columns linking them, as the database would return them
+	rows := map[string][]map[string]string{
+		"service": {
+			{"id": "pantry", "organization_id": "food-bank"},
+			{"id": "shelter", "organization_id": "food-bank"},
+			{"id": "clinic", "organization_id": "health-center"},
+		},
+		"contact":          {{"id": "maria", "service_id": "pantry"}},
+		"phone":            {{"id": "front-desk", "contact_id": "maria"}, {"id": "hotline", "service_id": "clinic"}},
+		"service_capacity": {{"id": "beds", "service_id": "shelter", "unit_id": "bed"}},
+	}
+	link := func(table, selectColumn, filterColumn string, values []string) ([]string, error) {
+		var linked []string
+		for _, row := range rows[table] {
+			for _, value := range values {
+				if row[filterColumn] == value && row[selectColumn] != "" {
+					linked = append(linked, row[selectColumn])
+				}
+			}
+		}
+		return linked, nil
+	}
+
+	tests := []struct {
+		name    string
+		table   string
+		changed map[string]map[string]bool
+		want    map[string]bool
+	}{
+		{name: "nothing changed", table: "service", want: map[string]bool{}},
+		{
+			name:    "service itself",
+			table:   "service",
+			changed: map[string]map[string]bool{"service": {"clinic": true}},
+			want:    map[string]bool{"clinic": true},
+		},
+		{
+			name:    "phone of a service contact",
+			table:   "service",
+			changed: map[string]map[string]bool{"phone": {"front-desk": true}},
+			want:    map[string]bool{"pantry": true},
+		},
+		{
+			name:    "capacity and its unit",
+			table:   "service",
+			changed: map[string]map[string]bool{"service_capacity": {"beds": true}, "unit": {"bed": true}},
+			want:    map[string]bool{"shelter": true},
+		},
+		{
+			name:    "organization nested in its services",
+			table:   "service",
+			changed: map[string]map[string]bool{"organization": {"food-bank": true}},
+			want:    map[string]bool{"pantry": true, "shelter": true},
+		},
+		{
+			name:    "services are not nested in organizations",
+			table:   "organization",
+			changed: map[string]map[string]bool{"service": {"clinic": true}, "phone": {"hotline": true}},
+			want:    map[string]bool{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := modifiedIDs(tt.table, hsdsListNesting[tt.table], tt.changed, link)
+			if err != nil {
+				t.Fatalf("modifiedIDs(): %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("modifiedIDs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPageRows(t *testing.T) {
+	entries := func() []pageEntry[string] {
+		return []pageEntry[string]{
+			{ID: "3", Order: "Shelter", Row: "shelter"},
+			{ID: "2", Order: "Pantry", Row: "pantry-2"},
+			{ID: "1", Order: "Pantry", Row: "pantry-1"},
+			{ID: "4", Order: "Clinic", Row: "clinic"},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		page    int
+		perPage int
+		want    []string
+	}{
+		{name: "first page", page: 1, perPage: 3, want: []string{"clinic", "pantry-1", "pantry-2"}},
+		{name: "last page", page: 2, perPage: 3, want: []string{"shelter"}},
+		{name: "past the end", page: 3, perPage: 3, want: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, total := pageRows(entries(), tt.page, tt.perPage)
+			if !reflect.DeepEqual(got, tt.want) || total != 4 {
+				t.Errorf("pageRows() = %v, %d, want %v, 4", got, total, tt.want)
+			}
+		})
+	}
+}
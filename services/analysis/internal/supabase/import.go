@@ -0,0 +1,129 @@
+package supabase
+
+import (
+	"fmt"
+	"time"
+)
+
+// CallVerifiedAllFields marks a resource that was created from a call, so every field came from one
+const CallVerifiedAllFields = "*"
+
+// FetchCallVerifiedFields returns, per resource, the fields that were created or updated from a call.
+// Changes from a call that has since been reverted do not count.
+func FetchCallVerifiedFields(resourceIDs []string) (map[string]map[string]bool, error) {
+	verified := make(map[string]map[string]bool)
+	if len(resourceIDs) == 0 {
+		return verified, nil
+	}
+
+	client, err := InitSupabaseClient()
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize Supabase client: %w", err)
+	}
+
+	changes, err := fetchChangesBy(client, "resource_id", resourceIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	for resourceID, fields := range callConfirmations(changes) {
+		verified[resourceID] = make(map[string]bool, len(fields))
+		for field := range fields {
+			verified[resourceID][field] = true
+		}
+	}
+
+	return verified, nil
+}
+
+// callConfirmations returns, per resource, when a call last created, updated or confirmed each field.
+// Resources a call created are keyed CallVerifiedAllFields. Changes from a call that has since been
+// reverted do not count, and neither do its confirmations once anything it did was reverted.
+func callConfirmations(changes []Change) map[string]map[string]time.Time {
+	reverted := make(map[string]bool)
+	revertedCalls := make(map[string]bool)
+	for _, change := range changes {
+		if change.ActionType == "REVERT" && change.CallID != nil {
+			revertedCalls[*change.CallID] = true
+			// Reverting a created resource is recorded against the whole resource
+			field := change.FieldName
+			if field == "new" {
+				field = ""
+			}
+			reverted[*change.CallID+"|"+change.ResourceID+"|"+field] = true
+		}
+	}
+
+	confirmed := make(map[string]map[string]time.Time)
+	for _, change := range changes {
+		if change.CallID == nil || *change.CallID == "" {
+			continue
+		}
+		field := change.FieldName
+		switch change.ActionType {
+		case "CREATE":
+			field = ""
+		case "UPDATE":
+		case "CONFIRM":
+			if revertedCalls[*change.CallID] {
+				continue
+			}
+		default:
+			continue
+		}
+		if reverted[*change.CallID+"|"+change.ResourceID+"|"+field] {
+			continue
+		}
+
+		if confirmed[change.ResourceID] == nil {
+			confirmed[change.ResourceID] = make(map[string]time.Time)
+		}
+		if field == "" {
+			field = CallVerifiedAllFields
+		}
+		if change.ChangedAt.After(confirmed[change.ResourceID][field]) {
+			confirmed[change.ResourceID][field] = change.ChangedAt
+		}
+	}
+
+	return confirmed
+}
+
+// InsertRows inserts rows into a table in a single request
+func InsertRows(table string, rows []map[string]interface{}) error {
+	if len(rows) == 0 {
+		return nil
+	}
+
+	client, err := InitSupabaseClient()
+	if err != nil {
+		return fmt.Errorf("failed to initialize Supabase client: %w", err)
+	}
+
+	data, _, err := client.From(table).
+		Insert(rows, false, "", "minimal", "").
+		Execute()
+	if err != nil {
+		return fmt.Errorf("failed to insert %s rows: %w, data: %s", table, err, string(data))
+	}
+
+	return nil
+}
+
+// UpdateRow sets the given columns on one row of a table
+func UpdateRow(table string, id string, values map[string]interface{}) error {
+	client, err := InitSupabaseClient()
+	if err != nil {
+		return fmt.Errorf("failed to initialize Supabase client: %w", err)
+	}
+
+	data, _, err := client.From(table).
+		Update(values, "", "").
+		Eq("id", id).
+		Execute()
+	if err != nil {
+		return fmt.Errorf("failed to update %s %s: %w, data: %s", table, id, err, string(data))
+	}
+
+	return nil
+}
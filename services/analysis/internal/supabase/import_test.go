@@ -0,0 +1,66 @@
+package supabase
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCallConfirmations(t *testing.T) {
+	first := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	later := first.Add(48 * time.Hour)
+	call := func(id string) *string { return &id }
+
+	tests := []struct {
+		name    string
+		changes []Change
+		want    map[string]map[string]time.Time
+	}{
+		{
+			name: "created resource confirms every field",
+			changes: []Change{
+				{ResourceID: "svc", ActionType: "CREATE", FieldName: "new", CallID: call("c1"), ChangedAt: first},
+			},
+			want: map[string]map[string]time.Time{"svc": {CallVerifiedAllFields: first}},
+		},
+		{
+			name: "update and confirmation keep the latest time per field",
+			changes: []Change{
+				{ResourceID: "svc", ActionType: "UPDATE", FieldName: "status", CallID: call("c1"), ChangedAt: first},
+				{ResourceID: "svc", ActionType: "CONFIRM", FieldName: "status", CallID: call("c2"), ChangedAt: later},
+				{ResourceID: "svc", ActionType: "CONFIRM", FieldName: "email", CallID: call("c2"), ChangedAt: later},
+			},
+			want: map[string]map[string]time.Time{"svc": {"status": later, "email": later}},
+		},
+		{
+			name: "changes made outside a call and conflicts are ignored",
+			changes: []Change{
+				{ResourceID: "svc", ActionType: "IMPORT", FieldName: "status", ChangedAt: first},
+				{ResourceID: "svc", ActionType: "UPDATE", FieldName: "email", ChangedAt: first},
+				{ResourceID: "svc", ActionType: "CONFLICT", FieldName: "url", CallID: call("c1"), ChangedAt: first},
+			},
+			want: map[string]map[string]time.Time{},
+		},
+		{
+			name: "reverted call counts for nothing it reverted",
+			changes: []Change{
+				{ResourceID: "svc", ActionType: "UPDATE", FieldName: "status", CallID: call("c1"), ChangedAt: first},
+				{ResourceID: "svc", ActionType: "UPDATE", FieldName: "email", CallID: call("c1"), ChangedAt: first},
+				{ResourceID: "svc", ActionType: "CONFIRM", FieldName: "url", CallID: call("c1"), ChangedAt: first},
+				{ResourceID: "svc", ActionType: "REVERT", FieldName: "status", CallID: call("c1"), ChangedAt: later},
+				{ResourceID: "org", ActionType: "CREATE", FieldName: "new", CallID: call("c3"), ChangedAt: first},
+				{ResourceID: "org", ActionType: "REVERT", FieldName: "new", CallID: call("c3"), ChangedAt: later},
+			},
+			want: map[string]map[string]time.Time{"svc": {"email": first}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := callConfirmations(tt.changes)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("callConfirmations() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,9 +1,13 @@
 package supabase
 
 import (
+	"encoding/json"
 	"fmt"
+	"reflect"
+	"time"
 
 	"github.com/david-botos/BearHug/services/analysis/internal/hsds_types"
+	"github.com/google/uuid"
 )
 
 // MetadataInput represents the required and optional fields for creating a metadata entry
@@ -12,19 +16,74 @@ type MetadataInput struct {
 	CallID           string
 	ResourceType     string
 	FieldName        string
-	PreviousValue    string
-	ReplacementValue string
-	LastActionType   string // Optional, defaults to "UPDATE"
+	PreviousValue    interface{}                         // The field's value before the change. Pointers are followed, nil and "" mean no value.
+	ReplacementValue interface{}                         // The field's value after the change, or a description such as "new entry" for a resource
+	LastActionType   string                              // Optional, defaults to "UPDATE"
+	Rationale        string                              // Optional, why the change was made
+	Evidence         map[string]hsds_types.FieldEvidence // Optional, transcript spans supporting the change
+	UpdatedBy        string                              // Optional, defaults to "BearHug"
+	ChangeSetID      string                              // Optional, defaults to one ID shared by the whole batch
 }
 
 // CreateAndStoreMetadata creates and stores multiple metadata entries in Supabase
 func CreateAndStoreMetadata(inputs []MetadataInput) error {
+	_, err := RecordMetadata(inputs)
+	return err
+}
+
+// RecordMetadata stores metadata entries and returns their IDs, so entries written ahead of a change can
+// be withdrawn if the change then fails
+func RecordMetadata(inputs []MetadataInput) ([]string, error) {
+	client, err := InitSupabaseClient()
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize Supabase client: %w", err)
+	}
+
+	metadataRecords, err := buildMetadataRecords(inputs)
+	if err != nil {
+		return nil, err
+	}
+
+	// Store all metadata records in a single transaction
+	_, _, err = client.From("metadata").Insert(metadataRecords, false, "", "representation", "").Execute()
+	if err != nil {
+		return nil, fmt.Errorf("failed to store metadata records: %w", err)
+	}
+
+	ids := make([]string, 0, len(metadataRecords))
+	for _, record := range metadataRecords {
+		ids = append(ids, record.ID)
+	}
+	return ids, nil
+}
+
+// WithdrawMetadata deletes metadata entries recorded for a change that was not made
+func WithdrawMetadata(ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
 	client, err := InitSupabaseClient()
 	if err != nil {
 		return fmt.Errorf("failed to initialize Supabase client: %w", err)
 	}
 
+	for start := 0; start < len(ids); start += exportFilterChunk {
+		if _, _, err := client.From("metadata").
+			Delete("", "").
+			In("id", ids[start:min(start+exportFilterChunk, len(ids))]).
+			Execute(); err != nil {
+			return fmt.Errorf("failed to withdraw metadata records: %w", err)
+		}
+	}
+	return nil
+}
+
+// buildMetadataRecords fills in defaults and typed values for each input. Rows that don't use an optional
+// column still send it as null, so the batch can go in one insert.
+func buildMetadataRecords(inputs []MetadataInput) ([]hsds_types.Metadata, error) {
 	var metadataRecords []hsds_types.Metadata
+	batchChangeSetID := uuid.New().String()
 
 	// Create metadata objects for each input
 	for _, input := range inputs {
@@ -34,38 +93,107 @@ func CreateAndStoreMetadata(inputs []MetadataInput) error {
 			actionType = "UPDATE"
 		}
 
-		previousValue := input.PreviousValue
+		previousValue := formatMetadataValue(input.PreviousValue)
 		if previousValue == "" {
 			previousValue = "none"
 		}
+		replacementValue := formatMetadataValue(input.ReplacementValue)
+		if replacementValue == "" {
+			replacementValue = "none"
+		}
 
 		fieldName := input.FieldName
 		if fieldName == "" {
 			fieldName = "new"
 		}
 
+		updatedBy := input.UpdatedBy
+		if updatedBy == "" {
+			updatedBy = "BearHug"
+		}
+
 		metadata, err := hsds_types.NewMetadata(
 			input.ResourceID,
 			input.CallID,
 			input.ResourceType,
 			actionType,
-			input.FieldName,
+			fieldName,
 			previousValue,
-			input.ReplacementValue,
-			"BearHug", // Hardcoded updater as specified
+			replacementValue,
+			updatedBy,
 		)
 		if err != nil {
-			return fmt.Errorf("failed to create metadata object: %w", err)
+			return nil, fmt.Errorf("failed to create metadata object: %w", err)
+		}
+		if input.Rationale != "" {
+			rationale := input.Rationale
+			metadata.Rationale = &rationale
+		}
+		if len(input.Evidence) > 0 {
+			metadata.Evidence = input.Evidence
+		}
+		// Typed values only describe a field, not a whole resource being created or removed
+		if input.FieldName != "" {
+			if metadata.PreviousValueJSON, err = encodeMetadataValue(input.PreviousValue); err != nil {
+				return nil, fmt.Errorf("failed to encode previous value of %s: %w", input.FieldName, err)
+			}
+			if metadata.ReplacementValueJSON, err = encodeMetadataValue(input.ReplacementValue); err != nil {
+				return nil, fmt.Errorf("failed to encode replacement value of %s: %w", input.FieldName, err)
+			}
+		}
+		metadata.ChangeSetID = input.ChangeSetID
+		if metadata.ChangeSetID == "" {
+			metadata.ChangeSetID = batchChangeSetID
 		}
 
 		metadataRecords = append(metadataRecords, *metadata)
 	}
 
-	// Store all metadata records in a single transaction
-	_, _, err = client.From("metadata").Insert(metadataRecords, false, "", "representation", "").Execute()
-	if err != nil {
-		return fmt.Errorf("failed to store metadata records: %w", err)
+	return metadataRecords, nil
+}
+
+// metadataValue follows pointers to the value they hold. Nil pointers and empty strings are no value.
+func metadataValue(value interface{}) interface{} {
+	v := reflect.ValueOf(value)
+	for v.IsValid() && v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
 	}
+	if !v.IsValid() || (v.Kind() == reflect.String && v.String() == "") {
+		return nil
+	}
+	return v.Interface()
+}
 
-	return nil
+// formatMetadataValue renders a value for the human-readable previous_value and replacement_value columns
+func formatMetadataValue(value interface{}) string {
+	switch v := metadataValue(value).(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case time.Time:
+		return v.UTC().Format(time.RFC3339)
+	case fmt.Stringer:
+		return v.String()
+	default:
+		switch reflect.ValueOf(v).Kind() {
+		case reflect.Map, reflect.Slice, reflect.Struct:
+			if encoded, err := json.Marshal(v); err == nil {
+				return string(encoded)
+			}
+		}
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+// encodeMetadataValue encodes a value as JSON, keeping its type, for the *_value_json columns
+func encodeMetadataValue(value interface{}) (json.RawMessage, error) {
+	v := metadataValue(value)
+	if v == nil {
+		return json.RawMessage("null"), nil
+	}
+	return json.Marshal(v)
 }
@@ -0,0 +1,143 @@
+package supabase
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/david-botos/BearHug/services/analysis/internal/hsds_types"
+)
+
+func TestBuildMetadataRecordsSendsSameKeys(t *testing.T) {
+	status := "active"
+	tests := []struct {
+		name   string
+		inputs []MetadataInput
+	}{
+		{
+			name: "field update beside new resource",
+			inputs: []MetadataInput{
+				{
+					ResourceID:       "11111111-1111-1111-1111-111111111111",
+					CallID:           "call-1",
+					ResourceType:     "service",
+					FieldName:        "status",
+					PreviousValue:    "inactive",
+					ReplacementValue: &status,
+					Rationale:        "caller said the pantry reopened",
+					Evidence: map[string]hsds_types.FieldEvidence{
+						"status": {Quote: "we're open again", StartOffset: 10, EndOffset: 26, Confidence: 0.9, Verified: true},
+					},
+				},
+				{
+					ResourceID:       "22222222-2222-2222-2222-222222222222",
+					CallID:           "call-1",
+					ResourceType:     "organization",
+					LastActionType:   "CREATE",
+					ReplacementValue: "new entry",
+				},
+			},
+		},
+		{
+			name: "revert restoring a field beside a delete",
+			inputs: []MetadataInput{
+				{
+					ResourceID:       "33333333-3333-3333-3333-333333333333",
+					ResourceType:     "service",
+					FieldName:        "email",
+					PreviousValue:    "new@example.org",
+					ReplacementValue: "old@example.org",
+					LastActionType:   "REVERT",
+					UpdatedBy:        "admin",
+					ChangeSetID:      "revert-1",
+				},
+				{
+					ResourceID:     "44444444-4444-4444-4444-444444444444",
+					ResourceType:   "phone",
+					PreviousValue:  "record",
+					LastActionType: "REVERT",
+					UpdatedBy:      "admin",
+					ChangeSetID:    "revert-1",
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			records, err := buildMetadataRecords(tt.inputs)
+			if err != nil {
+				t.Fatalf("buildMetadataRecords: %v", err)
+			}
+			data, err := json.Marshal(records)
+			if err != nil {
+				t.Fatalf("marshal: %v", err)
+			}
+			var rows []map[string]json.RawMessage
+			if err := json.Unmarshal(data, &rows); err != nil {
+				t.Fatalf("unmarshal: %v", err)
+			}
+			if len(rows) != len(tt.inputs) {
+				t.Fatalf("got %d rows, want %d", len(rows), len(tt.inputs))
+			}
+
+			want := sortedKeys(rows[0])
+			for i, row := range rows[1:] {
+				if got := sortedKeys(row); !reflect.DeepEqual(got, want) {
+					t.Errorf("row %d keys = %v, want %v", i+1, got, want)
+				}
+			}
+			for _, key := range []string{"rationale", "evidence", "previous_value_json", "replacement_value_json", "change_set_id"} {
+				if _, ok := rows[0][key]; !ok {
+					t.Errorf("missing %s", key)
+				}
+			}
+		})
+	}
+}
+
+func TestBuildMetadataRecordsTypedValues(t *testing.T) {
+	records, err := buildMetadataRecords([]MetadataInput{
+		{ResourceID: "a", ResourceType: "service", FieldName: "status", PreviousValue: nil, ReplacementValue: "active"},
+		{ResourceID: "b", ResourceType: "service", ReplacementValue: "new entry", LastActionType: "CREATE"},
+	})
+	if err != nil {
+		t.Fatalf("buildMetadataRecords: %v", err)
+	}
+
+	tests := []struct {
+		name              string
+		record            hsds_types.Metadata
+		previous, replace string
+		fieldName         string
+	}{
+		{"field", records[0], "null", `"active"`, "status"},
+		{"whole resource", records[1], "", "", "new"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(tt.record.PreviousValueJSON); got != tt.previous {
+				t.Errorf("previous_value_json = %q, want %q", got, tt.previous)
+			}
+			if got := string(tt.record.ReplacementValueJSON); got != tt.replace {
+				t.Errorf("replacement_value_json = %q, want %q", got, tt.replace)
+			}
+			if tt.record.FieldName != tt.fieldName {
+				t.Errorf("field_name = %q, want %q", tt.record.FieldName, tt.fieldName)
+			}
+		})
+	}
+	if records[0].ChangeSetID == "" || records[0].ChangeSetID != records[1].ChangeSetID {
+		t.Errorf("change set IDs = %q, %q, want one shared ID", records[0].ChangeSetID, records[1].ChangeSetID)
+	}
+}
+
+func sortedKeys(row map[string]json.RawMessage) []string {
+	names := make([]string, 0, len(row))
+	for name := range row {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,687 @@
+package supabase
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/david-botos/BearHug/services/analysis/internal/hsds_types"
+	"github.com/david-botos/BearHug/services/analysis/pkg/logger"
+	"github.com/google/uuid"
+	"github.com/supabase-community/postgrest-go"
+	"github.com/supabase-community/supabase-go"
+)
+
+var (
+	ErrCallNotFound        = errors.New("call not found")
+	ErrCallAlreadyReverted = errors.New("call has already been reverted")
+)
+
+// revertDeleteOrder lists tables so rows are deleted before the rows they reference
+var revertDeleteOrder = []string{
+	"phone",
+	"schedule",
+	"language",
+	"service_capacity",
+	"contact",
+	"service_area",
+	"service_at_location",
+	"address",
+	"accessibility",
+	"service",
+	"location",
+	"program",
+	"unit",
+	"organization",
+}
+
+// reviewTables hold items queued for review from a call. Pending items are closed when the call is reverted.
+var reviewTables = []string{"service_match_review", "validation_review", "unresolved_observation"}
+
+// unrecordedValue matches previous values that were written as a pointer address instead of the value
+var unrecordedValue = regexp.MustCompile(`^0x[0-9a-f]+$`)
+
+// CallRevertInput describes a requested revert of everything a call changed
+type CallRevertInput struct {
+	Reason      string `json:"reason,omitempty"`
+	RequestedBy string `json:"requested_by,omitempty"`
+	DryRun      bool   `json:"dry_run,omitempty"` // Report what would be reverted without writing
+}
+
+// RevertedChange is a resource deleted or a field restored by a revert
+type RevertedChange struct {
+	ResourceType  string      `json:"resource_type"`
+	ResourceID    string      `json:"resource_id"`
+	FieldName     string      `json:"field_name,omitempty"`
+	RestoredValue interface{} `json:"restored_value,omitempty"`
+}
+
+// RevertConflict is a change that was left in place and why
+type RevertConflict struct {
+	ResourceType string `json:"resource_type"`
+	ResourceID   string `json:"resource_id"`
+	FieldName    string `json:"field_name,omitempty"`
+	Reason       string `json:"reason"`
+}
+
+// CallRevertResult reports what a revert touched
+type CallRevertResult struct {
+	CallID        string           `json:"call_id"`
+	DryRun        bool             `json:"dry_run"`
+	Deleted       []RevertedChange `json:"deleted"`
+	Restored      []RevertedChange `json:"restored"`
+	Conflicts     []RevertConflict `json:"conflicts"`
+	ReviewsClosed int              `json:"reviews_closed"`
+}
+
+// fieldRevert is the net effect of a call's updates to one field
+type fieldRevert struct {
+	ResourceType string
+	ResourceID   string
+	FieldName    string
+	Previous     string // Before the call's first update
+	Replacement  string // After the call's last update
+	ChangedAt    time.Time
+
+	// Typed is set when every update recorded JSON-encoded values, which are used instead of the text
+	Typed           bool
+	PreviousJSON    json.RawMessage
+	ReplacementJSON json.RawMessage
+}
+
+// RevertCall replays a call's metadata in reverse: resources it created are deleted and fields it updated
+// get their previous values back. A change is left in place and reported as a conflict when something
+// else has changed the resource since, or when the stored value no longer matches what the call wrote.
+// Each restore or delete is recorded as a REVERT metadata row on the call before it is made, and the row
+// is withdrawn if the change then fails. Like merges there is no transaction, so a failure part way
+// through leaves earlier changes reverted; requesting the revert again picks up where it stopped. A call
+// counts as reverted once every change it made has a REVERT row, so changes left as conflicts can be
+// retried.
+func RevertCall(callID string, input CallRevertInput) (*CallRevertResult, error) {
+	log := logger.Get()
+
+	updatedBy := input.RequestedBy
+	if updatedBy == "" {
+		updatedBy = "admin"
+	}
+	rationale := fmt.Sprintf("Reverted call %s", callID)
+	if input.Reason != "" {
+		rationale += ". " + input.Reason
+	}
+
+	client, err := InitSupabaseClient()
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize Supabase client: %w", err)
+	}
+
+	callData, _, err := client.From("calls").Select("id", "", false).Eq("id", callID).Execute()
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch call %s: %w", callID, err)
+	}
+	var calls []struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(callData, &calls); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal call: %w", err)
+	}
+	if len(calls) == 0 {
+		return nil, ErrCallNotFound
+	}
+
+	callChanges, err := fetchChangesBy(client, "call_fk", []string{callID})
+	if err != nil {
+		return nil, err
+	}
+
+	created := make(map[string]Change)
+	fields := make(map[string]*fieldRevert)
+	resourceIDs := make(map[string]bool)
+	// REVERT rows left by an earlier revert of this call, keyed like fields
+	recorded := make(map[string]bool)
+	for _, change := range callChanges {
+		switch change.ActionType {
+		case "REVERT":
+			recorded[revertKey(change.ResourceID, change.FieldName)] = true
+			continue
+		case "CREATE":
+			created[change.ResourceID] = change
+		case "UPDATE":
+			key := revertKey(change.ResourceID, change.FieldName)
+			if existing, ok := fields[key]; ok {
+				existing.Replacement = change.ReplacementValue
+				existing.ReplacementJSON = change.ReplacementValueJSON
+				existing.Typed = existing.Typed && change.HasTypedValues()
+				existing.ChangedAt = change.ChangedAt
+				continue
+			}
+			fields[key] = &fieldRevert{
+				ResourceType:    change.ResourceType,
+				ResourceID:      change.ResourceID,
+				FieldName:       change.FieldName,
+				Previous:        change.PreviousValue,
+				Replacement:     change.ReplacementValue,
+				ChangedAt:       change.ChangedAt,
+				Typed:           change.HasTypedValues(),
+				PreviousJSON:    change.PreviousValueJSON,
+				ReplacementJSON: change.ReplacementValueJSON,
+			}
+		default:
+			continue
+		}
+		resourceIDs[change.ResourceID] = true
+	}
+
+	if len(recorded) > 0 {
+		pending := false
+		for id := range created {
+			pending = pending || !recorded[revertKey(id, "")]
+		}
+		for key, revert := range fields {
+			if _, ok := created[revert.ResourceID]; !ok {
+				pending = pending || !recorded[key]
+			}
+		}
+		if !pending {
+			return nil, ErrCallAlreadyReverted
+		}
+	}
+
+	ids := make([]string, 0, len(resourceIDs))
+	for id := range resourceIDs {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	touched, err := fetchChangesBy(client, "resource_id", ids)
+	if err != nil {
+		return nil, err
+	}
+	var laterChanges []Change
+	for _, change := range touched {
+		// Conflicts and confirmations leave the stored value as it was
+		if change.ActionType != "CONFLICT" && change.ActionType != "CONFIRM" && (change.CallID == nil || *change.CallID != callID) {
+			laterChanges = append(laterChanges, change)
+		}
+	}
+
+	result := &CallRevertResult{
+		CallID:    callID,
+		DryRun:    input.DryRun,
+		Deleted:   []RevertedChange{},
+		Restored:  []RevertedChange{},
+		Conflicts: []RevertConflict{},
+	}
+	changeSetID := uuid.New().String()
+	revertInput := func(resourceType, resourceID, field string, previous, replacement interface{}) MetadataInput {
+		return MetadataInput{
+			ResourceID:       resourceID,
+			CallID:           callID,
+			ResourceType:     resourceType,
+			FieldName:        field,
+			PreviousValue:    previous,
+			ReplacementValue: replacement,
+			LastActionType:   "REVERT",
+			Rationale:        rationale,
+			UpdatedBy:        updatedBy,
+			ChangeSetID:      changeSetID,
+		}
+	}
+
+	// Restore updated fields first, so capacity readings are back in place before anything is deleted
+	reverts := make([]*fieldRevert, 0, len(fields))
+	for _, revert := range fields {
+		if _, ok := created[revert.ResourceID]; !ok {
+			reverts = append(reverts, revert)
+		}
+	}
+	sort.Slice(reverts, func(i, j int) bool {
+		if reverts[i].ResourceID != reverts[j].ResourceID {
+			return reverts[i].ResourceID < reverts[j].ResourceID
+		}
+		return reverts[i].FieldName < reverts[j].FieldName
+	})
+
+	currentRows := make(map[string]map[string]interface{})
+	currentRow := func(revert *fieldRevert) (map[string]interface{}, error) {
+		current, ok := currentRows[revert.ResourceID]
+		if !ok {
+			var err error
+			if current, err = fetchRowByID(client, revert.ResourceType, revert.ResourceID); err != nil {
+				return nil, err
+			}
+			currentRows[revert.ResourceID] = current
+		}
+		return current, nil
+	}
+
+	updatedCapacity := make(map[string]bool)
+	restoredCapacity := make(map[string]bool)
+	for _, revert := range reverts {
+		if revert.ResourceType == "service_capacity" {
+			updatedCapacity[revert.ResourceID] = true
+		}
+
+		conflict := func(reason string) {
+			result.Conflicts = append(result.Conflicts, RevertConflict{
+				ResourceType: revert.ResourceType,
+				ResourceID:   revert.ResourceID,
+				FieldName:    revert.FieldName,
+				Reason:       reason,
+			})
+		}
+		restore := func(restored interface{}) {
+			if revert.ResourceType == "service_capacity" {
+				restoredCapacity[revert.ResourceID] = true
+			}
+			result.Restored = append(result.Restored, RevertedChange{
+				ResourceType:  revert.ResourceType,
+				ResourceID:    revert.ResourceID,
+				FieldName:     revert.FieldName,
+				RestoredValue: restored,
+			})
+		}
+
+		// An earlier attempt recorded this restore; it only needs finishing if the write didn't happen
+		alreadyRecorded := recorded[revertKey(revert.ResourceID, revert.FieldName)]
+		if alreadyRecorded {
+			current, err := currentRow(revert)
+			if err != nil {
+				return result, err
+			}
+			if current != nil && revert.isRestored(current[revert.FieldName]) {
+				restored, _ := revert.restoredValue()
+				restore(restored)
+				continue
+			}
+		}
+
+		if later := changedSince(laterChanges, revert.ResourceID, revert.FieldName, revert.ChangedAt); later != nil {
+			conflict(fmt.Sprintf("changed again by %s on %s", later.UpdatedBy, later.ChangedAt.Format(time.RFC3339)))
+			continue
+		}
+		if !revert.Typed && (revert.Previous == "new data" || unrecordedValue.MatchString(revert.Previous)) {
+			conflict("the value before the call was not recorded")
+			continue
+		}
+
+		current, err := currentRow(revert)
+		if err != nil {
+			return result, err
+		}
+		if current == nil {
+			conflict("the resource no longer exists")
+			continue
+		}
+		currentValue := current[revert.FieldName]
+		if !revert.matches(currentValue) {
+			conflict(fmt.Sprintf("the stored value %v no longer matches what the call wrote", currentValue))
+			continue
+		}
+
+		restored, err := revert.restoredValue()
+		if err != nil {
+			conflict(fmt.Sprintf("the value before the call could not be decoded: %v", err))
+			continue
+		}
+
+		if !input.DryRun {
+			var recordID string
+			if !alreadyRecorded {
+				recordID, err = recordRevert(revertInput(revert.ResourceType, revert.ResourceID, revert.FieldName, currentValue, restored))
+				if err != nil {
+					return result, err
+				}
+			}
+			data, _, err := client.From(revert.ResourceType).
+				Update(map[string]interface{}{revert.FieldName: restored}, "", "").
+				Eq("id", revert.ResourceID).
+				Execute()
+			if err != nil {
+				withdrawRevert(recordID)
+				return result, fmt.Errorf("failed to restore %s.%s on %s: %w, data: %s",
+					revert.ResourceType, revert.FieldName, revert.ResourceID, err, string(data))
+			}
+		}
+		restore(restored)
+	}
+
+	if !input.DryRun {
+		if err := revertCapacityHistory(client, callID, updatedCapacity, restoredCapacity); err != nil {
+			return result, err
+		}
+	}
+
+	deletes := make([]Change, 0, len(created))
+	for _, change := range created {
+		deletes = append(deletes, change)
+	}
+	sort.Slice(deletes, func(i, j int) bool {
+		return deleteRank(deletes[i].ResourceType) < deleteRank(deletes[j].ResourceType)
+	})
+	for _, change := range deletes {
+		deleted := RevertedChange{
+			ResourceType: change.ResourceType,
+			ResourceID:   change.ResourceID,
+		}
+
+		alreadyRecorded := recorded[revertKey(change.ResourceID, "")]
+		if alreadyRecorded {
+			gone, err := resourceDeleted(client, change.ResourceType, change.ResourceID)
+			if err != nil {
+				return result, err
+			}
+			if gone {
+				result.Deleted = append(result.Deleted, deleted)
+				continue
+			}
+		}
+
+		if later := changedSince(laterChanges, change.ResourceID, "", change.ChangedAt); later != nil {
+			result.Conflicts = append(result.Conflicts, RevertConflict{
+				ResourceType: change.ResourceType,
+				ResourceID:   change.ResourceID,
+				Reason:       fmt.Sprintf("changed since it was created, by %s on %s", later.UpdatedBy, later.ChangedAt.Format(time.RFC3339)),
+			})
+			continue
+		}
+
+		if !input.DryRun {
+			var recordID string
+			if !alreadyRecorded {
+				var err error
+				recordID, err = recordRevert(revertInput(change.ResourceType, change.ResourceID, "", "new entry", "deleted"))
+				if err != nil {
+					return result, err
+				}
+			}
+			if err := deleteResource(client, change.ResourceType, change.ResourceID); err != nil {
+				withdrawRevert(recordID)
+				// Usually a row created later still references this one
+				log.Warn().
+					Err(err).
+					Str("resource_type", change.ResourceType).
+					Str("resource_id", change.ResourceID).
+					Msg("Could not delete resource created by call")
+				result.Conflicts = append(result.Conflicts, RevertConflict{
+					ResourceType: change.ResourceType,
+					ResourceID:   change.ResourceID,
+					Reason:       fmt.Sprintf("could not be deleted: %v", err),
+				})
+				continue
+			}
+		}
+
+		result.Deleted = append(result.Deleted, deleted)
+	}
+
+	if input.DryRun {
+		return result, nil
+	}
+
+	for _, table := range reviewTables {
+		data, _, err := client.From(table).
+			Update(map[string]interface{}{"status": "reverted"}, "", "representation").
+			Eq("call_fk", callID).
+			Eq("status", "pending").
+			Execute()
+		if err != nil {
+			return result, fmt.Errorf("failed to close pending %s rows: %w", table, err)
+		}
+		var closed []json.RawMessage
+		if err := json.Unmarshal(data, &closed); err == nil {
+			result.ReviewsClosed += len(closed)
+		}
+	}
+
+	log.Info().
+		Str("call_id", callID).
+		Int("deleted", len(result.Deleted)).
+		Int("restored", len(result.Restored)).
+		Int("conflicts", len(result.Conflicts)).
+		Int("reviews_closed", result.ReviewsClosed).
+		Msg("Reverted call")
+
+	return result, nil
+}
+
+// matches reports whether a stored value is still what the call last wrote to the field
+func (r *fieldRevert) matches(current interface{}) bool {
+	if !r.Typed {
+		return current != nil && fmt.Sprintf("%v", current) == r.Replacement
+	}
+
+	var written interface{}
+	if len(r.ReplacementJSON) > 0 {
+		if err := json.Unmarshal(r.ReplacementJSON, &written); err != nil {
+			return false
+		}
+	}
+	// Round trip the stored value so both sides decode to the same types
+	encoded, err := json.Marshal(current)
+	if err != nil {
+		return false
+	}
+	var stored interface{}
+	if err := json.Unmarshal(encoded, &stored); err != nil {
+		return false
+	}
+	if reflect.DeepEqual(stored, written) {
+		return true
+	}
+
+	// Timestamps come back from the database in a different layout than they were written in
+	storedString, ok1 := stored.(string)
+	writtenString, ok2 := written.(string)
+	if !ok1 || !ok2 {
+		return false
+	}
+	storedTime, err1 := hsds_types.ParseTime(storedString)
+	writtenTime, err2 := hsds_types.ParseTime(writtenString)
+	return err1 == nil && err2 == nil && storedTime.Equal(writtenTime)
+}
+
+// isRestored reports whether a stored value is already what the field held before the call
+func (r *fieldRevert) isRestored(current interface{}) bool {
+	if restored, err := r.restoredValue(); err == nil && restored == nil {
+		return current == nil || current == ""
+	}
+	previous := fieldRevert{Typed: r.Typed, Replacement: r.Previous, ReplacementJSON: r.PreviousJSON}
+	return previous.matches(current)
+}
+
+// restoredValue is the value the field held before the call, nil when it was empty
+func (r *fieldRevert) restoredValue() (interface{}, error) {
+	if !r.Typed {
+		if r.Previous == "none" || r.Previous == "" {
+			return nil, nil
+		}
+		return r.Previous, nil
+	}
+
+	var previous interface{}
+	if len(r.PreviousJSON) > 0 {
+		if err := json.Unmarshal(r.PreviousJSON, &previous); err != nil {
+			return nil, err
+		}
+	}
+	return previous, nil
+}
+
+// revertKey identifies a field a call changed, or with an empty field a resource it created. Metadata
+// about a whole resource is stored with the field name "new".
+func revertKey(resourceID, field string) string {
+	if field == "new" {
+		field = ""
+	}
+	return resourceID + "|" + field
+}
+
+// recordRevert stores one REVERT row ahead of the change it describes, returning the row's id
+func recordRevert(input MetadataInput) (string, error) {
+	ids, err := RecordMetadata([]MetadataInput{input})
+	if err != nil {
+		return "", fmt.Errorf("failed to record revert of %s %s: %w", input.ResourceType, input.ResourceID, err)
+	}
+	return ids[0], nil
+}
+
+// withdrawRevert removes a REVERT row whose change could not be made. A row that can't be removed is
+// logged; the next revert of the call sees the change was never applied and makes it.
+func withdrawRevert(id string) {
+	if id == "" {
+		return
+	}
+	if err := WithdrawMetadata([]string{id}); err != nil {
+		logger.Get().Error().
+			Err(err).
+			Str("metadata_id", id).
+			Msg("Failed to withdraw REVERT metadata for a change that was not made")
+	}
+}
+
+// resourceDeleted reports whether a row is gone, or soft-deleted in tables that soft-delete
+func resourceDeleted(client *supabase.Client, table, id string) (bool, error) {
+	row, err := fetchRowByID(client, table, id)
+	if err != nil {
+		return false, err
+	}
+	return row == nil || (softDeletedTables[table] && row["deleted_at"] != nil), nil
+}
+
+// fetchChangesBy returns every metadata row whose column is one of values, oldest first
+func fetchChangesBy(client *supabase.Client, column string, values []string) ([]Change, error) {
+	var changes []Change
+	for start := 0; start < len(values); start += exportFilterChunk {
+		chunk := values[start:min(start+exportFilterChunk, len(values))]
+		for from := 0; ; from += exportPageSize {
+			data, _, err := client.From("metadata").
+				Select("*", "", false).
+				In(column, chunk).
+				Order("created_at", &postgrest.OrderOpts{Ascending: true}).
+				Order("id", &postgrest.OrderOpts{Ascending: true}).
+				Range(from, from+exportPageSize-1, "").
+				Execute()
+			if err != nil {
+				return nil, fmt.Errorf("failed to fetch metadata by %s: %w", column, err)
+			}
+
+			var page []Change
+			if err := hsds_types.UnmarshalJSONWithTime(data, &page); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal metadata: %w", err)
+			}
+			changes = append(changes, page...)
+			if len(page) < exportPageSize {
+				break
+			}
+		}
+	}
+
+	sort.SliceStable(changes, func(i, j int) bool {
+		return changes[i].ChangedAt.Before(changes[j].ChangedAt)
+	})
+	return changes, nil
+}
+
+// changedSince returns the first change to a resource at or after a time, limited to one field unless
+// field is empty. Merges touch the whole resource, so they always count.
+func changedSince(changes []Change, resourceID, field string, since time.Time) *Change {
+	for i, change := range changes {
+		if change.ResourceID != resourceID || change.ChangedAt.Before(since) {
+			continue
+		}
+		if field == "" || change.FieldName == field || change.ActionType == "MERGE" {
+			return &changes[i]
+		}
+	}
+	return nil
+}
+
+// fetchRowByID returns a row as a column map, or nil if it does not exist
+func fetchRowByID(client *supabase.Client, table, id string) (map[string]interface{}, error) {
+	data, _, err := client.From(table).Select("*", "", false).Eq("id", id).Execute()
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s %s: %w", table, id, err)
+	}
+
+	var rows []map[string]interface{}
+	if err := json.Unmarshal(data, &rows); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal %s %s: %w", table, id, err)
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	return rows[0], nil
+}
+
+// revertCapacityHistory undoes the history a call wrote. When a live reading was restored, the reading the
+// call archived goes back to being live and its history row is removed. History rows holding the call's
+// own older readings are removed too. Readings whose revert conflicted keep their history.
+func revertCapacityHistory(client *supabase.Client, callID string, updatedCapacity, restoredCapacity map[string]bool) error {
+	data, _, err := client.From("service_capacity_history").
+		Select("id,service_capacity_id,observed_at", "", false).
+		Eq("archived_by_call_fk", callID).
+		Execute()
+	if err != nil {
+		return fmt.Errorf("failed to fetch capacity history for call %s: %w", callID, err)
+	}
+
+	var archived []struct {
+		ID                string `json:"id"`
+		ServiceCapacityID string `json:"service_capacity_id"`
+		ObservedAt        string `json:"observed_at"`
+	}
+	if err := json.Unmarshal(data, &archived); err != nil {
+		return fmt.Errorf("failed to unmarshal capacity history: %w", err)
+	}
+
+	for _, reading := range archived {
+		if updatedCapacity[reading.ServiceCapacityID] && !restoredCapacity[reading.ServiceCapacityID] {
+			continue
+		}
+		if restoredCapacity[reading.ServiceCapacityID] {
+			if _, _, err := client.From("service_capacity").
+				Update(map[string]interface{}{"updated": reading.ObservedAt}, "", "").
+				Eq("id", reading.ServiceCapacityID).
+				Execute(); err != nil {
+				return fmt.Errorf("failed to restore capacity reading time for %s: %w", reading.ServiceCapacityID, err)
+			}
+		}
+		if _, _, err := client.From("service_capacity_history").
+			Delete("", "").
+			Eq("id", reading.ID).
+			Execute(); err != nil {
+			return fmt.Errorf("failed to remove capacity history %s: %w", reading.ID, err)
+		}
+	}
+
+	return nil
+}
+
+// deleteResource removes a row a call created. Contacts are soft-deleted like everywhere else.
+func deleteResource(client *supabase.Client, table, id string) error {
+	if softDeletedTables[table] {
+		_, _, err := client.From(table).
+			Update(map[string]interface{}{"deleted_at": time.Now().UTC()}, "", "").
+			Eq("id", id).
+			Execute()
+		return err
+	}
+
+	_, _, err := client.From(table).
+		Delete("", "").
+		Eq("id", id).
+		Execute()
+	return err
+}
+
+// deleteRank orders tables for deletion, with tables missing from revertDeleteOrder last
+func deleteRank(table string) int {
+	if i := slices.Index(revertDeleteOrder, table); i >= 0 {
+		return i
+	}
+	return len(revertDeleteOrder)
+}
@@ -0,0 +1,153 @@
+package supabase
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestFieldRevertMatches(t *testing.T) {
+	typed := func(replacement string) fieldRevert {
+		return fieldRevert{Typed: true, ReplacementJSON: json.RawMessage(replacement)}
+	}
+
+	tests := []struct {
+		name    string
+		revert  fieldRevert
+		current interface{}
+		want    bool
+	}{
+		{name: "text value", revert: fieldRevert{Replacement: "active"}, current: "active", want: true},
+		{name: "text value changed", revert: fieldRevert{Replacement: "active"}, current: "inactive"},
+		{name: "text number", revert: fieldRevert{Replacement: "12"}, current: float64(12), want: true},
+		{name: "text value removed", revert: fieldRevert{Replacement: "active"}, current: nil},
+		{name: "typed string", revert: typed(`"active"`), current: "active", want: true},
+		{name: "typed number", revert: typed(`12`), current: 12, want: true},
+		{name: "typed number changed", revert: typed(`12`), current: 14.5},
+		{name: "typed null", revert: typed(`null`), current: nil, want: true},
+		{name: "typed array", revert: typed(`["en","es"]`), current: []interface{}{"en", "es"}, want: true},
+		{name: "typed array reordered", revert: typed(`["en","es"]`), current: []interface{}{"es", "en"}},
+		{name: "timestamp in another layout", revert: typed(`"2026-03-01T10:00:00Z"`), current: "2026-03-01T10:00:00+00:00", want: true},
+		{name: "different timestamp", revert: typed(`"2026-03-01T10:00:00Z"`), current: "2026-03-01T11:00:00+00:00"},
+		{name: "undecodable replacement", revert: typed(`{`), current: "active"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.revert.matches(tt.current); got != tt.want {
+				t.Errorf("matches(%v) = %v, want %v", tt.current, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFieldRevertRestoredValue(t *testing.T) {
+	tests := []struct {
+		name    string
+		revert  fieldRevert
+		want    interface{}
+		wantErr bool
+	}{
+		{name: "text value", revert: fieldRevert{Previous: "active"}, want: "active"},
+		{name: "text none", revert: fieldRevert{Previous: "none"}},
+		{name: "text empty", revert: fieldRevert{Previous: ""}},
+		{name: "typed number", revert: fieldRevert{Typed: true, PreviousJSON: json.RawMessage(`12`)}, want: float64(12)},
+		{name: "typed null", revert: fieldRevert{Typed: true, PreviousJSON: json.RawMessage(`null`)}},
+		{name: "typed string none is a value", revert: fieldRevert{Typed: true, PreviousJSON: json.RawMessage(`"none"`)}, want: "none"},
+		{name: "typed missing", revert: fieldRevert{Typed: true}},
+		{name: "typed undecodable", revert: fieldRevert{Typed: true, PreviousJSON: json.RawMessage(`{`)}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.revert.restoredValue()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("restoredValue() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("restoredValue() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFieldRevertIsRestored(t *testing.T) {
+	tests := []struct {
+		name    string
+		revert  fieldRevert
+		current interface{}
+		want    bool
+	}{
+		{name: "text value back", revert: fieldRevert{Previous: "active", Replacement: "inactive"}, current: "active", want: true},
+		{name: "text value still replaced", revert: fieldRevert{Previous: "active", Replacement: "inactive"}, current: "inactive"},
+		{name: "emptied field", revert: fieldRevert{Previous: "none", Replacement: "a@example.org"}, current: nil, want: true},
+		{name: "emptied field still set", revert: fieldRevert{Previous: "none", Replacement: "a@example.org"}, current: "a@example.org"},
+		{
+			name:    "typed value back",
+			revert:  fieldRevert{Typed: true, PreviousJSON: json.RawMessage(`10`), ReplacementJSON: json.RawMessage(`12`)},
+			current: 10, want: true,
+		},
+		{
+			name:    "typed value still replaced",
+			revert:  fieldRevert{Typed: true, PreviousJSON: json.RawMessage(`10`), ReplacementJSON: json.RawMessage(`12`)},
+			current: 12,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.revert.isRestored(tt.current); got != tt.want {
+				t.Errorf("isRestored(%v) = %v, want %v", tt.current, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChangedSince(t *testing.T) {
+	call := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	changes := []Change{
+		{ID: "before", ResourceID: "svc", FieldName: "status", ActionType: "UPDATE", ChangedAt: call.Add(-time.Hour)},
+		{ID: "other-resource", ResourceID: "org", FieldName: "status", ActionType: "UPDATE", ChangedAt: call.Add(time.Hour)},
+		{ID: "email", ResourceID: "svc", FieldName: "email", ActionType: "UPDATE", ChangedAt: call.Add(time.Hour)},
+		{ID: "merge", ResourceID: "svc", FieldName: "new", ActionType: "MERGE", ChangedAt: call.Add(2 * time.Hour)},
+		{ID: "status", ResourceID: "svc", FieldName: "status", ActionType: "UPDATE", ChangedAt: call.Add(3 * time.Hour)},
+	}
+
+	tests := []struct {
+		name   string
+		field  string
+		since  time.Time
+		wantID string // Empty when nothing changed
+	}{
+		{name: "any field", since: call, wantID: "email"},
+		{name: "one field", field: "email", since: call, wantID: "email"},
+		{name: "merges count for every field", field: "status", since: call, wantID: "merge"},
+		{name: "same instant counts", field: "status", since: call.Add(3 * time.Hour), wantID: "status"},
+		{name: "nothing later", field: "status", since: call.Add(4 * time.Hour)},
+		{name: "untouched field", field: "url", since: call.Add(150 * time.Minute)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := changedSince(changes, "svc", tt.field, tt.since)
+			var gotID string
+			if got != nil {
+				gotID = got.ID
+			}
+			if gotID != tt.wantID {
+				t.Errorf("changedSince(%q, %v) = %q, want %q", tt.field, tt.since, gotID, tt.wantID)
+			}
+		})
+	}
+}
+
+func TestRevertKey(t *testing.T) {
+	// Created resources are recorded with the field name "new" but looked up without one
+	if revertKey("svc", "new") != revertKey("svc", "") {
+		t.Errorf("revertKey(svc, new) = %q, want %q", revertKey("svc", "new"), revertKey("svc", ""))
+	}
+	if revertKey("svc", "status") == revertKey("svc", "") {
+		t.Errorf("field and resource keys collide")
+	}
+}
@@ -0,0 +1,285 @@
+package supabase
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/david-botos/BearHug/services/analysis/internal/hsds_types"
+	"github.com/david-botos/BearHug/services/analysis/pkg/logger"
+	"github.com/google/uuid"
+	"github.com/supabase-community/postgrest-go"
+)
+
+// ServiceMatchCandidateInput is one documented service that an ambiguous mention may refer to
+type ServiceMatchCandidateInput struct {
+	ServiceID   string   `json:"service_id"`
+	ServiceName string   `json:"service_name"`
+	Confidence  float64  `json:"confidence"`
+	NameScore   float64  `json:"name_score"`
+	DescScore   *float64 `json:"description_score,omitempty"`
+}
+
+// ServiceMatchReviewInput represents an extracted service that needs a human to decide whether it
+// is a new service or one of the candidates
+type ServiceMatchReviewInput struct {
+	CallID               string
+	OrganizationID       string
+	ExtractedName        string
+	ExtractedDescription string
+	ExtractedService     interface{} // Full extraction so the reviewer can create the service as-is
+	Candidates           []ServiceMatchCandidateInput
+	Confidence           float64
+	Reason               string
+}
+
+// StoreServiceMatchReviews records ambiguous service matches in the service_match_review table
+func StoreServiceMatchReviews(inputs []ServiceMatchReviewInput) error {
+	log := logger.Get()
+	if len(inputs) == 0 {
+		return nil
+	}
+
+	client, err := InitSupabaseClient()
+	if err != nil {
+		return fmt.Errorf("failed to initialize Supabase client: %w", err)
+	}
+
+	records := make([]map[string]interface{}, 0, len(inputs))
+	for _, input := range inputs {
+		records = append(records, map[string]interface{}{
+			"id":                    uuid.New().String(),
+			"call_fk":               input.CallID,
+			"organization_id":       input.OrganizationID,
+			"extracted_name":        input.ExtractedName,
+			"extracted_description": input.ExtractedDescription,
+			"extracted_service":     input.ExtractedService,
+			"candidates":            input.Candidates,
+			"confidence":            input.Confidence,
+			"reason":                input.Reason,
+			"status":                "pending",
+		})
+	}
+
+	data, _, err := client.From("service_match_review").
+		Insert(records, false, "", "representation", "").
+		Execute()
+	if err != nil {
+		log.Error().
+			Err(err).
+			Str("response_data", string(data)).
+			Msg("Failed to store service match reviews")
+		return fmt.Errorf("failed to store service match reviews: %w, data: %s", err, string(data))
+	}
+
+	log.Info().
+		Int("review_count", len(records)).
+		Msg("Stored ambiguous service matches for review")
+
+	return nil
+}
+
+// ValidationReviewInput represents validation issues that persisted through every fix round
+type ValidationReviewInput struct {
+	CallID      string
+	Issues      interface{} // Unresolved validation items
+	FixAttempts interface{} // History of fix rounds, so the reviewer can see what was tried
+}
+
+// StoreValidationReview records unresolved validation issues in the validation_review table
+func StoreValidationReview(input ValidationReviewInput) error {
+	log := logger.Get()
+
+	client, err := InitSupabaseClient()
+	if err != nil {
+		return fmt.Errorf("failed to initialize Supabase client: %w", err)
+	}
+
+	record := map[string]interface{}{
+		"id":           uuid.New().String(),
+		"call_fk":      input.CallID,
+		"issues":       input.Issues,
+		"fix_attempts": input.FixAttempts,
+		"status":       "pending",
+	}
+
+	data, _, err := client.From("validation_review").
+		Insert(record, false, "", "representation", "").
+		Execute()
+	if err != nil {
+		log.Error().
+			Err(err).
+			Str("call_id", input.CallID).
+			Str("response_data", string(data)).
+			Msg("Failed to store validation review")
+		return fmt.Errorf("failed to store validation review: %w, data: %s", err, string(data))
+	}
+
+	log.Info().
+		Str("call_id", input.CallID).
+		Msg("Stored unresolved validation issues for review")
+
+	return nil
+}
+
+// UnresolvedObservationInput is something said on a call that could not be tied to a record,
+// e.g. a capacity for a service name that matches nothing the organization offers
+type UnresolvedObservationInput struct {
+	CallID      string
+	Category    string
+	ServiceName string
+	Observation interface{} // The extracted values, stored as-is
+	Evidence    map[string]hsds_types.FieldEvidence
+	Reason      string
+}
+
+// StoreUnresolvedObservations parks observations in the unresolved_observation table for review
+func StoreUnresolvedObservations(inputs []UnresolvedObservationInput) error {
+	log := logger.Get()
+	if len(inputs) == 0 {
+		return nil
+	}
+
+	client, err := InitSupabaseClient()
+	if err != nil {
+		return fmt.Errorf("failed to initialize Supabase client: %w", err)
+	}
+
+	records := make([]map[string]interface{}, 0, len(inputs))
+	for _, input := range inputs {
+		records = append(records, map[string]interface{}{
+			"id":           uuid.New().String(),
+			"call_fk":      input.CallID,
+			"category":     input.Category,
+			"service_name": input.ServiceName,
+			"observation":  input.Observation,
+			"evidence":     input.Evidence,
+			"reason":       input.Reason,
+			"status":       "pending",
+		})
+	}
+
+	data, _, err := client.From("unresolved_observation").
+		Insert(records, false, "", "representation", "").
+		Execute()
+	if err != nil {
+		log.Error().
+			Err(err).
+			Str("response_data", string(data)).
+			Msg("Failed to store unresolved observations")
+		return fmt.Errorf("failed to store unresolved observations: %w, data: %s", err, string(data))
+	}
+
+	log.Info().
+		Int("observation_count", len(records)).
+		Msg("Parked unresolved observations for review")
+
+	return nil
+}
+
+// StoreContactReconciliationReport saves the contact reconciliation report for a call
+func StoreContactReconciliationReport(callID, organizationID string, report interface{}) error {
+	client, err := InitSupabaseClient()
+	if err != nil {
+		return fmt.Errorf("failed to initialize Supabase client: %w", err)
+	}
+
+	record := map[string]interface{}{
+		"id":              uuid.New().String(),
+		"call_fk":         callID,
+		"organization_id": organizationID,
+		"report":          report,
+	}
+
+	data, _, err := client.From("contact_reconciliation_report").
+		Insert(record, false, "", "representation", "").
+		Execute()
+	if err != nil {
+		return fmt.Errorf("failed to store contact reconciliation report: %w, data: %s", err, string(data))
+	}
+
+	return nil
+}
+
+// FetchContactReconciliationReport returns the most recent contact reconciliation report for a call,
+// or nil if the call has none
+func FetchContactReconciliationReport(callID string) (json.RawMessage, error) {
+	client, err := InitSupabaseClient()
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize Supabase client: %w", err)
+	}
+
+	data, _, err := client.From("contact_reconciliation_report").
+		Select("report", "", false).
+		Eq("call_fk", callID).
+		Order("created_at", &postgrest.OrderOpts{Ascending: false}).
+		Limit(1, "").
+		Execute()
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch contact reconciliation report: %w", err)
+	}
+
+	var rows []struct {
+		Report json.RawMessage `json:"report"`
+	}
+	if err := json.Unmarshal(data, &rows); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal contact reconciliation report: %w", err)
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	return rows[0].Report, nil
+}
+
+// StoreTranscriptRedactions saves the audit map of what was redacted from a call's transcript
+func StoreTranscriptRedactions(callID string, redactionMap interface{}) error {
+	client, err := InitSupabaseClient()
+	if err != nil {
+		return fmt.Errorf("failed to initialize Supabase client: %w", err)
+	}
+
+	record := map[string]interface{}{
+		"id":            uuid.New().String(),
+		"call_fk":       callID,
+		"redaction_map": redactionMap,
+	}
+
+	data, _, err := client.From("transcript_redaction").
+		Insert(record, false, "", "representation", "").
+		Execute()
+	if err != nil {
+		return fmt.Errorf("failed to store transcript redactions: %w, data: %s", err, string(data))
+	}
+
+	return nil
+}
+
+// FetchTranscriptRedactions returns the redaction map stored for a call, or nil if the call has none
+func FetchTranscriptRedactions(callID string) (json.RawMessage, error) {
+	client, err := InitSupabaseClient()
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize Supabase client: %w", err)
+	}
+
+	data, _, err := client.From("transcript_redaction").
+		Select("redaction_map", "", false).
+		Eq("call_fk", callID).
+		Order("created_at", &postgrest.OrderOpts{Ascending: false}).
+		Limit(1, "").
+		Execute()
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch transcript redactions: %w", err)
+	}
+
+	var rows []struct {
+		RedactionMap json.RawMessage `json:"redaction_map"`
+	}
+	if err := json.Unmarshal(data, &rows); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal transcript redactions: %w", err)
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	return rows[0].RedactionMap, nil
+}
@@ -3,6 +3,8 @@ package supabase
 import (
 	"encoding/json"
 	"fmt"
+	"reflect"
+	"time"
 
 	"github.com/david-botos/BearHug/services/analysis/internal/hsds_types"
 	"github.com/david-botos/BearHug/services/analysis/internal/types"
@@ -18,7 +20,9 @@ func StoreCallData(params types.TranscriptsReqBody) (string, error) {
 	log.Info().
 		Str("organization_id", params.OrganizationID).
 		Str("room_url", params.RoomURL).
-		Str("transcript", params.Transcript).
+		Str("transcript", params.Transcript.Render()).
+		Bool("diarized", params.Transcript.IsDiarized()).
+		Str("language", params.Language).
 		Msg("Storing transcript data")
 
 	client, err := InitSupabaseClient()
@@ -31,10 +35,19 @@ func StoreCallData(params types.TranscriptsReqBody) (string, error) {
 		ID string `json:"id"`
 	}
 
+	// The rendered text is what analysis quotes evidence from; the turns keep speakers and timing
+	transcriptData := map[string]interface{}{
+		"full_transcript": params.Transcript.Render(),
+	}
+	if params.Transcript.IsDiarized() {
+		transcriptData["turns"] = params.Transcript.Turns
+	}
+	if params.Language != "" {
+		transcriptData["language"] = params.Language
+	}
+
 	data, _, err := client.From("transcripts").
-		Insert(map[string]interface{}{
-			"full_transcript": params.Transcript,
-		}, false, "", "representation", "").
+		Insert(transcriptData, false, "", "representation", "").
 		Execute()
 
 	if err != nil {
@@ -105,8 +118,9 @@ func StoreCallData(params types.TranscriptsReqBody) (string, error) {
 	return callID, nil
 }
 
-// StoreNewServices stores multiple service records in Supabase and creates corresponding metadata
-func StoreNewServices(services []*hsds_types.Service, callID string) error {
+// StoreNewServices stores multiple service records in Supabase and creates corresponding metadata.
+// Evidence is keyed by service ID and may be nil.
+func StoreNewServices(services []*hsds_types.Service, callID string, evidence map[string]map[string]hsds_types.FieldEvidence) error {
 	log := logger.Get()
 
 	client, err := InitSupabaseClient()
@@ -122,10 +136,14 @@ func StoreNewServices(services []*hsds_types.Service, callID string) error {
 
 	for _, service := range services {
 		serviceData := map[string]interface{}{
-			"id":                      service.ID,
-			"organization_id":         service.OrganizationID,
-			"name":                    service.Name,
-			"status":                  service.Status,
+			"id":              service.ID,
+			"organization_id": service.OrganizationID,
+			"name":            service.Name,
+			"status":          service.Status,
+		}
+
+		// Add optional fields only if they're not nil
+		optionalFields := map[string]interface{}{
 			"program_id":              service.ProgramID,
 			"alternate_name":          service.AlternateName,
 			"description":             service.Description,
@@ -145,6 +163,11 @@ func StoreNewServices(services []*hsds_types.Service, callID string) error {
 			"assured_date":            service.AssuredDate,
 			"assurer_email":           service.AssurerEmail,
 		}
+		for column, value := range optionalFields {
+			if !isNilValue(value) {
+				serviceData[column] = value
+			}
+		}
 
 		data, _, err := client.From("service").
 			Insert(serviceData, false, "", "representation", "").
@@ -168,6 +191,7 @@ func StoreNewServices(services []*hsds_types.Service, callID string) error {
 			ReplacementValue: "new entry",
 			LastActionType:   "CREATE",
 			CallID:           callID,
+			Evidence:         evidence[service.ID],
 		})
 	}
 
@@ -189,8 +213,10 @@ func StoreNewServices(services []*hsds_types.Service, callID string) error {
 	return nil
 }
 
-// StoreNewCapacity stores multiple service capacity records in Supabase and creates corresponding metadata
-func StoreNewCapacity(capacityObjects []*hsds_types.ServiceCapacity, callID string) error {
+// StoreNewCapacity records capacity readings from a call. Capacity is a time series per service and unit:
+// when a reading already exists it is archived to service_capacity_history and the live row is updated in
+// place, otherwise a new row is inserted. Evidence is keyed by capacity ID and may be nil.
+func StoreNewCapacity(capacityObjects []*hsds_types.ServiceCapacity, callID string, evidence map[string]map[string]hsds_types.FieldEvidence) error {
 	log := logger.Get()
 
 	client, err := InitSupabaseClient()
@@ -201,10 +227,100 @@ func StoreNewCapacity(capacityObjects []*hsds_types.ServiceCapacity, callID stri
 		return fmt.Errorf("failed to initialize Supabase client: %w", err)
 	}
 
+	observedAt, err := FetchCallObservedAt(callID)
+	if err != nil {
+		log.Warn().
+			Err(err).
+			Str("call_id", callID).
+			Msg("Could not look up call time, using the current time as the observation time")
+		observedAt = time.Now().UTC()
+	}
+
 	// Create a slice to collect metadata entries
 	var metadataInputs []MetadataInput
 
 	for _, capObj := range capacityObjects {
+		capObj.Updated = observedAt
+
+		latest, err := fetchLatestCapacity(client, capObj.ServiceID, capObj.UnitID)
+		if err != nil {
+			log.Error().
+				Err(err).
+				Str("service_id", capObj.ServiceID).
+				Str("unit_id", capObj.UnitID).
+				Msg("Failed to look up latest capacity")
+			return fmt.Errorf("failed to look up latest capacity: %w", err)
+		}
+
+		// A reading older than the live one (e.g. a reprocessed call) only belongs in the history
+		if latest != nil && latest.Updated.After(observedAt) {
+			if err := archiveCapacityReading(client, latest.ID, capObj, callID); err != nil {
+				return err
+			}
+			log.Info().
+				Str("capacity_id", latest.ID).
+				Time("observed_at", observedAt).
+				Msg("Recorded older capacity reading in history")
+			continue
+		}
+
+		if latest != nil {
+			if err := archiveCapacityReading(client, latest.ID, latest, callID); err != nil {
+				return err
+			}
+
+			updateData := map[string]interface{}{
+				"available": capObj.Available,
+				"updated":   capObj.Updated,
+			}
+			if capObj.Maximum != nil {
+				updateData["maximum"] = *capObj.Maximum
+			}
+			if capObj.Description != nil {
+				updateData["description"] = *capObj.Description
+			}
+
+			data, _, err := client.From("service_capacity").
+				Update(updateData, "", "").
+				Eq("id", latest.ID).
+				Execute()
+			if err != nil {
+				log.Error().
+					Err(err).
+					Str("capacity_id", latest.ID).
+					Interface("update_data", updateData).
+					Msg("Failed to update capacity data")
+				return fmt.Errorf("failed to update capacity data: %w, data: %s", err, string(data))
+			}
+
+			for field, values := range capacityChanges(latest, capObj) {
+				var fieldEvidence map[string]hsds_types.FieldEvidence
+				if ev, ok := evidence[capObj.ID][field]; ok {
+					fieldEvidence = map[string]hsds_types.FieldEvidence{field: ev}
+				}
+				metadataInputs = append(metadataInputs, MetadataInput{
+					ResourceID:       latest.ID,
+					CallID:           callID,
+					ResourceType:     "service_capacity",
+					FieldName:        field,
+					PreviousValue:    values[0],
+					ReplacementValue: values[1],
+					LastActionType:   "UPDATE",
+					Evidence:         fieldEvidence,
+				})
+			}
+
+			log.Debug().
+				Str("capacity_id", latest.ID).
+				Float64("previous_available", latest.Available).
+				Float64("available", capObj.Available).
+				Msg("Updated capacity reading in place")
+
+			// Point the caller at the row that now holds this reading
+			capObj.ID = latest.ID
+			continue
+		}
+
 		capacityData := map[string]interface{}{
 			"id":         capObj.ID,
 			"service_id": capObj.ServiceID,
@@ -243,6 +359,7 @@ func StoreNewCapacity(capacityObjects []*hsds_types.ServiceCapacity, callID stri
 			ResourceType:     "service_capacity",
 			ReplacementValue: "new entry",
 			LastActionType:   "CREATE",
+			Evidence:         evidence[capObj.ID],
 		})
 	}
 
@@ -339,7 +456,9 @@ func StoreNewUnits(unitObjects []*hsds_types.Unit, callID string) error {
 	return nil
 }
 
-func StoreNewContacts(contactObjects []*hsds_types.Contact, callID string) error {
+// StoreNewContacts stores multiple contact records in Supabase and creates corresponding metadata.
+// Evidence is keyed by contact ID and may be nil.
+func StoreNewContacts(contactObjects []*hsds_types.Contact, callID string, evidence map[string]map[string]hsds_types.FieldEvidence) error {
 	log := logger.Get()
 
 	client, err := InitSupabaseClient()
@@ -405,6 +524,7 @@ func StoreNewContacts(contactObjects []*hsds_types.Contact, callID string) error
 			ResourceType:     "contact",
 			ReplacementValue: "new entry",
 			LastActionType:   "CREATE",
+			Evidence:         evidence[contactObj.ID],
 		})
 	}
 
@@ -425,7 +545,9 @@ func StoreNewContacts(contactObjects []*hsds_types.Contact, callID string) error
 	return nil
 }
 
-func StoreNewPhones(phoneObjects []*hsds_types.Phone, callID string) error {
+// StoreNewPhones stores multiple phone records in Supabase and creates corresponding metadata.
+// Evidence is keyed by phone ID and may be nil.
+func StoreNewPhones(phoneObjects []*hsds_types.Phone, callID string, evidence map[string]map[string]hsds_types.FieldEvidence) error {
 	log := logger.Get()
 
 	client, err := InitSupabaseClient()
@@ -492,6 +614,7 @@ func StoreNewPhones(phoneObjects []*hsds_types.Phone, callID string) error {
 			ResourceType:     "phone",
 			ReplacementValue: "new entry",
 			LastActionType:   "CREATE",
+			Evidence:         evidence[phoneObj.ID],
 		})
 	}
 
@@ -511,3 +634,81 @@ func StoreNewPhones(phoneObjects []*hsds_types.Phone, callID string) error {
 
 	return nil
 }
+
+// isNilValue reports whether an optional field holds a nil pointer
+func isNilValue(value interface{}) bool {
+	if value == nil {
+		return true
+	}
+	v := reflect.ValueOf(value)
+	return v.Kind() == reflect.Ptr && v.IsNil()
+}
+
+// StoreNewLanguages stores the languages services are delivered in and creates corresponding metadata.
+// Evidence is keyed by language ID and may be nil.
+func StoreNewLanguages(languages []*hsds_types.Language, callID string, evidence map[string]map[string]hsds_types.FieldEvidence) error {
+	log := logger.Get()
+
+	client, err := InitSupabaseClient()
+	if err != nil {
+		return fmt.Errorf("failed to initialize Supabase client: %w", err)
+	}
+
+	var metadataInputs []MetadataInput
+
+	for _, language := range languages {
+		languageData := map[string]interface{}{
+			"id": language.ID,
+		}
+		optionalFields := map[string]interface{}{
+			"service_id":  language.ServiceID,
+			"location_id": language.LocationID,
+			"phone_id":    language.PhoneID,
+			"name":        language.Name,
+			"code":        language.Code,
+			"note":        language.Note,
+		}
+		for column, value := range optionalFields {
+			if !isNilValue(value) {
+				languageData[column] = value
+			}
+		}
+
+		data, _, err := client.From("language").
+			Insert(languageData, false, "", "representation", "").
+			Execute()
+		if err != nil {
+			log.Error().
+				Err(err).
+				Str("language_id", language.ID).
+				Interface("language_data", languageData).
+				Msg("Failed to insert language data")
+			return fmt.Errorf("failed to insert language data: %w, data: %s", err, string(data))
+		}
+
+		metadataInputs = append(metadataInputs, MetadataInput{
+			ResourceID:       language.ID,
+			CallID:           callID,
+			ResourceType:     "language",
+			ReplacementValue: "new entry",
+			LastActionType:   "CREATE",
+			Evidence:         evidence[language.ID],
+		})
+	}
+
+	if len(metadataInputs) > 0 {
+		if err := CreateAndStoreMetadata(metadataInputs); err != nil {
+			log.Error().
+				Err(err).
+				Int("metadata_count", len(metadataInputs)).
+				Msg("Failed to create metadata for languages")
+			return fmt.Errorf("failed to create metadata for languages: %w", err)
+		}
+
+		log.Info().
+			Int("metadata_count", len(metadataInputs)).
+			Msg("Successfully created metadata for languages")
+	}
+
+	return nil
+}
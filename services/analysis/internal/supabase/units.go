@@ -0,0 +1,322 @@
+package supabase
+
+import (
+	"fmt"
+
+	"github.com/david-botos/BearHug/services/analysis/internal/hsds_types"
+	"github.com/david-botos/BearHug/services/analysis/pkg/logger"
+	"github.com/google/uuid"
+	"github.com/supabase-community/supabase-go"
+)
+
+// UnitSynonym is an alternate name that should resolve to an existing unit
+type UnitSynonym struct {
+	ID      string `json:"id"`
+	UnitID  string `json:"unit_id"`
+	Synonym string `json:"synonym"`
+	Source  string `json:"source"`
+}
+
+// UnitMergeInput describes an admin request to fold duplicate units into one
+type UnitMergeInput struct {
+	TargetID    string   `json:"target_id"`
+	SourceIDs   []string `json:"source_ids"`
+	Reason      string   `json:"reason,omitempty"`
+	RequestedBy string   `json:"requested_by,omitempty"`
+}
+
+// UnitMergeResult reports what a merge touched
+type UnitMergeResult struct {
+	TargetID             string   `json:"target_id"`
+	MergedUnitIDs        []string `json:"merged_unit_ids"`
+	RepointedCapacityIDs []string `json:"repointed_capacity_ids"`
+	CollapsedCapacityIDs []string `json:"collapsed_capacity_ids"` // Rows folded into an existing reading for the same service
+	SynonymsAdded        []string `json:"synonyms_added"`
+}
+
+// FetchUnitSynonyms returns every stored unit synonym
+func FetchUnitSynonyms() ([]UnitSynonym, error) {
+	client, err := InitSupabaseClient()
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize Supabase client: %w", err)
+	}
+
+	data, _, err := client.From("unit_synonym").
+		Select("id, unit_id, synonym, source", "", false).
+		Execute()
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch unit synonyms from supa: %w", err)
+	}
+
+	var synonyms []UnitSynonym
+	if err := hsds_types.UnmarshalJSONWithTime(data, &synonyms); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal unit synonyms: %w", err)
+	}
+
+	return synonyms, nil
+}
+
+// MergeUnits repoints capacity that uses the source units at the target unit, keeps the source
+// names as synonyms of the target and deletes the source units. PostgREST has no transactions,
+// so each source is merged fully before moving to the next and a failure leaves earlier sources merged.
+func MergeUnits(input UnitMergeInput) (*UnitMergeResult, error) {
+	log := logger.Get()
+
+	if err := validateUnitMerge(input); err != nil {
+		return nil, err
+	}
+
+	updatedBy := input.RequestedBy
+	if updatedBy == "" {
+		updatedBy = "admin"
+	}
+
+	client, err := InitSupabaseClient()
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize Supabase client: %w", err)
+	}
+
+	data, _, err := client.From("unit").
+		Select("id, name, scheme, identifier, uri", "", false).
+		In("id", append([]string{input.TargetID}, input.SourceIDs...)).
+		Execute()
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch units to merge: %w", err)
+	}
+
+	var units []hsds_types.Unit
+	if err := hsds_types.UnmarshalJSONWithTime(data, &units); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal units: %w", err)
+	}
+	unitsByID := make(map[string]hsds_types.Unit, len(units))
+	for _, unit := range units {
+		unitsByID[unit.ID] = unit
+	}
+
+	target, ok := unitsByID[input.TargetID]
+	if !ok {
+		return nil, fmt.Errorf("target unit %s not found", input.TargetID)
+	}
+	for _, sourceID := range input.SourceIDs {
+		if _, ok := unitsByID[sourceID]; !ok {
+			return nil, fmt.Errorf("source unit %s not found", sourceID)
+		}
+	}
+
+	result := &UnitMergeResult{TargetID: target.ID}
+	var metadataInputs []MetadataInput
+
+	for _, sourceID := range input.SourceIDs {
+		source := unitsByID[sourceID]
+		rationale := unitMergeRationale(source, target, input.Reason)
+
+		capacityMetadata, repointed, collapsed, err := repointUnitCapacity(client, source, target, rationale)
+		if err != nil {
+			return result, fmt.Errorf("failed to repoint capacity for unit %s: %w", source.ID, err)
+		}
+		metadataInputs = append(metadataInputs, capacityMetadata...)
+		result.RepointedCapacityIDs = append(result.RepointedCapacityIDs, repointed...)
+		result.CollapsedCapacityIDs = append(result.CollapsedCapacityIDs, collapsed...)
+
+		// Earlier readings and synonyms follow the unit
+		if _, _, err := client.From("service_capacity_history").
+			Update(map[string]interface{}{"unit_id": target.ID}, "", "").
+			Eq("unit_id", source.ID).
+			Execute(); err != nil {
+			return result, fmt.Errorf("failed to repoint capacity history for unit %s: %w", source.ID, err)
+		}
+		if _, _, err := client.From("unit_synonym").
+			Update(map[string]interface{}{"unit_id": target.ID}, "", "").
+			Eq("unit_id", source.ID).
+			Execute(); err != nil {
+			return result, fmt.Errorf("failed to repoint synonyms for unit %s: %w", source.ID, err)
+		}
+
+		synonym := UnitSynonym{
+			ID:      uuid.New().String(),
+			UnitID:  target.ID,
+			Synonym: source.Name,
+			Source:  "merge",
+		}
+		if _, _, err := client.From("unit_synonym").
+			Insert(synonym, false, "", "representation", "").
+			Execute(); err != nil {
+			return result, fmt.Errorf("failed to store synonym %q: %w", source.Name, err)
+		}
+		result.SynonymsAdded = append(result.SynonymsAdded, source.Name)
+
+		if _, _, err := client.From("unit").
+			Delete("", "").
+			Eq("id", source.ID).
+			Execute(); err != nil {
+			return result, fmt.Errorf("failed to delete merged unit %s: %w", source.ID, err)
+		}
+		result.MergedUnitIDs = append(result.MergedUnitIDs, source.ID)
+
+		metadataInputs = append(metadataInputs, MetadataInput{
+			ResourceID:       source.ID,
+			ResourceType:     "unit",
+			FieldName:        "id",
+			PreviousValue:    source.ID,
+			ReplacementValue: target.ID,
+			LastActionType:   "MERGE",
+			Rationale:        rationale,
+		})
+
+		log.Info().
+			Str("source_unit_id", source.ID).
+			Str("source_unit_name", source.Name).
+			Str("target_unit_id", target.ID).
+			Str("target_unit_name", target.Name).
+			Int("capacities_repointed", len(repointed)).
+			Int("capacities_collapsed", len(collapsed)).
+			Msg("Merged unit")
+	}
+
+	for i := range metadataInputs {
+		metadataInputs[i].UpdatedBy = updatedBy
+	}
+	if err := CreateAndStoreMetadata(metadataInputs); err != nil {
+		log.Error().
+			Err(err).
+			Int("metadata_count", len(metadataInputs)).
+			Msg("Failed to create metadata for unit merge")
+		return result, fmt.Errorf("failed to create metadata for unit merge: %w", err)
+	}
+
+	return result, nil
+}
+
+// validateUnitMerge rejects malformed ids, an empty source list and a unit merged into itself
+func validateUnitMerge(input UnitMergeInput) error {
+	if !hsds_types.ValidateUUID(input.TargetID) {
+		return fmt.Errorf("invalid target unit ID: %s", input.TargetID)
+	}
+	if len(input.SourceIDs) == 0 {
+		return fmt.Errorf("no source units to merge")
+	}
+	for _, sourceID := range input.SourceIDs {
+		if sourceID == input.TargetID {
+			return fmt.Errorf("unit %s cannot be merged into itself", sourceID)
+		}
+		if !hsds_types.ValidateUUID(sourceID) {
+			return fmt.Errorf("invalid source unit ID: %s", sourceID)
+		}
+	}
+	return nil
+}
+
+// unitMergeRationale explains a merge in the metadata trail, with the admin's reason when given
+func unitMergeRationale(source, target hsds_types.Unit, reason string) string {
+	rationale := fmt.Sprintf("Unit %q merged into %q", source.Name, target.Name)
+	if reason != "" {
+		rationale += ": " + reason
+	}
+	return rationale
+}
+
+// orderCapacityReadings returns the older and newer of two readings for the same service and unit.
+// On a tie the reading already on the target unit is kept live.
+func orderCapacityReadings(repointed, existing *hsds_types.ServiceCapacity) (older, newer *hsds_types.ServiceCapacity) {
+	if repointed.Updated.After(existing.Updated) {
+		return existing, repointed
+	}
+	return repointed, existing
+}
+
+// repointUnitCapacity moves the source unit's capacity rows to the target unit. When the service
+// already has a reading for the target unit the two rows are collapsed: the newer reading stays
+// live on the target row and the older one is archived to its history.
+func repointUnitCapacity(client *supabase.Client, source, target hsds_types.Unit, rationale string) ([]MetadataInput, []string, []string, error) {
+	data, _, err := client.From("service_capacity").
+		Select("id, service_id, unit_id, available, maximum, description, updated", "", false).
+		Eq("unit_id", source.ID).
+		Execute()
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to fetch capacity for unit: %w", err)
+	}
+
+	var capacities []hsds_types.ServiceCapacity
+	if err := hsds_types.UnmarshalJSONWithTime(data, &capacities); err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to unmarshal capacity: %w", err)
+	}
+
+	var metadataInputs []MetadataInput
+	var repointed, collapsed []string
+
+	for i := range capacities {
+		capacity := &capacities[i]
+
+		existing, err := fetchLatestCapacity(client, capacity.ServiceID, target.ID)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+
+		if existing == nil {
+			if _, _, err := client.From("service_capacity").
+				Update(map[string]interface{}{"unit_id": target.ID}, "", "").
+				Eq("id", capacity.ID).
+				Execute(); err != nil {
+				return nil, nil, nil, fmt.Errorf("failed to repoint capacity %s: %w", capacity.ID, err)
+			}
+			repointed = append(repointed, capacity.ID)
+			metadataInputs = append(metadataInputs, MetadataInput{
+				ResourceID:       capacity.ID,
+				ResourceType:     "service_capacity",
+				FieldName:        "unit_id",
+				PreviousValue:    source.ID,
+				ReplacementValue: target.ID,
+				LastActionType:   "UPDATE",
+				Rationale:        rationale,
+			})
+			continue
+		}
+
+		// Archive whichever reading is older, under the surviving row
+		capacity.UnitID = target.ID
+		older, newer := orderCapacityReadings(capacity, existing)
+		if err := archiveCapacityReading(client, existing.ID, older, ""); err != nil {
+			return nil, nil, nil, err
+		}
+		if newer == capacity {
+			updateData := map[string]interface{}{
+				"available":   capacity.Available,
+				"maximum":     capacity.Maximum,
+				"description": capacity.Description,
+				"updated":     capacity.Updated,
+			}
+			if _, _, err := client.From("service_capacity").
+				Update(updateData, "", "").
+				Eq("id", existing.ID).
+				Execute(); err != nil {
+				return nil, nil, nil, fmt.Errorf("failed to update capacity %s: %w", existing.ID, err)
+			}
+		}
+
+		if _, _, err := client.From("service_capacity_history").
+			Update(map[string]interface{}{"service_capacity_id": existing.ID}, "", "").
+			Eq("service_capacity_id", capacity.ID).
+			Execute(); err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to repoint history of capacity %s: %w", capacity.ID, err)
+		}
+		if _, _, err := client.From("service_capacity").
+			Delete("", "").
+			Eq("id", capacity.ID).
+			Execute(); err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to delete collapsed capacity %s: %w", capacity.ID, err)
+		}
+
+		collapsed = append(collapsed, capacity.ID)
+		metadataInputs = append(metadataInputs, MetadataInput{
+			ResourceID:       capacity.ID,
+			ResourceType:     "service_capacity",
+			FieldName:        "id",
+			PreviousValue:    capacity.ID,
+			ReplacementValue: existing.ID,
+			LastActionType:   "MERGE",
+			Rationale:        rationale,
+		})
+	}
+
+	return metadataInputs, repointed, collapsed, nil
+}
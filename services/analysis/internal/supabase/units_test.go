@@ -0,0 +1,97 @@
+package supabase
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/david-botos/BearHug/services/analysis/internal/hsds_types"
+)
+
+func TestValidateUnitMerge(t *testing.T) {
+	const (
+		target = "3f1c2a4e-8b7d-4e6f-9a0b-1c2d3e4f5a6b"
+		source = "7a8b9c0d-1e2f-4a3b-8c4d-5e6f7a8b9c0d"
+	)
+
+	tests := []struct {
+		name    string
+		input   UnitMergeInput
+		wantErr string // empty when the merge is valid
+	}{
+		{name: "valid", input: UnitMergeInput{TargetID: target, SourceIDs: []string{source}}},
+		{name: "invalid target", input: UnitMergeInput{TargetID: "unit-1", SourceIDs: []string{source}}, wantErr: "invalid target"},
+		{name: "no sources", input: UnitMergeInput{TargetID: target}, wantErr: "no source units"},
+		{name: "merged into itself", input: UnitMergeInput{TargetID: target, SourceIDs: []string{source, target}}, wantErr: "into itself"},
+		{name: "invalid source", input: UnitMergeInput{TargetID: target, SourceIDs: []string{"unit-2"}}, wantErr: "invalid source"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateUnitMerge(tt.input)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("validateUnitMerge() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("validateUnitMerge() = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestUnitMergeRationale(t *testing.T) {
+	source := hsds_types.Unit{ID: "unit-source", Name: "cots"}
+	target := hsds_types.Unit{ID: "unit-target", Name: "Bed"}
+
+	tests := []struct {
+		name   string
+		reason string
+		want   string
+	}{
+		{name: "without reason", want: `Unit "cots" merged into "Bed"`},
+		{name: "with reason", reason: "same thing", want: `Unit "cots" merged into "Bed": same thing`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := unitMergeRationale(source, target, tt.reason); got != tt.want {
+				t.Errorf("unitMergeRationale() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOrderCapacityReadings(t *testing.T) {
+	earlier := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	later := earlier.Add(time.Hour)
+
+	tests := []struct {
+		name              string
+		repointedUpdated  time.Time
+		existingUpdated   time.Time
+		wantRepointedLive bool
+	}{
+		{name: "repointed reading is newer", repointedUpdated: later, existingUpdated: earlier, wantRepointedLive: true},
+		{name: "existing reading is newer", repointedUpdated: earlier, existingUpdated: later},
+		{name: "tie keeps the existing reading", repointedUpdated: earlier, existingUpdated: earlier},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repointed := &hsds_types.ServiceCapacity{ID: "capacity-source", Updated: tt.repointedUpdated}
+			existing := &hsds_types.ServiceCapacity{ID: "capacity-target", Updated: tt.existingUpdated}
+
+			older, newer := orderCapacityReadings(repointed, existing)
+			wantOlder, wantNewer := repointed, existing
+			if tt.wantRepointedLive {
+				wantOlder, wantNewer = existing, repointed
+			}
+			if older != wantOlder || newer != wantNewer {
+				t.Errorf("orderCapacityReadings() = (%s, %s), want (%s, %s)", older.ID, newer.ID, wantOlder.ID, wantNewer.ID)
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package types
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+type SpeakerRole string
+
+const (
+	SpeakerAgent          SpeakerRole = "agent"          // The AI caller
+	SpeakerRepresentative SpeakerRole = "representative" // Staff of the community organization being called
+	SpeakerUnknown        SpeakerRole = "unknown"
+)
+
+// speakerAliases maps the role names used by call and transcription services onto our roles
+var speakerAliases = map[string]SpeakerRole{
+	"agent":          SpeakerAgent,
+	"assistant":      SpeakerAgent,
+	"bot":            SpeakerAgent,
+	"ai":             SpeakerAgent,
+	"caller":         SpeakerAgent,
+	"representative": SpeakerRepresentative,
+	"user":           SpeakerRepresentative,
+	"cbo":            SpeakerRepresentative,
+	"staff":          SpeakerRepresentative,
+	"unknown":        SpeakerUnknown,
+	"":               SpeakerUnknown,
+}
+
+// Label is how the speaker is named in prompts
+func (r SpeakerRole) Label() string {
+	switch r {
+	case SpeakerAgent:
+		return "Agent (AI caller)"
+	case SpeakerRepresentative:
+		return "Representative (organization staff)"
+	}
+	return "Unknown speaker"
+}
+
+// TranscriptTurn is one uninterrupted stretch of speech by a single speaker
+type TranscriptTurn struct {
+	Speaker SpeakerRole `json:"speaker"`
+	Start   *float64    `json:"start,omitempty"` // Seconds from the start of the call
+	End     *float64    `json:"end,omitempty"`
+	Text    string      `json:"text"`
+}
+
+// Transcript is a call transcript, sent either as plain text or as speaker turns
+type Transcript struct {
+	Text  string
+	Turns []TranscriptTurn
+}
+
+// UnmarshalJSON accepts a plain string or an array of turns
+func (t *Transcript) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || bytes.Equal(data, []byte("null")) {
+		*t = Transcript{}
+		return nil
+	}
+
+	if data[0] == '"' {
+		var text string
+		if err := json.Unmarshal(data, &text); err != nil {
+			return fmt.Errorf("transcript text: %w", err)
+		}
+		*t = Transcript{Text: text}
+		return nil
+	}
+
+	var turns []TranscriptTurn
+	if err := json.Unmarshal(data, &turns); err != nil {
+		return fmt.Errorf("transcript must be a string or an array of turns: %w", err)
+	}
+	for i := range turns {
+		role, ok := speakerAliases[strings.ToLower(strings.TrimSpace(string(turns[i].Speaker)))]
+		if !ok {
+			return fmt.Errorf("turn %d has unknown speaker %q", i, turns[i].Speaker)
+		}
+		turns[i].Speaker = role
+		if turns[i].Start != nil && turns[i].End != nil && *turns[i].End < *turns[i].Start {
+			return fmt.Errorf("turn %d ends before it starts", i)
+		}
+	}
+	*t = Transcript{Turns: turns}
+	return nil
+}
+
+// MarshalJSON writes the transcript back in the form it was received
+func (t Transcript) MarshalJSON() ([]byte, error) {
+	if t.IsDiarized() {
+		return json.Marshal(t.Turns)
+	}
+	return json.Marshal(t.Text)
+}
+
+// IsDiarized reports whether the transcript was sent as speaker turns
+func (t Transcript) IsDiarized() bool {
+	return len(t.Turns) > 0
+}
+
+// Render returns the text used in prompts and as the source for evidence quotes. Turns are written
+// one per line with their speaker label and start time, e.g. "[01:05] Representative (organization staff): ..."
+func (t Transcript) Render() string {
+	if !t.IsDiarized() {
+		return t.Text
+	}
+
+	var builder strings.Builder
+	for i, turn := range t.Turns {
+		if i > 0 {
+			builder.WriteString("\n")
+		}
+		if turn.Start != nil {
+			seconds := int(*turn.Start)
+			builder.WriteString(fmt.Sprintf("[%02d:%02d] ", seconds/60, seconds%60))
+		}
+		builder.WriteString(turn.Speaker.Label())
+		builder.WriteString(": ")
+		builder.WriteString(strings.TrimSpace(turn.Text))
+	}
+	return builder.String()
+}
@@ -0,0 +1,101 @@
+package types
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestTranscriptUnmarshalJSON(t *testing.T) {
+	seconds := func(f float64) *float64 { return &f }
+
+	tests := []struct {
+		name    string
+		input   string
+		want    Transcript
+		wantErr bool
+	}{
+		{name: "plain text", input: `"Hi, is this the food bank?"`, want: Transcript{Text: "Hi, is this the food bank?"}},
+		{name: "null", input: `null`, want: Transcript{}},
+		{
+			name:  "turns with aliased speakers",
+			input: `[{"speaker":"Assistant","start":0,"end":2.5,"text":"Hi there"},{"speaker":" user ","text":"Hello"}]`,
+			want: Transcript{Turns: []TranscriptTurn{
+				{Speaker: SpeakerAgent, Start: seconds(0), End: seconds(2.5), Text: "Hi there"},
+				{Speaker: SpeakerRepresentative, Text: "Hello"},
+			}},
+		},
+		{
+			name:  "missing speaker is unknown",
+			input: `[{"text":"..."}]`,
+			want:  Transcript{Turns: []TranscriptTurn{{Speaker: SpeakerUnknown, Text: "..."}}},
+		},
+		{name: "unknown speaker", input: `[{"speaker":"narrator","text":"Hello"}]`, wantErr: true},
+		{name: "turn ends before it starts", input: `[{"speaker":"agent","start":5,"end":3,"text":"Hi"}]`, wantErr: true},
+		{name: "object", input: `{"text":"Hello"}`, wantErr: true},
+		{name: "number", input: `42`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got Transcript
+			err := json.Unmarshal([]byte(tt.input), &got)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Unmarshal(%s) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Unmarshal(%s) = %+v, want %+v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTranscriptRoundTrip(t *testing.T) {
+	for _, input := range []string{
+		`"Hi, is this the food bank?"`,
+		`[{"speaker":"agent","start":1,"end":2,"text":"Hi"},{"speaker":"representative","text":"Hello"}]`,
+	} {
+		var transcript Transcript
+		if err := json.Unmarshal([]byte(input), &transcript); err != nil {
+			t.Fatalf("Unmarshal(%s): %v", input, err)
+		}
+		output, err := json.Marshal(transcript)
+		if err != nil {
+			t.Fatalf("Marshal(%+v): %v", transcript, err)
+		}
+		if string(output) != input {
+			t.Errorf("round trip of %s = %s", input, output)
+		}
+	}
+}
+
+func TestTranscriptRender(t *testing.T) {
+	start := func(f float64) *float64 { return &f }
+
+	tests := []struct {
+		name       string
+		transcript Transcript
+		want       string
+	}{
+		{name: "plain text", transcript: Transcript{Text: "Agent: Hi\nStaff: Hello"}, want: "Agent: Hi\nStaff: Hello"},
+		{
+			name: "turns",
+			transcript: Transcript{Turns: []TranscriptTurn{
+				{Speaker: SpeakerAgent, Start: start(0), Text: "Hi, is this the food bank? "},
+				{Speaker: SpeakerRepresentative, Start: start(65.4), Text: "Yes it is."},
+				{Speaker: SpeakerUnknown, Text: "(hold music)"},
+			}},
+			want: "[00:00] Agent (AI caller): Hi, is this the food bank?\n" +
+				"[01:05] Representative (organization staff): Yes it is.\n" +
+				"Unknown speaker: (hold music)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.transcript.Render(); got != tt.want {
+				t.Errorf("Render() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -36,14 +36,16 @@ func (sc ServiceCategory) IsValid() bool {
 }
 
 type TranscriptsReqBody struct {
-	OrganizationID string `json:"organization_id"`
-	RoomURL        string `json:"room_url"`
-	Transcript     string `json:"transcript"`
+	OrganizationID string     `json:"organization_id"`
+	RoomURL        string     `json:"room_url"`
+	Transcript     Transcript `json:"transcript"`         // Plain text or an array of speaker turns
+	Language       string     `json:"language,omitempty"` // ISO 639-1 code; detected from the transcript when not given
 }
 
 type ProcTranscriptParams struct {
 	OrganizationID string `json:"organization_id"`
 	RoomURL        string `json:"room_url"`
-	Transcript     string `json:"transcript"`
+	Transcript     string `json:"transcript"` // Rendered with speaker labels when the call was diarized
 	CallID         string `json:"call_fk"`
+	Language       string `json:"language"` // ISO 639-1 code of the spoken language, or "und"
 }
@@ -0,0 +1,320 @@
+--
+-- BearHug specific tables that live alongside the HSDS schema in hsds.sql
+--
+
+--
+-- Name: service_match_review; Type: TABLE; Schema: public; Owner: postgres
+--
+
+CREATE TABLE public.service_match_review (
+    id character varying(250) NOT NULL,
+    call_fk character varying(250) NOT NULL,
+    organization_id character varying(250) NOT NULL,
+    extracted_name text NOT NULL,
+    extracted_description text,
+    extracted_service jsonb,
+    candidates jsonb NOT NULL,
+    confidence numeric NOT NULL,
+    reason text,
+    status text DEFAULT 'pending'::text NOT NULL,
+    resolved_service_id character varying(250),
+    created_at timestamp without time zone DEFAULT now() NOT NULL
+);
+
+
+ALTER TABLE public.service_match_review OWNER TO postgres;
+
+COMMENT ON TABLE public.service_match_review IS 'Extracted services that could not be confidently matched to, or ruled out from, an existing service.';
+
+ALTER TABLE ONLY public.service_match_review
+    ADD CONSTRAINT service_match_review_pkey PRIMARY KEY (id);
+
+ALTER TABLE ONLY public.service_match_review
+    ADD CONSTRAINT service_match_review_organization_id_fkey FOREIGN KEY (organization_id) REFERENCES public.organization(id);
+
+
+--
+-- Name: metadata.rationale; Type: COLUMN; Schema: public; Owner: postgres
+--
+
+ALTER TABLE public.metadata
+    ADD COLUMN rationale text;
+
+COMMENT ON COLUMN public.metadata.rationale IS 'Why BearHug made the change, e.g. the merge decision for a field.';
+
+
+--
+-- Name: metadata.evidence; Type: COLUMN; Schema: public; Owner: postgres
+--
+
+ALTER TABLE public.metadata
+    ADD COLUMN evidence jsonb;
+
+COMMENT ON COLUMN public.metadata.evidence IS 'Transcript quotes, character offsets and confidence supporting the change, keyed by field name.';
+
+
+--
+-- Name: metadata.previous_value_json; Type: COLUMN; Schema: public; Owner: postgres
+--
+
+ALTER TABLE public.metadata
+    ADD COLUMN previous_value_json jsonb;
+
+COMMENT ON COLUMN public.metadata.previous_value_json IS 'The field''s value before the change, JSON-encoded with its type. NULL when the field was empty.';
+
+
+--
+-- Name: metadata.replacement_value_json; Type: COLUMN; Schema: public; Owner: postgres
+--
+
+ALTER TABLE public.metadata
+    ADD COLUMN replacement_value_json jsonb;
+
+COMMENT ON COLUMN public.metadata.replacement_value_json IS 'The field''s value after the change, JSON-encoded with its type. NULL when the field was cleared.';
+
+
+--
+-- Name: metadata.change_set_id; Type: COLUMN; Schema: public; Owner: postgres
+--
+
+ALTER TABLE public.metadata
+    ADD COLUMN change_set_id character varying(250);
+
+COMMENT ON COLUMN public.metadata.change_set_id IS 'Shared by the rows written together for one change. Rows without one predate the typed value columns.';
+
+CREATE INDEX metadata_change_set_idx ON public.metadata USING btree (change_set_id);
+
+
+--
+-- Name: metadata.seq; Type: COLUMN; Schema: public; Owner: postgres
+--
+
+ALTER TABLE public.metadata
+    ADD COLUMN seq bigint GENERATED ALWAYS AS IDENTITY;
+
+COMMENT ON COLUMN public.metadata.seq IS 'Assigned by the database in insert order. The change feed pages on it, since created_at is set by the writer and only second precision.';
+
+CREATE UNIQUE INDEX metadata_seq_idx ON public.metadata USING btree (seq);
+
+
+--
+-- Name: metadata.recorded_at; Type: COLUMN; Schema: public; Owner: postgres
+--
+
+ALTER TABLE public.metadata
+    ADD COLUMN recorded_at timestamp with time zone DEFAULT clock_timestamp() NOT NULL;
+
+COMMENT ON COLUMN public.metadata.recorded_at IS 'When the database inserted the row. seq is assigned at insert rather than commit, so the change feed only returns rows recorded longer ago than any write takes to commit.';
+
+
+--
+-- Name: validation_review; Type: TABLE; Schema: public; Owner: postgres
+--
+
+CREATE TABLE public.validation_review (
+    id character varying(250) NOT NULL,
+    call_fk character varying(250) NOT NULL,
+    issues jsonb NOT NULL,
+    fix_attempts jsonb,
+    status text DEFAULT 'pending'::text NOT NULL,
+    created_at timestamp without time zone DEFAULT now() NOT NULL
+);
+
+
+ALTER TABLE public.validation_review OWNER TO postgres;
+
+COMMENT ON TABLE public.validation_review IS 'Hallucination and duplicate issues that validation could not fix. The flagged items were withheld from storage.';
+
+ALTER TABLE ONLY public.validation_review
+    ADD CONSTRAINT validation_review_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: service_capacity_history; Type: TABLE; Schema: public; Owner: postgres
+--
+
+CREATE TABLE public.service_capacity_history (
+    id character varying(250) NOT NULL,
+    service_capacity_id character varying(250) NOT NULL,
+    service_id character varying(250) NOT NULL,
+    unit_id character varying(250) NOT NULL,
+    available numeric NOT NULL,
+    maximum numeric,
+    description text,
+    observed_at timestamp without time zone NOT NULL,
+    archived_by_call_fk character varying(250),
+    archived_at timestamp without time zone DEFAULT now() NOT NULL
+);
+
+
+ALTER TABLE public.service_capacity_history OWNER TO postgres;
+
+COMMENT ON TABLE public.service_capacity_history IS 'Earlier capacity readings for a service and unit. service_capacity holds the latest reading.';
+
+ALTER TABLE ONLY public.service_capacity_history
+    ADD CONSTRAINT service_capacity_history_pkey PRIMARY KEY (id);
+
+CREATE INDEX service_capacity_history_service_unit_idx ON public.service_capacity_history USING btree (service_id, unit_id, observed_at);
+
+
+--
+-- Name: unit_synonym; Type: TABLE; Schema: public; Owner: postgres
+--
+
+CREATE TABLE public.unit_synonym (
+    id character varying(250) NOT NULL,
+    unit_id character varying(250) NOT NULL,
+    synonym text NOT NULL,
+    source text DEFAULT 'manual'::text NOT NULL,
+    created_at timestamp without time zone DEFAULT now() NOT NULL
+);
+
+
+ALTER TABLE public.unit_synonym OWNER TO postgres;
+
+COMMENT ON TABLE public.unit_synonym IS 'Other names a unit is known by, e.g. the names of units merged into it. Used when resolving unit names from transcripts.';
+
+ALTER TABLE ONLY public.unit_synonym
+    ADD CONSTRAINT unit_synonym_pkey PRIMARY KEY (id);
+
+ALTER TABLE ONLY public.unit_synonym
+    ADD CONSTRAINT unit_synonym_unit_id_fkey FOREIGN KEY (unit_id) REFERENCES public.unit(id);
+
+
+--
+-- Name: unresolved_observation; Type: TABLE; Schema: public; Owner: postgres
+--
+
+CREATE TABLE public.unresolved_observation (
+    id character varying(250) NOT NULL,
+    call_fk character varying(250) NOT NULL,
+    category text NOT NULL,
+    service_name text,
+    observation jsonb NOT NULL,
+    evidence jsonb,
+    reason text,
+    status text DEFAULT 'pending'::text NOT NULL,
+    resolved_service_id character varying(250),
+    created_at timestamp without time zone DEFAULT now() NOT NULL
+);
+
+
+ALTER TABLE public.unresolved_observation OWNER TO postgres;
+
+COMMENT ON TABLE public.unresolved_observation IS 'Details extracted from a call that could not be tied to a service, kept with their transcript evidence for review.';
+
+ALTER TABLE ONLY public.unresolved_observation
+    ADD CONSTRAINT unresolved_observation_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: contact_reconciliation_report; Type: TABLE; Schema: public; Owner: postgres
+--
+
+CREATE TABLE public.contact_reconciliation_report (
+    id character varying(250) NOT NULL,
+    call_fk character varying(250) NOT NULL,
+    organization_id character varying(250) NOT NULL,
+    report jsonb NOT NULL,
+    created_at timestamp without time zone DEFAULT now() NOT NULL
+);
+
+
+ALTER TABLE public.contact_reconciliation_report OWNER TO postgres;
+
+COMMENT ON TABLE public.contact_reconciliation_report IS 'How each contact mentioned on a call was reconciled: candidates, scores, the rule that fired and the resulting actions.';
+
+ALTER TABLE ONLY public.contact_reconciliation_report
+    ADD CONSTRAINT contact_reconciliation_report_pkey PRIMARY KEY (id);
+
+CREATE INDEX contact_reconciliation_report_call_idx ON public.contact_reconciliation_report USING btree (call_fk);
+
+
+--
+-- Name: contact.deleted_at; Type: COLUMN; Schema: public; Owner: postgres
+--
+
+ALTER TABLE public.contact
+    ADD COLUMN deleted_at timestamp without time zone,
+    ADD COLUMN merged_into_id character varying(250);
+
+COMMENT ON COLUMN public.contact.deleted_at IS 'When the contact was merged into another and soft-deleted. Soft-deleted contacts are skipped during reconciliation.';
+
+COMMENT ON COLUMN public.contact.merged_into_id IS 'The contact that survived the merge this contact was folded into.';
+
+ALTER TABLE ONLY public.contact
+    ADD CONSTRAINT contact_merged_into_id_fkey FOREIGN KEY (merged_into_id) REFERENCES public.contact(id);
+
+
+--
+-- Name: transcripts.turns; Type: COLUMN; Schema: public; Owner: postgres
+--
+
+ALTER TABLE public.transcripts
+    ADD COLUMN turns jsonb;
+
+COMMENT ON COLUMN public.transcripts.turns IS 'Speaker turns (speaker, start, end, text) when the transcript was sent diarized. full_transcript holds the same turns rendered with speaker labels.';
+
+
+--
+-- Name: transcript_redaction; Type: TABLE; Schema: public; Owner: postgres
+--
+
+CREATE TABLE public.transcript_redaction (
+    id character varying(250) NOT NULL,
+    call_fk character varying(250) NOT NULL,
+    redaction_map jsonb NOT NULL,
+    created_at timestamp without time zone DEFAULT now() NOT NULL
+);
+
+
+ALTER TABLE public.transcript_redaction OWNER TO postgres;
+
+COMMENT ON TABLE public.transcript_redaction IS 'What was masked from a call transcript before storage and analysis: the policy in force and each placeholder with its entity type, rule and offsets. Original values are never stored.';
+
+ALTER TABLE ONLY public.transcript_redaction
+    ADD CONSTRAINT transcript_redaction_pkey PRIMARY KEY (id);
+
+CREATE INDEX transcript_redaction_call_idx ON public.transcript_redaction USING btree (call_fk);
+
+
+--
+-- Name: transcripts.language; Type: COLUMN; Schema: public; Owner: postgres
+--
+
+ALTER TABLE public.transcripts
+    ADD COLUMN language text;
+
+COMMENT ON COLUMN public.transcripts.language IS 'ISO 639-1 code of the language the organization spoke on the call, as given by the caller or detected from the transcript; und when undetermined. Extracted HSDS fields are always written in English.';
+
+
+--
+-- Name: reverification_dial; Type: TABLE; Schema: public; Owner: postgres
+--
+
+CREATE TABLE public.reverification_dial (
+    id character varying(250) NOT NULL,
+    organization_id character varying(250) NOT NULL,
+    phone_number text NOT NULL,
+    service_categories jsonb NOT NULL,
+    status text NOT NULL,
+    response text,
+    created_at timestamp without time zone DEFAULT now() NOT NULL
+);
+
+
+ALTER TABLE public.reverification_dial OWNER TO postgres;
+
+COMMENT ON TABLE public.reverification_dial IS 'Re-verification calls the freshness scheduler asked go-bot to place. Every request counts toward the per-organization rate limit, including failed ones.';
+
+COMMENT ON COLUMN public.reverification_dial.status IS 'dialed when go-bot accepted the request, failed otherwise.';
+
+COMMENT ON COLUMN public.reverification_dial.response IS 'go-bot''s response body, or the error when the request failed.';
+
+ALTER TABLE ONLY public.reverification_dial
+    ADD CONSTRAINT reverification_dial_pkey PRIMARY KEY (id);
+
+ALTER TABLE ONLY public.reverification_dial
+    ADD CONSTRAINT reverification_dial_organization_id_fkey FOREIGN KEY (organization_id) REFERENCES public.organization(id);
+
+CREATE INDEX reverification_dial_organization_idx ON public.reverification_dial USING btree (organization_id, created_at);
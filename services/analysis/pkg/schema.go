@@ -0,0 +1,14 @@
+// Package schema embeds the database schema dumps so the service can describe its own tables
+package schema
+
+import _ "embed"
+
+// HSDS is the pg_dump of the HSDS 3.0 tables
+//
+//go:embed hsds.sql
+var HSDS string
+
+// BearHug is the DDL for the tables and columns BearHug adds on top of HSDS
+//
+//go:embed bearhug.sql
+var BearHug string